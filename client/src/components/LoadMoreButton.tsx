@@ -0,0 +1,20 @@
+import { Button } from "@/components/ui/button.tsx";
+
+interface LoadMoreButtonProps {
+  hasNextPage: boolean;
+  isFetchingNextPage: boolean;
+  onLoadMore: () => void;
+}
+
+// LoadMoreButton fetches the next page of a paged list, it is hidden once the last page has been loaded.
+export function LoadMoreButton({ hasNextPage, isFetchingNextPage, onLoadMore }: LoadMoreButtonProps) {
+  if (!hasNextPage) return null;
+
+  return (
+    <div className="flex justify-center mt-4">
+      <Button variant="outline" disabled={isFetchingNextPage} onClick={onLoadMore}>
+        {isFetchingNextPage ? "Loading..." : "Load more"}
+      </Button>
+    </div>
+  );
+}
@@ -1,13 +1,21 @@
 import {Item, ItemHistoryEvent, ItemWithCurrentLocation} from "@/data/models/item";
+import {Page} from "@/data/models/page";
+
+// historyPageSize is the number of history records fetched per page.
+export const historyPageSize = 50;
 
 export function useItemsApi() {
-  async function listItems(groupKey?: string): Promise<ItemWithCurrentLocation[]> {
+  // listItems fetches a single page of items, the first page when no cursor is given.
+  async function listItems(groupKey?: string, cursor?: string | null): Promise<Page<ItemWithCurrentLocation>> {
     let url = "http://localhost:42069/api/v1/item";
 
     const params = new URLSearchParams();
     if (groupKey) {
       params.append("group", groupKey);
     }
+    if (cursor) {
+      params.append("cursor", cursor);
+    }
     if (params.size > 0) {
       url = `${url}?${params.toString()}`;
     }
@@ -88,12 +96,18 @@ export function useItemsApi() {
     return Promise.resolve([]);
   }
 
-  async function getItemHistory(itemId: string): Promise<ItemHistoryEvent[]> {
+  // getItemHistory fetches a single page of the item history, newest first.
+  async function getItemHistory(itemId: string, cursor?: string | null): Promise<Page<ItemHistoryEvent>> {
     if (!itemId) {
       throw new Error("Invalid item ID");
     }
 
-    const response = await fetch(`http://localhost:42069/api/v1/item/${itemId}/history`, {
+    const params = new URLSearchParams({ limit: historyPageSize.toString() });
+    if (cursor) {
+      params.append("cursor", cursor);
+    }
+
+    const response = await fetch(`http://localhost:42069/api/v1/item/${itemId}/history?${params.toString()}`, {
       method: "GET",
       credentials: "include",
       headers: {
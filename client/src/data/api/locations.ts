@@ -1,8 +1,10 @@
 import {CreateLocationRequest, Location} from "@/data/models/location";
 import {ItemWithCurrentLocation} from "@/data/models/item.ts";
+import {Page} from "@/data/models/page";
 
 export function useLocationsApi() {
-  async function listLocations(max?: number, filter?: string): Promise<Location[]> {
+  // listLocations fetches a single page of locations, of at most max locations when max is given.
+  async function listLocations(max?: number, filter?: string, cursor?: string | null): Promise<Page<Location>> {
     const params = new URLSearchParams({});
     if (max) {
       params.append("max", max.toString());
@@ -10,6 +12,9 @@ export function useLocationsApi() {
     if (filter) {
       params.append("filter", filter);
     }
+    if (cursor) {
+      params.append("cursor", cursor);
+    }
 
     const response = await fetch(`http://localhost:42069/api/v1/location?${params.toString()}`, {
       method: "GET",
@@ -73,8 +78,14 @@ export function useLocationsApi() {
     }
   }
 
-  async function listItemsAtLocation(locationId: string): Promise<ItemWithCurrentLocation[]> {
-    const response = await fetch(`http://localhost:42069/api/v1/location/${locationId}/items`, {
+  // listItemsAtLocation fetches a single page of the items at the location, the first page when no cursor is given.
+  async function listItemsAtLocation(locationId: string, cursor?: string | null): Promise<Page<ItemWithCurrentLocation>> {
+    const params = new URLSearchParams({});
+    if (cursor) {
+      params.append("cursor", cursor);
+    }
+
+    const response = await fetch(`http://localhost:42069/api/v1/location/${locationId}/items?${params.toString()}`, {
       method: "GET",
       credentials: "include",
       headers: {
@@ -0,0 +1,9 @@
+export type Page<T> = {
+  items: T[];
+  nextCursor: string | null;
+}
+
+// pageItems joins the items of the pages an infinite query has loaded so far.
+export function pageItems<T>(data: { pages: Page<T>[] } | undefined): T[] {
+  return data?.pages.flatMap((page) => page.items) ?? [];
+}
@@ -38,7 +38,7 @@ export default function CreateItemPage() {
     queryKey: ["locations", locationsFilter],
     queryFn: ({ queryKey }) => {
       const [, filter] = queryKey;
-      return listLocations(5, filter)
+      return listLocations(5, filter).then((page) => page.items);
     },
   });
 
@@ -1,11 +1,11 @@
 import { Page } from "@/components/Page.tsx";
 import { useParams } from "react-router";
-import { useItemsApi } from "@/data/api/items";
+import { historyPageSize, useItemsApi } from "@/data/api/items";
 import { toast } from "sonner";
 import { ItemDetailsCard } from "./ItemDetailsCard";
 import { ItemHistoryCard } from "./ItemHistoryCard";
 import { EditItemButton } from "./EditItemButton";
-import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import { useInfiniteQuery, useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
 import { useSettings } from "@/hooks/use-settings.tsx";
 import { useState } from "react";
 import { useUser } from "@/hooks/use-user.ts";
@@ -13,6 +13,8 @@ import {useApi} from "@/hooks/use-api.ts";
 import {TrackableLocation} from "@/data/models/location.ts";
 import {Item, ItemHistoryEvent} from "@/data/models/item.ts";
 import {useBreadcrumbs} from "@/hooks/use-breadcrumbs.ts";
+import {Page as ResultPage, pageItems} from "@/data/models/page";
+import {LoadMoreButton} from "@/components/LoadMoreButton.tsx";
 
 export default function ItemDetailsPage() {
   const api = useApi();
@@ -51,27 +53,33 @@ export default function ItemDetailsPage() {
       if (locationFilter)
         params.append("filter", locationFilter)
 
-      const response = await api<TrackableLocation[]>(`/location?${params.toString()}`);
+      const response = await api<ResultPage<TrackableLocation>>(`/location?${params.toString()}`);
       if (response.ok) {
-        return response.data;
+        return response.data.items;
       }
 
       throw new Error(response.error ?? "Failed to fetch locations");
     },
   });
 
-  const itemHistoryQuery = useQuery({
+  const itemHistoryQuery = useInfiniteQuery({
     queryKey: ["item-history", itemId],
-    queryFn: async () => {
-      if (!itemId) return [];
+    queryFn: async ({ pageParam }) => {
+      const params = new URLSearchParams({ limit: historyPageSize.toString() });
+      if (pageParam) {
+        params.append("cursor", pageParam);
+      }
 
-      const response = await api<ItemHistoryEvent[]>(`/item/${itemId}/history`);
+      const response = await api<ResultPage<ItemHistoryEvent>>(`/item/${itemId}/history?${params.toString()}`);
       if (response.ok) {
         return response.data;
       }
 
       throw new Error(response.error || `There has been an issue fetching the ${terminology.item.toLowerCase()} history`);
     },
+    initialPageParam: null as string | null,
+    getNextPageParam: (page) => page.nextCursor,
+    enabled: !!itemId,
   });
 
   const trackItemMutation = useMutation({
@@ -155,21 +163,28 @@ export default function ItemDetailsPage() {
         {itemHistoryQuery.isLoading
           ? <p>Loading history</p>
           : (
-            <ItemHistoryCard
-              history={itemHistoryQuery.data ?? []}
-              onDownload={() => {
-                if (!itemQuery.data) return;
-                const item = itemQuery.data;
-
-                downloadHistoryCsv(item.id).then(blob => {
-                  const url = URL.createObjectURL(blob);
-                  const a = document.createElement("a");
-                  a.href = url;
-                  a.download = `${terminology.item}-${item.reference}-history.csv`;
-                  a.click();
-                  URL.revokeObjectURL(url);
-                });
-              }} />
+            <>
+              <ItemHistoryCard
+                history={pageItems(itemHistoryQuery.data)}
+                onDownload={() => {
+                  if (!itemQuery.data) return;
+                  const item = itemQuery.data;
+
+                  downloadHistoryCsv(item.id).then(blob => {
+                    const url = URL.createObjectURL(blob);
+                    const a = document.createElement("a");
+                    a.href = url;
+                    a.download = `${terminology.item}-${item.reference}-history.csv`;
+                    a.click();
+                    URL.revokeObjectURL(url);
+                  });
+                }} />
+              <LoadMoreButton
+                hasNextPage={itemHistoryQuery.hasNextPage}
+                isFetchingNextPage={itemHistoryQuery.isFetchingNextPage}
+                onLoadMore={() => itemHistoryQuery.fetchNextPage()}
+              />
+            </>
           )}
       </section>
     </Page>
@@ -2,12 +2,14 @@ import { ItemDataTable } from "@/components/ItemDataTable/ItemDataTable.tsx";
 import { Page } from "@/components/Page.tsx";
 import { useParams } from "react-router";
 import { useItemsApi } from "@/data/api/items";
-import { useQuery } from "@tanstack/react-query";
+import { useInfiniteQuery } from "@tanstack/react-query";
 import { Alert, AlertDescription, AlertTitle } from "@/components/ui/alert.tsx";
 import { AlertCircle } from "lucide-react";
 import { useSettings } from "@/hooks/use-settings.tsx";
 import { useBreadcrumbs } from "@/hooks/use-breadcrumbs.ts";
 import { usePersistentColumns } from "@/hooks/use-persistent-columns.ts";
+import { LoadMoreButton } from "@/components/LoadMoreButton.tsx";
+import { pageItems } from "@/data/models/page";
 
 const visibleColumns = {
   location: true,
@@ -26,23 +28,27 @@ export default function ItemGroupListingPage() {
   const persistentColumns = usePersistentColumns(
     { key: "items-listing-group", defaults: visibleColumns });
 
-  const { isLoading, data } = useQuery({
+  const itemsQuery = useInfiniteQuery({
     queryKey: ["items", groupKey],
-    queryFn: async () => {
-      if (!groupKey) return;
-      const response = await listItems(groupKey)
+    queryFn: async ({ pageParam }) => {
+      const response = await listItems(groupKey, pageParam);
 
       setBreadcrumbs({
         crumbs: [{
           href: "/items",
           text: "Item listing",
         }],
-        current: groupKey
+        current: groupKey!
       })
 
       return response;
     },
+    initialPageParam: null as string | null,
+    getNextPageParam: (page) => page.nextCursor,
+    enabled: !!groupKey,
   });
+  const isLoading = itemsQuery.isLoading;
+  const data = itemsQuery.data && pageItems(itemsQuery.data);
 
   return (
     <Page title={groupKey ? `${terminology.group} ${groupKey}` : `${terminology.item} ${terminology.group.toLowerCase()} listing`}>
@@ -57,11 +63,18 @@ export default function ItemGroupListingPage() {
        </Alert>)}
       {isLoading || !data
         ? <p>Loading...</p>
-        : <ItemDataTable
-            data={data}
-            persistentColumns={persistentColumns}
-            onDeleteItem={(item) => console.warn(`Deleting item ${item.reference}. Not implemented`)}
-          />
+        : <>
+            <ItemDataTable
+              data={data}
+              persistentColumns={persistentColumns}
+              onDeleteItem={(item) => console.warn(`Deleting item ${item.reference}. Not implemented`)}
+            />
+            <LoadMoreButton
+              hasNextPage={itemsQuery.hasNextPage}
+              isFetchingNextPage={itemsQuery.isFetchingNextPage}
+              onLoadMore={() => itemsQuery.fetchNextPage()}
+            />
+          </>
       }
     </Page>
   );
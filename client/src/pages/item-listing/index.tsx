@@ -4,7 +4,7 @@ import { Page } from "@/components/Page.tsx";
 import { Link } from "react-router";
 import { PackagePlus } from "lucide-react";
 import { useItemsApi } from "@/data/api/items";
-import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import { useInfiniteQuery, useMutation, useQueryClient } from "@tanstack/react-query";
 import { useSettings } from "@/hooks/use-settings.tsx";
 import { usePersistentColumns } from "@/hooks/use-persistent-columns.ts";
 import { useUser } from "@/hooks/use-user.ts";
@@ -14,6 +14,8 @@ import { toast } from "sonner";
 import { useState } from "react";
 import { ConfirmAlertDialog } from "@/components/ConfirmAlertDialog.tsx";
 import { useBreadcrumbs } from "@/hooks/use-breadcrumbs.ts";
+import { LoadMoreButton } from "@/components/LoadMoreButton.tsx";
+import { pageItems } from "@/data/models/page";
 
 const visibleColumns = {
   location: true,
@@ -39,9 +41,11 @@ export default function ItemListingPage() {
     current: "Item listing"
   });
 
-  const itemsQuery = useQuery({
+  const itemsQuery = useInfiniteQuery({
     queryKey: ["items"],
-    queryFn: () => listItems(),
+    queryFn: ({ pageParam }) => listItems(undefined, pageParam),
+    initialPageParam: null as string | null,
+    getNextPageParam: (page) => page.nextCursor,
   });
 
   const deleteItemMutation = useMutation({
@@ -63,10 +67,15 @@ export default function ItemListingPage() {
       actionItems={user.hasWriterPermissions() && <CreateNewItemButton text={`Create new ${terminology.item.toLowerCase()}`} />}
     >
       <ItemDataTable
-        data={itemsQuery.data ?? []}
+        data={pageItems(itemsQuery.data)}
         persistentColumns={persistentColumns}
         onDeleteItem={setItemPendingDeletion}
       />
+      <LoadMoreButton
+        hasNextPage={itemsQuery.hasNextPage}
+        isFetchingNextPage={itemsQuery.isFetchingNextPage}
+        onLoadMore={() => itemsQuery.fetchNextPage()}
+      />
       <ConfirmAlertDialog
         target={itemPendingDeletion!}
         open={!!itemPendingDeletion}
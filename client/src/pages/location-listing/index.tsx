@@ -15,10 +15,12 @@ import {
   AlertDialogTitle
 } from "@/components/ui/alert-dialog.tsx";
 import { toast } from "sonner";
-import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
+import { useInfiniteQuery, useMutation, useQueryClient } from "@tanstack/react-query";
 import { useSettings } from "@/hooks/use-settings.tsx";
 import { useUser } from "@/hooks/use-user.ts";
 import {useBreadcrumbs} from "@/hooks/use-breadcrumbs.ts";
+import { LoadMoreButton } from "@/components/LoadMoreButton.tsx";
+import { pageItems } from "@/data/models/page";
 
 export default function LocationListingPage() {
   const user = useUser();
@@ -28,9 +30,11 @@ export default function LocationListingPage() {
   useBreadcrumbs({ current: "Location listing" });
 
   const queryClient = useQueryClient();
-  const locationsQuery = useQuery({
+  const locationsQuery = useInfiniteQuery({
     queryKey: ["locations"],
-    queryFn: () => listLocations(),
+    queryFn: ({ pageParam }) => listLocations(undefined, undefined, pageParam),
+    initialPageParam: null as string | null,
+    getNextPageParam: (page) => page.nextCursor,
   });
 
   const deleteLocationMutation = useMutation({
@@ -62,7 +66,12 @@ export default function LocationListingPage() {
         </CreateNewLocationButton>
       )
     }>
-      <LocationDataTable data={locationsQuery.data ?? []} onDelete={handleDeleteLocationClicked} />
+      <LocationDataTable data={pageItems(locationsQuery.data)} onDelete={handleDeleteLocationClicked} />
+      <LoadMoreButton
+        hasNextPage={locationsQuery.hasNextPage}
+        isFetchingNextPage={locationsQuery.isFetchingNextPage}
+        onLoadMore={() => locationsQuery.fetchNextPage()}
+      />
 
       <AlertDialog open={!!locationPendingDeletion} onOpenChange={(opening) => {
         if (!opening) setLocationPendingDeletion(undefined);
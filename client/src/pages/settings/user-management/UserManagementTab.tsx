@@ -1,28 +1,43 @@
 import { User, UserRole } from "@/data/models/user";
+import { Page, pageItems } from "@/data/models/page";
 import { useApi } from "@/hooks/use-api";
-import {useMutation, useQuery} from "@tanstack/react-query";
+import {useInfiniteQuery, useMutation} from "@tanstack/react-query";
 import {UserDataTable} from "@/pages/settings/user-management/UserDataTable/UserDataTable.tsx";
 import {useState} from "react";
 import {CreateUserButton} from "@/pages/settings/user-management/CreateUserButton.tsx";
 import {useUser} from "@/hooks/use-user.ts";
 import {toast} from "sonner";
+import {LoadMoreButton} from "@/components/LoadMoreButton.tsx";
 
 export function UserManagementTab() {
   const user = useUser();
   const api = useApi();
   const [roleFilter, setRoleFilter] = useState<UserRole[]>([]);
 
-  const usersQuery = useQuery({
+  const usersQuery = useInfiniteQuery({
     queryKey: ["users", roleFilter],
-    queryFn: async () => {
-      let url = "/user";
+    queryFn: async ({ pageParam }) => {
+      const params = new URLSearchParams();
       if (roleFilter && roleFilter.length > 0) {
-        const params = new URLSearchParams({ roles: roleFilter.map(r => r.toString()).join(",") });
+        params.append("roles", roleFilter.map(r => r.toString()).join(","));
+      }
+      if (pageParam) {
+        params.append("cursor", pageParam);
+      }
+
+      let url = "/user";
+      if (params.size > 0) {
         url = `${url}?${params.toString()}`;
       }
-      const result = await api<User[]>(url);
+
+      const result = await api<Page<User>>(url);
+      if (!result.ok) {
+        throw new Error(result.error ?? "Failed to fetch users");
+      }
       return result.data;
-    }
+    },
+    initialPageParam: null as string | null,
+    getNextPageParam: (page) => page.nextCursor,
   });
 
   const deleteUserMutation = useMutation({
@@ -52,12 +67,17 @@ export function UserManagementTab() {
       </section>
       <section className="grid grid-cols-1">
         <UserDataTable
-          data={usersQuery.data ?? []}
+          data={pageItems(usersQuery.data)}
           isLoading={usersQuery.isLoading}
           filteredRoles={roleFilter}
           onFilteredRolesChanged={filterUsersByRole}
           onDelete={(user) => deleteUserMutation.mutate(user)}
         />
+        <LoadMoreButton
+          hasNextPage={usersQuery.hasNextPage}
+          isFetchingNextPage={usersQuery.isFetchingNextPage}
+          onLoadMore={() => usersQuery.fetchNextPage()}
+        />
       </section>
     </div>
   );
@@ -1,17 +1,26 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"quantum/internal/handler"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"time"
 
 	"github.com/joho/godotenv"
 
 	"quantum/internal/app"
 )
 
+const (
+	eventBrokerMinBackoff = time.Second
+	eventBrokerMaxBackoff = time.Minute
+)
+
 func init() {
 	if err := godotenv.Load(); err != nil {
 		panic("failed to load environment variables")
@@ -37,8 +46,24 @@ func run() error {
 		return fmt.Errorf("error building application: %w", err)
 	}
 
+	logger.Debug("Starting webhook dispatcher...")
+	dispatcher := service.NewWebhookDispatcher(
+		repository.NewWebhookRepository(application.DB),
+		&http.Client{},
+		logger,
+	)
+	go dispatcher.Run(context.Background())
+
+	logger.Debug("Starting item event broker...")
+	eventBroker := service.NewItemEventBroker(
+		application.Config.Database.ConnectionString,
+		repository.NewItemRepository(application.DB),
+		logger,
+	)
+	go runItemEventBroker(context.Background(), eventBroker, logger)
+
 	logger.Debug("Setting up routes...")
-	mux := handler.BuildServerMux(application)
+	mux := handler.BuildServerMux(application, eventBroker)
 
 	logger.Debug("Starting server", "host", application.Config.Host)
 	if err := http.ListenAndServe(application.Config.Host, mux); err != nil {
@@ -48,6 +73,31 @@ func run() error {
 	return nil
 }
 
+// runItemEventBroker runs the broker until the context is cancelled, restarting it with exponential backoff
+// whenever it stops with an error. The backoff is reset once the broker has run for longer than the maximum backoff.
+func runItemEventBroker(ctx context.Context, broker *service.ItemEventBroker, logger *slog.Logger) {
+	backoff := eventBrokerMinBackoff
+	for {
+		started := time.Now()
+		err := broker.Run(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(started) > eventBrokerMaxBackoff {
+			backoff = eventBrokerMinBackoff
+		}
+		logger.Error("item event broker stopped, restarting", "error", err, "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, eventBrokerMaxBackoff)
+	}
+}
+
 func makeLogger() *slog.Logger {
 	environment := app.NewEnvironment(os.Getenv("ENVIRONMENT"))
 
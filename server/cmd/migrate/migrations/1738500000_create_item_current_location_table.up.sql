@@ -0,0 +1,49 @@
+-- item_current_location holds the latest created, tracked or tracked-user history record of each item.
+-- It is maintained by the application in the same transaction as each history record is appended
+-- and can be rebuilt from item_history with the rebuild-locations command.
+create table if not exists item_current_location (
+    item_id uuid primary key references items(id) on delete cascade,
+    type text not null,
+    location_id uuid not null, -- location_id is the id of the location or user
+    tracked_at timestamp with time zone not null
+);
+
+create index idx_item_current_location_location_id
+    on item_current_location (location_id);
+
+insert into item_current_location (item_id, type, location_id, tracked_at)
+select distinct on (item_id)
+    item_id,
+    (data->>'type')::text,
+    case (data->>'type')::text
+        when 'tracked-user' then (data->'data'->>'userId')::uuid
+        else (data->'data'->>'locationId')::uuid
+    end,
+    created_at
+from item_history
+where (data->>'type') in ('created', 'tracked', 'tracked-user')
+order by item_id, created_at desc, id desc;
+
+create or replace view items_with_current_location as (
+    select
+        i.*,
+        cl.location_id as location_id, -- location_id is the id of the location or user
+        case cl.type
+            when 'tracked' then l.name
+            when 'created' then l.name
+            when 'tracked-user' then u.name
+        end as location_name,
+        case cl.type
+            when 'tracked' then l.description
+            when 'created' then l.description
+            when 'tracked-user' then u.username
+        end as location_description,
+        cl.tracked_at as tracked_at,
+        cl.type = 'tracked-user' as tracked_to_user
+    from item_current_location cl
+        join items i on cl.item_id = i.id
+        left join locations l
+            on cl.location_id = l.id and cl.type in ('tracked', 'created')
+        left join users u
+            on cl.location_id = u.id and cl.type = 'tracked-user'
+);
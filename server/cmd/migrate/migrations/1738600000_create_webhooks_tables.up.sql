@@ -0,0 +1,42 @@
+create table if not exists webhooks (
+    id uuid primary key default uuid_generate_v4(),
+    url text not null,
+    event_types text[] not null default '{}', -- an empty array subscribes to all event types
+    secret text not null,
+    active boolean not null default true,
+    created_at timestamp with time zone not null default current_timestamp,
+    updated_at timestamp with time zone not null default current_timestamp
+);
+
+-- webhook_deliveries is the outbox of webhook events.
+-- A delivery is written for each subscribed webhook in the same transaction as the item_history record.
+create table if not exists webhook_deliveries (
+    id bigserial primary key,
+    webhook_id uuid not null references webhooks(id) on delete cascade,
+    event_type text not null,
+    payload jsonb not null,
+    status text not null default 'pending', -- pending, delivered or failed
+    attempts int not null default 0,
+    next_attempt_at timestamp with time zone not null default current_timestamp,
+    delivered_at timestamp with time zone,
+    created_at timestamp with time zone not null default current_timestamp
+);
+
+create index idx_webhook_deliveries_pending
+    on webhook_deliveries (next_attempt_at)
+    where status = 'pending';
+
+create index idx_webhook_deliveries_webhook_id
+    on webhook_deliveries (webhook_id, created_at desc);
+
+create table if not exists webhook_delivery_attempts (
+    id bigserial primary key,
+    delivery_id bigint not null references webhook_deliveries(id) on delete cascade,
+    status_code int,
+    error text,
+    duration_ms int not null,
+    created_at timestamp with time zone not null default current_timestamp
+);
+
+create index idx_webhook_delivery_attempts_delivery_id
+    on webhook_delivery_attempts (delivery_id);
@@ -0,0 +1,5 @@
+drop index if exists idx_locations_parent_id;
+
+alter table locations
+    drop constraint if exists locations_parent_id_not_self,
+    drop column if exists parent_id;
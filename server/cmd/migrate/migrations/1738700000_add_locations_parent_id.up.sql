@@ -0,0 +1,5 @@
+alter table locations
+    add column if not exists parent_id uuid references locations(id) on delete restrict,
+    add constraint locations_parent_id_not_self check (parent_id <> id);
+
+create index idx_locations_parent_id on locations (parent_id);
@@ -0,0 +1 @@
+drop table if exists location_history;
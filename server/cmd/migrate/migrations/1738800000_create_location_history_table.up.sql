@@ -0,0 +1,10 @@
+create table if not exists location_history (
+    id serial primary key,
+    user_id uuid not null references users(id) on delete no action,
+    location_id uuid not null references locations(id) on delete no action,
+    data jsonb not null,
+    created_at timestamp with time zone not null default now()
+);
+
+create index idx_location_history_location_created_at
+    on location_history (location_id, created_at desc);
@@ -0,0 +1,20 @@
+-- btree_gist lets the exclusion constraint compare item IDs with = alongside the time range overlap.
+create extension if not exists btree_gist;
+
+create table if not exists reservations (
+    id uuid primary key default uuid_generate_v4(),
+    item_id uuid not null references items(id) on delete cascade,
+    user_id uuid not null references users(id) on delete cascade,
+    purpose text not null default '',
+    starts_at timestamp with time zone not null,
+    ends_at timestamp with time zone not null,
+    created_by_user_id uuid not null references users(id) on delete no action,
+    created_at timestamp with time zone not null default now(),
+    constraint reservations_valid_range check (ends_at > starts_at),
+    constraint reservations_no_overlap exclude using gist (
+        item_id with =,
+        tstzrange(starts_at, ends_at) with &&
+    )
+);
+
+create index idx_reservations_user_starts_at on reservations (user_id, starts_at);
@@ -0,0 +1,3 @@
+drop table if exists audit_results;
+drop table if exists audit_scans;
+drop table if exists audit_sessions;
@@ -0,0 +1,48 @@
+create table if not exists audit_sessions (
+    id uuid primary key default uuid_generate_v4(),
+    location_id uuid not null references locations(id) on delete no action,
+    include_children boolean not null default false,
+    status text not null default 'open',
+    started_by_user_id uuid not null references users(id) on delete no action,
+    started_at timestamp with time zone not null default now(),
+    closed_by_user_id uuid references users(id) on delete no action,
+    closed_at timestamp with time zone,
+    applied_by_user_id uuid references users(id) on delete no action,
+    applied_at timestamp with time zone,
+    constraint audit_sessions_status check (status in ('open', 'closed'))
+);
+
+create index idx_audit_sessions_location_started_at on audit_sessions (location_id, started_at desc);
+
+-- An item is scanned at most once per session, scanning it again records where it was found last.
+-- The item is copied by reference and identifier, as item_id is cleared when the item is purged.
+create table if not exists audit_scans (
+    id serial primary key,
+    session_id uuid not null references audit_sessions(id) on delete cascade,
+    item_id uuid references items(id) on delete set null,
+    item_reference text not null,
+    item_identifier text not null,
+    location_id uuid not null references locations(id) on delete no action,
+    code text not null,
+    scanned_by_user_id uuid not null references users(id) on delete no action,
+    scanned_at timestamp with time zone not null default now(),
+    constraint audit_scans_session_item unique (session_id, item_id)
+);
+
+-- The reconciliation of a session, written when it is closed and kept for later review.
+-- The expected location is copied by name as well, as it may be a user or renamed later,
+-- and the item by reference and identifier, as item_id is cleared when the item is purged.
+create table if not exists audit_results (
+    id serial primary key,
+    session_id uuid not null references audit_sessions(id) on delete cascade,
+    item_id uuid references items(id) on delete set null,
+    item_reference text not null,
+    item_identifier text not null,
+    status text not null,
+    expected_location_id uuid,
+    expected_location_name text,
+    found_location_id uuid references locations(id) on delete no action,
+    applied boolean not null default false,
+    constraint audit_results_session_item unique (session_id, item_id),
+    constraint audit_results_status check (status in ('found', 'missing', 'unexpected'))
+);
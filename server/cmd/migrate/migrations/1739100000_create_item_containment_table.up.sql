@@ -0,0 +1,11 @@
+-- item_containment holds the item each item is currently inside of, such as a charger inside a laptop bag.
+-- A row is written with each contained history record and removed with each uncontained one, so it matches the latest of them.
+create table if not exists item_containment (
+    item_id uuid primary key references items(id) on delete cascade,
+    container_id uuid not null references items(id) on delete cascade,
+    contained_at timestamp with time zone not null default now(),
+    constraint item_containment_not_self check (item_id <> container_id)
+);
+
+create index idx_item_containment_container_id
+    on item_containment (container_id);
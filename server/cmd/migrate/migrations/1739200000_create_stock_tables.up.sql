@@ -0,0 +1,52 @@
+-- Items are either units, each tracked on its own, or stock held in quantities at each location.
+alter table items add column if not exists kind text not null default 'unit';
+alter table items add constraint items_kind check (kind in ('unit', 'stock'));
+
+-- The view selects i.*, which is expanded when the view is created, so it is recreated to pick up the kind column.
+drop view if exists items_with_current_location;
+create view items_with_current_location as (
+    select
+        i.*,
+        cl.location_id as location_id, -- location_id is the id of the location or user
+        case cl.type
+            when 'tracked' then l.name
+            when 'created' then l.name
+            when 'tracked-user' then u.name
+        end as location_name,
+        case cl.type
+            when 'tracked' then l.description
+            when 'created' then l.description
+            when 'tracked-user' then u.username
+        end as location_description,
+        cl.tracked_at as tracked_at,
+        cl.type = 'tracked-user' as tracked_to_user
+    from item_current_location cl
+        join items i on cl.item_id = i.id
+        left join locations l
+            on cl.location_id = l.id and cl.type in ('tracked', 'created')
+        left join users u
+            on cl.location_id = u.id and cl.type = 'tracked-user'
+);
+
+-- stock_levels holds the quantity of each stock item at each location.
+-- quantity is the running total of the deltas in the stock history records of the item at the location.
+create table if not exists stock_levels (
+    item_id uuid not null references items(id) on delete cascade,
+    location_id uuid not null references locations(id) on delete no action,
+    quantity integer not null,
+    updated_at timestamp with time zone not null default now(),
+    primary key (item_id, location_id),
+    constraint stock_levels_quantity check (quantity >= 0)
+);
+
+create index idx_stock_levels_location_id on stock_levels (location_id);
+
+-- stock_thresholds holds the quantity of each group of stock items below which a location should reorder.
+create table if not exists stock_thresholds (
+    group_key text not null,
+    location_id uuid not null references locations(id) on delete cascade,
+    threshold integer not null,
+    updated_at timestamp with time zone not null default now(),
+    primary key (group_key, location_id),
+    constraint stock_thresholds_threshold check (threshold >= 0)
+);
@@ -0,0 +1,29 @@
+drop table if exists group_custom_fields;
+
+drop view if exists items_with_current_location;
+alter table items drop constraint if exists items_custom_fields;
+alter table items drop column if exists custom_fields;
+
+create view items_with_current_location as (
+    select
+        i.*,
+        cl.location_id as location_id, -- location_id is the id of the location or user
+        case cl.type
+            when 'tracked' then l.name
+            when 'created' then l.name
+            when 'tracked-user' then u.name
+        end as location_name,
+        case cl.type
+            when 'tracked' then l.description
+            when 'created' then l.description
+            when 'tracked-user' then u.username
+        end as location_description,
+        cl.tracked_at as tracked_at,
+        cl.type = 'tracked-user' as tracked_to_user
+    from item_current_location cl
+        join items i on cl.item_id = i.id
+        left join locations l
+            on cl.location_id = l.id and cl.type in ('tracked', 'created')
+        left join users u
+            on cl.location_id = u.id and cl.type = 'tracked-user'
+);
@@ -0,0 +1,36 @@
+-- Custom fields hold per-group attributes of an item, such as a serial number or MAC address, keyed by field name.
+alter table items add column if not exists custom_fields jsonb not null default '{}'::jsonb;
+alter table items add constraint items_custom_fields check (jsonb_typeof(custom_fields) = 'object');
+
+-- Recreated so that i.* includes custom_fields.
+drop view if exists items_with_current_location;
+create view items_with_current_location as (
+    select
+        i.*,
+        cl.location_id as location_id, -- location_id is the id of the location or user
+        case cl.type
+            when 'tracked' then l.name
+            when 'created' then l.name
+            when 'tracked-user' then u.name
+        end as location_name,
+        case cl.type
+            when 'tracked' then l.description
+            when 'created' then l.description
+            when 'tracked-user' then u.username
+        end as location_description,
+        cl.tracked_at as tracked_at,
+        cl.type = 'tracked-user' as tracked_to_user
+    from item_current_location cl
+        join items i on cl.item_id = i.id
+        left join locations l
+            on cl.location_id = l.id and cl.type in ('tracked', 'created')
+        left join users u
+            on cl.location_id = u.id and cl.type = 'tracked-user'
+);
+
+-- group_custom_fields holds the definitions of the custom fields of the items in each group, as a JSON array.
+create table if not exists group_custom_fields (
+    group_key text primary key,
+    fields jsonb not null default '[]'::jsonb,
+    updated_at timestamp with time zone not null default now()
+);
@@ -0,0 +1,33 @@
+-- The lifecycle status of an item, changed through the transitions allowed in the settings.
+alter table items add column if not exists status text not null default 'in-service';
+alter table items add constraint items_status check (
+    status in ('in-service', 'in-repair', 'on-loan', 'lost', 'retired', 'disposed')
+);
+
+create index idx_items_status on items (status);
+
+-- Recreated so that i.* includes status.
+drop view if exists items_with_current_location;
+create view items_with_current_location as (
+    select
+        i.*,
+        cl.location_id as location_id, -- location_id is the id of the location or user
+        case cl.type
+            when 'tracked' then l.name
+            when 'created' then l.name
+            when 'tracked-user' then u.name
+        end as location_name,
+        case cl.type
+            when 'tracked' then l.description
+            when 'created' then l.description
+            when 'tracked-user' then u.username
+        end as location_description,
+        cl.tracked_at as tracked_at,
+        cl.type = 'tracked-user' as tracked_to_user
+    from item_current_location cl
+        join items i on cl.item_id = i.id
+        left join locations l
+            on cl.location_id = l.id and cl.type in ('tracked', 'created')
+        left join users u
+            on cl.location_id = u.id and cl.type = 'tracked-user'
+);
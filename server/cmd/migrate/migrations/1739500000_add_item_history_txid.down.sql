@@ -0,0 +1,2 @@
+drop index if exists idx_item_history_txid_id;
+alter table item_history drop column if exists txid;
@@ -0,0 +1,6 @@
+-- txid is the transaction that appended the record. Serial ids are allocated at insert rather than at commit,
+-- so the item event stream orders records by (txid, id) and only reads records of transactions older than
+-- any still in progress, which gives a cursor that a record committed late can never fall behind.
+alter table item_history add column if not exists txid xid8 not null default pg_current_xact_id();
+
+create index idx_item_history_txid_id on item_history (txid, id);
@@ -0,0 +1 @@
+drop table if exists reservation_feed_tokens;
@@ -0,0 +1,7 @@
+-- reservation_feed_tokens holds the token that authorizes the calendar feed of the reservations of each user.
+-- Rotating the token of a user replaces the row, so the old feed address stops working.
+create table if not exists reservation_feed_tokens (
+    user_id uuid primary key references users(id) on delete cascade,
+    token text not null unique,
+    created_at timestamp with time zone not null default now()
+);
@@ -139,3 +139,18 @@ SELECT DISTINCT ON (item_id)
             'type', 'created'
     )
 FROM item_locations;
+
+-- Project the seeded history onto the current location table.
+INSERT INTO item_current_location (item_id, type, location_id, tracked_at)
+SELECT DISTINCT ON (item_id)
+    item_id,
+    (data->>'type')::text,
+    CASE (data->>'type')::text
+        WHEN 'tracked-user' THEN (data->'data'->>'userId')::uuid
+        ELSE (data->'data'->>'locationId')::uuid
+    END,
+    created_at
+FROM item_history
+WHERE (data->>'type') IN ('created', 'tracked', 'tracked-user')
+ORDER BY item_id, created_at DESC, id DESC
+ON CONFLICT (item_id) DO NOTHING;
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/joho/godotenv"
+	"quantum/internal/app"
+	"quantum/internal/repository"
+)
+
+func init() {
+	if err := godotenv.Load(); err != nil {
+		panic("failed to load environment variables")
+	}
+}
+
+// rebuild-locations rebuilds the item_current_location table from the item_history table.
+// It is safe to run while the API is serving requests, history appended during the rebuild is applied afterwards.
+func main() {
+	if err := run(); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	application, err := app.NewApp(logger)
+	if err != nil {
+		return fmt.Errorf("error creating new application: %w", err)
+	}
+
+	if err := application.Build(); err != nil {
+		return fmt.Errorf("error building application: %w", err)
+	}
+	defer application.DB.Close()
+
+	itemRepo := repository.NewItemRepository(application.DB)
+	count, err := itemRepo.RebuildCurrentLocations()
+	if err != nil {
+		return fmt.Errorf("error rebuilding current locations: %w", err)
+	}
+
+	logger.Info("Rebuilt item current locations", "items", count)
+	return nil
+}
@@ -26,6 +26,8 @@ type DatabaseConfig struct {
 type Config struct {
 	Host          string
 	ClientBaseURL string
+	// APIBaseURL is the public address of the API, used to build addresses handed out to other apps.
+	APIBaseURL    string
 	SessionSecret string
 	Environment   Environment
 	Database      DatabaseConfig
@@ -35,6 +37,7 @@ func NewAppConfig(get func(string) string) *Config {
 	return &Config{
 		Host:          get("HOST"),
 		ClientBaseURL: get("CLIENT_BASE_URL"),
+		APIBaseURL:    get("API_BASE_URL"),
 		SessionSecret: get("SESSION_SECRET"),
 		Environment:   NewEnvironment(get("ENVIRONMENT")),
 		Database: DatabaseConfig{
@@ -0,0 +1,172 @@
+package dto
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"quantum/internal/model"
+	"time"
+)
+
+var (
+	ErrInvalidAuditLocation = errors.New("locationId is required")
+	ErrInvalidAuditScanCode = errors.New("code is required")
+)
+
+// StartAuditRequest starts an audit of the items at a location, and at the locations below it when IncludeChildren is set.
+type StartAuditRequest struct {
+	LocationID      uuid.UUID `json:"locationId"`
+	IncludeChildren bool      `json:"includeChildren"`
+}
+
+func (r *StartAuditRequest) Validate() error {
+	if r.LocationID == uuid.Nil {
+		return ErrInvalidAuditLocation
+	}
+	return nil
+}
+
+// AuditScanRequest records a scanned item code in an audit session.
+// LocationID is where the item was found, defaulting to the audited location.
+type AuditScanRequest struct {
+	Code       string     `json:"code"`
+	LocationID *uuid.UUID `json:"locationId"`
+}
+
+func (r *AuditScanRequest) Validate() error {
+	if r.Code == "" {
+		return ErrInvalidAuditScanCode
+	}
+	return nil
+}
+
+// ApplyAuditRequest applies the corrections of a closed audit session.
+// Missing items are tracked to MissingLocationID when it is given and are left where they are otherwise.
+type ApplyAuditRequest struct {
+	MissingLocationID *uuid.UUID `json:"missingLocationId"`
+}
+
+type AuditSessionResponse struct {
+	ID              uuid.UUID         `json:"id"`
+	LocationID      uuid.UUID         `json:"locationId"`
+	LocationName    string            `json:"locationName"`
+	IncludeChildren bool              `json:"includeChildren"`
+	Status          model.AuditStatus `json:"status"`
+	StartedByUserID uuid.UUID         `json:"startedByUserId"`
+	StartedAt       time.Time         `json:"startedAt"`
+	ClosedByUserID  *uuid.UUID        `json:"closedByUserId"`
+	ClosedAt        *time.Time        `json:"closedAt"`
+	AppliedByUserID *uuid.UUID        `json:"appliedByUserId"`
+	AppliedAt       *time.Time        `json:"appliedAt"`
+}
+
+func NewAuditSessionResponseFromModel(s model.AuditSessionModel) AuditSessionResponse {
+	return AuditSessionResponse{
+		ID:              s.ID,
+		LocationID:      s.LocationID,
+		LocationName:    s.LocationName,
+		IncludeChildren: s.IncludeChildren,
+		Status:          s.Status,
+		StartedByUserID: s.StartedByUserID,
+		StartedAt:       s.StartedAt,
+		ClosedByUserID:  s.ClosedByUserID,
+		ClosedAt:        s.ClosedAt,
+		AppliedByUserID: s.AppliedByUserID,
+		AppliedAt:       s.AppliedAt,
+	}
+}
+
+type AuditScanResponse struct {
+	ItemID          *uuid.UUID `json:"itemId"`
+	ItemReference   string     `json:"itemReference"`
+	ItemIdentifier  string     `json:"itemIdentifier"`
+	LocationID      uuid.UUID  `json:"locationId"`
+	LocationName    string     `json:"locationName"`
+	Code            string     `json:"code"`
+	ScannedByUserID uuid.UUID  `json:"scannedByUserId"`
+	ScannedAt       time.Time  `json:"scannedAt"`
+}
+
+func NewAuditScanResponseFromModel(s model.AuditScanModel) AuditScanResponse {
+	return AuditScanResponse{
+		ItemID:          s.ItemID,
+		ItemReference:   s.ItemReference,
+		ItemIdentifier:  s.ItemIdentifier,
+		LocationID:      s.LocationID,
+		LocationName:    s.LocationName,
+		Code:            s.Code,
+		ScannedByUserID: s.ScannedByUserID,
+		ScannedAt:       s.ScannedAt,
+	}
+}
+
+type AuditResultResponse struct {
+	// ItemID is nil once the item has been purged.
+	ItemID               *uuid.UUID `json:"itemId"`
+	ItemReference        string     `json:"itemReference"`
+	ItemIdentifier       string     `json:"itemIdentifier"`
+	ExpectedLocationID   *uuid.UUID `json:"expectedLocationId"`
+	ExpectedLocationName *string    `json:"expectedLocationName"`
+	FoundLocationID      *uuid.UUID `json:"foundLocationId"`
+	FoundLocationName    *string    `json:"foundLocationName"`
+	// NeedsCorrection is true if applying the corrections tracks the item to where it was found.
+	NeedsCorrection bool `json:"needsCorrection"`
+	Applied         bool `json:"applied"`
+}
+
+func NewAuditResultResponseFromModel(r model.AuditResultModel) AuditResultResponse {
+	return AuditResultResponse{
+		ItemID:               r.ItemID,
+		ItemReference:        r.ItemReference,
+		ItemIdentifier:       r.ItemIdentifier,
+		ExpectedLocationID:   r.ExpectedLocationID,
+		ExpectedLocationName: r.ExpectedLocationName,
+		FoundLocationID:      r.FoundLocationID,
+		FoundLocationName:    r.FoundLocationName,
+		NeedsCorrection:      r.NeedsCorrection(),
+		Applied:              r.Applied,
+	}
+}
+
+// AuditReconciliationResponse is the outcome of a closed audit session.
+type AuditReconciliationResponse struct {
+	// Found are the items that were expected at the audited locations and scanned.
+	Found []AuditResultResponse `json:"found"`
+	// Missing are the items that were expected at the audited locations but not scanned.
+	Missing []AuditResultResponse `json:"missing"`
+	// Unexpected are the items that were scanned but recorded somewhere else.
+	Unexpected []AuditResultResponse `json:"unexpected"`
+}
+
+func NewAuditReconciliationResponse(results []model.AuditResultModel) AuditReconciliationResponse {
+	response := AuditReconciliationResponse{
+		Found:      make([]AuditResultResponse, 0),
+		Missing:    make([]AuditResultResponse, 0),
+		Unexpected: make([]AuditResultResponse, 0),
+	}
+	for _, r := range results {
+		result := NewAuditResultResponseFromModel(r)
+		switch r.Status {
+		case model.AuditResultFound:
+			response.Found = append(response.Found, result)
+		case model.AuditResultMissing:
+			response.Missing = append(response.Missing, result)
+		case model.AuditResultUnexpected:
+			response.Unexpected = append(response.Unexpected, result)
+		}
+	}
+	return response
+}
+
+// AuditSessionDetailResponse is an audit session with the items scanned into it and, once closed, its reconciliation.
+type AuditSessionDetailResponse struct {
+	AuditSessionResponse
+	Scans          []AuditScanResponse          `json:"scans"`
+	Reconciliation *AuditReconciliationResponse `json:"reconciliation"`
+}
+
+// ApplyAuditResponse is the number of items tracked when applying the corrections of an audit session,
+// and the number of corrections skipped because the item was tracked after the session closed.
+type ApplyAuditResponse struct {
+	Tracked int `json:"tracked"`
+	Stale   int `json:"stale"`
+}
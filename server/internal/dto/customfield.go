@@ -0,0 +1,138 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"quantum/internal/model"
+	"regexp"
+	"slices"
+	"time"
+)
+
+var (
+	ErrInvalidCustomFields     = errors.New("invalid custom fields")
+	ErrInvalidCustomFieldsSpec = errors.New("invalid custom field definitions")
+)
+
+// customFieldNamePattern restricts custom field names to identifiers, so they can be used as filter query parameters.
+var customFieldNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// customFieldDateLayout is the layout of the value of a date custom field.
+const customFieldDateLayout = time.DateOnly
+
+// ValidateCustomFields checks the values against the custom field definitions of a group.
+// Values that are null or empty strings are treated as not set and are dropped from the result.
+// Returns an error wrapping ErrInvalidCustomFields naming the first invalid field.
+func ValidateCustomFields(definitions []model.CustomFieldDefinition, values map[string]any) (map[string]any, error) {
+	valid := make(map[string]any, len(values))
+
+	for name, value := range values {
+		if !slices.ContainsFunc(definitions, func(d model.CustomFieldDefinition) bool { return d.Name == name }) {
+			return nil, fmt.Errorf("%w: %s is not a custom field of the group", ErrInvalidCustomFields, name)
+		}
+		if value == nil || value == "" {
+			continue
+		}
+		valid[name] = value
+	}
+
+	for _, definition := range definitions {
+		value, set := valid[definition.Name]
+		if !set {
+			if definition.Required {
+				return nil, fmt.Errorf("%w: %s is required", ErrInvalidCustomFields, definition.Name)
+			}
+			continue
+		}
+		if err := validateCustomFieldValue(definition, value); err != nil {
+			return nil, fmt.Errorf("%w: %s %s", ErrInvalidCustomFields, definition.Name, err.Error())
+		}
+	}
+
+	return valid, nil
+}
+
+func validateCustomFieldValue(definition model.CustomFieldDefinition, value any) error {
+	if definition.Type == model.CustomFieldTypeNumber {
+		if _, ok := value.(float64); !ok {
+			return errors.New("must be a number")
+		}
+		return nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return errors.New("must be a string")
+	}
+
+	switch definition.Type {
+	case model.CustomFieldTypeDate:
+		if _, err := time.Parse(customFieldDateLayout, s); err != nil {
+			return fmt.Errorf("must be a date in the form %s", customFieldDateLayout)
+		}
+	case model.CustomFieldTypeEnum:
+		if !slices.Contains(definition.Options, s) {
+			return fmt.Errorf("must be one of %v", definition.Options)
+		}
+	case model.CustomFieldTypeRegex:
+		// The pattern is checked to compile when the definitions are set.
+		if !regexp.MustCompile(definition.Pattern).MatchString(s) {
+			return fmt.Errorf("must match %s", definition.Pattern)
+		}
+	}
+	return nil
+}
+
+// SetGroupCustomFieldsRequest replaces the custom field definitions of a group.
+type SetGroupCustomFieldsRequest struct {
+	Fields []model.CustomFieldDefinition `json:"fields"`
+}
+
+func (r *SetGroupCustomFieldsRequest) Validate() error {
+	if r.Fields == nil {
+		r.Fields = make([]model.CustomFieldDefinition, 0)
+	}
+
+	seen := make(map[string]struct{}, len(r.Fields))
+	for _, field := range r.Fields {
+		if !customFieldNamePattern.MatchString(field.Name) {
+			return fmt.Errorf("%w: name %q must start with a letter and contain only letters, digits and underscores", ErrInvalidCustomFieldsSpec, field.Name)
+		}
+		if _, duplicate := seen[field.Name]; duplicate {
+			return fmt.Errorf("%w: %s is defined more than once", ErrInvalidCustomFieldsSpec, field.Name)
+		}
+		seen[field.Name] = struct{}{}
+
+		if !field.Type.Valid() {
+			return fmt.Errorf("%w: %s has an invalid type, expected string, number, date, enum or regex", ErrInvalidCustomFieldsSpec, field.Name)
+		}
+		if field.Type == model.CustomFieldTypeEnum && len(field.Options) == 0 {
+			return fmt.Errorf("%w: enum field %s must have options", ErrInvalidCustomFieldsSpec, field.Name)
+		}
+		if field.Type != model.CustomFieldTypeEnum && len(field.Options) > 0 {
+			return fmt.Errorf("%w: only enum fields have options, %s is a %s field", ErrInvalidCustomFieldsSpec, field.Name, field.Type)
+		}
+		if field.Type == model.CustomFieldTypeRegex {
+			if field.Pattern == "" {
+				return fmt.Errorf("%w: regex field %s must have a pattern", ErrInvalidCustomFieldsSpec, field.Name)
+			}
+			if _, err := regexp.Compile(field.Pattern); err != nil {
+				return fmt.Errorf("%w: pattern of %s is not a valid regular expression", ErrInvalidCustomFieldsSpec, field.Name)
+			}
+		} else if field.Pattern != "" {
+			return fmt.Errorf("%w: only regex fields have a pattern, %s is a %s field", ErrInvalidCustomFieldsSpec, field.Name, field.Type)
+		}
+	}
+	return nil
+}
+
+// ValidCustomFieldName reports whether the name could be the name of a custom field.
+func ValidCustomFieldName(name string) bool {
+	return customFieldNamePattern.MatchString(name)
+}
+
+type GroupCustomFieldsResponse struct {
+	GroupKey  string                        `json:"groupKey"`
+	Fields    []model.CustomFieldDefinition `json:"fields"`
+	UpdatedAt *time.Time                    `json:"updatedAt"`
+}
@@ -1,13 +1,20 @@
 package dto
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
 	"quantum/internal/model"
+	"strings"
 	"time"
 )
 
-var ErrInvalidItemReference = errors.New("invalid item reference")
+var (
+	ErrInvalidItemReference = errors.New("invalid item reference")
+	ErrInvalidItemKind      = errors.New("invalid item kind, expected unit or stock")
+	ErrInvalidItemStatus    = errors.New("invalid item status, expected in-service, in-repair, on-loan, lost, retired or disposed")
+	ErrInvalidStatusReason  = errors.New("a reason is required to change the status of an item")
+)
 
 type ItemResponse struct {
 	ID              uuid.UUID            `json:"id"`
@@ -15,6 +22,9 @@ type ItemResponse struct {
 	Reference       string               `json:"reference"`
 	GroupKey        string               `json:"groupKey"`
 	Description     *string              `json:"description"`
+	Kind            model.ItemKind       `json:"kind"`
+	Status          model.ItemStatus     `json:"status"`
+	CustomFields    json.RawMessage      `json:"customFields"`
 	CurrentLocation *ItemCurrentLocation `json:"currentLocation"`
 	Deleted         bool                 `json:"deleted"`
 	CreatedAt       time.Time            `json:"createdAt"`
@@ -23,14 +33,21 @@ type ItemResponse struct {
 
 func NewItemResponseFromModel(item model.ItemModel, location *model.LocationModel) ItemResponse {
 	r := ItemResponse{
-		ID:          item.ID,
-		Identifier:  item.Identifier,
-		Reference:   item.Reference,
-		GroupKey:    item.GroupKey,
-		Description: item.Description,
-		Deleted:     item.Deleted,
-		CreatedAt:   item.CreatedAt,
-		UpdatedAt:   item.UpdatedAt,
+		ID:           item.ID,
+		Identifier:   item.Identifier,
+		Reference:    item.Reference,
+		GroupKey:     item.GroupKey,
+		Description:  item.Description,
+		Kind:         item.Kind,
+		Status:       item.Status,
+		CustomFields: item.CustomFields,
+		Deleted:      item.Deleted,
+		CreatedAt:    item.CreatedAt,
+		UpdatedAt:    item.UpdatedAt,
+	}
+
+	if len(r.CustomFields) == 0 {
+		r.CustomFields = json.RawMessage("{}")
 	}
 
 	if location != nil {
@@ -51,27 +68,113 @@ type CurrentLocation struct {
 	TrackedToUser bool      `json:"trackedToUser"`
 }
 
+// ItemLocationAtResponse is the location or user an item was tracked to at a point in time.
+type ItemLocationAtResponse struct {
+	ItemID   uuid.UUID       `json:"itemId"`
+	At       time.Time       `json:"at"`
+	Location CurrentLocation `json:"location"`
+}
+
 type ItemWithCurrentLocationResponse struct {
 	ItemResponse
 	CurrentLocation CurrentLocation `json:"currentLocation"`
 }
 
+type DeletedItemResponse struct {
+	ItemResponse
+	DeletedAt             time.Time `json:"deletedAt"`
+	DeletedByUserID       uuid.UUID `json:"deletedByUserId"`
+	DeletedByUserName     string    `json:"deletedByUserName"`
+	DeletedByUserUsername string    `json:"deletedByUserUsername"`
+}
+
+func NewDeletedItemResponseFromModel(item model.DeletedItemModel) DeletedItemResponse {
+	return DeletedItemResponse{
+		ItemResponse:          NewItemResponseFromModel(item.ItemModel, nil),
+		DeletedAt:             item.DeletedAt,
+		DeletedByUserID:       item.DeletedByUserID,
+		DeletedByUserName:     item.DeletedByUserName,
+		DeletedByUserUsername: item.DeletedByUserUsername,
+	}
+}
+
+type PurgeItemsResponse struct {
+	Purged int64 `json:"purged"`
+}
+
 type ItemCurrentLocation struct {
 	ID   uuid.UUID `json:"id"`
 	Name string    `json:"name"`
 }
 
+// CreateItemRequest creates an item at a location. Kind defaults to a unit when it is not given.
 type CreateItemRequest struct {
-	Identifier  string    `json:"identifier"`
-	Reference   string    `json:"reference"`
-	Description *string   `json:"description"`
-	GroupKey    string    `json:"groupKey"`
-	LocationID  uuid.UUID `json:"locationId"`
+	Identifier   string         `json:"identifier"`
+	Reference    string         `json:"reference"`
+	Description  *string        `json:"description"`
+	GroupKey     string         `json:"groupKey"`
+	Kind         model.ItemKind `json:"kind"`
+	CustomFields map[string]any `json:"customFields"`
+	LocationID   uuid.UUID      `json:"locationId"`
 }
 
-func (cir *CreateItemRequest) Validate() error {
+// Validate checks the request, and its custom fields against the custom field definitions of its group.
+func (cir *CreateItemRequest) Validate(customFields []model.CustomFieldDefinition) error {
 	if cir.Reference == "" {
 		return ErrInvalidItemReference
 	}
+	switch cir.Kind {
+	case "":
+		cir.Kind = model.ItemKindUnit
+	case model.ItemKindUnit, model.ItemKindStock:
+	default:
+		return ErrInvalidItemKind
+	}
+
+	values, err := ValidateCustomFields(customFields, cir.CustomFields)
+	if err != nil {
+		return err
+	}
+	cir.CustomFields = values
+	return nil
+}
+
+// UpdateItemRequest replaces the fields of an item. The custom fields are left unchanged when they are not given.
+type UpdateItemRequest struct {
+	Identifier   string         `json:"identifier"`
+	Reference    string         `json:"reference"`
+	Description  *string        `json:"description"`
+	GroupKey     string         `json:"groupKey"`
+	CustomFields map[string]any `json:"customFields"`
+}
+
+// Validate checks the request, and its custom fields against the custom field definitions of its group.
+func (uir *UpdateItemRequest) Validate(customFields []model.CustomFieldDefinition) error {
+	if uir.Reference == "" {
+		return ErrInvalidItemReference
+	}
+
+	values, err := ValidateCustomFields(customFields, uir.CustomFields)
+	if err != nil {
+		return err
+	}
+	uir.CustomFields = values
+	return nil
+}
+
+// ChangeItemStatusRequest moves an item to another lifecycle status, giving the reason for the change.
+type ChangeItemStatusRequest struct {
+	Status model.ItemStatus `json:"status"`
+	Reason string           `json:"reason"`
+}
+
+func (r *ChangeItemStatusRequest) Validate() error {
+	if !r.Status.Valid() {
+		return ErrInvalidItemStatus
+	}
+	r.Reason = strings.TrimSpace(r.Reason)
+	if r.Reason == "" {
+		return ErrInvalidStatusReason
+	}
 	return nil
 }
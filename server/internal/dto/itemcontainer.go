@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"errors"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidContainerID = errors.New("containerId is required")
+
+// PutInContainerRequest puts an item inside another item, such as a charger inside a laptop bag.
+type PutInContainerRequest struct {
+	ContainerID uuid.UUID `json:"containerId"`
+}
+
+func (r *PutInContainerRequest) Validate() error {
+	if r.ContainerID == uuid.Nil {
+		return ErrInvalidContainerID
+	}
+	return nil
+}
+
+// ItemDetailResponse is an item with the item it is inside of, nil if none, and the items directly inside it.
+type ItemDetailResponse struct {
+	ItemWithCurrentLocationResponse
+	Container *ItemResponse  `json:"container"`
+	Contents  []ItemResponse `json:"contents"`
+}
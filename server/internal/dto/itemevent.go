@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"quantum/internal/model"
+	"time"
+)
+
+// ItemEventResponse is the data of an item event sent on the event stream.
+type ItemEventResponse struct {
+	ID            int64                 `json:"id"`
+	Type          model.ItemHistoryType `json:"type"`
+	ItemID        uuid.UUID             `json:"itemId"`
+	ItemReference string                `json:"itemReference"`
+	GroupKey      string                `json:"groupKey"`
+	UserID        uuid.UUID             `json:"userId"`
+	UserName      *string               `json:"userName"`
+	ToID          *uuid.UUID            `json:"toId"`
+	FromID        *uuid.UUID            `json:"fromId"`
+	Date          time.Time             `json:"date"`
+}
+
+func NewItemEventResponseFromModel(e model.ItemEventModel) ItemEventResponse {
+	return ItemEventResponse{
+		ID:            e.ID,
+		Type:          e.Type,
+		ItemID:        e.ItemID,
+		ItemReference: e.ItemReference,
+		GroupKey:      e.GroupKey,
+		UserID:        e.UserID,
+		UserName:      e.UserName,
+		ToID:          e.ToID,
+		FromID:        e.FromID,
+		Date:          e.CreatedAt,
+	}
+}
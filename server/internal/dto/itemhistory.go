@@ -1,25 +1,43 @@
 package dto
 
 import (
-	"encoding/csv"
+	"fmt"
 	"github.com/google/uuid"
 	"quantum/internal/model"
+	"sort"
+	"strings"
 	"time"
 )
 
 const dateLayout = "Mon Jan _2 2006 15:04:05 MST"
 
 type ItemHistoryRecord interface {
-	CSV(writer *csv.Writer) error
+	// CSVRecord returns the Date, Type, User, Group, Location and Changes columns of the record.
+	CSVRecord() []string
+	// Item returns the ID and reference of the item the record belongs to.
+	Item() (uuid.UUID, string)
+	// Status returns the current status of the item the record belongs to, empty if the item no longer exists.
+	Status() model.ItemStatus
 }
 
 type ItemHistoryHeader[T any] struct {
-	Type         model.ItemHistoryType `json:"type"`
-	UserID       uuid.UUID             `json:"userId"`
-	UserName     string                `json:"userName"`
-	UserUsername string                `json:"userUsername"`
-	Date         time.Time             `json:"date"`
-	Data         T                     `json:"data"`
+	ItemID        uuid.UUID             `json:"itemId"`
+	ItemReference string                `json:"itemReference"`
+	ItemStatus    model.ItemStatus      `json:"itemStatus"`
+	Type          model.ItemHistoryType `json:"type"`
+	UserID        uuid.UUID             `json:"userId"`
+	UserName      string                `json:"userName"`
+	UserUsername  string                `json:"userUsername"`
+	Date          time.Time             `json:"date"`
+	Data          T                     `json:"data"`
+}
+
+func (h ItemHistoryHeader[T]) Item() (uuid.UUID, string) {
+	return h.ItemID, h.ItemReference
+}
+
+func (h ItemHistoryHeader[T]) Status() model.ItemStatus {
+	return h.ItemStatus
 }
 
 type CreatedItemHistoryRecordData struct {
@@ -34,8 +52,8 @@ type CreatedItemHistoryRecord struct {
 	ItemHistoryHeader[CreatedItemHistoryRecordData]
 }
 
-func (r CreatedItemHistoryRecord) CSV(w *csv.Writer) error {
-	return w.Write([]string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, r.Data.GroupKey, r.Data.LocationName})
+func (r CreatedItemHistoryRecord) CSVRecord() []string {
+	return []string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, r.Data.GroupKey, r.Data.LocationName, ""}
 }
 
 type TrackedItemHistoryRecordData struct {
@@ -48,8 +66,8 @@ type TrackedItemHistoryRecord struct {
 	ItemHistoryHeader[TrackedItemHistoryRecordData]
 }
 
-func (r TrackedItemHistoryRecord) CSV(w *csv.Writer) error {
-	return w.Write([]string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", r.Data.LocationName})
+func (r TrackedItemHistoryRecord) CSVRecord() []string {
+	return []string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", r.Data.LocationName, ""}
 }
 
 type TrackedItemUserHistoryRecordData struct {
@@ -63,8 +81,64 @@ type TrackedItemUserHistoryRecord struct {
 	ItemHistoryHeader[TrackedItemUserHistoryRecordData]
 }
 
-func (r TrackedItemUserHistoryRecord) CSV(w *csv.Writer) error {
-	return w.Write([]string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", r.Data.UserUsername})
+func (r TrackedItemUserHistoryRecord) CSVRecord() []string {
+	return []string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", r.Data.UserUsername, ""}
+}
+
+// ContainmentItemHistoryRecordData is the container an item was put inside of or taken out of.
+type ContainmentItemHistoryRecordData struct {
+	ItemReference      string    `json:"itemReference"`
+	ContainerID        uuid.UUID `json:"containerId"`
+	ContainerReference string    `json:"containerReference"`
+}
+
+// ContainmentItemHistoryRecord is a contained or an uncontained history record.
+type ContainmentItemHistoryRecord struct {
+	ItemHistoryHeader[ContainmentItemHistoryRecordData]
+}
+
+func (r ContainmentItemHistoryRecord) CSVRecord() []string {
+	changes := "container: " + r.Data.ContainerReference
+	return []string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", "", changes}
+}
+
+// StockItemHistoryRecordData is a change to the quantity of a stock item held at a location.
+type StockItemHistoryRecordData struct {
+	ItemReference      string     `json:"itemReference"`
+	LocationID         uuid.UUID  `json:"locationId"`
+	LocationName       string     `json:"locationName"`
+	Delta              int        `json:"delta"`
+	Quantity           int        `json:"quantity"`
+	TransferLocationID *uuid.UUID `json:"transferLocationId,omitempty"`
+	Note               *string    `json:"note,omitempty"`
+}
+
+// StockItemHistoryRecord is a stock-received, stock-issued, stock-transferred or stock-adjusted history record.
+type StockItemHistoryRecord struct {
+	ItemHistoryHeader[StockItemHistoryRecordData]
+}
+
+func (r StockItemHistoryRecord) CSVRecord() []string {
+	changes := fmt.Sprintf("delta: %+d; quantity: %d", r.Data.Delta, r.Data.Quantity)
+	if r.Data.Note != nil {
+		changes += "; note: " + *r.Data.Note
+	}
+	return []string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", r.Data.LocationName, changes}
+}
+
+type StatusChangedItemHistoryRecordData struct {
+	From   model.ItemStatus `json:"from"`
+	To     model.ItemStatus `json:"to"`
+	Reason string           `json:"reason"`
+}
+
+type StatusChangedItemHistoryRecord struct {
+	ItemHistoryHeader[StatusChangedItemHistoryRecordData]
+}
+
+func (r StatusChangedItemHistoryRecord) CSVRecord() []string {
+	changes := fmt.Sprintf("status: %s -> %s; reason: %s", r.Data.From, r.Data.To, r.Data.Reason)
+	return []string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", "", changes}
 }
 
 type DeletedItemHistoryRecordData struct{}
@@ -73,6 +147,48 @@ type DeletedItemHistoryRecord struct {
 	ItemHistoryHeader[DeletedItemHistoryRecordData]
 }
 
-func (r DeletedItemHistoryRecord) CSV(w *csv.Writer) error {
-	return w.Write([]string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", ""})
+func (r DeletedItemHistoryRecord) CSVRecord() []string {
+	return []string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", "", ""}
+}
+
+type RestoredItemHistoryRecordData struct{}
+
+type RestoredItemHistoryRecord struct {
+	ItemHistoryHeader[RestoredItemHistoryRecordData]
+}
+
+func (r RestoredItemHistoryRecord) CSVRecord() []string {
+	return []string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", "", ""}
+}
+
+type UpdatedItemHistoryRecordData struct {
+	ItemReference string                           `json:"itemReference"`
+	UpdatedFields map[string]model.ItemFieldChange `json:"updatedFields"`
+}
+
+type UpdatedItemHistoryRecord struct {
+	ItemHistoryHeader[UpdatedItemHistoryRecordData]
+}
+
+func (r UpdatedItemHistoryRecord) CSVRecord() []string {
+	fields := make([]string, 0, len(r.Data.UpdatedFields))
+	for field := range r.Data.UpdatedFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	changes := make([]string, len(fields))
+	for i, field := range fields {
+		change := r.Data.UpdatedFields[field]
+		changes[i] = fmt.Sprintf("%s: %s -> %s", field, stringOrEmpty(change.Old), stringOrEmpty(change.New))
+	}
+
+	return []string{r.Date.Format(dateLayout), r.Type.String(), r.UserName, "", "", strings.Join(changes, "; ")}
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
 }
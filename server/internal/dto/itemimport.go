@@ -0,0 +1,119 @@
+package dto
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"strings"
+)
+
+var ErrImportNoHeader = errors.New("import file must start with a header row")
+
+// importColumnAliases maps the normalised CSV header names to the item field they populate.
+var importColumnAliases = map[string]string{
+	"identifier":   "identifier",
+	"reference":    "reference",
+	"group":        "group",
+	"groupkey":     "group",
+	"description":  "description",
+	"location":     "location",
+	"locationname": "location",
+}
+
+var requiredImportColumns = []string{"identifier", "reference", "group", "location"}
+
+type ImportItemRow struct {
+	// Row is the line number of the row in the CSV file, the header being line 1.
+	Row          int
+	Identifier   string
+	Reference    string
+	GroupKey     string
+	Description  *string
+	LocationName string
+}
+
+// ParseImportItemsCSV reads the item rows from the given CSV.
+// The first row must be a header naming the identifier, reference, group, description and location columns.
+// Header names are case-insensitive and ignore spaces and underscores, the description column is optional.
+func ParseImportItemsCSV(r io.Reader) ([]ImportItemRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrImportNoHeader
+		}
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		normalised := strings.ToLower(strings.NewReplacer(" ", "", "_", "").Replace(strings.TrimSpace(name)))
+		if field, ok := importColumnAliases[normalised]; ok {
+			columns[field] = i
+		}
+	}
+
+	for _, column := range requiredImportColumns {
+		if _, ok := columns[column]; !ok {
+			return nil, fmt.Errorf("import file is missing the %s column", column)
+		}
+	}
+
+	value := func(record []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := make([]ImportItemRow, 0)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := ImportItemRow{
+			Row:          line,
+			Identifier:   value(record, "identifier"),
+			Reference:    value(record, "reference"),
+			GroupKey:     value(record, "group"),
+			LocationName: value(record, "location"),
+		}
+
+		if description := value(record, "description"); description != "" {
+			row.Description = &description
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+type ImportItemRowResult struct {
+	Row       int        `json:"row"`
+	Reference string     `json:"reference"`
+	Valid     bool       `json:"valid"`
+	Imported  bool       `json:"imported"`
+	ItemID    *uuid.UUID `json:"itemId"`
+	Errors    []string   `json:"errors"`
+}
+
+type ImportItemsResponse struct {
+	DryRun   bool                  `json:"dryRun"`
+	Total    int                   `json:"total"`
+	Valid    int                   `json:"valid"`
+	Invalid  int                   `json:"invalid"`
+	Imported int                   `json:"imported"`
+	Rows     []ImportItemRowResult `json:"rows"`
+}
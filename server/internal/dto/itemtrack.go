@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+// bulkTrackMaxItems is the maximum number of items that can be tracked in a single request.
+const bulkTrackMaxItems = 1000
+
+var (
+	ErrBulkTrackNoItems       = errors.New("at least one item id or reference is required")
+	ErrBulkTrackInvalidTarget = errors.New("exactly one of locationId or userId is required")
+	ErrBulkTrackTooManyItems  = errors.New("too many items in a single request")
+	ErrBulkTrackDueAtLocation = errors.New("dueAt can only be given when tracking to a user")
+)
+
+// BulkTrackItemsRequest tracks many items to a single location or user.
+// Items can be identified by ID, reference or a mix of both.
+type BulkTrackItemsRequest struct {
+	ItemIDs    []uuid.UUID `json:"itemIds"`
+	References []string    `json:"references"`
+	LocationID *uuid.UUID  `json:"locationId"`
+	UserID     *uuid.UUID  `json:"userId"`
+	// DueAt is when items tracked to a user are expected back.
+	DueAt *time.Time `json:"dueAt"`
+	// Partial tracks the items that can be tracked when some cannot, otherwise no items are tracked if any fail.
+	Partial bool `json:"partial"`
+}
+
+func (r *BulkTrackItemsRequest) Validate() error {
+	count := len(r.ItemIDs) + len(r.References)
+	if count == 0 {
+		return ErrBulkTrackNoItems
+	}
+	if count > bulkTrackMaxItems {
+		return ErrBulkTrackTooManyItems
+	}
+	if (r.LocationID == nil) == (r.UserID == nil) {
+		return ErrBulkTrackInvalidTarget
+	}
+	if r.DueAt != nil && r.UserID == nil {
+		return ErrBulkTrackDueAtLocation
+	}
+	return nil
+}
+
+type BulkTrackItemResult struct {
+	ItemID    *uuid.UUID `json:"itemId"`
+	Reference string     `json:"reference"`
+	Tracked   bool       `json:"tracked"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type BulkTrackItemsResponse struct {
+	Tracked int                   `json:"tracked"`
+	Failed  int                   `json:"failed"`
+	Results []BulkTrackItemResult `json:"results"`
+	// ReservationWarnings are the reservations held by other users of the items that were tracked to a user anyway.
+	ReservationWarnings []ReservationResponse `json:"reservationWarnings,omitempty"`
+}
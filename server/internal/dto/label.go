@@ -0,0 +1,40 @@
+package dto
+
+import (
+	"fmt"
+	"quantum/pkg/label"
+	"strings"
+)
+
+// NewItemLabel returns the label of an item, encoding its reference.
+func NewItemLabel(item ItemResponse, symbology label.Symbology, terms TerminologySettingsResponse) label.Label {
+	return label.Label{
+		Symbology: symbology,
+		Data:      item.Reference,
+		Title:     item.Identifier,
+		Lines: []string{
+			fmt.Sprintf("%s %s", terms.Item, item.Reference),
+			fmt.Sprintf("%s: %s", terms.Group, item.GroupKey),
+		},
+	}
+}
+
+// NewLocationLabel returns the label of a location, encoding its ID.
+// The breadcrumb of the parent locations is printed below the name when the location is nested.
+func NewLocationLabel(location LocationResponse, symbology label.Symbology, terms TerminologySettingsResponse) label.Label {
+	lines := []string{terms.Location}
+	if len(location.Path) > 1 {
+		names := make([]string, 0, len(location.Path)-1)
+		for _, p := range location.Path[:len(location.Path)-1] {
+			names = append(names, p.Name)
+		}
+		lines[0] = fmt.Sprintf("%s in %s", terms.Location, strings.Join(names, " / "))
+	}
+
+	return label.Label{
+		Symbology: symbology,
+		Data:      location.ID.String(),
+		Title:     location.Name,
+		Lines:     lines,
+	}
+}
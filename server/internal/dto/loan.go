@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"github.com/google/uuid"
+	"quantum/internal/model"
+	"time"
+)
+
+// TrackItemToUserRequest is the optional body when checking an item out to a user.
+type TrackItemToUserRequest struct {
+	DueAt *time.Time `json:"dueAt"`
+}
+
+// CheckInItemRequest returns an item held by a user to a location.
+// Without a LocationID the item returns to the location it was at before it was checked out.
+type CheckInItemRequest struct {
+	LocationID *uuid.UUID `json:"locationId"`
+}
+
+type LoanResponse struct {
+	Item         ItemResponse `json:"item"`
+	CheckedOutAt time.Time    `json:"checkedOutAt"`
+	DueAt        *time.Time   `json:"dueAt"`
+	Overdue      bool         `json:"overdue"`
+}
+
+func NewLoanResponseFromModel(l model.LoanModel, now time.Time) LoanResponse {
+	return LoanResponse{
+		Item:         NewItemResponseFromModel(l.ItemModel, nil),
+		CheckedOutAt: l.CheckedOutAt,
+		DueAt:        l.DueAt,
+		Overdue:      l.DueAt != nil && l.DueAt.Before(now),
+	}
+}
+
+// LoanHolderResponse is a user along with the items they hold.
+type LoanHolderResponse struct {
+	UserID   uuid.UUID      `json:"userId"`
+	Name     *string        `json:"name"`
+	Username *string        `json:"username"`
+	Loans    []LoanResponse `json:"loans"`
+}
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
 	"quantum/internal/model"
@@ -10,13 +11,29 @@ import (
 var ErrInvalidLocationName = errors.New("invalid location name")
 
 type LocationResponse struct {
-	ID          uuid.UUID `json:"id"`
-	Name        string    `json:"name"`
-	Description *string   `json:"description"`
-	IsDeleted   bool      `json:"isDeleted"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
-	IsUser      bool      `json:"isUser"`
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	ParentID    *uuid.UUID `json:"parentId"`
+	// Path is the breadcrumb from the top level location down to, and including, this location.
+	Path      []LocationPathItem `json:"path"`
+	IsDeleted bool               `json:"isDeleted"`
+	CreatedAt time.Time          `json:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt"`
+	IsUser    bool               `json:"isUser"`
+}
+
+type LocationPathItem struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// LocationTreeNode is a location along with the locations directly inside it.
+type LocationTreeNode struct {
+	ID          uuid.UUID          `json:"id"`
+	Name        string             `json:"name"`
+	Description *string            `json:"description"`
+	Children    []LocationTreeNode `json:"children"`
 }
 
 func NewLocationResponseFromModel(l model.LocationModel) LocationResponse {
@@ -24,6 +41,8 @@ func NewLocationResponseFromModel(l model.LocationModel) LocationResponse {
 		ID:          l.ID,
 		Name:        l.Name,
 		Description: l.Description,
+		ParentID:    l.ParentID,
+		Path:        []LocationPathItem{{ID: l.ID, Name: l.Name}},
 		IsDeleted:   l.IsDeleted,
 		CreatedAt:   l.CreatedAt,
 		UpdatedAt:   l.UpdatedAt,
@@ -32,8 +51,9 @@ func NewLocationResponseFromModel(l model.LocationModel) LocationResponse {
 }
 
 type CreateLocationRequest struct {
-	Name        string  `json:"name"`
-	Description *string `json:"description"`
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	ParentID    *uuid.UUID `json:"parentId"`
 }
 
 func (clr *CreateLocationRequest) Validate() error {
@@ -42,3 +62,52 @@ func (clr *CreateLocationRequest) Validate() error {
 	}
 	return nil
 }
+
+type UpdateLocationRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"`
+}
+
+func (ulr *UpdateLocationRequest) Validate() error {
+	if ulr.Name == "" {
+		return ErrInvalidLocationName
+	}
+	return nil
+}
+
+// MoveLocationRequest moves a location, along with everything inside it, under a new parent.
+// A nil ParentID makes the location a top level location.
+type MoveLocationRequest struct {
+	ParentID *uuid.UUID `json:"parentId"`
+}
+
+// LocationHasItemsResponse is the error returned when a location cannot be deleted because items are still at it.
+type LocationHasItemsResponse struct {
+	Error string         `json:"error"`
+	Items []ItemResponse `json:"items"`
+}
+
+type LocationHistoryResponse struct {
+	ID        int64                     `json:"id"`
+	Type      model.LocationHistoryType `json:"type"`
+	UserID    uuid.UUID                 `json:"userId"`
+	UserName  *string                   `json:"userName"`
+	Data      json.RawMessage           `json:"data"`
+	CreatedAt time.Time                 `json:"createdAt"`
+}
+
+func NewLocationHistoryResponseFromModel(h model.LocationHistoryModel) (LocationHistoryResponse, error) {
+	var container model.LocationHistoryDataContainer
+	if err := json.Unmarshal(h.Data, &container); err != nil {
+		return LocationHistoryResponse{}, err
+	}
+
+	return LocationHistoryResponse{
+		ID:        h.ID,
+		Type:      container.Type,
+		UserID:    h.UserID,
+		UserName:  h.UserName,
+		Data:      container.Data,
+		CreatedAt: h.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,94 @@
+package dto
+
+import (
+	"encoding/csv"
+	"github.com/google/uuid"
+	"quantum/internal/model"
+	"time"
+)
+
+// LocationDiffItem is an item that arrived at, left or stayed at a location, with the movement that caused it.
+type LocationDiffItem struct {
+	Item                ItemResponse             `json:"item"`
+	Change              model.LocationDiffChange `json:"change"`
+	MovementType        model.ItemHistoryType    `json:"movementType"`
+	MovedAt             time.Time                `json:"movedAt"`
+	MovedByUserID       uuid.UUID                `json:"movedByUserId"`
+	MovedByUserName     string                   `json:"movedByUserName"`
+	MovedByUserUsername string                   `json:"movedByUserUsername"`
+	DestinationID       *uuid.UUID               `json:"destinationId"`
+	DestinationName     *string                  `json:"destinationName"`
+}
+
+func NewLocationDiffItemFromModel(m model.LocationDiffModel) LocationDiffItem {
+	return LocationDiffItem{
+		Item:                NewItemResponseFromModel(m.ItemModel, nil),
+		Change:              m.Change,
+		MovementType:        m.MovementType,
+		MovedAt:             m.MovedAt,
+		MovedByUserID:       m.MovedByUserID,
+		MovedByUserName:     m.MovedByUserName,
+		MovedByUserUsername: m.MovedByUserUsername,
+		DestinationID:       m.DestinationID,
+		DestinationName:     m.DestinationName,
+	}
+}
+
+// LocationDiffResponse is the change in the contents of a location between two points in time.
+type LocationDiffResponse struct {
+	LocationID uuid.UUID          `json:"locationId"`
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"`
+	Arrived    []LocationDiffItem `json:"arrived"`
+	Left       []LocationDiffItem `json:"left"`
+	Stayed     []LocationDiffItem `json:"stayed"`
+}
+
+func NewLocationDiffResponse(locationID uuid.UUID, from, to time.Time, items []model.LocationDiffModel) LocationDiffResponse {
+	r := LocationDiffResponse{
+		LocationID: locationID,
+		From:       from,
+		To:         to,
+		Arrived:    make([]LocationDiffItem, 0),
+		Left:       make([]LocationDiffItem, 0),
+		Stayed:     make([]LocationDiffItem, 0),
+	}
+
+	for _, item := range items {
+		switch item.Change {
+		case model.LocationDiffChangeArrived:
+			r.Arrived = append(r.Arrived, NewLocationDiffItemFromModel(item))
+		case model.LocationDiffChangeLeft:
+			r.Left = append(r.Left, NewLocationDiffItemFromModel(item))
+		case model.LocationDiffChangeStayed:
+			r.Stayed = append(r.Stayed, NewLocationDiffItemFromModel(item))
+		}
+	}
+
+	return r
+}
+
+// CSV writes the diff with a header row using the configured terminology.
+func (r LocationDiffResponse) CSV(w *csv.Writer, terms TerminologySettingsResponse) error {
+	if err := w.Write([]string{"Change", terms.Item, terms.Group, "Date", "User", "Movement", "Destination"}); err != nil {
+		return err
+	}
+
+	for _, group := range [][]LocationDiffItem{r.Arrived, r.Left, r.Stayed} {
+		for _, item := range group {
+			record := []string{
+				string(item.Change),
+				item.Item.Reference,
+				item.Item.GroupKey,
+				item.MovedAt.Format(dateLayout),
+				item.MovedByUserName,
+				string(item.MovementType),
+				stringOrEmpty(item.DestinationName),
+			}
+			if err := w.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
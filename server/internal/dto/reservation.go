@@ -0,0 +1,153 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"quantum/internal/model"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidReservationItem   = errors.New("itemId is required")
+	ErrInvalidReservationWindow = errors.New("startsAt must be before endsAt")
+)
+
+// CreateReservationRequest books an item for a time window.
+// UserID is the user the item is reserved for, defaulting to the user making the request.
+type CreateReservationRequest struct {
+	ItemID   uuid.UUID  `json:"itemId"`
+	UserID   *uuid.UUID `json:"userId"`
+	Purpose  string     `json:"purpose"`
+	StartsAt time.Time  `json:"startsAt"`
+	EndsAt   time.Time  `json:"endsAt"`
+}
+
+func (r *CreateReservationRequest) Validate() error {
+	if r.ItemID == uuid.Nil {
+		return ErrInvalidReservationItem
+	}
+	if !r.StartsAt.Before(r.EndsAt) {
+		return ErrInvalidReservationWindow
+	}
+	return nil
+}
+
+type ReservationResponse struct {
+	ID              uuid.UUID `json:"id"`
+	ItemID          uuid.UUID `json:"itemId"`
+	ItemReference   string    `json:"itemReference"`
+	ItemIdentifier  string    `json:"itemIdentifier"`
+	GroupKey        string    `json:"groupKey"`
+	UserID          uuid.UUID `json:"userId"`
+	UserName        string    `json:"userName"`
+	UserUsername    string    `json:"userUsername"`
+	Purpose         string    `json:"purpose"`
+	StartsAt        time.Time `json:"startsAt"`
+	EndsAt          time.Time `json:"endsAt"`
+	CreatedByUserID uuid.UUID `json:"createdByUserId"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+func NewReservationResponseFromModel(r model.ReservationDetailModel) ReservationResponse {
+	return ReservationResponse{
+		ID:              r.ID,
+		ItemID:          r.ItemID,
+		ItemReference:   r.ItemReference,
+		ItemIdentifier:  r.ItemIdentifier,
+		GroupKey:        r.ItemGroupKey,
+		UserID:          r.UserID,
+		UserName:        r.UserName,
+		UserUsername:    r.UserUsername,
+		Purpose:         r.Purpose,
+		StartsAt:        r.StartsAt,
+		EndsAt:          r.EndsAt,
+		CreatedByUserID: r.CreatedByUserID,
+		CreatedAt:       r.CreatedAt,
+	}
+}
+
+// ItemReservedResponse is the error returned when items cannot be tracked because they are reserved by someone else.
+type ItemReservedResponse struct {
+	Error        string                `json:"error"`
+	Reservations []ReservationResponse `json:"reservations"`
+}
+
+// ReservationFeedResponse is the address calendar apps subscribe to for the reservations of a user.
+type ReservationFeedResponse struct {
+	URL string `json:"url"`
+}
+
+// icsTimeFormat is the UTC date-time format used by iCalendar.
+const icsTimeFormat = "20060102T150405Z"
+
+// icsMaxLineLength is the number of octets after which iCalendar content lines are folded.
+const icsMaxLineLength = 75
+
+// WriteReservationsICS writes the reservations as an iCalendar (RFC 5545) calendar with one event per reservation.
+func WriteReservationsICS(w io.Writer, name string, reservations []ReservationResponse, terms TerminologySettingsResponse) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Quantum//Reservations//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:" + icsEscape(name),
+	}
+
+	for _, r := range reservations {
+		summary := fmt.Sprintf("%s %s reserved", terms.Item, r.ItemReference)
+		description := fmt.Sprintf("%s: %s\n%s: %s", terms.Item, r.ItemIdentifier, terms.Group, r.GroupKey)
+		if r.Purpose != "" {
+			description = r.Purpose + "\n" + description
+		}
+
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+r.ID.String()+"@quantum",
+			"DTSTAMP:"+r.CreatedAt.UTC().Format(icsTimeFormat),
+			"DTSTART:"+r.StartsAt.UTC().Format(icsTimeFormat),
+			"DTEND:"+r.EndsAt.UTC().Format(icsTimeFormat),
+			"SUMMARY:"+icsEscape(summary),
+			"DESCRIPTION:"+icsEscape(description),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, icsFold(line)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// icsEscape escapes a TEXT value for use in an iCalendar content line.
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icsFold terminates the content line with CRLF, folding it so that no line is longer than icsMaxLineLength octets.
+// Continuation lines start with a space and multi-byte characters are never split.
+func icsFold(line string) string {
+	var b strings.Builder
+	limit := icsMaxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length.
+		limit = icsMaxLineLength - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
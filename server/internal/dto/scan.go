@@ -0,0 +1,43 @@
+package dto
+
+type ScanResultType string
+
+const (
+	ScanResultItem     ScanResultType = "item"
+	ScanResultLocation ScanResultType = "location"
+	ScanResultUnknown  ScanResultType = "unknown"
+)
+
+// ScanMatch is how a scanned code was matched to an item or location.
+type ScanMatch string
+
+const (
+	ScanMatchID         ScanMatch = "id"
+	ScanMatchReference  ScanMatch = "reference"
+	ScanMatchIdentifier ScanMatch = "identifier"
+	ScanMatchName       ScanMatch = "name"
+)
+
+// GS1ElementResponse is an Application Identifier and its value read from a GS1 element string.
+type GS1ElementResponse struct {
+	AI    string `json:"ai"`
+	Title string `json:"title,omitempty"`
+	Value string `json:"value"`
+}
+
+// ScanResponse is the item or location a scanned code resolved to.
+// When the code is unknown, Suggested holds the values to offer when creating an item for it.
+type ScanResponse struct {
+	Code         string                           `json:"code"`
+	Type         ScanResultType                   `json:"type"`
+	MatchedBy    ScanMatch                        `json:"matchedBy,omitempty"`
+	MatchedValue string                           `json:"matchedValue,omitempty"`
+	GS1          []GS1ElementResponse             `json:"gs1,omitempty"`
+	Item         *ItemWithCurrentLocationResponse `json:"item,omitempty"`
+	Location     *LocationResponse                `json:"location,omitempty"`
+	Suggested    *ScanSuggestion                  `json:"suggested,omitempty"`
+}
+
+type ScanSuggestion struct {
+	Reference string `json:"reference"`
+}
@@ -2,9 +2,14 @@ package dto
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"quantum/internal/model"
+	"slices"
 )
 
+var ErrInvalidStatusTransitions = errors.New("invalid status transitions")
+
 type TerminologySettingsResponse struct {
 	Item      string `json:"item"`
 	Items     string `json:"items"`
@@ -14,8 +19,50 @@ type TerminologySettingsResponse struct {
 	Groups    string `json:"groups"`
 }
 
+type TrashSettingsResponse struct {
+	// RetentionDays is the number of days a deleted item is kept before it can be purged.
+	RetentionDays int `json:"retentionDays"`
+}
+
+type ReservationSettingsResponse struct {
+	// BlockTracking rejects tracking an item to a user while another user holds a reservation in progress.
+	// When false the item is tracked and the reservation is returned as a warning.
+	BlockTracking bool `json:"blockTracking"`
+}
+
+type StatusSettingsResponse struct {
+	// Transitions are the statuses an item with each status may be changed to.
+	// A status that is not a key cannot be changed from.
+	Transitions map[model.ItemStatus][]model.ItemStatus `json:"transitions"`
+}
+
+// Allowed reports whether an item may be changed from one status to the other.
+func (s StatusSettingsResponse) Allowed(from, to model.ItemStatus) bool {
+	return slices.Contains(s.Transitions[from], to)
+}
+
+func (s StatusSettingsResponse) Validate() error {
+	for from, to := range s.Transitions {
+		if !from.Valid() {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidStatusTransitions, from)
+		}
+		for _, status := range to {
+			if !status.Valid() {
+				return fmt.Errorf("%w: unknown status %q", ErrInvalidStatusTransitions, status)
+			}
+			if status == from {
+				return fmt.Errorf("%w: %s cannot transition to itself", ErrInvalidStatusTransitions, from)
+			}
+		}
+	}
+	return nil
+}
+
 type SettingsResponse struct {
-	Terminology TerminologySettingsResponse `json:"terminology"`
+	Terminology  TerminologySettingsResponse `json:"terminology"`
+	Trash        TrashSettingsResponse       `json:"trash"`
+	Reservations ReservationSettingsResponse `json:"reservations"`
+	Statuses     StatusSettingsResponse      `json:"statuses"`
 }
 
 func NewSettingsResponseFromModel(m model.SettingsModel) (SettingsResponse, error) {
@@ -0,0 +1,180 @@
+package dto
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"quantum/internal/model"
+	"time"
+)
+
+var (
+	ErrInvalidStockItem      = errors.New("itemId is required")
+	ErrInvalidStockLocation  = errors.New("locationId is required")
+	ErrInvalidStockQuantity  = errors.New("quantity must be greater than zero")
+	ErrInvalidStockDelta     = errors.New("delta must not be zero")
+	ErrInvalidStockTransfer  = errors.New("fromLocationId and toLocationId must be different locations")
+	ErrInvalidStockGroupKey  = errors.New("groupKey is required")
+	ErrInvalidStockThreshold = errors.New("threshold must not be negative")
+)
+
+// StockMovementRequest receives stock into, or issues stock from, a location.
+type StockMovementRequest struct {
+	ItemID     uuid.UUID `json:"itemId"`
+	LocationID uuid.UUID `json:"locationId"`
+	Quantity   int       `json:"quantity"`
+	Note       *string   `json:"note"`
+}
+
+func (r *StockMovementRequest) Validate() error {
+	if r.ItemID == uuid.Nil {
+		return ErrInvalidStockItem
+	}
+	if r.LocationID == uuid.Nil {
+		return ErrInvalidStockLocation
+	}
+	if r.Quantity <= 0 {
+		return ErrInvalidStockQuantity
+	}
+	return nil
+}
+
+// StockTransferRequest moves a quantity of stock from one location to another.
+type StockTransferRequest struct {
+	ItemID         uuid.UUID `json:"itemId"`
+	FromLocationID uuid.UUID `json:"fromLocationId"`
+	ToLocationID   uuid.UUID `json:"toLocationId"`
+	Quantity       int       `json:"quantity"`
+	Note           *string   `json:"note"`
+}
+
+func (r *StockTransferRequest) Validate() error {
+	if r.ItemID == uuid.Nil {
+		return ErrInvalidStockItem
+	}
+	if r.FromLocationID == uuid.Nil || r.ToLocationID == uuid.Nil {
+		return ErrInvalidStockLocation
+	}
+	if r.FromLocationID == r.ToLocationID {
+		return ErrInvalidStockTransfer
+	}
+	if r.Quantity <= 0 {
+		return ErrInvalidStockQuantity
+	}
+	return nil
+}
+
+// StockAdjustRequest corrects the quantity of stock held at a location by a signed delta, for example after a count.
+type StockAdjustRequest struct {
+	ItemID     uuid.UUID `json:"itemId"`
+	LocationID uuid.UUID `json:"locationId"`
+	Delta      int       `json:"delta"`
+	Note       *string   `json:"note"`
+}
+
+func (r *StockAdjustRequest) Validate() error {
+	if r.ItemID == uuid.Nil {
+		return ErrInvalidStockItem
+	}
+	if r.LocationID == uuid.Nil {
+		return ErrInvalidStockLocation
+	}
+	if r.Delta == 0 {
+		return ErrInvalidStockDelta
+	}
+	return nil
+}
+
+type StockLevelResponse struct {
+	LocationID   uuid.UUID `json:"locationId"`
+	LocationName string    `json:"locationName"`
+	Quantity     int       `json:"quantity"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// ItemStockResponse is the quantity of a stock item held at each location and in total.
+type ItemStockResponse struct {
+	ItemID    uuid.UUID            `json:"itemId"`
+	Reference string               `json:"reference"`
+	GroupKey  string               `json:"groupKey"`
+	Total     int                  `json:"total"`
+	Levels    []StockLevelResponse `json:"levels"`
+}
+
+func NewItemStockResponse(item model.ItemModel, levels []model.StockLevelModel) ItemStockResponse {
+	response := ItemStockResponse{
+		ItemID:    item.ID,
+		Reference: item.Reference,
+		GroupKey:  item.GroupKey,
+		Levels:    make([]StockLevelResponse, len(levels)),
+	}
+	for i, l := range levels {
+		response.Total += l.Quantity
+		response.Levels[i] = StockLevelResponse{
+			LocationID:   l.LocationID,
+			LocationName: l.LocationName,
+			Quantity:     l.Quantity,
+			UpdatedAt:    l.UpdatedAt,
+		}
+	}
+	return response
+}
+
+// StockThresholdRequest sets the quantity of a group of stock items below which a location should reorder.
+type StockThresholdRequest struct {
+	GroupKey   string    `json:"groupKey"`
+	LocationID uuid.UUID `json:"locationId"`
+	Threshold  int       `json:"threshold"`
+}
+
+func (r *StockThresholdRequest) Validate() error {
+	if r.GroupKey == "" {
+		return ErrInvalidStockGroupKey
+	}
+	if r.LocationID == uuid.Nil {
+		return ErrInvalidStockLocation
+	}
+	if r.Threshold < 0 {
+		return ErrInvalidStockThreshold
+	}
+	return nil
+}
+
+type StockThresholdResponse struct {
+	GroupKey     string    `json:"groupKey"`
+	LocationID   uuid.UUID `json:"locationId"`
+	LocationName string    `json:"locationName"`
+	Threshold    int       `json:"threshold"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func NewStockThresholdResponseFromModel(t model.StockThresholdModel) StockThresholdResponse {
+	return StockThresholdResponse{
+		GroupKey:     t.GroupKey,
+		LocationID:   t.LocationID,
+		LocationName: t.LocationName,
+		Threshold:    t.Threshold,
+		UpdatedAt:    t.UpdatedAt,
+	}
+}
+
+// LowStockResponse is a group of stock items held at a location below its reorder threshold.
+// Shortfall is how many are needed to reach the threshold.
+type LowStockResponse struct {
+	GroupKey     string    `json:"groupKey"`
+	LocationID   uuid.UUID `json:"locationId"`
+	LocationName string    `json:"locationName"`
+	Threshold    int       `json:"threshold"`
+	Quantity     int       `json:"quantity"`
+	Shortfall    int       `json:"shortfall"`
+}
+
+func NewLowStockResponseFromModel(l model.LowStockModel) LowStockResponse {
+	return LowStockResponse{
+		GroupKey:     l.GroupKey,
+		LocationID:   l.LocationID,
+		LocationName: l.LocationName,
+		Threshold:    l.Threshold,
+		Quantity:     l.Quantity,
+		Shortfall:    l.Threshold - l.Quantity,
+	}
+}
@@ -0,0 +1,118 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"net/url"
+	"quantum/internal/model"
+	"time"
+)
+
+var (
+	ErrInvalidWebhookURL       = errors.New("invalid webhook url, expected an absolute http or https url")
+	ErrInvalidWebhookEventType = errors.New("invalid webhook event type")
+)
+
+type WebhookResponse struct {
+	ID         uuid.UUID `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+func NewWebhookResponseFromModel(w model.WebhookModel) WebhookResponse {
+	eventTypes := []string(w.EventTypes)
+	if eventTypes == nil {
+		eventTypes = make([]string, 0)
+	}
+
+	return WebhookResponse{
+		ID:         w.ID,
+		URL:        w.URL,
+		EventTypes: eventTypes,
+		Active:     w.Active,
+		CreatedAt:  w.CreatedAt,
+		UpdatedAt:  w.UpdatedAt,
+	}
+}
+
+// WebhookWithSecretResponse is returned when a webhook is created or its secret changed,
+// it is the only time the secret is returned.
+type WebhookWithSecretResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+// WebhookRequest creates or updates a webhook.
+// An empty EventTypes subscribes to all events, a nil Secret generates a new secret on creation and keeps
+// the existing secret on update, and a nil Active leaves the webhook active on creation and unchanged on update.
+type WebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"eventTypes"`
+	Secret     *string  `json:"secret"`
+	Active     *bool    `json:"active"`
+}
+
+func (r *WebhookRequest) Validate() error {
+	u, err := url.Parse(r.URL)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	for _, eventType := range r.EventTypes {
+		if !model.ItemHistoryType(eventType).Valid() {
+			return ErrInvalidWebhookEventType
+		}
+	}
+	return nil
+}
+
+type WebhookDeliveryAttemptResponse struct {
+	StatusCode *int      `json:"statusCode"`
+	Error      *string   `json:"error"`
+	DurationMS int       `json:"durationMs"`
+	Date       time.Time `json:"date"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID            int64                            `json:"id"`
+	EventType     model.ItemHistoryType            `json:"eventType"`
+	Payload       json.RawMessage                  `json:"payload"`
+	Status        model.WebhookDeliveryStatus      `json:"status"`
+	Attempts      int                              `json:"attempts"`
+	NextAttemptAt *time.Time                       `json:"nextAttemptAt"`
+	DeliveredAt   *time.Time                       `json:"deliveredAt"`
+	CreatedAt     time.Time                        `json:"createdAt"`
+	AttemptLog    []WebhookDeliveryAttemptResponse `json:"attemptLog"`
+}
+
+func NewWebhookDeliveryResponseFromModel(d model.WebhookDeliveryModel, attempts []model.WebhookDeliveryAttemptModel) WebhookDeliveryResponse {
+	r := WebhookDeliveryResponse{
+		ID:          d.ID,
+		EventType:   d.EventType,
+		Payload:     d.Payload,
+		Status:      d.Status,
+		Attempts:    d.Attempts,
+		DeliveredAt: d.DeliveredAt,
+		CreatedAt:   d.CreatedAt,
+		AttemptLog:  make([]WebhookDeliveryAttemptResponse, len(attempts)),
+	}
+
+	if d.Status == model.WebhookDeliveryStatusPending {
+		r.NextAttemptAt = &d.NextAttemptAt
+	}
+
+	for i, a := range attempts {
+		r.AttemptLog[i] = WebhookDeliveryAttemptResponse{
+			StatusCode: a.StatusCode,
+			Error:      a.Error,
+			DurationMS: a.DurationMS,
+			Date:       a.CreatedAt,
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/service"
+	"quantum/pkg/res"
+)
+
+var (
+	ErrInvalidAuditLocationID = errors.New("invalid locationId, expected a UUID")
+	ErrInvalidAuditStatus     = errors.New("invalid status, expected open or closed")
+)
+
+type AuditHandler struct {
+	auditService *service.AuditService
+	logger       *slog.Logger
+}
+
+func NewAuditHandler(auditService *service.AuditService, logger *slog.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+func (h *AuditHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
+	mux.HandleFunc("GET /api/v1/audit", mf(h.listAudits))
+	mux.HandleFunc("POST /api/v1/audit", mf(h.startAudit))
+	mux.HandleFunc("GET /api/v1/audit/{auditId}", mf(h.getAudit))
+	mux.HandleFunc("POST /api/v1/audit/{auditId}/scan", mf(h.scanAuditItem))
+	mux.HandleFunc("DELETE /api/v1/audit/{auditId}/scan/{itemId}", mf(h.deleteAuditScan))
+	mux.HandleFunc("POST /api/v1/audit/{auditId}/close", mf(h.closeAudit))
+	mux.HandleFunc("POST /api/v1/audit/{auditId}/apply", mf(h.applyAudit))
+}
+
+// listAudits lists audit sessions, optionally filtered by the locationId and status query parameters.
+func (h *AuditHandler) listAudits(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	locationID, err := getUUIDQueryParam(r, "locationId")
+	if err != nil {
+		res.Error(w, ErrInvalidAuditLocationID.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var status *model.AuditStatus
+	if value := r.URL.Query().Get("status"); value != "" {
+		s := model.AuditStatus(value)
+		if s != model.AuditStatusOpen && s != model.AuditStatusClosed {
+			res.Error(w, ErrInvalidAuditStatus.Error(), http.StatusBadRequest)
+			return
+		}
+		status = &s
+	}
+
+	sessions, err := h.auditService.List(locationID, status)
+	if err != nil {
+		h.logger.Error("error listing audit sessions", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, sessions)
+}
+
+func (h *AuditHandler) startAudit(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	var request dto.StartAuditRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.auditService.Start(request, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, dto.ErrInvalidAuditLocation):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrLocationNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrLocationDeleted):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error starting audit session", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.WithStatus(w, http.StatusCreated).SendJSON(session)
+}
+
+// getAudit returns an audit session with the items scanned into it and, once closed, its reconciliation.
+func (h *AuditHandler) getAudit(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	auditID, err := uuid.Parse(r.PathValue("auditId"))
+	if err != nil {
+		res.Error(w, "invalid audit ID", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.auditService.Get(auditID)
+	if err != nil {
+		if errors.Is(err, service.ErrAuditNotFound) {
+			res.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		h.logger.Error("error getting audit session", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, session)
+}
+
+// scanAuditItem records a scanned item code as found in an open audit session.
+func (h *AuditHandler) scanAuditItem(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	auditID, err := uuid.Parse(r.PathValue("auditId"))
+	if err != nil {
+		res.Error(w, "invalid audit ID", http.StatusBadRequest)
+		return
+	}
+
+	var request dto.AuditScanRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	scan, err := h.auditService.Scan(auditID, request, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, dto.ErrInvalidAuditScanCode), errors.Is(err, service.ErrEmptyScanCode),
+			errors.Is(err, service.ErrAuditLocationOutOfArea):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrAuditNotFound), errors.Is(err, service.ErrLocationNotFound),
+			errors.Is(err, service.ErrAuditCodeNotItem):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrAuditClosed), errors.Is(err, service.ErrAmbiguousScanCode),
+			errors.Is(err, service.ErrItemDeleted), errors.Is(err, service.ErrItemIsStock),
+			errors.Is(err, service.ErrLocationDeleted):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error scanning item into audit session", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.WithStatus(w, http.StatusCreated).SendJSON(scan)
+}
+
+func (h *AuditHandler) deleteAuditScan(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	auditID, err := uuid.Parse(r.PathValue("auditId"))
+	if err != nil {
+		res.Error(w, "invalid audit ID", http.StatusBadRequest)
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemId"))
+	if err != nil {
+		res.Error(w, "invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.auditService.DeleteScan(auditID, itemID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrAuditNotFound), errors.Is(err, service.ErrAuditScanNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrAuditClosed):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error deleting audit scan", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// closeAudit closes an audit session and returns it with its reconciliation.
+func (h *AuditHandler) closeAudit(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	auditID, err := uuid.Parse(r.PathValue("auditId"))
+	if err != nil {
+		res.Error(w, "invalid audit ID", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.auditService.Close(auditID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAuditNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrAuditClosed):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error closing audit session", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, session)
+}
+
+// applyAudit tracks the items of a closed audit session to where they were found.
+// The request body is optional and may name a location to track the missing items to.
+func (h *AuditHandler) applyAudit(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	auditID, err := uuid.Parse(r.PathValue("auditId"))
+	if err != nil {
+		res.Error(w, "invalid audit ID", http.StatusBadRequest)
+		return
+	}
+
+	var request dto.ApplyAuditRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			res.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := h.auditService.Apply(auditID, request, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAuditNotFound), errors.Is(err, service.ErrLocationNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrAuditNotClosed), errors.Is(err, service.ErrAuditAlreadyApplied),
+			errors.Is(err, service.ErrLocationDeleted):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error applying audit corrections", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, result)
+}
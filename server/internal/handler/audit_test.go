@@ -0,0 +1,323 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"quantum/internal/dto"
+	"quantum/internal/handler"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpAuditHandler(db *sqlx.DB, logger *slog.Logger) *handler.AuditHandler {
+	auditRepo := repository.NewAuditRepository(db)
+	itemRepo := repository.NewItemRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+
+	return handler.NewAuditHandler(service.NewAuditService(auditRepo, itemRepo, locationRepo), logger)
+}
+
+func insertTool(t testing.TB, db *sqlx.DB, reference string, userID, locationID uuid.UUID) *model.ItemModel {
+	return testdata.NewItemBuilder(t, db).
+		WithIdentifier("Tool "+reference).
+		WithReference(reference).
+		WithGroupKey("TOOLS").
+		WithCreatedHistoryRecord(userID, locationID).
+		Build()
+}
+
+func getCurrentLocationID(t testing.TB, db *sqlx.DB, itemID uuid.UUID) uuid.UUID {
+	var current model.ItemWithCurrentLocationModel
+	if err := db.Get(&current, "select * from items_with_current_location where id = $1;", itemID); err != nil {
+		t.Fatalf("failed to get the current location: %v", err)
+	}
+	return current.LocationID
+}
+
+func TestStartAudit(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpAuditHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	reader := testdata.InsertReaderUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	body := fmt.Sprintf(`{"locationId": "%s", "includeChildren": true}`, store.ID)
+
+	rr := testutils.ServeRequestAs(t, h, reader, application.Config.SessionSecret, "POST", "/api/v1/audit", body)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	rr = testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", "/api/v1/audit", body)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var session dto.AuditSessionResponse
+	if err := json.NewDecoder(rr.Body).Decode(&session); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.Equal(t, model.AuditStatusOpen, session.Status)
+	assert.Equal(t, "Store", session.LocationName)
+}
+
+func TestScanAuditItem(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpAuditHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	shelf := testdata.NewLocationBuilder(t, application.DB).WithName("Shelf").WithParent(store.ID).Build()
+	office := testdata.NewLocationBuilder(t, application.DB).WithName("Office").Build()
+
+	drill := insertTool(t, application.DB, "DRILL-1", tracker.ID, shelf.ID)
+	insertTool(t, application.DB, "LADDER-1", tracker.ID, store.ID)
+	insertTool(t, application.DB, "HAMMER-1", tracker.ID, office.ID)
+	insertTool(t, application.DB, "SAW-1", tracker.ID, store.ID)
+
+	session := testdata.NewAuditSessionBuilder(t, application.DB, store.ID, tracker.ID).
+		IncludingChildren().
+		Build()
+	scanURL := fmt.Sprintf("/api/v1/audit/%s/scan", session.ID)
+
+	testCases := []struct {
+		name         string
+		code         string
+		locationID   uuid.UUID
+		expectStatus int
+	}{
+		{"scans an item by reference", "LADDER-1", shelf.ID, http.StatusCreated},
+		{"scans an item recorded elsewhere", "HAMMER-1", shelf.ID, http.StatusCreated},
+		{"rejects scans outside the audited area", "SAW-1", office.ID, http.StatusBadRequest},
+		{"rejects codes that are not items", "Shelf", shelf.ID, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"code": "%s", "locationId": "%s"}`, tc.code, tc.locationID)
+			rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", scanURL, body)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+
+	t.Run("scans an item by id", func(t *testing.T) {
+		body := fmt.Sprintf(`{"code": "%s", "locationId": "%s"}`, drill.ID, shelf.ID)
+		rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", scanURL, body)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var response dto.AuditScanResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, &drill.ID, response.ItemID)
+		assert.Equal(t, "Shelf", response.LocationName)
+	})
+}
+
+func TestRemoveAuditScan(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpAuditHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	saw := insertTool(t, application.DB, "SAW-1", tracker.ID, store.ID)
+
+	session := testdata.NewAuditSessionBuilder(t, application.DB, store.ID, tracker.ID).
+		WithScan(saw, store.ID).
+		Build()
+	scanURL := fmt.Sprintf("/api/v1/audit/%s/scan/%s", session.ID, saw.ID)
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "DELETE", scanURL, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "DELETE", scanURL, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestCloseAudit_ReconcilesExpectedAndFoundItems(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpAuditHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	shelf := testdata.NewLocationBuilder(t, application.DB).WithName("Shelf").WithParent(store.ID).Build()
+	office := testdata.NewLocationBuilder(t, application.DB).WithName("Office").Build()
+
+	drill := insertTool(t, application.DB, "DRILL-1", tracker.ID, shelf.ID)
+	ladder := insertTool(t, application.DB, "LADDER-1", tracker.ID, store.ID)
+	saw := insertTool(t, application.DB, "SAW-1", tracker.ID, store.ID)
+	hammer := insertTool(t, application.DB, "HAMMER-1", tracker.ID, office.ID)
+
+	session := testdata.NewAuditSessionBuilder(t, application.DB, store.ID, tracker.ID).
+		IncludingChildren().
+		WithScan(drill, shelf.ID).
+		WithScan(ladder, shelf.ID).
+		WithScan(hammer, shelf.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/audit/%s/close", session.ID), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var detail dto.AuditSessionDetailResponse
+	if err := json.NewDecoder(rr.Body).Decode(&detail); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.Equal(t, model.AuditStatusClosed, detail.Status)
+	assert.Len(t, detail.Scans, 3)
+	if !assert.NotNil(t, detail.Reconciliation) {
+		return
+	}
+
+	reconciliation := detail.Reconciliation
+	if assert.Len(t, reconciliation.Found, 2) {
+		for _, result := range reconciliation.Found {
+			assert.Equal(t, *result.ItemID == ladder.ID, result.NeedsCorrection)
+		}
+	}
+	if assert.Len(t, reconciliation.Missing, 1) {
+		assert.Equal(t, &saw.ID, reconciliation.Missing[0].ItemID)
+	}
+	if assert.Len(t, reconciliation.Unexpected, 1) {
+		assert.Equal(t, &hammer.ID, reconciliation.Unexpected[0].ItemID)
+		assert.Equal(t, "Office", *reconciliation.Unexpected[0].ExpectedLocationName)
+		assert.True(t, reconciliation.Unexpected[0].NeedsCorrection)
+	}
+}
+
+func TestAudit_OpenAndClosedSessionsConflict(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpAuditHandler(application.DB, application.Logger)
+	auditRepo := repository.NewAuditRepository(application.DB)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	insertTool(t, application.DB, "SAW-1", tracker.ID, store.ID)
+
+	open := testdata.NewAuditSessionBuilder(t, application.DB, store.ID, tracker.ID).Build()
+	closed := testdata.NewAuditSessionBuilder(t, application.DB, store.ID, tracker.ID).Build()
+	assert.NoError(t, auditRepo.Close(closed.ID, tracker.ID))
+
+	testCases := []struct {
+		name      string
+		sessionID uuid.UUID
+		path      string
+		body      string
+	}{
+		{"corrections cannot be applied to an open session", open.ID, "apply", ""},
+		{"a closed session cannot be scanned into", closed.ID, "scan", fmt.Sprintf(`{"code": "SAW-1", "locationId": "%s"}`, store.ID)},
+		{"a closed session cannot be closed again", closed.ID, "close", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("/api/v1/audit/%s/%s", tc.sessionID, tc.path)
+			rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", url, tc.body)
+
+			assert.Equal(t, http.StatusConflict, rr.Code)
+		})
+	}
+}
+
+func TestApplyAudit_TracksCorrections(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpAuditHandler(application.DB, application.Logger)
+	auditRepo := repository.NewAuditRepository(application.DB)
+	itemRepo := repository.NewItemRepository(application.DB)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	shelf := testdata.NewLocationBuilder(t, application.DB).WithName("Shelf").WithParent(store.ID).Build()
+	office := testdata.NewLocationBuilder(t, application.DB).WithName("Office").Build()
+	lost := testdata.NewLocationBuilder(t, application.DB).WithName("Lost").Build()
+
+	drill := insertTool(t, application.DB, "DRILL-1", tracker.ID, shelf.ID)
+	ladder := insertTool(t, application.DB, "LADDER-1", tracker.ID, store.ID)
+	saw := insertTool(t, application.DB, "SAW-1", tracker.ID, store.ID)
+	hammer := insertTool(t, application.DB, "HAMMER-1", tracker.ID, office.ID)
+
+	session := testdata.NewAuditSessionBuilder(t, application.DB, store.ID, tracker.ID).
+		IncludingChildren().
+		WithScan(drill, shelf.ID).
+		WithScan(ladder, shelf.ID).
+		WithScan(hammer, shelf.ID).
+		Build()
+	assert.NoError(t, auditRepo.Close(session.ID, tracker.ID))
+
+	// The hammer is tracked after the session closed, so it is not moved back to where the audit found it.
+	assert.NoError(t, itemRepo.AppendNewItemTrackedToLocationHistory(tracker.ID, []uuid.UUID{hammer.ID}, office.ID))
+
+	applyURL := fmt.Sprintf("/api/v1/audit/%s/apply", session.ID)
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", applyURL, fmt.Sprintf(`{"missingLocationId": "%s"}`, lost.ID))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response dto.ApplyAuditResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.Equal(t, 2, response.Tracked)
+	assert.Equal(t, 1, response.Stale)
+
+	assert.Equal(t, shelf.ID, getCurrentLocationID(t, application.DB, drill.ID))
+	assert.Equal(t, shelf.ID, getCurrentLocationID(t, application.DB, ladder.ID))
+	assert.Equal(t, office.ID, getCurrentLocationID(t, application.DB, hammer.ID))
+	assert.Equal(t, lost.ID, getCurrentLocationID(t, application.DB, saw.ID))
+
+	rr = testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", applyURL, "")
+	assert.Equal(t, http.StatusConflict, rr.Code, "corrections are applied once")
+}
+
+func TestListAudits_KeepsSessionsForReview(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpAuditHandler(application.DB, application.Logger)
+	auditRepo := repository.NewAuditRepository(application.DB)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	reader := testdata.InsertReaderUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	lost := testdata.NewLocationBuilder(t, application.DB).WithName("Lost").Build()
+	insertTool(t, application.DB, "SAW-1", tracker.ID, store.ID)
+
+	testdata.NewAuditSessionBuilder(t, application.DB, store.ID, tracker.ID).Build()
+	applied := testdata.NewAuditSessionBuilder(t, application.DB, store.ID, tracker.ID).Build()
+	assert.NoError(t, auditRepo.Close(applied.ID, tracker.ID))
+	_, err := auditRepo.ApplyCorrections(applied.ID, tracker.ID, &lost.ID)
+	assert.NoError(t, err)
+
+	rr := testutils.ServeRequestAs(t, h, reader, application.Config.SessionSecret, "GET", fmt.Sprintf("/api/v1/audit?locationId=%s&status=closed", store.ID), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var sessions []dto.AuditSessionResponse
+	if err := json.NewDecoder(rr.Body).Decode(&sessions); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, applied.ID, sessions[0].ID)
+		assert.NotNil(t, sessions[0].AppliedAt)
+	}
+
+	rr = testutils.ServeRequestAs(t, h, reader, application.Config.SessionSecret, "GET", "/api/v1/audit/"+applied.ID.String(), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var detail dto.AuditSessionDetailResponse
+	if err := json.NewDecoder(rr.Body).Decode(&detail); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if assert.NotNil(t, detail.Reconciliation) && assert.Len(t, detail.Reconciliation.Missing, 1) {
+		assert.True(t, detail.Reconciliation.Missing[0].Applied)
+	}
+}
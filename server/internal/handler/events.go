@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/service"
+	"quantum/pkg/res"
+	"strconv"
+	"time"
+)
+
+// eventStreamHeartbeat is how often a comment is sent on an idle event stream to keep the connection open.
+const eventStreamHeartbeat = 15 * time.Second
+
+type EventHandler struct {
+	broker *service.ItemEventBroker
+	logger *slog.Logger
+}
+
+func NewEventHandler(broker *service.ItemEventBroker, logger *slog.Logger) *EventHandler {
+	return &EventHandler{
+		broker: broker,
+		logger: logger,
+	}
+}
+
+func (h *EventHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
+	mux.HandleFunc("GET /api/v1/events/stream", mf(h.streamEvents))
+}
+
+// streamEvents sends item events as Server-Sent Events, each with the item history ID as its event ID.
+// A client reconnecting with the Last-Event-ID header, or lastEventId query parameter, is first sent the events it missed.
+func (h *EventHandler) streamEvents(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var filter service.ItemEventFilter
+	locationID, err := getUUIDQueryParam(r, "locationId")
+	if err != nil {
+		res.Error(w, "invalid location id", http.StatusBadRequest)
+		return
+	}
+	filter.LocationID = locationID
+	if group := r.URL.Query().Get("group"); group != "" {
+		filter.GroupKey = &group
+	}
+
+	lastEventIDParam := r.Header.Get("Last-Event-ID")
+	if lastEventIDParam == "" {
+		lastEventIDParam = r.URL.Query().Get("lastEventId")
+	}
+	var lastEventID *int64
+	if lastEventIDParam != "" {
+		id, err := strconv.ParseInt(lastEventIDParam, 10, 64)
+		if err != nil || id < 0 {
+			res.Error(w, "invalid last event id", http.StatusBadRequest)
+			return
+		}
+		lastEventID = &id
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		res.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before replaying so that no event is missed between the two.
+	events, unsubscribe := h.broker.Subscribe(filter)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var replayed model.ItemEventCursor
+	if lastEventID != nil {
+		replayed, err = h.broker.CursorAt(*lastEventID)
+		if err != nil {
+			h.logger.Error("error getting last item event", "error", err)
+			return
+		}
+		replayed, err = h.broker.Replay(replayed, filter, func(event model.ItemEventModel) error {
+			return writeItemEvent(w, event)
+		})
+		if err != nil {
+			h.logger.Error("error replaying item events", "error", err)
+			return
+		}
+		flusher.Flush()
+	}
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-events:
+			if !ok {
+				// The stream fell too far behind, the client reconnects and resumes from its last event ID.
+				return
+			}
+			if !event.Cursor().After(replayed) {
+				continue
+			}
+			if err := writeItemEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeItemEvent(w http.ResponseWriter, event model.ItemEventModel) error {
+	data, err := json.Marshal(dto.NewItemEventResponseFromModel(event))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
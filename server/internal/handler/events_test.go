@@ -0,0 +1,175 @@
+package handler_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"quantum/internal/handler"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type streamedEvent struct {
+	id        string
+	eventType string
+}
+
+// readStreamedEvents reads events from the stream until n events are read or the timeout passes.
+func readStreamedEvents(t *testing.T, scanner *bufio.Scanner, n int, timeout time.Duration) []streamedEvent {
+	t.Helper()
+
+	done := make(chan []streamedEvent)
+	go func() {
+		var events []streamedEvent
+		var current streamedEvent
+		for len(events) < n && scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				current.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				current.eventType = strings.TrimPrefix(line, "event: ")
+			case line == "" && current.id != "":
+				events = append(events, current)
+				current = streamedEvent{}
+			}
+		}
+		done <- events
+	}()
+
+	select {
+	case events := <-done:
+		return events
+	case <-time.After(timeout):
+		t.Fatalf("timed out waiting for %d events", n)
+		return nil
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+
+	itemRepo := repository.NewItemRepository(application.DB)
+	broker := service.NewItemEventBroker(application.Config.Database.ConnectionString, itemRepo, application.Logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = broker.Run(ctx) }()
+
+	mux := http.NewServeMux()
+	handler.NewEventHandler(broker, application.Logger).RegisterRoutes(mux, func(next http.HandlerFunc) http.HandlerFunc {
+		return handler.WithAuthenticatedUserMiddleware(next, application.Config.SessionSecret)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	warehouse := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Warehouse").
+		Build()
+	shop := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Shop").
+		Build()
+
+	// An item created before the stream is opened is only sent when resuming from an earlier event ID.
+	before := model.ItemModel{Identifier: "ITEM-1", Reference: "REF-1", GroupKey: "XYZ"}
+	assert.NoError(t, itemRepo.Create(&before, tracker.ID, warehouse.ID))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/v1/events/stream?locationId="+shop.ID.String(), nil)
+	assert.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "0")
+	testutils.RequestWithJWT(t, req, tracker, application.Config.SessionSecret)
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// The replayed created event is at the warehouse, so it is filtered out of the shop stream.
+	// Tracking the item to the shop and then to a user gives an arrival and a departure.
+	assert.NoError(t, itemRepo.AppendNewItemTrackedToLocationHistory(tracker.ID, []uuid.UUID{before.ID}, shop.ID))
+	assert.NoError(t, itemRepo.AppendNewItemTrackedToUserHistory(tracker.ID, tracker.ID, []uuid.UUID{before.ID}, nil))
+
+	events := readStreamedEvents(t, bufio.NewScanner(resp.Body), 2, 10*time.Second)
+	assert.Equal(t, []string{"tracked", "tracked-user"}, []string{events[0].eventType, events[1].eventType})
+}
+
+func TestStreamEvents_RequiresReadPermission(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+
+	itemRepo := repository.NewItemRepository(application.DB)
+	broker := service.NewItemEventBroker(application.Config.Database.ConnectionString, itemRepo, application.Logger)
+	h := handler.NewEventHandler(broker, application.Logger)
+
+	req := httptest.NewRequest("GET", "/api/v1/events/stream", nil)
+	rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestStreamEvents_SendsEventsCommittedOutOfOrder(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+
+	itemRepo := repository.NewItemRepository(application.DB)
+	broker := service.NewItemEventBroker(application.Config.Database.ConnectionString, itemRepo, application.Logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = broker.Run(ctx) }()
+
+	mux := http.NewServeMux()
+	handler.NewEventHandler(broker, application.Logger).RegisterRoutes(mux, func(next http.HandlerFunc) http.HandlerFunc {
+		return handler.WithAuthenticatedUserMiddleware(next, application.Config.SessionSecret)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	shop := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Shop").
+		Build()
+
+	first := model.ItemModel{Identifier: "ITEM-1", Reference: "REF-1", GroupKey: "XYZ"}
+	second := model.ItemModel{Identifier: "ITEM-2", Reference: "REF-2", GroupKey: "XYZ"}
+	assert.NoError(t, itemRepo.Create(&first, tracker.ID, shop.ID))
+	assert.NoError(t, itemRepo.Create(&second, tracker.ID, shop.ID))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", srv.URL+"/api/v1/events/stream", nil)
+	assert.NoError(t, err)
+	testutils.RequestWithJWT(t, req, tracker, application.Config.SessionSecret)
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The first record takes the lower history ID but is committed after the second.
+	tx, err := application.DB.Beginx()
+	assert.NoError(t, err)
+	stmt := `
+		insert into item_history (user_id, item_id, data)
+		values ($1, $2, jsonb_build_object('type', 'tracked', 'data', jsonb_build_object('locationId', $3::uuid)));`
+	_, err = tx.Exec(stmt, tracker.ID, first.ID, shop.ID)
+	assert.NoError(t, err)
+
+	assert.NoError(t, itemRepo.AppendNewItemTrackedToLocationHistory(tracker.ID, []uuid.UUID{second.ID}, shop.ID))
+	assert.NoError(t, tx.Commit())
+
+	events := readStreamedEvents(t, bufio.NewScanner(resp.Body), 2, 10*time.Second)
+	assert.Len(t, events, 2)
+}
@@ -18,7 +18,8 @@ type HandlerBuilder interface {
 
 type MiddlewareFunc func(http.HandlerFunc) http.HandlerFunc
 
-func BuildServerMux(app *app.App) *http.ServeMux {
+// BuildServerMux registers the routes of every handler. The event broker is run by the caller.
+func BuildServerMux(app *app.App, eventBroker *service.ItemEventBroker) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	repositories := repository.NewRepositories(app.DB)
@@ -28,8 +29,17 @@ func BuildServerMux(app *app.App) *http.ServeMux {
 		NewAuthHandler(services.UserService, app.Config.SessionSecret, app.Logger),
 		NewUserHandler(services.UserService, app.Logger),
 		NewItemHandler(services.ItemService, services.SettingsService, app.Logger),
-		NewLocationHandler(services.LocationService, services.ItemService, app.Logger),
+		NewLocationHandler(services.LocationService, services.ItemService, services.SettingsService, app.Logger),
 		NewSettingsHandler(services.SettingsService, app.Logger),
+		NewHistoryHandler(services.ItemService, services.SettingsService, app.Logger),
+		NewLoanHandler(services.ItemService, app.Logger),
+		NewLabelHandler(services.ItemService, services.LocationService, services.SettingsService, app.Logger),
+		NewScanHandler(services.ScanService, app.Logger),
+		NewAuditHandler(services.AuditService, app.Logger),
+		NewStockHandler(services.StockService, app.Logger),
+		NewReservationHandler(services.ReservationService, services.SettingsService, app.Config.APIBaseURL, app.Logger),
+		NewWebhookHandler(services.WebhookService, app.Logger),
+		NewEventHandler(eventBroker, app.Logger),
 	}
 
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
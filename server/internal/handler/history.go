@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/pkg/res"
+)
+
+var (
+	ErrInvalidHistoryType     = errors.New("invalid history type")
+	ErrInvalidHistoryUserID   = errors.New("invalid userId, expected a UUID")
+	ErrInvalidHistoryTargetID = errors.New("invalid targetId, expected a UUID")
+	ErrInvalidHistoryFrom     = errors.New("invalid from date, expected RFC3339")
+	ErrInvalidHistoryTo       = errors.New("invalid to date, expected RFC3339")
+)
+
+type HistoryHandler struct {
+	itemService     *service.ItemService
+	settingsService *service.SettingsService
+	logger          *slog.Logger
+}
+
+func NewHistoryHandler(
+	itemService *service.ItemService,
+	settingsService *service.SettingsService,
+	logger *slog.Logger,
+) *HistoryHandler {
+	return &HistoryHandler{
+		itemService:     itemService,
+		settingsService: settingsService,
+		logger:          logger,
+	}
+}
+
+func (h *HistoryHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
+	mux.HandleFunc("GET /api/v1/history", mf(h.listHistory))
+	mux.HandleFunc("GET /api/v1/history/csv", mf(h.downloadHistoryCSV))
+	mux.HandleFunc("GET /api/v1/history/ndjson", mf(h.downloadHistoryNDJSON))
+}
+
+func (h *HistoryHandler) listHistory(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	filter, err := getHistoryFilterFromRequest(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := getPageQueryParams(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.itemService.ListHistory(filter, page)
+	if err != nil {
+		if isPageError(err) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("error listing history", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, history)
+}
+
+func (h *HistoryHandler) downloadHistoryCSV(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	filter, err := getHistoryFilterFromRequest(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.settingsService.Get()
+	if err != nil {
+		h.logger.Error("error getting settings", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	terms := settings.Terminology
+	_ = writer.Write([]string{terms.Item, "Status", "Date", "Type", "User", terms.Group, terms.Location, "Changes"})
+	err = h.itemService.ExportHistory(filter, func(record dto.ItemHistoryRecord) error {
+		_, reference := record.Item()
+		return writer.Write(append([]string{reference, string(record.Status())}, record.CSVRecord()...))
+	})
+	if err != nil {
+		// The response has already started, so the error can only be logged.
+		h.logger.Error("error exporting history csv", "error", err)
+	}
+}
+
+func (h *HistoryHandler) downloadHistoryNDJSON(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	filter, err := getHistoryFilterFromRequest(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	err = h.itemService.ExportHistory(filter, func(record dto.ItemHistoryRecord) error {
+		return encoder.Encode(record)
+	})
+	if err != nil {
+		// The response has already started, so the error can only be logged.
+		h.logger.Error("error exporting history ndjson", "error", err)
+	}
+}
+
+// getHistoryFilterFromRequest reads the history filters from the query parameters:
+// type (comma separated), userId, targetId, group, from and to.
+func getHistoryFilterFromRequest(r *http.Request) (repository.ItemHistoryFilter, error) {
+	var filter repository.ItemHistoryFilter
+	query := r.URL.Query()
+
+	if types := query.Get("type"); types != "" {
+		for _, t := range strings.Split(types, ",") {
+			historyType := model.ItemHistoryType(strings.TrimSpace(t))
+			if !historyType.Valid() {
+				return filter, ErrInvalidHistoryType
+			}
+			filter.Types = append(filter.Types, historyType)
+		}
+	}
+
+	var err error
+	if filter.UserID, err = getUUIDQueryParam(r, "userId"); err != nil {
+		return filter, ErrInvalidHistoryUserID
+	}
+	if filter.TargetID, err = getUUIDQueryParam(r, "targetId"); err != nil {
+		return filter, ErrInvalidHistoryTargetID
+	}
+	if group := query.Get("group"); group != "" {
+		filter.GroupKey = &group
+	}
+	if filter.From, err = getTimeQueryParam(r, "from"); err != nil {
+		return filter, ErrInvalidHistoryFrom
+	}
+	if filter.To, err = getTimeQueryParam(r, "to"); err != nil {
+		return filter, ErrInvalidHistoryTo
+	}
+
+	return filter, nil
+}
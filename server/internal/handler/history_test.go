@@ -0,0 +1,143 @@
+package handler_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"quantum/internal/handler"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/internal/types/pagination"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpHistoryHandler(db *sqlx.DB, logger *slog.Logger) *handler.HistoryHandler {
+	itemRepo := repository.NewItemRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	settingsRepo := repository.NewPostgresSettingsRepository(db)
+
+	itemService := service.NewItemService(itemRepo, locationRepo, userRepo, repository.NewReservationRepository(db))
+	settingsService := service.NewSettingsService(settingsRepo)
+
+	return handler.NewHistoryHandler(itemService, settingsService, logger)
+}
+
+func TestListHistory(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpHistoryHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	reader := testdata.InsertReaderUser(t, application.DB)
+
+	warehouse := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Warehouse").
+		Build()
+
+	shop := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Shop").
+		Build()
+
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("ABC").
+		WithCreatedHistoryRecord(tracker.ID, warehouse.ID).
+		WithTrackedHistoryRecord(tracker.ID, shop.ID).
+		Build()
+
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, warehouse.ID).
+		WithTrackedUserHistoryRecord(tracker.ID, reader.ID).
+		Build()
+
+	testCases := []struct {
+		name          string
+		query         string
+		expectStatus  int
+		expectedTypes []model.ItemHistoryType
+	}{
+		{"all history newest first", "", http.StatusOK, []model.ItemHistoryType{"tracked-user", "created", "tracked", "created"}},
+		{"by type", "?type=tracked,tracked-user", http.StatusOK, []model.ItemHistoryType{"tracked-user", "tracked"}},
+		{"by target location", fmt.Sprintf("?targetId=%s", shop.ID), http.StatusOK, []model.ItemHistoryType{"tracked"}},
+		{"by target user", fmt.Sprintf("?targetId=%s", reader.ID), http.StatusOK, []model.ItemHistoryType{"tracked-user"}},
+		{"by group", "?group=ABC", http.StatusOK, []model.ItemHistoryType{"tracked", "created"}},
+		{"by acting user", fmt.Sprintf("?userId=%s", reader.ID), http.StatusOK, []model.ItemHistoryType{}},
+		{"unknown type", "?type=moved", http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/history"+tc.query, nil)
+			testutils.RequestWithJWT(t, req, reader, application.Config.SessionSecret)
+			rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+			if tc.expectStatus != http.StatusOK {
+				return
+			}
+
+			var page pagination.Page[struct {
+				Type model.ItemHistoryType `json:"type"`
+			}]
+			if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			types := make([]model.ItemHistoryType, len(page.Items))
+			for i, item := range page.Items {
+				types[i] = item.Type
+			}
+			assert.Equal(t, tc.expectedTypes, types)
+		})
+	}
+}
+
+func TestDownloadHistoryNDJSON(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpHistoryHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	location := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Warehouse").
+		Build()
+
+	for i := range 3 {
+		testdata.NewItemBuilder(t, application.DB).
+			WithIdentifier(fmt.Sprintf("ITEM-%d", i)).
+			WithReference(fmt.Sprintf("REF-%d", i)).
+			WithGroupKey("XYZ").
+			WithCreatedHistoryRecord(tracker.ID, location.ID).
+			Build()
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/history/ndjson?type=created", nil)
+	testutils.RequestWithJWT(t, req, tracker, application.Config.SessionSecret)
+	rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+
+	lines := 0
+	scanner := bufio.NewScanner(rr.Body)
+	for scanner.Scan() {
+		var record map[string]any
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		assert.Equal(t, "created", record["type"])
+		lines++
+	}
+	assert.Equal(t, 3, lines)
+}
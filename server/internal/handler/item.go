@@ -4,9 +4,13 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/thisisthemurph/emit"
@@ -36,15 +40,30 @@ func NewItemHandler(
 
 func (h *ItemHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
 	mux.HandleFunc("GET /api/v1/item/groups", mf(h.listItemGroups))
+	mux.HandleFunc("GET /api/v1/item/trash", mf(h.listDeletedItems))
+	mux.HandleFunc("DELETE /api/v1/item/trash", mf(h.purgeDeletedItems))
 	mux.HandleFunc("GET /api/v1/item/{itemId}", mf(h.getItemByID))
+	mux.HandleFunc("PUT /api/v1/item/{itemId}", mf(h.updateItem))
 	mux.HandleFunc("DELETE /api/v1/item/{itemId}", mf(h.deleteItem))
+	mux.HandleFunc("POST /api/v1/item/{itemId}/restore", mf(h.restoreItem))
 	mux.HandleFunc("GET /api/v1/item/groups/exist", mf(h.getItemGroupsExist))
+	mux.HandleFunc("GET /api/v1/item/groups/fields", mf(h.listGroupCustomFields))
+	mux.HandleFunc("GET /api/v1/item/groups/{groupKey}/fields", mf(h.getGroupCustomFields))
+	mux.HandleFunc("PUT /api/v1/item/groups/{groupKey}/fields", mf(h.setGroupCustomFields))
+	mux.HandleFunc("DELETE /api/v1/item/groups/{groupKey}/fields", mf(h.deleteGroupCustomFields))
+	mux.HandleFunc("GET /api/v1/item/{itemId}/location", mf(h.getItemLocationAt))
 	mux.HandleFunc("GET /api/v1/item/{itemId}/history", mf(h.getItemHistory))
 	mux.HandleFunc("GET /api/v1/item/{itemId}/history/csv", mf(h.downloadItemHistoryCSV))
 	mux.HandleFunc("GET /api/v1/item", mf(h.listItems))
 	mux.HandleFunc("POST /api/v1/item", mf(h.createItem))
+	mux.HandleFunc("POST /api/v1/item/import", mf(h.importItems))
+	mux.HandleFunc("POST /api/v1/item/track", mf(h.trackItems))
 	mux.HandleFunc("POST /api/v1/item/{itemId}/track/{locationId}", mf(h.trackItem))
 	mux.HandleFunc("POST /api/v1/item/{itemId}/track/user/{userId}", mf(h.trackItemToUser))
+	mux.HandleFunc("POST /api/v1/item/{itemId}/checkin", mf(h.checkInItem))
+	mux.HandleFunc("PUT /api/v1/item/{itemId}/container", mf(h.putItemInContainer))
+	mux.HandleFunc("DELETE /api/v1/item/{itemId}/container", mf(h.removeItemFromContainer))
+	mux.HandleFunc("POST /api/v1/item/{itemId}/status", mf(h.changeItemStatus))
 }
 
 func (h *ItemHandler) getItemByID(w http.ResponseWriter, r *http.Request) {
@@ -65,7 +84,7 @@ func (h *ItemHandler) getItemByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	itemResponse, err := h.itemService.Get(questionID)
+	itemResponse, err := h.itemService.GetDetail(questionID)
 	if err != nil {
 		if errors.Is(err, service.ErrItemNotFound) {
 			emit.New(w).Status(http.StatusNotFound).ErrorJSON("Item not found")
@@ -89,14 +108,36 @@ func (h *ItemHandler) listItems(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	filters, err := getFiltersFromRequest(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	groupKeyParam := r.URL.Query().Get("group")
 	var groupKeyFilter *string
 	if groupKeyParam != "" {
 		groupKeyFilter = &groupKeyParam
 	}
 
-	items, err := h.itemService.List(groupKeyFilter)
+	statusFilter, err := getItemStatusQueryParam(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	customFieldFilter, err := getCustomFieldQueryParams(r)
 	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.itemService.List(groupKeyFilter, statusFilter, customFieldFilter, filters.Page)
+	if err != nil {
+		if isPageError(err) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		h.logger.Error("error listing items", "error", err)
 		res.InternalServerError(w)
 		return
@@ -116,7 +157,12 @@ func (h *ItemHandler) listItemGroups(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	filters := getFiltersFromRequest(r)
+	filters, err := getFiltersFromRequest(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if filters.Max == nil {
 		defaultMaxFilter := 10
 		filters.Max = &defaultMaxFilter
@@ -150,19 +196,106 @@ func (h *ItemHandler) createItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := item.Validate(); err != nil {
+	newItem, err := h.itemService.Create(userID, item)
+	if err != nil {
+		if isItemValidationError(err) {
+			emit.New(w).Status(http.StatusBadRequest).ErrorJSON(err.Error())
+			return
+		}
+		h.logger.Error("error creating item", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	emit.New(w).Status(http.StatusCreated).JSON(newItem)
+}
+
+// isItemValidationError reports whether the error is from validating a create or update item request.
+func isItemValidationError(err error) bool {
+	return errors.Is(err, dto.ErrInvalidItemReference) ||
+		errors.Is(err, dto.ErrInvalidItemKind) ||
+		errors.Is(err, dto.ErrInvalidCustomFields)
+}
+
+// maxImportSize is the maximum size of a CSV file accepted by the item import endpoint.
+const maxImportSize = 10 << 20
+
+func (h *ItemHandler) importItems(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		emit.New(w).Status(http.StatusUnauthorized).ErrorJSON("Unauthorized")
+		return
+	}
+
+	if !currentUserRoles(r).HasWritePermissions() {
+		emit.New(w).Status(http.StatusForbidden).ErrorJSON("Forbidden")
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryRun"))
+
+	rows, err := dto.ParseImportItemsCSV(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		h.logger.Error("error parsing import file", "error", err)
 		emit.New(w).Status(http.StatusBadRequest).ErrorJSON(err.Error())
 		return
 	}
 
-	newItem, err := h.itemService.Create(userID, item)
+	report, err := h.itemService.Import(userID, rows, dryRun)
 	if err != nil {
-		h.logger.Error("error creating item", "error", err)
+		h.logger.Error("error importing items", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	emit.New(w).Status(http.StatusCreated).JSON(newItem)
+	emit.New(w).JSON(report)
+}
+
+func (h *ItemHandler) updateItem(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		emit.New(w).Status(http.StatusUnauthorized).ErrorJSON("Unauthorized")
+		return
+	}
+
+	if !currentUserRoles(r).HasWritePermissions() {
+		emit.New(w).Status(http.StatusForbidden).ErrorJSON("Forbidden")
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemId"))
+	if err != nil {
+		h.logger.Error("invalid item id", "error", err)
+		emit.New(w).Status(http.StatusBadRequest).ErrorJSON("Invalid ID")
+		return
+	}
+
+	var item dto.UpdateItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		emit.New(w).Status(http.StatusBadRequest).ErrorJSON("Error decoding request")
+		return
+	}
+
+	updatedItem, err := h.itemService.Update(itemID, userID, item)
+	if err != nil {
+		if isItemValidationError(err) {
+			emit.New(w).Status(http.StatusBadRequest).ErrorJSON(err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrItemNotFound) {
+			emit.New(w).Status(http.StatusNotFound).ErrorJSON("Item not found")
+			return
+		}
+		if errors.Is(err, service.ErrItemReferenceExists) {
+			emit.New(w).Status(http.StatusConflict).ErrorJSON("Reference is already in use, please choose another")
+			return
+		}
+		h.logger.Error("error updating item", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	emit.New(w).JSON(updatedItem)
 }
 
 func (h *ItemHandler) deleteItem(w http.ResponseWriter, r *http.Request) {
@@ -193,6 +326,120 @@ func (h *ItemHandler) deleteItem(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *ItemHandler) restoreItem(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		emit.New(w).Status(http.StatusUnauthorized).ErrorJSON("Unauthorized")
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		emit.New(w).Status(http.StatusForbidden).ErrorJSON("Forbidden")
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemId"))
+	if err != nil {
+		h.logger.Error("invalid item id", "error", err)
+		emit.New(w).Status(http.StatusBadRequest).ErrorJSON("Invalid ID")
+		return
+	}
+
+	if err := h.itemService.Restore(itemID, userID); err != nil {
+		if errors.Is(err, service.ErrItemNotFound) {
+			emit.New(w).Status(http.StatusNotFound).ErrorJSON("Item not found")
+			return
+		}
+		if errors.Is(err, service.ErrItemNotDeleted) {
+			emit.New(w).Status(http.StatusConflict).ErrorJSON("Item is not deleted")
+			return
+		}
+		h.logger.Error("error restoring item", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ItemHandler) listDeletedItems(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	deletedBy, err := getUUIDQueryParam(r, "deletedBy")
+	if err != nil {
+		res.Error(w, "invalid deletedBy user id", http.StatusBadRequest)
+		return
+	}
+
+	from, err := getTimeQueryParam(r, "from")
+	if err != nil {
+		res.Error(w, "invalid from date, expected RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	to, err := getTimeQueryParam(r, "to")
+	if err != nil {
+		res.Error(w, "invalid to date, expected RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	filters, err := getFiltersFromRequest(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := h.itemService.ListDeleted(deletedBy, from, to, filters.Page)
+	if err != nil {
+		if isPageError(err) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("error listing deleted items", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, items)
+}
+
+func (h *ItemHandler) purgeDeletedItems(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	settings, err := h.settingsService.Get()
+	if err != nil {
+		h.logger.Error("error getting settings", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	retention := time.Duration(settings.Trash.RetentionDays) * 24 * time.Hour
+	purged, err := h.itemService.PurgeDeleted(retention)
+	if err != nil {
+		h.logger.Error("error purging deleted items", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, dto.PurgeItemsResponse{Purged: purged})
+}
+
 func (h *ItemHandler) trackItem(w http.ResponseWriter, r *http.Request) {
 	userID, authed := currentUserID(r)
 	if !authed {
@@ -220,6 +467,10 @@ func (h *ItemHandler) trackItem(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.itemService.TrackItem(userID, itemID, locationID); err != nil {
+		if status, ok := trackingErrorStatus(err); ok {
+			res.Error(w, err.Error(), status)
+			return
+		}
 		h.logger.Error("error tracking item", "error", err)
 		res.InternalServerError(w)
 		return
@@ -228,6 +479,58 @@ func (h *ItemHandler) trackItem(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *ItemHandler) trackItems(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	var req dto.BulkTrackItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		res.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.settingsService.Get()
+	if err != nil {
+		h.logger.Error("error getting settings", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	result, err := h.itemService.TrackItems(userID, req, settings.Reservations.BlockTracking)
+	if err != nil {
+		if errors.Is(err, service.ErrItemsNotTracked) {
+			res.WithStatus(w, http.StatusUnprocessableEntity).SendJSON(result)
+			return
+		}
+		if errors.Is(err, service.ErrInvalidDueDate) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if status, ok := trackingErrorStatus(err); ok {
+			res.Error(w, err.Error(), status)
+			return
+		}
+		h.logger.Error("error tracking items", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, result)
+}
+
 func (h *ItemHandler) trackItemToUser(w http.ResponseWriter, r *http.Request) {
 	userID, authed := currentUserID(r)
 	if !authed {
@@ -254,15 +557,298 @@ func (h *ItemHandler) trackItemToUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.itemService.TrackItemToUser(userID, toUserID, itemID); err != nil {
+	// The body is optional, an empty body checks the item out without a due date.
+	var request dto.TrackItemToUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		res.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.settingsService.Get()
+	if err != nil {
+		h.logger.Error("error getting settings", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	warnings, err := h.itemService.TrackItemToUser(userID, toUserID, itemID, request.DueAt, settings.Reservations.BlockTracking)
+	if err != nil {
+		var reservedErr *service.ItemReservedError
+		if errors.As(err, &reservedErr) {
+			res.WithStatus(w, http.StatusConflict).SendJSON(dto.ItemReservedResponse{
+				Error:        reservedErr.Error(),
+				Reservations: reservedErr.Reservations,
+			})
+			return
+		}
+		if errors.Is(err, service.ErrInvalidDueDate) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if status, ok := trackingErrorStatus(err); ok {
+			res.Error(w, err.Error(), status)
+			return
+		}
 		h.logger.Error("error tracking item", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	// The item was tracked, but reservations held by other users are reported as warnings.
+	for _, reservation := range warnings {
+		w.Header().Add("Warning", fmt.Sprintf(`199 quantum "item is reserved by %s until %s"`,
+			reservation.UserUsername, reservation.EndsAt.Format(time.RFC3339)))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ItemHandler) checkInItem(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemId"))
+	if err != nil {
+		res.Error(w, "invalid item id", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional, an empty body returns the item to where it was before it was checked out.
+	var request dto.CheckInItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		res.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.itemService.CheckIn(userID, itemID, request.LocationID); err != nil {
+		if errors.Is(err, service.ErrItemNotCheckedOut) {
+			res.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if status, ok := trackingErrorStatus(err); ok {
+			res.Error(w, err.Error(), status)
+			return
+		}
+		h.logger.Error("error checking in item", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// putItemInContainer puts the item inside the container item in the request, such as a charger in a laptop bag.
+// The item and anything inside it move to where the container is, and move with the container from then on.
+func (h *ItemHandler) putItemInContainer(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemId"))
+	if err != nil {
+		res.Error(w, "invalid item id", http.StatusBadRequest)
+		return
+	}
+
+	var request dto.PutInContainerRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.itemService.PutInContainer(userID, itemID, request); err != nil {
+		switch {
+		case errors.Is(err, dto.ErrInvalidContainerID), errors.Is(err, service.ErrItemContainmentCycle):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrItemNotFound), errors.Is(err, service.ErrContainerNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrItemDeleted), errors.Is(err, service.ErrContainerDeleted),
+			errors.Is(err, service.ErrItemNotTrackable), errors.Is(err, service.ErrItemIsStock):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error putting item in container", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// removeItemFromContainer takes the item out of the item it is inside of, leaving it where it is.
+func (h *ItemHandler) removeItemFromContainer(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemId"))
+	if err != nil {
+		res.Error(w, "invalid item id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.itemService.RemoveFromContainer(userID, itemID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrItemNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrItemDeleted), errors.Is(err, service.ErrItemNotContained),
+			errors.Is(err, service.ErrItemNotTrackable), errors.Is(err, service.ErrItemIsStock):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error removing item from container", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// changeItemStatus moves the item to another lifecycle status through a transition allowed in the settings.
+func (h *ItemHandler) changeItemStatus(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasWritePermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemId"))
+	if err != nil {
+		res.Error(w, "invalid item id", http.StatusBadRequest)
+		return
+	}
+
+	var request dto.ChangeItemStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.settingsService.Get()
+	if err != nil {
+		h.logger.Error("error getting settings", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	item, err := h.itemService.ChangeStatus(userID, itemID, request, settings.Statuses)
+	if err != nil {
+		switch {
+		case errors.Is(err, dto.ErrInvalidItemStatus), errors.Is(err, dto.ErrInvalidStatusReason):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrItemNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrItemDeleted), errors.Is(err, service.ErrItemStatusUnchanged),
+			errors.Is(err, service.ErrItemStatusTransitionDenied), errors.Is(err, service.ErrItemStatusChangedConcurrent):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error changing item status", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, item)
+}
+
+// trackingErrorStatus returns the status code for an error rejecting the tracking of items.
+// Returns false if the error is unexpected.
+func trackingErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, service.ErrItemNotFound),
+		errors.Is(err, service.ErrLocationNotFound),
+		errors.Is(err, service.ErrUserNotFound):
+		return http.StatusNotFound, true
+	case errors.Is(err, service.ErrItemDeleted),
+		errors.Is(err, service.ErrItemIsStock),
+		errors.Is(err, service.ErrItemNotTrackable),
+		errors.Is(err, service.ErrLocationDeleted),
+		errors.Is(err, service.ErrUserDeleted):
+		return http.StatusConflict, true
+	case errors.Is(err, service.ErrUserNotTracker):
+		return http.StatusUnprocessableEntity, true
+	default:
+		return 0, false
+	}
+}
+
+// getItemLocationAt returns where the item was at the time given by the at query parameter, defaulting to now.
+func (h *ItemHandler) getItemLocationAt(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemId"))
+	if err != nil {
+		h.logger.Error("invalid item id", "error", err)
+		res.Error(w, "invalid item id", http.StatusBadRequest)
+		return
+	}
+
+	at, err := getTimeQueryParam(r, "at")
+	if err != nil {
+		res.Error(w, "invalid at date, expected RFC3339", http.StatusBadRequest)
+		return
+	}
+	if at == nil {
+		now := time.Now()
+		at = &now
+	}
+
+	location, err := h.itemService.GetLocationAt(itemID, *at)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrItemNotFound):
+			res.Error(w, "item not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrItemNotPresentAt):
+			res.Error(w, "item did not exist at the given time", http.StatusNotFound)
+		default:
+			h.logger.Error("error getting item location", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, location)
+}
+
+// getItemHistory returns the whole history of the item as an array, newest first.
+// When any of the limit, before or cursor query parameters is given a page of the history is returned instead:
+// limit is the page size, before only lists records created before the given RFC3339 time
+// and cursor is the nextCursor of the previous page.
 func (h *ItemHandler) getItemHistory(w http.ResponseWriter, r *http.Request) {
 	if !authenticated(r) {
 		w.WriteHeader(http.StatusUnauthorized)
@@ -281,8 +867,37 @@ func (h *ItemHandler) getItemHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	history, err := h.itemService.GetItemHistory(itemID)
+	before, err := getTimeQueryParam(r, "before")
+	if err != nil {
+		emit.New(w).Status(http.StatusBadRequest).ErrorJSON("invalid before date, expected RFC3339")
+		return
+	}
+
+	query := r.URL.Query()
+	if before == nil && !query.Has("limit") && !query.Has("cursor") {
+		history, err := h.itemService.GetItemHistory(itemID)
+		if err != nil {
+			h.logger.Error("error getting item history", "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		emit.New(w).JSON(history)
+		return
+	}
+
+	page, err := getPageQueryParams(r)
 	if err != nil {
+		emit.New(w).Status(http.StatusBadRequest).ErrorJSON(err.Error())
+		return
+	}
+
+	history, err := h.itemService.ListItemHistory(itemID, before, page)
+	if err != nil {
+		if isPageError(err) {
+			emit.New(w).Status(http.StatusBadRequest).ErrorJSON(err.Error())
+			return
+		}
 		h.logger.Error("error getting item history", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -327,9 +942,9 @@ func (h *ItemHandler) downloadItemHistoryCSV(w http.ResponseWriter, r *http.Requ
 	defer writer.Flush()
 
 	terms := settings.Terminology
-	_ = writer.Write([]string{"Date", "Type", "User", terms.Group, terms.Location})
+	_ = writer.Write([]string{"Date", "Type", "User", terms.Group, terms.Location, "Changes"})
 	for _, record := range history {
-		if err := record.CSV(writer); err != nil {
+		if err := writer.Write(record.CSVRecord()); err != nil {
 			h.logger.Error("error writing csv record", "error", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -366,3 +981,102 @@ func (h *ItemHandler) getItemGroupsExist(w http.ResponseWriter, r *http.Request)
 
 	emit.New(w).JSON(results)
 }
+
+func (h *ItemHandler) listGroupCustomFields(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	groups, err := h.itemService.ListGroupCustomFields()
+	if err != nil {
+		h.logger.Error("error listing group custom fields", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, groups)
+}
+
+// getGroupCustomFields returns the custom field definitions of a group, which are empty if it has none.
+func (h *ItemHandler) getGroupCustomFields(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	group, err := h.itemService.GetGroupCustomFields(r.PathValue("groupKey"))
+	if err != nil {
+		h.logger.Error("error getting group custom fields", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, group)
+}
+
+// setGroupCustomFields replaces the custom field definitions of a group.
+func (h *ItemHandler) setGroupCustomFields(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Forbidden(w)
+		return
+	}
+
+	var request dto.SetGroupCustomFieldsRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.itemService.SetGroupCustomFields(r.PathValue("groupKey"), request)
+	if err != nil {
+		if errors.Is(err, dto.ErrInvalidCustomFieldsSpec) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("error setting group custom fields", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, group)
+}
+
+func (h *ItemHandler) deleteGroupCustomFields(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Forbidden(w)
+		return
+	}
+
+	if err := h.itemService.DeleteGroupCustomFields(r.PathValue("groupKey")); err != nil {
+		if errors.Is(err, service.ErrGroupCustomFieldsNotFound) {
+			res.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		h.logger.Error("error deleting group custom fields", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
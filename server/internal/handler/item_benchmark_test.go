@@ -0,0 +1,119 @@
+package handler_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"quantum/internal/handler"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+)
+
+const (
+	benchmarkItemCount      = 10_000
+	benchmarkHistoryPerItem = 100
+	benchmarkLocationCount  = 100
+	benchmarkListItemsLimit = 50
+)
+
+// BenchmarkListItems measures listing items with 1M rows in the item_history table.
+// Each item has a created record followed by tracked records that move it between locations.
+func BenchmarkListItems(b *testing.B) {
+	b.Cleanup(func() { testutils.CleanDatabase(b, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(b, application.DB)
+	seedBenchmarkHistory(b, tracker.ID.String())
+
+	var locationID string
+	err := application.DB.Get(&locationID, "select id from locations order by name limit 1;")
+	if err != nil {
+		b.Fatalf("failed to get location: %v", err)
+	}
+
+	locationHandler := setUpLocationHandler(application.DB, application.Logger)
+
+	benchmarks := []struct {
+		name    string
+		handler handler.HandlerBuilder
+		url     string
+	}{
+		{"all items", h, fmt.Sprintf("/api/v1/item?limit=%d", benchmarkListItemsLimit)},
+		{"items by group", h, fmt.Sprintf("/api/v1/item?group=GROUP-1&limit=%d", benchmarkListItemsLimit)},
+		{"items by location", locationHandler, fmt.Sprintf("/api/v1/location/%s/items?limit=%d", locationID, benchmarkListItemsLimit)},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for range b.N {
+				req := httptest.NewRequest("GET", bm.url, nil)
+				testutils.RequestWithJWT(b, req, tracker, application.Config.SessionSecret)
+				rr := testutils.ServeRequest(bm.handler, req, application.Config.SessionSecret)
+
+				if rr.Code != http.StatusOK {
+					b.Fatalf("unexpected status %d: %s", rr.Code, rr.Body.String())
+				}
+			}
+		})
+	}
+}
+
+// seedBenchmarkHistory inserts the benchmark locations, items and history in bulk,
+// then rebuilds the item_current_location table from the history.
+func seedBenchmarkHistory(b *testing.B, userID string) {
+	b.Helper()
+
+	_, err := application.DB.Exec(`
+		insert into locations (name)
+		select 'Location ' || lpad(n::text, 4, '0')
+		from generate_series(1, $1) n;`, benchmarkLocationCount)
+	if err != nil {
+		b.Fatalf("failed to insert locations: %v", err)
+	}
+
+	_, err = application.DB.Exec(`
+		insert into items (identifier, reference, group_key)
+		select 'ITEM-' || n, 'REF-' || lpad(n::text, 6, '0'), 'GROUP-' || (n % 10)
+		from generate_series(1, $1) n;`, benchmarkItemCount)
+	if err != nil {
+		b.Fatalf("failed to insert items: %v", err)
+	}
+
+	_, err = application.DB.Exec(`
+		with numbered_locations as (
+			select id, row_number() over (order by name) - 1 as n
+			from locations
+		)
+		insert into item_history (user_id, item_id, data, created_at)
+		select
+			$1::uuid,
+			i.id,
+			jsonb_build_object(
+				'type', case when h.n = 0 then 'created' else 'tracked' end,
+				'data', jsonb_build_object('locationId', l.id)
+			),
+			now() - make_interval(hours => $2 - h.n)
+		from items i
+		cross join generate_series(0, $2 - 1) h(n)
+		join numbered_locations l on l.n = (abs(hashtext(i.id::text)) + h.n) % $3;`,
+		userID, benchmarkHistoryPerItem, benchmarkLocationCount)
+	if err != nil {
+		b.Fatalf("failed to insert item history: %v", err)
+	}
+
+	_, err = application.DB.Exec(`
+		insert into item_current_location (item_id, type, location_id, tracked_at)
+		select distinct on (item_id)
+			item_id,
+			(data->>'type')::text,
+			(data->'data'->>'locationId')::uuid,
+			created_at
+		from item_history
+		order by item_id, created_at desc, id desc;
+		analyze items, item_history, item_current_location;`)
+	if err != nil {
+		b.Fatalf("failed to project item history: %v", err)
+	}
+}
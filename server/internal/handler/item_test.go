@@ -7,9 +7,12 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"quantum/internal/permissions"
+	"strings"
 	"testing"
+	"time"
 
 	"quantum/internal/app"
 	"quantum/internal/dto"
@@ -17,6 +20,7 @@ import (
 	"quantum/internal/model"
 	"quantum/internal/repository"
 	"quantum/internal/service"
+	"quantum/internal/types/pagination"
 	"quantum/pkg/migrator"
 	"quantum/tests/testdata"
 	"quantum/tests/testutils"
@@ -58,7 +62,7 @@ func setUpItemHandler(db *sqlx.DB, logger *slog.Logger) *handler.ItemHandler {
 	userRepo := repository.NewUserRepository(db)
 	settingsRepo := repository.NewPostgresSettingsRepository(db)
 
-	itemService := service.NewItemService(itemRepo, locationRepo, userRepo)
+	itemService := service.NewItemService(itemRepo, locationRepo, userRepo, repository.NewReservationRepository(db))
 	settingsService := service.NewSettingsService(settingsRepo)
 
 	return handler.NewItemHandler(itemService, settingsService, logger)
@@ -265,12 +269,98 @@ func TestListItems(t *testing.T) {
 
 			assert.Equal(t, http.StatusOK, rr.Code)
 
-			var itemResponse []dto.ItemResponse
+			var itemResponse pagination.Page[dto.ItemResponse]
 			if err := json.NewDecoder(rr.Body).Decode(&itemResponse); err != nil {
 				t.Fatalf("failed to decode response: %v", err)
 			}
 
-			assert.Len(t, itemResponse, len(allItemModels))
+			assert.Len(t, itemResponse.Items, len(allItemModels))
+			assert.Nil(t, itemResponse.NextCursor)
+		})
+	}
+}
+
+func TestListItems_PagesThroughAllItems(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	reader := testdata.InsertReaderUser(t, application.DB)
+
+	location := testdata.NewLocationBuilder(t, application.DB).
+		WithName("test").
+		Build()
+
+	for i := 1; i <= 5; i++ {
+		_ = testdata.NewItemBuilder(t, application.DB).
+			WithIdentifier(fmt.Sprintf("ITEM-%d", i)).
+			WithReference(fmt.Sprintf("REF-%d", i)).
+			WithGroupKey("XYZ").
+			WithCreatedHistoryRecord(reader.ID, location.ID).
+			Build()
+	}
+
+	references := make([]string, 0)
+	url := "/api/v1/item?limit=2&sort=reference&order=desc"
+	for pages := 0; pages < 5; pages++ {
+		req := httptest.NewRequest("GET", url, nil)
+		testutils.RequestWithJWT(t, req, reader, application.Config.SessionSecret)
+		rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var page pagination.Page[dto.ItemResponse]
+		if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		for _, item := range page.Items {
+			references = append(references, item.Reference)
+		}
+
+		if page.NextCursor == nil {
+			break
+		}
+		url = fmt.Sprintf("/api/v1/item?limit=2&cursor=%s", *page.NextCursor)
+	}
+
+	assert.Equal(t, []string{"REF-5", "REF-4", "REF-3", "REF-2", "REF-1"}, references)
+}
+
+func TestListItems_RejectsUnknownSortField(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	reader := testdata.InsertReaderUser(t, application.DB)
+
+	req := httptest.NewRequest("GET", "/api/v1/item?sort=password", nil)
+	testutils.RequestWithJWT(t, req, reader, application.Config.SessionSecret)
+	rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestListItems_RejectsInvalidCursors(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	reader := testdata.InsertReaderUser(t, application.DB)
+
+	testCases := []struct {
+		name   string
+		cursor pagination.Cursor
+	}{
+		{"tampered timestamp", pagination.Cursor{List: "items", Sort: "createdAt", Direction: pagination.Ascending, Value: "yesterday", ID: uuid.NewString()}},
+		{"tampered id", pagination.Cursor{List: "items", Sort: "reference", Direction: pagination.Ascending, Value: "REF-1", ID: "1"}},
+		{"cursor from the locations list", pagination.Cursor{List: "locations", Sort: "createdAt", Direction: pagination.Ascending, Value: time.Now().Format(time.RFC3339Nano), ID: uuid.NewString()}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/item?cursor="+tc.cursor.Encode(), nil)
+			testutils.RequestWithJWT(t, req, reader, application.Config.SessionSecret)
+			rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
 		})
 	}
 }
@@ -322,3 +412,641 @@ func TestTrackItem_OnlyTrackersCanTrackItems(t *testing.T) {
 		})
 	}
 }
+
+func TestTrackItem_RejectsInvalidTargets(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	reader := testdata.InsertReaderUser(t, application.DB)
+
+	location := testdata.NewLocationBuilder(t, application.DB).WithName("Warehouse").Build()
+	deletedLocation := testdata.NewLocationBuilder(t, application.DB).WithName("Closed").AsDeleted().Build()
+
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, location.ID).
+		Build()
+
+	deletedItem := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		AsDeleted().
+		Build()
+
+	testCases := []struct {
+		name         string
+		url          string
+		expectStatus int
+	}{
+		{"unknown location", fmt.Sprintf("/api/v1/item/%s/track/%s", item.ID, uuid.New()), http.StatusNotFound},
+		{"deleted location", fmt.Sprintf("/api/v1/item/%s/track/%s", item.ID, deletedLocation.ID), http.StatusConflict},
+		{"unknown item", fmt.Sprintf("/api/v1/item/%s/track/%s", uuid.New(), location.ID), http.StatusNotFound},
+		{"deleted item", fmt.Sprintf("/api/v1/item/%s/track/%s", deletedItem.ID, location.ID), http.StatusConflict},
+		{"unknown user", fmt.Sprintf("/api/v1/item/%s/track/user/%s", item.ID, uuid.New()), http.StatusNotFound},
+		{"user without the tracker role", fmt.Sprintf("/api/v1/item/%s/track/user/%s", item.ID, reader.ID), http.StatusUnprocessableEntity},
+		{"deleted item to user", fmt.Sprintf("/api/v1/item/%s/track/user/%s", deletedItem.ID, tracker.ID), http.StatusConflict},
+		{"valid location", fmt.Sprintf("/api/v1/item/%s/track/%s", item.ID, location.ID), http.StatusNoContent},
+		{"valid user", fmt.Sprintf("/api/v1/item/%s/track/user/%s", item.ID, tracker.ID), http.StatusNoContent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tc.url, nil)
+			testutils.RequestWithJWT(t, req, tracker, application.Config.SessionSecret)
+			rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+}
+
+func TestUpdateItem_RecordsChangedFieldsInHistory(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	writer := testdata.InsertWriterUser(t, application.DB)
+
+	location := testdata.NewLocationBuilder(t, application.DB).
+		WithName("test").
+		Build()
+
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(writer.ID, location.ID).
+		Build()
+
+	body := `{"identifier": "ITEM-1", "reference": "REF-2", "groupKey": "ABC"}`
+	req := httptest.NewRequest("PUT", fmt.Sprintf("/api/v1/item/%s", item.ID), strings.NewReader(body))
+	testutils.RequestWithJWT(t, req, writer, application.Config.SessionSecret)
+	rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var itemResponse dto.ItemResponse
+	if err := json.NewDecoder(rr.Body).Decode(&itemResponse); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	assert.Equal(t, "REF-2", itemResponse.Reference)
+	assert.Equal(t, "ABC", itemResponse.GroupKey)
+
+	var history []model.ItemHistoryModel
+	err := application.DB.Select(&history, "select * from item_history where item_id = $1 and data->>'type' = 'updated';", item.ID)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	_, data, err := history[0].ParseData()
+	assert.NoError(t, err)
+
+	updated := data.(model.ItemUpdatedHistoryData)
+	assert.Len(t, updated.UpdatedFields, 2)
+	assert.Equal(t, "REF-1", *updated.UpdatedFields["reference"].Old)
+	assert.Equal(t, "REF-2", *updated.UpdatedFields["reference"].New)
+	assert.Equal(t, "XYZ", *updated.UpdatedFields["groupKey"].Old)
+	assert.Equal(t, "ABC", *updated.UpdatedFields["groupKey"].New)
+}
+
+func TestUpdateItem_DuplicateReferenceConflicts(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	writer := testdata.InsertWriterUser(t, application.DB)
+
+	_ = testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		Build()
+
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		Build()
+
+	body := `{"identifier": "ITEM-2", "reference": "REF-1", "groupKey": "XYZ"}`
+	req := httptest.NewRequest("PUT", fmt.Sprintf("/api/v1/item/%s", item.ID), strings.NewReader(body))
+	testutils.RequestWithJWT(t, req, writer, application.Config.SessionSecret)
+	rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestRestoreItem(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	writer := testdata.InsertWriterUser(t, application.DB)
+
+	location := testdata.NewLocationBuilder(t, application.DB).
+		WithName("test").
+		Build()
+
+	deletedItem := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		AsDeleted().
+		WithCreatedHistoryRecord(admin.ID, location.ID).
+		WithDeletedHistoryRecord(admin.ID).
+		Build()
+
+	activeItem := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(admin.ID, location.ID).
+		Build()
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		itemID       uuid.UUID
+		expectStatus int
+	}{
+		{"writer should not be able to restore", writer, deletedItem.ID, http.StatusForbidden},
+		{"admin should not be able to restore an active item", admin, activeItem.ID, http.StatusConflict},
+		{"admin should be able to restore a deleted item", admin, deletedItem.ID, http.StatusNoContent},
+		{"admin should get not found for an unknown item", admin, uuid.New(), http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/item/%s/restore", tc.itemID), nil)
+			testutils.RequestWithJWT(t, req, tc.user, application.Config.SessionSecret)
+			rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+
+	var deleted bool
+	err := application.DB.Get(&deleted, "select deleted from items where id = $1;", deletedItem.ID)
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	var restoredCount int
+	err = application.DB.Get(&restoredCount, "select count(*) from item_history where item_id = $1 and data->>'type' = 'restored';", deletedItem.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, restoredCount)
+}
+
+func TestListDeletedItems(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	writer := testdata.InsertWriterUser(t, application.DB)
+	otherAdmin := testdata.NewUserBuilder(t, application.DB).
+		WithName("Other Admin").
+		WithUsername("other.admin").
+		WithRole(permissions.AdminRole).
+		Build()
+
+	location := testdata.NewLocationBuilder(t, application.DB).
+		WithName("test").
+		Build()
+
+	now := time.Now()
+	lastWeek := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		AsDeleted().
+		WithCreatedHistoryRecord(admin.ID, location.ID).
+		WithDeletedHistoryRecordAt(admin.ID, now.Add(-7*24*time.Hour)).
+		Build()
+	yesterday := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		AsDeleted().
+		WithCreatedHistoryRecord(admin.ID, location.ID).
+		WithDeletedHistoryRecordAt(otherAdmin.ID, now.Add(-24*time.Hour)).
+		Build()
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-3").
+		WithReference("REF-3").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(admin.ID, location.ID).
+		Build()
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		query        url.Values
+		expectStatus int
+		expectIDs    []uuid.UUID
+	}{
+		{"writer should not be able to list the trash", writer, url.Values{}, http.StatusForbidden, nil},
+		{"lists deleted items, most recently deleted first", admin, url.Values{}, http.StatusOK, []uuid.UUID{yesterday.ID, lastWeek.ID}},
+		{"filters by the user who deleted the item", admin, url.Values{"deletedBy": {otherAdmin.ID.String()}}, http.StatusOK, []uuid.UUID{yesterday.ID}},
+		{"filters by deletion date", admin, url.Values{
+			"from": {now.Add(-8 * 24 * time.Hour).Format(time.RFC3339)},
+			"to":   {now.Add(-2 * 24 * time.Hour).Format(time.RFC3339)},
+		}, http.StatusOK, []uuid.UUID{lastWeek.ID}},
+		{"rejects an invalid deletedBy", admin, url.Values{"deletedBy": {"nobody"}}, http.StatusBadRequest, nil},
+		{"rejects an invalid date", admin, url.Values{"from": {"yesterday"}}, http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/item/trash?"+tc.query.Encode(), nil)
+			testutils.RequestWithJWT(t, req, tc.user, application.Config.SessionSecret)
+			rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+			if tc.expectStatus != http.StatusOK {
+				return
+			}
+
+			var page pagination.Page[dto.DeletedItemResponse]
+			if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			ids := make([]uuid.UUID, 0, len(page.Items))
+			for _, item := range page.Items {
+				ids = append(ids, item.ID)
+			}
+			assert.Equal(t, tc.expectIDs, ids)
+		})
+	}
+}
+
+func TestPurgeDeletedItems(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	writer := testdata.InsertWriterUser(t, application.DB)
+
+	location := testdata.NewLocationBuilder(t, application.DB).
+		WithName("test").
+		Build()
+
+	// The default trash retention is 30 days.
+	retention := 30 * 24 * time.Hour
+	now := time.Now()
+	expired := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		AsDeleted().
+		WithCreatedHistoryRecord(admin.ID, location.ID).
+		WithDeletedHistoryRecordAt(admin.ID, now.Add(-retention-time.Hour)).
+		Build()
+	retained := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		AsDeleted().
+		WithCreatedHistoryRecord(admin.ID, location.ID).
+		WithDeletedHistoryRecordAt(admin.ID, now.Add(-retention+time.Hour)).
+		Build()
+	active := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-3").
+		WithReference("REF-3").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(admin.ID, location.ID).
+		Build()
+
+	countItems := func(id uuid.UUID) int {
+		var count int
+		err := application.DB.Get(&count, "select count(*) from items where id = $1;", id)
+		assert.NoError(t, err)
+		return count
+	}
+
+	req := httptest.NewRequest("DELETE", "/api/v1/item/trash", nil)
+	testutils.RequestWithJWT(t, req, writer, application.Config.SessionSecret)
+	rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, 1, countItems(expired.ID), "a forbidden purge removes nothing")
+
+	req = httptest.NewRequest("DELETE", "/api/v1/item/trash", nil)
+	testutils.RequestWithJWT(t, req, admin, application.Config.SessionSecret)
+	rr = testutils.ServeRequest(h, req, application.Config.SessionSecret)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response dto.PurgeItemsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.Equal(t, int64(1), response.Purged)
+
+	assert.Equal(t, 0, countItems(expired.ID), "items deleted before the retention cutoff are purged")
+	assert.Equal(t, 1, countItems(retained.ID), "items deleted after the retention cutoff are kept")
+	assert.Equal(t, 1, countItems(active.ID), "active items are kept")
+
+	var historyCount int
+	err := application.DB.Get(&historyCount, "select count(*) from item_history where item_id = $1;", expired.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, historyCount)
+}
+
+func TestImportItems(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	writer := testdata.InsertWriterUser(t, application.DB)
+
+	location := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Warehouse").
+		Build()
+
+	_ = testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-0").
+		WithReference("REF-0").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(writer.ID, location.ID).
+		Build()
+
+	csvBody := "Identifier,Reference,Group,Description,Location\n" +
+		"ITEM-1,REF-1,XYZ,First item,Warehouse\n" +
+		"ITEM-2,REF-0,XYZ,,Warehouse\n" +
+		"ITEM-3,REF-1,XYZ,,Warehouse\n" +
+		"ITEM-4,REF-4,XYZ,,Nowhere\n" +
+		"ITEM-5,,XYZ,,Warehouse\n"
+
+	testCases := []struct {
+		name           string
+		dryRun         bool
+		expectImported int
+	}{
+		{"dry run validates without writing", true, 0},
+		{"commit inserts the valid rows", false, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/item/import?dryRun=%t", tc.dryRun), strings.NewReader(csvBody))
+			testutils.RequestWithJWT(t, req, writer, application.Config.SessionSecret)
+			rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var report dto.ImportItemsResponse
+			if err := json.NewDecoder(rr.Body).Decode(&report); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			assert.Equal(t, 5, report.Total)
+			assert.Equal(t, 1, report.Valid)
+			assert.Equal(t, 4, report.Invalid)
+			assert.Equal(t, tc.expectImported, report.Imported)
+			assert.True(t, report.Rows[0].Valid)
+			assert.False(t, report.Rows[1].Valid)
+			assert.False(t, report.Rows[2].Valid)
+			assert.False(t, report.Rows[3].Valid)
+			assert.False(t, report.Rows[4].Valid)
+
+			var count int
+			err := application.DB.Get(&count, "select count(*) from items where reference = 'REF-1';")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectImported, count)
+		})
+	}
+}
+
+func TestTrackItems(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	start := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Start").
+		Build()
+
+	target := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Target").
+		Build()
+
+	item1 := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, start.ID).
+		Build()
+
+	_ = testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, start.ID).
+		Build()
+
+	countTracked := func() int {
+		var count int
+		err := application.DB.Get(&count, "select count(*) from item_history where data->>'type' = 'tracked';")
+		assert.NoError(t, err)
+		return count
+	}
+
+	testCases := []struct {
+		name          string
+		partial       bool
+		expectStatus  int
+		expectTracked int
+	}{
+		{"all or nothing does not track when an item is missing", false, http.StatusUnprocessableEntity, 0},
+		{"partial tracks the items that exist", true, http.StatusOK, 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := fmt.Sprintf(
+				`{"itemIds": [%q], "references": ["REF-2", "REF-MISSING"], "locationId": %q, "partial": %t}`,
+				item1.ID, target.ID, tc.partial,
+			)
+
+			req := httptest.NewRequest("POST", "/api/v1/item/track", strings.NewReader(body))
+			testutils.RequestWithJWT(t, req, tracker, application.Config.SessionSecret)
+			rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+			assert.Equal(t, tc.expectTracked, countTracked())
+		})
+	}
+}
+
+func TestGetItemLocationAt(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	first := testdata.NewLocationBuilder(t, application.DB).
+		WithName("First").
+		Build()
+
+	second := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Second").
+		Build()
+
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, first.ID).
+		WithTrackedHistoryRecord(tracker.ID, second.ID).
+		Build()
+
+	// Move the history into the past so there is a point in time for each location.
+	_, err := application.DB.Exec(`
+		update item_history
+		set created_at = case data->>'type'
+			when 'created' then now() - interval '3 days'
+			else now() - interval '1 day'
+		end
+		where item_id = $1;`, item.ID)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name               string
+		at                 time.Time
+		expectStatus       int
+		expectedLocationID uuid.UUID
+	}{
+		{"before the item was created", time.Now().Add(-96 * time.Hour), http.StatusNotFound, uuid.Nil},
+		{"after creation and before tracking", time.Now().Add(-48 * time.Hour), http.StatusOK, first.ID},
+		{"after tracking", time.Now(), http.StatusOK, second.ID},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("/api/v1/item/%s/location?at=%s", item.ID, tc.at.UTC().Format(time.RFC3339))
+			req := httptest.NewRequest("GET", url, nil)
+			testutils.RequestWithJWT(t, req, tracker, application.Config.SessionSecret)
+			rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+			if tc.expectStatus != http.StatusOK {
+				return
+			}
+
+			var response dto.ItemLocationAtResponse
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+
+			assert.Equal(t, tc.expectedLocationID, response.Location.ID)
+		})
+	}
+}
+
+func TestGetItemHistory(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	location := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Warehouse").
+		Build()
+
+	missingUserID := uuid.New()
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, location.ID).
+		WithTrackedHistoryRecord(tracker.ID, uuid.New()).
+		WithTrackedUserHistoryRecord(tracker.ID, missingUserID).
+		Build()
+
+	getHistory := func(t *testing.T, query string, v any) {
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/item/%s/history%s", item.ID, query), nil)
+		testutils.RequestWithJWT(t, req, tracker, application.Config.SessionSecret)
+		rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		if err := json.NewDecoder(rr.Body).Decode(v); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+
+	t.Run("returns the whole history and degrades records referring to missing users and locations", func(t *testing.T) {
+		var history []map[string]any
+		getHistory(t, "", &history)
+		assert.Len(t, history, 3)
+
+		trackedUser := history[0]["data"].(map[string]any)
+		assert.Equal(t, missingUserID.String(), trackedUser["userId"])
+		assert.Equal(t, "Unknown user", trackedUser["userName"])
+
+		tracked := history[1]["data"].(map[string]any)
+		assert.Equal(t, "Unknown location", tracked["locationName"])
+
+		created := history[2]["data"].(map[string]any)
+		assert.Equal(t, "Warehouse", created["locationName"])
+		assert.Equal(t, tracker.Name, history[2]["userName"])
+	})
+
+	t.Run("pages with limit and cursor", func(t *testing.T) {
+		var firstPage pagination.Page[map[string]any]
+		getHistory(t, "?limit=2", &firstPage)
+		assert.Len(t, firstPage.Items, 2)
+		if !assert.NotNil(t, firstPage.NextCursor) {
+			return
+		}
+
+		var secondPage pagination.Page[map[string]any]
+		getHistory(t, "?limit=2&cursor="+url.QueryEscape(*firstPage.NextCursor), &secondPage)
+		assert.Nil(t, secondPage.NextCursor)
+		if assert.Len(t, secondPage.Items, 1) {
+			assert.Equal(t, string(model.ItemHistoryTypeCreated), secondPage.Items[0]["type"])
+		}
+	})
+}
+
+func TestGetItemHistory_PagesBefore(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+
+	location := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Warehouse").
+		Build()
+
+	now := time.Now()
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		AsDeleted().
+		WithCreatedHistoryRecord(admin.ID, location.ID).
+		WithDeletedHistoryRecordAt(admin.ID, now.Add(-2*time.Hour)).
+		WithDeletedHistoryRecordAt(admin.ID, now.Add(-time.Hour)).
+		Build()
+
+	query := url.Values{"before": {now.Add(-30 * time.Minute).Format(time.RFC3339)}, "limit": {"1"}}
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/item/%s/history?%s", item.ID, query.Encode()), nil)
+	testutils.RequestWithJWT(t, req, admin, application.Config.SessionSecret)
+	rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var page pagination.Page[dto.DeletedItemHistoryRecord]
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	assert.NotNil(t, page.NextCursor)
+	if assert.Len(t, page.Items, 1) {
+		assert.WithinDuration(t, now.Add(-time.Hour), page.Items[0].Date, time.Second)
+	}
+
+	req = httptest.NewRequest("GET", fmt.Sprintf("/api/v1/item/%s/history?before=yesterday", item.ID), nil)
+	testutils.RequestWithJWT(t, req, admin, application.Config.SessionSecret)
+	rr = testutils.ServeRequest(h, req, application.Config.SessionSecret)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,269 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func countHistoryRecords(t testing.TB, db *sqlx.DB, itemID uuid.UUID, historyType model.ItemHistoryType) int {
+	var count int
+	stmt := "select count(*) from item_history where item_id = $1 and data->>'type' = $2;"
+	if err := db.Get(&count, stmt, itemID, historyType); err != nil {
+		t.Fatalf("failed to count history records: %v", err)
+	}
+	return count
+}
+
+func TestPutItemInContainer(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	office := testdata.NewLocationBuilder(t, application.DB).WithName("Office").Build()
+	desk := testdata.NewLocationBuilder(t, application.DB).WithName("Desk").Build()
+
+	bag := insertTool(t, application.DB, "BAG-1", tracker.ID, office.ID)
+	charger := insertTool(t, application.DB, "CHARGER-1", tracker.ID, desk.ID)
+
+	body := fmt.Sprintf(`{"containerId": %q}`, bag.ID)
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "PUT", fmt.Sprintf("/api/v1/item/%s/container", charger.ID), body)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	assert.Equal(t, 1, countHistoryRecords(t, application.DB, charger.ID, model.ItemHistoryTypeContained))
+	assert.Equal(t, office.ID, getCurrentLocationID(t, application.DB, charger.ID), "the charger moves to where the bag is")
+}
+
+func TestGetItem_ShowsContentsAndContainer(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	office := testdata.NewLocationBuilder(t, application.DB).WithName("Office").Build()
+
+	bag := insertTool(t, application.DB, "BAG-1", tracker.ID, office.ID)
+	laptop := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("LAPTOP-1").
+		WithReference("LAPTOP-1").
+		WithGroupKey("IT").
+		WithCreatedHistoryRecord(tracker.ID, office.ID).
+		WithContainedHistoryRecord(tracker.ID, bag.ID).
+		Build()
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("MOUSE-1").
+		WithReference("MOUSE-1").
+		WithGroupKey("IT").
+		WithCreatedHistoryRecord(tracker.ID, office.ID).
+		WithContainedHistoryRecord(tracker.ID, bag.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "GET", "/api/v1/item/"+bag.ID.String(), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response dto.ItemDetailResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.Nil(t, response.Container)
+	assert.Len(t, response.Contents, 2)
+
+	rr = testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "GET", "/api/v1/item/"+laptop.ID.String(), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	response = dto.ItemDetailResponse{}
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if assert.NotNil(t, response.Container) {
+		assert.Equal(t, bag.ID, response.Container.ID)
+	}
+}
+
+func TestPutItemInContainer_RejectsCycles(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	office := testdata.NewLocationBuilder(t, application.DB).WithName("Office").Build()
+
+	bag := insertTool(t, application.DB, "BAG-1", tracker.ID, office.ID)
+	laptop := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("LAPTOP-1").
+		WithReference("LAPTOP-1").
+		WithGroupKey("IT").
+		WithCreatedHistoryRecord(tracker.ID, office.ID).
+		WithContainedHistoryRecord(tracker.ID, bag.ID).
+		Build()
+
+	testCases := []struct {
+		name      string
+		container *model.ItemModel
+	}{
+		{"an item cannot go inside its own contents", laptop},
+		{"an item cannot go inside itself", bag},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"containerId": %q}`, tc.container.ID)
+			rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "PUT", fmt.Sprintf("/api/v1/item/%s/container", bag.ID), body)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestTrackItem_MovesTheContentsOfAContainer(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	office := testdata.NewLocationBuilder(t, application.DB).WithName("Office").Build()
+	site := testdata.NewLocationBuilder(t, application.DB).WithName("Site").Build()
+
+	bag := insertTool(t, application.DB, "BAG-1", tracker.ID, office.ID)
+	laptop := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("LAPTOP-1").
+		WithReference("LAPTOP-1").
+		WithGroupKey("IT").
+		WithCreatedHistoryRecord(tracker.ID, office.ID).
+		WithContainedHistoryRecord(tracker.ID, bag.ID).
+		Build()
+	charger := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("CHARGER-1").
+		WithReference("CHARGER-1").
+		WithGroupKey("IT").
+		WithCreatedHistoryRecord(tracker.ID, office.ID).
+		WithContainedHistoryRecord(tracker.ID, laptop.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/track/%s", bag.ID, site.ID), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	for _, item := range []*model.ItemModel{bag, laptop, charger} {
+		assert.Equal(t, site.ID, getCurrentLocationID(t, application.DB, item.ID))
+	}
+}
+
+func TestRemoveItemFromContainer(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	office := testdata.NewLocationBuilder(t, application.DB).WithName("Office").Build()
+	site := testdata.NewLocationBuilder(t, application.DB).WithName("Site").Build()
+
+	bag := insertTool(t, application.DB, "BAG-1", tracker.ID, office.ID)
+	mouse := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("MOUSE-1").
+		WithReference("MOUSE-1").
+		WithGroupKey("IT").
+		WithCreatedHistoryRecord(tracker.ID, office.ID).
+		WithContainedHistoryRecord(tracker.ID, bag.ID).
+		Build()
+	containerURL := fmt.Sprintf("/api/v1/item/%s/container", mouse.ID)
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "DELETE", containerURL, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, 1, countHistoryRecords(t, application.DB, mouse.ID, model.ItemHistoryTypeUncontained))
+	assert.Equal(t, office.ID, getCurrentLocationID(t, application.DB, mouse.ID))
+
+	rr = testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "DELETE", containerURL, "")
+	assert.Equal(t, http.StatusConflict, rr.Code, "the mouse is no longer inside a container")
+
+	rr = testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/track/%s", bag.ID, site.ID), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, office.ID, getCurrentLocationID(t, application.DB, mouse.ID), "the mouse stays behind when the bag moves")
+}
+
+func TestTrackItem_TakesAnItemOutOfItsContainer(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	office := testdata.NewLocationBuilder(t, application.DB).WithName("Office").Build()
+	desk := testdata.NewLocationBuilder(t, application.DB).WithName("Desk").Build()
+
+	bag := insertTool(t, application.DB, "BAG-1", tracker.ID, office.ID)
+	laptop := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("LAPTOP-1").
+		WithReference("LAPTOP-1").
+		WithGroupKey("IT").
+		WithCreatedHistoryRecord(tracker.ID, office.ID).
+		WithContainedHistoryRecord(tracker.ID, bag.ID).
+		Build()
+	charger := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("CHARGER-1").
+		WithReference("CHARGER-1").
+		WithGroupKey("IT").
+		WithCreatedHistoryRecord(tracker.ID, office.ID).
+		WithContainedHistoryRecord(tracker.ID, bag.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/track/%s", charger.ID, desk.ID), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, 1, countHistoryRecords(t, application.DB, charger.ID, model.ItemHistoryTypeUncontained))
+
+	rr = testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "GET", "/api/v1/item/"+bag.ID.String(), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response dto.ItemDetailResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if assert.Len(t, response.Contents, 1) {
+		assert.Equal(t, laptop.ID, response.Contents[0].ID)
+	}
+}
+
+func TestGetItemHistory_NamesTheContainer(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	office := testdata.NewLocationBuilder(t, application.DB).WithName("Office").Build()
+
+	bag := insertTool(t, application.DB, "BAG-1", tracker.ID, office.ID)
+	charger := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("CHARGER-1").
+		WithReference("CHARGER-1").
+		WithGroupKey("IT").
+		WithCreatedHistoryRecord(tracker.ID, office.ID).
+		WithContainedHistoryRecord(tracker.ID, bag.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "GET", fmt.Sprintf("/api/v1/item/%s/history", charger.ID), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var history []map[string]any
+	if err := json.NewDecoder(rr.Body).Decode(&history); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	contained := 0
+	for _, record := range history {
+		if record["type"] == string(model.ItemHistoryTypeContained) {
+			contained++
+			data := record["data"].(map[string]any)
+			assert.Equal(t, "BAG-1", data["containerReference"])
+		}
+	}
+	assert.Equal(t, 1, contained)
+}
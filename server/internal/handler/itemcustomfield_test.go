@@ -0,0 +1,208 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"quantum/internal/types/pagination"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+const powerToolFields = `{"fields": [
+	{"name": "serialNumber", "type": "regex", "required": true, "pattern": "^SN-[0-9]+$"},
+	{"name": "purchaseDate", "type": "date"},
+	{"name": "voltage", "type": "number"},
+	{"name": "plug", "type": "enum", "options": ["UK", "EU"]}
+]}`
+
+// definePowerToolFields defines the custom fields of powerToolFields for the PowerTools group.
+func definePowerToolFields(t testing.TB, db *sqlx.DB) {
+	var req dto.SetGroupCustomFieldsRequest
+	if err := json.Unmarshal([]byte(powerToolFields), &req); err != nil {
+		t.Fatalf("failed to unmarshal custom fields: %v", err)
+	}
+
+	definitions, err := json.Marshal(req.Fields)
+	if err != nil {
+		t.Fatalf("failed to marshal custom fields: %v", err)
+	}
+
+	fields := &model.GroupCustomFieldsModel{GroupKey: "PowerTools", Fields: definitions}
+	if err := repository.NewItemRepository(db).SetGroupCustomFields(fields); err != nil {
+		t.Fatalf("failed to set custom fields: %v", err)
+	}
+}
+
+func TestSetGroupCustomFields(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	writer := testdata.InsertWriterUser(t, application.DB)
+
+	rr := testutils.ServeRequestAs(t, h, writer, application.Config.SessionSecret, "PUT", "/api/v1/item/groups/PowerTools/fields", powerToolFields)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "only admins define custom fields")
+
+	rr = testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, "PUT", "/api/v1/item/groups/PowerTools/fields", powerToolFields)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response dto.GroupCustomFieldsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.Len(t, response.Fields, 4)
+}
+
+func TestSetGroupCustomFields_RejectsInvalidDefinitions(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{"an enum without options", `{"fields": [{"name": "plug", "type": "enum"}]}`},
+		{"a pattern that does not compile", `{"fields": [{"name": "serial", "type": "regex", "pattern": "("}]}`},
+		{"a name with a space", `{"fields": [{"name": "mac address", "type": "string"}]}`},
+		{"a name used twice", `{"fields": [{"name": "a", "type": "string"}, {"name": "a", "type": "number"}]}`},
+		{"an unknown type", `{"fields": [{"name": "a", "type": "colour"}]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, "PUT", "/api/v1/item/groups/Laptops/fields", tc.body)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestCreateItem_ValidatesCustomFields(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	writer := testdata.InsertWriterUser(t, application.DB)
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+
+	definePowerToolFields(t, application.DB)
+
+	testCases := []struct {
+		name         string
+		reference    string
+		customFields string
+		expectStatus int
+	}{
+		{"every field given", "DRILL-1", `{"serialNumber": "SN-1", "purchaseDate": "2024-03-01", "voltage": 18, "plug": "UK"}`, http.StatusCreated},
+		{"only some fields given", "DRILL-2", `{"serialNumber": "SN-2", "voltage": 36}`, http.StatusCreated},
+		{"a required field missing", "DRILL-3", `{}`, http.StatusBadRequest},
+		{"a value not matching the pattern", "DRILL-4", `{"serialNumber": "1234"}`, http.StatusBadRequest},
+		{"a number given as a string", "DRILL-5", `{"serialNumber": "SN-5", "voltage": "18V"}`, http.StatusBadRequest},
+		{"a date in another format", "DRILL-6", `{"serialNumber": "SN-6", "purchaseDate": "01/03/2024"}`, http.StatusBadRequest},
+		{"a value not in the options", "DRILL-7", `{"serialNumber": "SN-7", "plug": "US"}`, http.StatusBadRequest},
+		{"a field that is not defined", "DRILL-8", `{"serialNumber": "SN-8", "colour": "red"}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"reference": %q, "groupKey": "PowerTools", "locationId": %q, "customFields": %s}`, tc.reference, workshop.ID, tc.customFields)
+			rr := testutils.ServeRequestAs(t, h, writer, application.Config.SessionSecret, "POST", "/api/v1/item", body)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+}
+
+func TestListItems_FiltersByCustomFields(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	writer := testdata.InsertWriterUser(t, application.DB)
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+
+	definePowerToolFields(t, application.DB)
+
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("DRILL-1").
+		WithReference("DRILL-1").
+		WithGroupKey("PowerTools").
+		WithCustomFields(`{"serialNumber": "SN-1", "voltage": 18}`).
+		WithCreatedHistoryRecord(writer.ID, workshop.ID).
+		Build()
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("DRILL-2").
+		WithReference("DRILL-2").
+		WithGroupKey("PowerTools").
+		WithCustomFields(`{"serialNumber": "SN-2", "voltage": 36}`).
+		WithCreatedHistoryRecord(writer.ID, workshop.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, writer, application.Config.SessionSecret, "GET", "/api/v1/item?group=PowerTools&field.voltage=36", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var page pagination.Page[dto.ItemWithCurrentLocationResponse]
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if assert.Len(t, page.Items, 1) {
+		assert.Equal(t, "DRILL-2", page.Items[0].Reference)
+	}
+
+	rr = testutils.ServeRequestAs(t, h, writer, application.Config.SessionSecret, "GET", "/api/v1/item?field.bad-name=1", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestUpdateItem_ValidatesAndRecordsCustomFields(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	writer := testdata.InsertWriterUser(t, application.DB)
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+
+	definePowerToolFields(t, application.DB)
+
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("DRILL-2").
+		WithReference("DRILL-2").
+		WithGroupKey("PowerTools").
+		WithCustomFields(`{"serialNumber": "SN-2", "voltage": 36}`).
+		WithCreatedHistoryRecord(writer.ID, workshop.ID).
+		Build()
+	url := fmt.Sprintf("/api/v1/item/%s", item.ID)
+
+	rr := testutils.ServeRequestAs(t, h, writer, application.Config.SessionSecret, "PUT", url, `{"reference": "DRILL-2", "groupKey": "PowerTools", "customFields": {"serialNumber": "SN-2", "voltage": "high"}}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = testutils.ServeRequestAs(t, h, writer, application.Config.SessionSecret, "PUT", url, `{"reference": "DRILL-2", "groupKey": "PowerTools", "customFields": {"serialNumber": "SN-2", "voltage": 18}}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var data json.RawMessage
+	stmt := "select data->'data' from item_history where item_id = $1 and data->>'type' = 'updated';"
+	assert.NoError(t, application.DB.Get(&data, stmt, item.ID))
+
+	var history model.ItemUpdatedHistoryData
+	assert.NoError(t, json.Unmarshal(data, &history))
+	if change, ok := history.UpdatedFields["customFields.voltage"]; assert.True(t, ok) {
+		assert.Equal(t, "36", *change.Old)
+		assert.Equal(t, "18", *change.New)
+	}
+
+	rr = testutils.ServeRequestAs(t, h, writer, application.Config.SessionSecret, "PUT", url, `{"reference": "DRILL-2B", "groupKey": "PowerTools"}`)
+	assert.Equal(t, http.StatusOK, rr.Code, "custom fields are kept when not given")
+
+	var response dto.ItemResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.JSONEq(t, `{"serialNumber": "SN-2", "voltage": 18}`, string(response.CustomFields))
+}
@@ -0,0 +1,253 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/types/pagination"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetItem_NewItemsAreInService(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+
+	drill := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("DRILL-1").
+		WithReference("DRILL-1").
+		WithGroupKey("PowerTools").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, "GET", fmt.Sprintf("/api/v1/item/%s", drill.ID), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response dto.ItemResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.Equal(t, model.ItemStatusInService, response.Status)
+}
+
+func TestChangeItemStatus_ValidatesTheRequest(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	reader := testdata.InsertReaderUser(t, application.DB)
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+
+	drill := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("DRILL-1").
+		WithReference("DRILL-1").
+		WithGroupKey("PowerTools").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		Build()
+	inRepair := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("DRILL-2").
+		WithReference("DRILL-2").
+		WithGroupKey("PowerTools").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		WithStatusChangedHistoryRecord(admin.ID, model.ItemStatusInRepair, "motor burnt out").
+		Build()
+
+	testCases := []struct {
+		name         string
+		user         *model.User
+		item         *model.ItemModel
+		body         string
+		expectStatus int
+	}{
+		{"readers cannot change the status", reader, drill, `{"status": "in-repair", "reason": "motor"}`, http.StatusForbidden},
+		{"an unknown status is rejected", admin, drill, `{"status": "broken", "reason": "motor"}`, http.StatusBadRequest},
+		{"a blank reason is rejected", admin, drill, `{"status": "in-repair", "reason": "  "}`, http.StatusBadRequest},
+		{"the current status conflicts", admin, drill, `{"status": "in-service", "reason": "check"}`, http.StatusConflict},
+		{"transitions the settings do not allow conflict", admin, inRepair, `{"status": "on-loan", "reason": "lent out"}`, http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := testutils.ServeRequestAs(t, h, tc.user, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/status", tc.item.ID), tc.body)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+}
+
+func TestChangeItemStatus_RecordsTheReason(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+
+	drill := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("DRILL-1").
+		WithReference("DRILL-1").
+		WithGroupKey("PowerTools").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/status", drill.ID), `{"status": "in-repair", "reason": "motor burnt out"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response dto.ItemResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.Equal(t, model.ItemStatusInRepair, response.Status)
+
+	var data json.RawMessage
+	stmt := "select data->'data' from item_history where item_id = $1 and data->>'type' = 'status-changed';"
+	assert.NoError(t, application.DB.Get(&data, stmt, drill.ID))
+
+	var history model.ItemStatusChangedHistoryData
+	assert.NoError(t, json.Unmarshal(data, &history))
+	assert.Equal(t, model.ItemStatusInService, history.From)
+	assert.Equal(t, model.ItemStatusInRepair, history.To)
+	assert.Equal(t, "motor burnt out", history.Reason)
+}
+
+func TestListItems_FiltersByStatus(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("DRILL-1").
+		WithReference("DRILL-1").
+		WithGroupKey("PowerTools").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		WithStatusChangedHistoryRecord(admin.ID, model.ItemStatusInRepair, "motor burnt out").
+		Build()
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("SAW-1").
+		WithReference("SAW-1").
+		WithGroupKey("PowerTools").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, "GET", "/api/v1/item?status=in-repair", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var page pagination.Page[dto.ItemWithCurrentLocationResponse]
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if assert.Len(t, page.Items, 1) {
+		assert.Equal(t, "DRILL-1", page.Items[0].Reference)
+	}
+
+	rr = testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, "GET", "/api/v1/item?status=broken", "")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestTrackItem_BlockedForRetiredItems(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+
+	retired := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("SAW-1").
+		WithReference("SAW-1").
+		WithGroupKey("PowerTools").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		WithStatusChangedHistoryRecord(admin.ID, model.ItemStatusRetired, "blade guard cracked").
+		Build()
+	inRepair := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("DRILL-1").
+		WithReference("DRILL-1").
+		WithGroupKey("PowerTools").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		WithStatusChangedHistoryRecord(admin.ID, model.ItemStatusInRepair, "motor burnt out").
+		Build()
+
+	testCases := []struct {
+		name         string
+		item         *model.ItemModel
+		expectStatus int
+	}{
+		{"retired items cannot be tracked", retired, http.StatusConflict},
+		{"items in repair can still be tracked", inRepair, http.StatusNoContent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/track/%s", tc.item.ID, store.ID), "")
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+}
+
+func TestChangeItemStatus_TakesRetiredItemsOutOfTheirContainer(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+
+	kit := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("KIT-1").
+		WithReference("KIT-1").
+		WithGroupKey("Kits").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		Build()
+	lamp := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("LAMP-1").
+		WithReference("LAMP-1").
+		WithGroupKey("Kits").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		WithContainedHistoryRecord(tracker.ID, kit.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/status", lamp.ID), `{"status": "retired", "reason": "bulb holder broken"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var contained int
+	assert.NoError(t, application.DB.Get(&contained, "select count(*) from item_containment where item_id = $1;", lamp.ID))
+	assert.Equal(t, 0, contained)
+
+	rr = testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/track/%s", kit.ID, store.ID), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, workshop.ID, getCurrentLocationID(t, application.DB, lamp.ID), "the retired lamp does not move with the kit")
+}
+
+func TestCheckInItem_BlockedForRetiredItems(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+
+	grinder := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("GRINDER-1").
+		WithReference("GRINDER-1").
+		WithGroupKey("PowerTools").
+		WithCreatedHistoryRecord(admin.ID, workshop.ID).
+		WithTrackedUserHistoryRecord(tracker.ID, tracker.ID).
+		WithStatusChangedHistoryRecord(admin.ID, model.ItemStatusRetired, "lost on site").
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/checkin", grinder.ID), "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"quantum/internal/dto"
+	"quantum/internal/service"
+	"quantum/internal/types/pagination"
+	"quantum/pkg/label"
+	"quantum/pkg/res"
+)
+
+// maxSheetLabels is the most labels printed on a single label sheet.
+const maxSheetLabels = 500
+
+var (
+	ErrLabelSheetTarget     = errors.New("either group or locationId is required")
+	ErrTooManySheetLabels   = errors.New("too many items for a label sheet, narrow the group or location")
+	ErrInvalidSheetLocation = errors.New("invalid locationId, expected a UUID")
+)
+
+type LabelHandler struct {
+	itemService     *service.ItemService
+	locationService *service.LocationService
+	settingsService *service.SettingsService
+	logger          *slog.Logger
+}
+
+func NewLabelHandler(
+	itemService *service.ItemService,
+	locationService *service.LocationService,
+	settingsService *service.SettingsService,
+	logger *slog.Logger,
+) *LabelHandler {
+	return &LabelHandler{
+		itemService:     itemService,
+		locationService: locationService,
+		settingsService: settingsService,
+		logger:          logger,
+	}
+}
+
+func (h *LabelHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
+	mux.HandleFunc("GET /api/v1/item/{itemId}/label", mf(h.getItemLabel))
+	mux.HandleFunc("GET /api/v1/location/{locationId}/label", mf(h.getLocationLabel))
+	mux.HandleFunc("GET /api/v1/label/sheet", mf(h.getLabelSheet))
+}
+
+// getItemLabel draws the label of an item. The code and format query parameters choose
+// between a QR code or a Code 128 barcode drawn as SVG or PNG.
+func (h *LabelHandler) getItemLabel(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemId"))
+	if err != nil {
+		res.Error(w, "invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	symbology, format, err := getLabelQueryParams(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	item, err := h.itemService.Get(itemID)
+	if err != nil {
+		if errors.Is(err, service.ErrItemNotFound) {
+			res.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		h.logger.Error("error getting item", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	settings, err := h.settingsService.Get()
+	if err != nil {
+		h.logger.Error("error getting settings", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	h.writeLabel(w, dto.NewItemLabel(item.ItemResponse, symbology, settings.Terminology), format)
+}
+
+func (h *LabelHandler) getLocationLabel(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	locationID, err := uuid.Parse(r.PathValue("locationId"))
+	if err != nil {
+		res.Error(w, "invalid location ID", http.StatusBadRequest)
+		return
+	}
+
+	symbology, format, err := getLabelQueryParams(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	location, err := h.locationService.Get(locationID)
+	if err != nil {
+		if errors.Is(err, service.ErrLocationNotFound) {
+			res.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		h.logger.Error("error getting location", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	settings, err := h.settingsService.Get()
+	if err != nil {
+		h.logger.Error("error getting settings", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	h.writeLabel(w, dto.NewLocationLabel(location, symbology, settings.Terminology), format)
+}
+
+// getLabelSheet lays out the labels of the items in a group, or at a location, on A4 PDF pages.
+// Items below the location are included when the recursive query parameter is true.
+func (h *LabelHandler) getLabelSheet(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	symbology, err := label.ParseSymbology(r.URL.Query().Get("code"))
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	locationID, err := getUUIDQueryParam(r, "locationId")
+	if err != nil {
+		res.Error(w, ErrInvalidSheetLocation.Error(), http.StatusBadRequest)
+		return
+	}
+	group := r.URL.Query().Get("group")
+	if locationID == nil && group == "" {
+		res.Error(w, ErrLabelSheetTarget.Error(), http.StatusBadRequest)
+		return
+	}
+	recursive, _ := strconv.ParseBool(r.URL.Query().Get("recursive"))
+
+	settings, err := h.settingsService.Get()
+	if err != nil {
+		h.logger.Error("error getting settings", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	var labels []label.Label
+	page := pagination.Params{Limit: pagination.MaxLimit}
+	for {
+		var items pagination.Page[dto.ItemWithCurrentLocationResponse]
+		if locationID != nil {
+			items, err = h.itemService.ListByLocationID(*locationID, recursive, nil, page)
+		} else {
+			items, err = h.itemService.List(&group, nil, nil, page)
+		}
+		if err != nil {
+			h.logger.Error("error listing items for label sheet", "error", err)
+			res.InternalServerError(w)
+			return
+		}
+
+		for _, item := range items.Items {
+			labels = append(labels, dto.NewItemLabel(item.ItemResponse, symbology, settings.Terminology))
+		}
+		if len(labels) > maxSheetLabels {
+			res.Error(w, ErrTooManySheetLabels.Error(), http.StatusBadRequest)
+			return
+		}
+		if items.NextCursor == nil {
+			break
+		}
+
+		cursor, err := pagination.DecodeCursor(*items.NextCursor)
+		if err != nil {
+			h.logger.Error("error decoding item cursor", "error", err)
+			res.InternalServerError(w)
+			return
+		}
+		page.Cursor = &cursor
+	}
+
+	if len(labels) == 0 {
+		res.Error(w, "no items to print labels for", http.StatusNotFound)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := label.WriteSheetPDF(&buf, labels); err != nil {
+		if label.IsEncodingError(err) {
+			res.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		h.logger.Error("error writing label sheet", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="labels.pdf"`)
+	w.Write(buf.Bytes())
+}
+
+func (h *LabelHandler) writeLabel(w http.ResponseWriter, l label.Label, format label.Format) {
+	write := label.WriteSVG
+	if format == label.PNG {
+		write = label.WritePNG
+	}
+
+	// The label is drawn before anything is sent so that an error can still be reported.
+	var buf bytes.Buffer
+	if err := write(&buf, l); err != nil {
+		if label.IsEncodingError(err) {
+			res.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		h.logger.Error("error writing label", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Write(buf.Bytes())
+}
+
+// getLabelQueryParams reads the code and format query parameters of a single label.
+func getLabelQueryParams(r *http.Request) (label.Symbology, label.Format, error) {
+	symbology, err := label.ParseSymbology(r.URL.Query().Get("code"))
+	if err != nil {
+		return "", "", err
+	}
+	format, err := label.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		return "", "", err
+	}
+	return symbology, format, nil
+}
@@ -0,0 +1,102 @@
+package handler_test
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"quantum/internal/handler"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpLabelHandler(db *sqlx.DB, logger *slog.Logger) *handler.LabelHandler {
+	itemRepo := repository.NewItemRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	settingsRepo := repository.NewPostgresSettingsRepository(db)
+
+	itemService := service.NewItemService(itemRepo, locationRepo, userRepo, repository.NewReservationRepository(db))
+	locationService := service.NewLocationService(locationRepo)
+	settingsService := service.NewSettingsService(settingsRepo)
+
+	return handler.NewLabelHandler(itemService, locationService, settingsService, logger)
+}
+
+func TestLabels(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpLabelHandler(application.DB, application.Logger)
+
+	user := testdata.InsertReaderUser(t, application.DB)
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	shelf := testdata.NewLocationBuilder(t, application.DB).WithName("Shelf").WithParent(store.ID).Build()
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("Cordless drill").
+		WithReference("REF-1").
+		WithGroupKey("TOOLS").
+		WithCreatedHistoryRecord(user.ID, shelf.ID).
+		Build()
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		testutils.RequestWithJWT(t, req, user, application.Config.SessionSecret)
+		return testutils.ServeRequest(h, req, application.Config.SessionSecret)
+	}
+
+	t.Run("item label as SVG", func(t *testing.T) {
+		rr := get(fmt.Sprintf("/api/v1/item/%s/label", item.ID))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "image/svg+xml", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), ">Cordless drill</text>")
+		assert.Contains(t, rr.Body.String(), ">Item REF-1</text>")
+	})
+
+	t.Run("location label as a PNG barcode", func(t *testing.T) {
+		rr := get(fmt.Sprintf("/api/v1/location/%s/label?code=code128&format=png", shelf.ID))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(rr.Body.String(), "\x89PNG"))
+	})
+
+	t.Run("location label shows the parent locations", func(t *testing.T) {
+		rr := get(fmt.Sprintf("/api/v1/location/%s/label", shelf.ID))
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), ">Location in Store</text>")
+	})
+
+	t.Run("rejects unknown codes and formats", func(t *testing.T) {
+		rr := get(fmt.Sprintf("/api/v1/item/%s/label?code=datamatrix", item.ID))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = get(fmt.Sprintf("/api/v1/item/%s/label?format=gif", item.ID))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("label sheet for a group", func(t *testing.T) {
+		rr := get("/api/v1/label/sheet?group=TOOLS")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/pdf", rr.Header().Get("Content-Type"))
+		assert.True(t, strings.HasPrefix(rr.Body.String(), "%PDF-"))
+	})
+
+	t.Run("label sheet for a location and the locations below it", func(t *testing.T) {
+		rr := get(fmt.Sprintf("/api/v1/label/sheet?locationId=%s", store.ID))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+
+		rr = get(fmt.Sprintf("/api/v1/label/sheet?locationId=%s&recursive=true", store.ID))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("label sheet requires a group or location", func(t *testing.T) {
+		rr := get("/api/v1/label/sheet")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+	"quantum/internal/service"
+	"quantum/pkg/res"
+)
+
+type LoanHandler struct {
+	itemService *service.ItemService
+	logger      *slog.Logger
+}
+
+func NewLoanHandler(itemService *service.ItemService, logger *slog.Logger) *LoanHandler {
+	return &LoanHandler{
+		itemService: itemService,
+		logger:      logger,
+	}
+}
+
+func (h *LoanHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
+	mux.HandleFunc("GET /api/v1/loans/overdue", mf(h.listOverdueLoans))
+	mux.HandleFunc("GET /api/v1/loans/mine", mf(h.listMyLoans))
+}
+
+func (h *LoanHandler) listOverdueLoans(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	holders, err := h.itemService.ListOverdueLoans()
+	if err != nil {
+		h.logger.Error("error listing overdue loans", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, holders)
+}
+
+// listMyLoans lists everything tracked to the current user.
+func (h *LoanHandler) listMyLoans(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	loans, err := h.itemService.ListUserLoans(userID)
+	if err != nil {
+		h.logger.Error("error listing loans", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, loans)
+}
@@ -0,0 +1,232 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"quantum/internal/dto"
+	"quantum/internal/handler"
+	"quantum/internal/model"
+	"quantum/internal/permissions"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpLoanHandler(db *sqlx.DB, logger *slog.Logger) *handler.LoanHandler {
+	itemRepo := repository.NewItemRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+	userRepo := repository.NewUserRepository(db)
+
+	itemService := service.NewItemService(itemRepo, locationRepo, userRepo, repository.NewReservationRepository(db))
+
+	return handler.NewLoanHandler(itemService, logger)
+}
+
+func insertBorrowerUser(t testing.TB, db *sqlx.DB) *model.User {
+	return testdata.NewUserBuilder(t, db).
+		WithName("Borrower").
+		WithUsername("borrower").
+		WithRole(permissions.TrackerRole).
+		Build()
+}
+
+func TestCheckOutItem(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	borrower := insertBorrowerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("REF-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, store.ID).
+		Build()
+
+	testCases := []struct {
+		name         string
+		dueAt        time.Time
+		expectStatus int
+	}{
+		{"a due date in the past is rejected", time.Now().Add(-24 * time.Hour), http.StatusBadRequest},
+		{"a due date in the future is accepted", time.Now().Add(24 * time.Hour), http.StatusNoContent},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url := fmt.Sprintf("/api/v1/item/%s/track/user/%s", item.ID, borrower.ID)
+			body := fmt.Sprintf(`{"dueAt": "%s"}`, tc.dueAt.Format(time.RFC3339))
+			rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", url, body)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+}
+
+func TestCheckInItem_ReturnsToThePreviousLocation(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	borrower := insertBorrowerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("REF-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, store.ID).
+		WithCheckedOutHistoryRecord(tracker.ID, borrower.ID, time.Now().Add(24*time.Hour)).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/checkin", item.ID), "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	var current model.ItemWithCurrentLocationModel
+	assert.NoError(t, application.DB.Get(&current, "select * from items_with_current_location where id = $1;", item.ID))
+	assert.Equal(t, store.ID, current.LocationID)
+}
+
+func TestCheckInItem_ToAChosenLocation(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	borrower := insertBorrowerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	workshop := testdata.NewLocationBuilder(t, application.DB).WithName("Workshop").Build()
+
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("REF-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, store.ID).
+		WithCheckedOutHistoryRecord(tracker.ID, borrower.ID, time.Now().Add(-24*time.Hour)).
+		Build()
+
+	body := fmt.Sprintf(`{"locationId": "%s"}`, workshop.ID)
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/checkin", item.ID), body)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	var current model.ItemWithCurrentLocationModel
+	assert.NoError(t, application.DB.Get(&current, "select * from items_with_current_location where id = $1;", item.ID))
+	assert.Equal(t, workshop.ID, current.LocationID)
+}
+
+func TestCheckInItem_NotCheckedOutConflicts(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+
+	item := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("REF-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, store.ID).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/checkin", item.ID), "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestListMyLoans(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpLoanHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	borrower := insertBorrowerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+
+	overdue := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("REF-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, store.ID).
+		WithCheckedOutHistoryRecord(tracker.ID, borrower.ID, time.Now().Add(-24*time.Hour)).
+		Build()
+	onTime := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("REF-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, store.ID).
+		WithCheckedOutHistoryRecord(tracker.ID, borrower.ID, time.Now().Add(24*time.Hour)).
+		Build()
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("REF-3").
+		WithReference("REF-3").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, store.ID).
+		WithCheckedOutHistoryRecord(tracker.ID, tracker.ID, time.Now().Add(24*time.Hour)).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, borrower, application.Config.SessionSecret, "GET", "/api/v1/loans/mine", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var loans []dto.LoanResponse
+	if err := json.NewDecoder(rr.Body).Decode(&loans); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if assert.Len(t, loans, 2) {
+		assert.Equal(t, overdue.ID, loans[0].Item.ID)
+		assert.True(t, loans[0].Overdue)
+		assert.Equal(t, onTime.ID, loans[1].Item.ID)
+		assert.False(t, loans[1].Overdue)
+	}
+}
+
+func TestListOverdueLoans(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpLoanHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	borrower := insertBorrowerUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+
+	overdue := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("REF-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, store.ID).
+		WithCheckedOutHistoryRecord(tracker.ID, borrower.ID, time.Now().Add(-24*time.Hour)).
+		Build()
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("REF-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, store.ID).
+		WithCheckedOutHistoryRecord(tracker.ID, borrower.ID, time.Now().Add(24*time.Hour)).
+		Build()
+
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "GET", "/api/v1/loans/overdue", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var holders []dto.LoanHolderResponse
+	if err := json.NewDecoder(rr.Body).Decode(&holders); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if assert.Len(t, holders, 1) {
+		assert.Equal(t, borrower.ID, holders[0].UserID)
+		if assert.Len(t, holders[0].Loans, 1) {
+			assert.Equal(t, overdue.ID, holders[0].Loans[0].Item.ID)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"github.com/google/uuid"
@@ -8,33 +9,45 @@ import (
 	"net/http"
 	"quantum/internal/dto"
 	"quantum/internal/service"
+	"quantum/internal/types/pagination"
 	"quantum/pkg/res"
+	"strconv"
 )
 
 type LocationHandler struct {
 	locationService *service.LocationService
 	itemService     *service.ItemService
+	settingsService *service.SettingsService
 	logger          *slog.Logger
 }
 
 func NewLocationHandler(
 	locationService *service.LocationService,
 	itemService *service.ItemService,
+	settingsService *service.SettingsService,
 	logger *slog.Logger,
 ) *LocationHandler {
 	return &LocationHandler{
 		locationService: locationService,
 		itemService:     itemService,
+		settingsService: settingsService,
 		logger:          logger,
 	}
 }
 
 func (h *LocationHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
 	mux.HandleFunc("GET /api/v1/location", mf(h.listLocations))
+	mux.HandleFunc("GET /api/v1/location/tree", mf(h.getLocationTree))
 	mux.HandleFunc("GET /api/v1/location/{locationId}/items", mf(h.listItemsByLocationID))
+	mux.HandleFunc("GET /api/v1/location/{locationId}/diff", mf(h.getLocationDiff))
+	mux.HandleFunc("GET /api/v1/location/{locationId}/diff/csv", mf(h.downloadLocationDiffCSV))
 	mux.HandleFunc("GET /api/v1/location/{locationId}", mf(h.getLocationByID))
 	mux.HandleFunc("POST /api/v1/location", mf(h.createLocation))
+	mux.HandleFunc("PUT /api/v1/location/{locationId}", mf(h.updateLocation))
+	mux.HandleFunc("PUT /api/v1/location/{locationId}/parent", mf(h.moveLocation))
 	mux.HandleFunc("DELETE /api/v1/location/{locationId}", mf(h.deleteLocation))
+	mux.HandleFunc("POST /api/v1/location/{locationId}/restore", mf(h.restoreLocation))
+	mux.HandleFunc("GET /api/v1/location/{locationId}/history", mf(h.getLocationHistory))
 }
 
 func (h *LocationHandler) listLocations(w http.ResponseWriter, r *http.Request) {
@@ -48,9 +61,23 @@ func (h *LocationHandler) listLocations(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	filters := getFiltersFromRequest(r)
-	locations, err := h.locationService.List(filters.Max, filters.Filter, filters.IncludeDeleted)
+	filters, err := getFiltersFromRequest(r)
 	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// max is the original way of limiting the number of locations and is used when no limit is given.
+	if filters.Page.Limit == 0 && filters.Max != nil {
+		filters.Page.Limit = *filters.Max
+	}
+
+	locations, err := h.locationService.List(filters.Filter, filters.IncludeDeleted, filters.Page)
+	if err != nil {
+		if isPageError(err) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		h.logger.Error("failed to list locations", "error", err)
 		res.InternalServerError(w)
 		return
@@ -59,6 +86,27 @@ func (h *LocationHandler) listLocations(w http.ResponseWriter, r *http.Request)
 	res.JSON(w, locations)
 }
 
+func (h *LocationHandler) getLocationTree(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	tree, err := h.locationService.Tree()
+	if err != nil {
+		h.logger.Error("failed to get location tree", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, tree)
+}
+
 func (h *LocationHandler) listItemsByLocationID(w http.ResponseWriter, r *http.Request) {
 	if !authenticated(r) {
 		res.Error(w, "unauthorized", http.StatusUnauthorized)
@@ -77,8 +125,41 @@ func (h *LocationHandler) listItemsByLocationID(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	items, err := h.itemService.ListByLocationID(locationID)
+	filters, err := getFiltersFromRequest(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	at, err := getTimeQueryParam(r, "at")
+	if err != nil {
+		res.Error(w, "invalid at date, expected RFC3339", http.StatusBadRequest)
+		return
+	}
+
+	recursive, _ := strconv.ParseBool(r.URL.Query().Get("recursive"))
+
+	status, err := getItemStatusQueryParam(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if status != nil && at != nil {
+		res.Error(w, "status can only filter the current contents of a location, not those at a past time", http.StatusBadRequest)
+		return
+	}
+
+	var items pagination.Page[dto.ItemWithCurrentLocationResponse]
+	if at != nil {
+		items, err = h.itemService.ListByLocationIDAt(locationID, recursive, *at, filters.Page)
+	} else {
+		items, err = h.itemService.ListByLocationID(locationID, recursive, status, filters.Page)
+	}
 	if err != nil {
+		if isPageError(err) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		h.logger.Error("error listing items by location id", "error", err)
 		res.InternalServerError(w)
 		return
@@ -87,6 +168,96 @@ func (h *LocationHandler) listItemsByLocationID(w http.ResponseWriter, r *http.R
 	res.JSON(w, items)
 }
 
+func (h *LocationHandler) getLocationDiff(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	diff, ok := h.locationDiff(w, r)
+	if !ok {
+		return
+	}
+
+	res.JSON(w, diff)
+}
+
+func (h *LocationHandler) downloadLocationDiffCSV(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	settings, err := h.settingsService.Get()
+	if err != nil {
+		h.logger.Error("error getting settings", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	diff, ok := h.locationDiff(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	writer := csv.NewWriter(w)
+	if err := diff.CSV(writer, settings.Terminology); err != nil {
+		h.logger.Error("error writing location diff csv", "error", err)
+		return
+	}
+	writer.Flush()
+}
+
+// locationDiff parses the location and date range from the request and returns the diff.
+// An error response has already been written when ok is false.
+func (h *LocationHandler) locationDiff(w http.ResponseWriter, r *http.Request) (diff dto.LocationDiffResponse, ok bool) {
+	locationID, err := uuid.Parse(r.PathValue("locationId"))
+	if err != nil {
+		h.logger.Error("invalid location id", "error", err)
+		res.Error(w, "invalid location id", http.StatusBadRequest)
+		return diff, false
+	}
+
+	from, err := getTimeQueryParam(r, "from")
+	if err != nil || from == nil {
+		res.Error(w, "invalid from date, expected RFC3339", http.StatusBadRequest)
+		return diff, false
+	}
+
+	to, err := getTimeQueryParam(r, "to")
+	if err != nil || to == nil {
+		res.Error(w, "invalid to date, expected RFC3339", http.StatusBadRequest)
+		return diff, false
+	}
+
+	diff, err = h.itemService.LocationDiff(locationID, *from, *to)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidDateRange):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrLocationNotFound):
+			res.Error(w, "location not found", http.StatusNotFound)
+		default:
+			h.logger.Error("error getting location diff", "error", err)
+			res.InternalServerError(w)
+		}
+		return diff, false
+	}
+
+	return diff, true
+}
+
 func (h *LocationHandler) getLocationByID(w http.ResponseWriter, r *http.Request) {
 	if !authenticated(r) {
 		res.Error(w, "unauthorized", http.StatusUnauthorized)
@@ -109,7 +280,9 @@ func (h *LocationHandler) getLocationByID(w http.ResponseWriter, r *http.Request
 	if err != nil {
 		if errors.Is(err, service.ErrLocationNotFound) {
 			res.Error(w, "location not found", http.StatusNotFound)
+			return
 		}
+		h.logger.Error("failed to get location", "error", err)
 		res.InternalServerError(w)
 		return
 	}
@@ -118,7 +291,8 @@ func (h *LocationHandler) getLocationByID(w http.ResponseWriter, r *http.Request
 }
 
 func (h *LocationHandler) createLocation(w http.ResponseWriter, r *http.Request) {
-	if !authenticated(r) {
+	userID, authed := currentUserID(r)
+	if !authed {
 		res.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -135,9 +309,105 @@ func (h *LocationHandler) createLocation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	locationResponse, err := h.locationService.Create(request)
+	locationResponse, err := h.locationService.Create(request, userID)
 	if err != nil {
-		res.InternalServerError(w)
+		switch {
+		case errors.Is(err, dto.ErrInvalidLocationName):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrParentLocationNotFound):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrLocationNameExists):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("failed to create location", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, locationResponse)
+}
+
+func (h *LocationHandler) updateLocation(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasWritePermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	locationID, err := uuid.Parse(r.PathValue("locationId"))
+	if err != nil {
+		res.Error(w, "invalid location id", http.StatusBadRequest)
+		return
+	}
+
+	var request dto.UpdateLocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid location request", http.StatusBadRequest)
+		return
+	}
+
+	locationResponse, err := h.locationService.Update(locationID, request, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, dto.ErrInvalidLocationName):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrLocationNotFound):
+			res.Error(w, "location not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrLocationNameExists):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("failed to update location", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, locationResponse)
+}
+
+func (h *LocationHandler) moveLocation(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasWritePermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	locationID, err := uuid.Parse(r.PathValue("locationId"))
+	if err != nil {
+		res.Error(w, "invalid location id", http.StatusBadRequest)
+		return
+	}
+
+	var request dto.MoveLocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid move location request", http.StatusBadRequest)
+		return
+	}
+
+	locationResponse, err := h.locationService.Move(locationID, request, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLocationNotFound):
+			res.Error(w, "location not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrParentLocationNotFound):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrLocationCycle):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("failed to move location", "error", err)
+			res.InternalServerError(w)
+		}
 		return
 	}
 
@@ -145,7 +415,8 @@ func (h *LocationHandler) createLocation(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *LocationHandler) deleteLocation(w http.ResponseWriter, r *http.Request) {
-	if !authenticated(r) {
+	userID, authed := currentUserID(r)
+	if !authed {
 		res.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -162,10 +433,99 @@ func (h *LocationHandler) deleteLocation(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.locationService.Delete(locationID); err != nil {
-		res.InternalServerError(w)
+	// destinationId names the location that any items still at the deleted location are moved to.
+	destinationID, err := getUUIDQueryParam(r, "destinationId")
+	if err != nil {
+		res.Error(w, "invalid destination id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.locationService.Delete(locationID, destinationID, userID); err != nil {
+		var hasItemsErr *service.LocationHasItemsError
+		switch {
+		case errors.As(err, &hasItemsErr):
+			res.WithStatus(w, http.StatusConflict).SendJSON(dto.LocationHasItemsResponse{
+				Error: hasItemsErr.Error(),
+				Items: hasItemsErr.Items,
+			})
+		case errors.Is(err, service.ErrLocationNotFound):
+			res.Error(w, "location not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrInvalidDestination):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrLocationHasChildren):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("failed to delete location", "error", err)
+			res.InternalServerError(w)
+		}
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *LocationHandler) restoreLocation(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	locationID, err := uuid.Parse(r.PathValue("locationId"))
+	if err != nil {
+		res.Error(w, "invalid location id", http.StatusBadRequest)
+		return
+	}
+
+	locationResponse, err := h.locationService.Restore(locationID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLocationNotFound):
+			res.Error(w, "location not found", http.StatusNotFound)
+		case errors.Is(err, service.ErrLocationNotDeleted), errors.Is(err, service.ErrLocationParentDeleted):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("failed to restore location", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, locationResponse)
+}
+
+func (h *LocationHandler) getLocationHistory(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	locationID, err := uuid.Parse(r.PathValue("locationId"))
+	if err != nil {
+		res.Error(w, "invalid location id", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.locationService.History(locationID)
+	if err != nil {
+		if errors.Is(err, service.ErrLocationNotFound) {
+			res.Error(w, "location not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("failed to get location history", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, history)
+}
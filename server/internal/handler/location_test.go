@@ -0,0 +1,409 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"quantum/internal/dto"
+	"quantum/internal/handler"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/internal/types/pagination"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpLocationHandler(db *sqlx.DB, logger *slog.Logger) *handler.LocationHandler {
+	itemRepo := repository.NewItemRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	settingsRepo := repository.NewPostgresSettingsRepository(db)
+
+	locationService := service.NewLocationService(locationRepo)
+	itemService := service.NewItemService(itemRepo, locationRepo, userRepo, repository.NewReservationRepository(db))
+	settingsService := service.NewSettingsService(settingsRepo)
+
+	return handler.NewLocationHandler(locationService, itemService, settingsService, logger)
+}
+
+func TestGetLocationDiff(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpLocationHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+
+	warehouse := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Warehouse").
+		Build()
+
+	shop := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Shop").
+		Build()
+
+	stayed := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, warehouse.ID).
+		Build()
+
+	left := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, warehouse.ID).
+		WithTrackedHistoryRecord(tracker.ID, shop.ID).
+		Build()
+
+	arrived := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-3").
+		WithReference("REF-3").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(tracker.ID, shop.ID).
+		WithTrackedHistoryRecord(tracker.ID, warehouse.ID).
+		Build()
+
+	// Creation happens three days ago and tracking one day ago, so the diff window covers the tracking only.
+	_, err := application.DB.Exec(`
+		update item_history
+		set created_at = case data->>'type'
+			when 'created' then now() - interval '3 days'
+			else now() - interval '1 day'
+		end;`)
+	assert.NoError(t, err)
+
+	from := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	to := time.Now().UTC().Format(time.RFC3339)
+	url := fmt.Sprintf("/api/v1/location/%s/diff?from=%s&to=%s", warehouse.ID, from, to)
+	req := httptest.NewRequest("GET", url, nil)
+	testutils.RequestWithJWT(t, req, tracker, application.Config.SessionSecret)
+	rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response dto.LocationDiffResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	assert.Len(t, response.Arrived, 1)
+	assert.Equal(t, arrived.ID, response.Arrived[0].Item.ID)
+	assert.Equal(t, model.ItemHistoryTypeTracked, response.Arrived[0].MovementType)
+
+	assert.Len(t, response.Left, 1)
+	assert.Equal(t, left.ID, response.Left[0].Item.ID)
+	assert.Equal(t, shop.ID, *response.Left[0].DestinationID)
+
+	assert.Len(t, response.Stayed, 1)
+	assert.Equal(t, stayed.ID, response.Stayed[0].Item.ID)
+	assert.Equal(t, model.ItemHistoryTypeCreated, response.Stayed[0].MovementType)
+}
+
+func TestLocationHierarchy(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpLocationHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+
+	site := testdata.NewLocationBuilder(t, application.DB).WithName("London HQ").Build()
+	room := testdata.NewLocationBuilder(t, application.DB).WithName("Store Room B").WithParent(site.ID).Build()
+	shelf := testdata.NewLocationBuilder(t, application.DB).WithName("Shelf 3").WithParent(room.ID).Build()
+	other := testdata.NewLocationBuilder(t, application.DB).WithName("Paris").Build()
+
+	onShelf := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-1").
+		WithReference("REF-1").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(admin.ID, shelf.ID).
+		Build()
+
+	inRoom := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("ITEM-2").
+		WithReference("REF-2").
+		WithGroupKey("XYZ").
+		WithCreatedHistoryRecord(admin.ID, room.ID).
+		Build()
+
+	serve := func(method, url, body string) *httptest.ResponseRecorder {
+		return testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, method, url, body)
+	}
+
+	listItemIDs := func(url string) []uuid.UUID {
+		rr := serve("GET", url, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var page pagination.Page[dto.ItemWithCurrentLocationResponse]
+		if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		ids := make([]uuid.UUID, 0, len(page.Items))
+		for _, item := range page.Items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	t.Run("breadcrumb path", func(t *testing.T) {
+		rr := serve("GET", fmt.Sprintf("/api/v1/location/%s", shelf.ID), "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response dto.LocationResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, room.ID, *response.ParentID)
+		assert.Equal(t, []dto.LocationPathItem{
+			{ID: site.ID, Name: "London HQ"},
+			{ID: room.ID, Name: "Store Room B"},
+			{ID: shelf.ID, Name: "Shelf 3"},
+		}, response.Path)
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		rr := serve("GET", "/api/v1/location/tree", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var tree []dto.LocationTreeNode
+		if err := json.NewDecoder(rr.Body).Decode(&tree); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Len(t, tree, 2)
+		assert.Equal(t, site.ID, tree[0].ID)
+		assert.Equal(t, room.ID, tree[0].Children[0].ID)
+		assert.Equal(t, shelf.ID, tree[0].Children[0].Children[0].ID)
+		assert.Equal(t, other.ID, tree[1].ID)
+	})
+
+	t.Run("recursive items", func(t *testing.T) {
+		assert.Empty(t, listItemIDs(fmt.Sprintf("/api/v1/location/%s/items", site.ID)))
+		assert.ElementsMatch(t, []uuid.UUID{onShelf.ID, inRoom.ID}, listItemIDs(fmt.Sprintf("/api/v1/location/%s/items?recursive=true", site.ID)))
+		assert.ElementsMatch(t, []uuid.UUID{onShelf.ID}, listItemIDs(fmt.Sprintf("/api/v1/location/%s/items?recursive=true", shelf.ID)))
+	})
+
+	t.Run("cycle is rejected", func(t *testing.T) {
+		rr := serve("PUT", fmt.Sprintf("/api/v1/location/%s/parent", site.ID), fmt.Sprintf(`{"parentId": "%s"}`, shelf.ID))
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("deleting a parent is rejected", func(t *testing.T) {
+		rr := serve("DELETE", fmt.Sprintf("/api/v1/location/%s", room.ID), "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("subtree move keeps history", func(t *testing.T) {
+		var historyBefore int
+		assert.NoError(t, application.DB.Get(&historyBefore, "select count(*) from item_history;"))
+
+		rr := serve("PUT", fmt.Sprintf("/api/v1/location/%s/parent", room.ID), fmt.Sprintf(`{"parentId": "%s"}`, other.ID))
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var historyAfter int
+		assert.NoError(t, application.DB.Get(&historyAfter, "select count(*) from item_history;"))
+		assert.Equal(t, historyBefore, historyAfter)
+
+		assert.Empty(t, listItemIDs(fmt.Sprintf("/api/v1/location/%s/items?recursive=true", site.ID)))
+		assert.ElementsMatch(t, []uuid.UUID{onShelf.ID, inRoom.ID}, listItemIDs(fmt.Sprintf("/api/v1/location/%s/items?recursive=true", other.ID)))
+	})
+}
+
+func TestUpdateAndRestoreLocation(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpLocationHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+
+	location := testdata.NewLocationBuilder(t, application.DB).WithName("Warehuose").Build()
+	testdata.NewLocationBuilder(t, application.DB).WithName("Shop").Build()
+
+	serve := func(method, url, body string) *httptest.ResponseRecorder {
+		return testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, method, url, body)
+	}
+
+	locationURL := fmt.Sprintf("/api/v1/location/%s", location.ID)
+
+	t.Run("rename", func(t *testing.T) {
+		rr := serve("PUT", locationURL, `{"name": "Warehouse", "description": "Main warehouse"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response dto.LocationResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Equal(t, "Warehouse", response.Name)
+		assert.Equal(t, "Main warehouse", *response.Description)
+	})
+
+	t.Run("name already in use", func(t *testing.T) {
+		rr := serve("PUT", locationURL, `{"name": "Shop"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("restore a location that is not deleted", func(t *testing.T) {
+		rr := serve("POST", locationURL+"/restore", "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("delete and restore", func(t *testing.T) {
+		rr := serve("DELETE", locationURL, "")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		rr = serve("POST", locationURL+"/restore", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response dto.LocationResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.False(t, response.IsDeleted)
+	})
+
+	t.Run("history", func(t *testing.T) {
+		rr := serve("GET", locationURL+"/history", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var history []dto.LocationHistoryResponse
+		if err := json.NewDecoder(rr.Body).Decode(&history); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		types := make([]model.LocationHistoryType, 0, len(history))
+		for _, h := range history {
+			types = append(types, h.Type)
+		}
+		assert.Equal(t, []model.LocationHistoryType{
+			model.LocationHistoryTypeRestored,
+			model.LocationHistoryTypeDeleted,
+			model.LocationHistoryTypeUpdated,
+		}, types)
+		assert.JSONEq(t, `{"updatedFields": {
+			"name": {"old": "Warehuose", "new": "Warehouse"},
+			"description": {"old": null, "new": "Main warehouse"}
+		}}`, string(history[2].Data))
+	})
+}
+
+func TestDeleteLocationWithItems(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpLocationHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+
+	closing := testdata.NewLocationBuilder(t, application.DB).WithName("Closing Store").Build()
+	destination := testdata.NewLocationBuilder(t, application.DB).WithName("Warehouse").Build()
+
+	items := make([]*model.ItemModel, 0, 2)
+	for i := 1; i <= 2; i++ {
+		items = append(items, testdata.NewItemBuilder(t, application.DB).
+			WithIdentifier(fmt.Sprintf("ITEM-%d", i)).
+			WithReference(fmt.Sprintf("REF-%d", i)).
+			WithGroupKey("XYZ").
+			WithCreatedHistoryRecord(admin.ID, closing.ID).
+			Build())
+	}
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		return testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, "DELETE", url, "")
+	}
+
+	t.Run("without a destination lists the items", func(t *testing.T) {
+		rr := serve(fmt.Sprintf("/api/v1/location/%s", closing.ID))
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		var response dto.LocationHasItemsResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		assert.Len(t, response.Items, 2)
+		assert.Equal(t, items[0].ID, response.Items[0].ID)
+		assert.Equal(t, items[1].ID, response.Items[1].ID)
+	})
+
+	t.Run("destination cannot be the location itself", func(t *testing.T) {
+		rr := serve(fmt.Sprintf("/api/v1/location/%s?destinationId=%s", closing.ID, closing.ID))
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("with a destination moves the items", func(t *testing.T) {
+		rr := serve(fmt.Sprintf("/api/v1/location/%s?destinationId=%s", closing.ID, destination.ID))
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		var deleted bool
+		assert.NoError(t, application.DB.Get(&deleted, "select is_deleted from locations where id = $1;", closing.ID))
+		assert.True(t, deleted)
+
+		for _, item := range items {
+			var current model.ItemWithCurrentLocationModel
+			assert.NoError(t, application.DB.Get(&current, "select * from items_with_current_location where id = $1;", item.ID))
+			assert.Equal(t, destination.ID, current.LocationID)
+
+			var tracked int
+			assert.NoError(t, application.DB.Get(&tracked, `
+				select count(*) from item_history
+				where item_id = $1 and data->>'type' = 'tracked' and data->'data'->>'locationId' = $2;`,
+				item.ID, destination.ID.String()))
+			assert.Equal(t, 1, tracked)
+		}
+	})
+}
+
+func TestDeleteLocationWithStock(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpLocationHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+
+	closing := testdata.NewLocationBuilder(t, application.DB).WithName("Closing Store").Build()
+	destination := testdata.NewLocationBuilder(t, application.DB).WithName("Warehouse").Build()
+
+	gloves := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("GLOVES-M").
+		WithReference("GLOVES-M").
+		WithGroupKey("GLOVES").
+		WithKind(model.ItemKindStock).
+		WithCreatedHistoryRecord(admin.ID, destination.ID).
+		Build()
+
+	stockRepo := repository.NewStockRepository(application.DB)
+	assert.NoError(t, stockRepo.Apply(gloves.ID, admin.ID, []repository.StockChange{
+		{Type: model.ItemHistoryTypeStockReceived, LocationID: closing.ID, Delta: 40},
+	}))
+
+	serve := func(url string) *httptest.ResponseRecorder {
+		return testutils.ServeRequestAs(t, h, admin, application.Config.SessionSecret, "DELETE", url, "")
+	}
+
+	t.Run("without a destination lists the stock items", func(t *testing.T) {
+		rr := serve(fmt.Sprintf("/api/v1/location/%s", closing.ID))
+		assert.Equal(t, http.StatusConflict, rr.Code)
+
+		var response dto.LocationHasItemsResponse
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		if assert.Len(t, response.Items, 1) {
+			assert.Equal(t, gloves.ID, response.Items[0].ID)
+		}
+	})
+
+	t.Run("with a destination transfers the stock", func(t *testing.T) {
+		rr := serve(fmt.Sprintf("/api/v1/location/%s?destinationId=%s", closing.ID, destination.ID))
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+
+		levels, err := stockRepo.ListLevels(gloves.ID)
+		assert.NoError(t, err)
+		quantities := make(map[uuid.UUID]int, len(levels))
+		for _, level := range levels {
+			quantities[level.LocationID] = level.Quantity
+		}
+		assert.Equal(t, 0, quantities[closing.ID])
+		assert.Equal(t, 40, quantities[destination.ID])
+	})
+}
@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"quantum/internal/dto"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/pkg/res"
+)
+
+var (
+	ErrInvalidReservationItemID = errors.New("invalid itemId, expected a UUID")
+	ErrInvalidReservationUserID = errors.New("invalid userId, expected a UUID")
+	ErrInvalidReservationFrom   = errors.New("invalid from date, expected RFC3339")
+	ErrInvalidReservationTo     = errors.New("invalid to date, expected RFC3339")
+)
+
+// reservationFeedHistory is how far back the calendar feed includes reservations that have already ended.
+const reservationFeedHistory = 30 * 24 * time.Hour
+
+type ReservationHandler struct {
+	reservationService *service.ReservationService
+	settingsService    *service.SettingsService
+	apiBaseURL         string
+	logger             *slog.Logger
+}
+
+func NewReservationHandler(
+	reservationService *service.ReservationService,
+	settingsService *service.SettingsService,
+	apiBaseURL string,
+	logger *slog.Logger,
+) *ReservationHandler {
+	return &ReservationHandler{
+		reservationService: reservationService,
+		settingsService:    settingsService,
+		apiBaseURL:         apiBaseURL,
+		logger:             logger,
+	}
+}
+
+func (h *ReservationHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
+	mux.HandleFunc("GET /api/v1/reservation", mf(h.listReservations))
+	mux.HandleFunc("POST /api/v1/reservation", mf(h.createReservation))
+	mux.HandleFunc("DELETE /api/v1/reservation/{reservationId}", mf(h.cancelReservation))
+	mux.HandleFunc("GET /api/v1/reservation/feed", mf(h.getReservationFeed))
+	mux.HandleFunc("POST /api/v1/reservation/feed/rotate", mf(h.rotateReservationFeed))
+	mux.HandleFunc("GET /api/v1/reservation/feed/{userId}/calendar.ics", mf(h.downloadReservationFeed))
+}
+
+// listReservations lists reservations filtered by the itemId, userId, group, from and to query parameters.
+func (h *ReservationHandler) listReservations(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	filter, err := getReservationFilterFromRequest(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reservations, err := h.reservationService.List(filter)
+	if err != nil {
+		h.logger.Error("error listing reservations", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, reservations)
+}
+
+// createReservation reserves an item. Reserving an item for another user requires write permissions.
+func (h *ReservationHandler) createReservation(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	roles := currentUserRoles(r)
+	if !roles.HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	var request dto.CreateReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.UserID != nil && *request.UserID != userID && !roles.HasWritePermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	reservation, err := h.reservationService.Create(request, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, dto.ErrInvalidReservationItem), errors.Is(err, dto.ErrInvalidReservationWindow):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrItemNotFound), errors.Is(err, service.ErrUserNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrItemDeleted), errors.Is(err, service.ErrUserDeleted),
+			errors.Is(err, service.ErrReservationOverlaps):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error creating reservation", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.WithStatus(w, http.StatusCreated).SendJSON(reservation)
+}
+
+func (h *ReservationHandler) cancelReservation(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	reservationID, err := uuid.Parse(r.PathValue("reservationId"))
+	if err != nil {
+		res.Error(w, "invalid reservation ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.reservationService.Cancel(reservationID, userID, currentUserRoles(r).IsAdmin()); err != nil {
+		switch {
+		case errors.Is(err, service.ErrReservationNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrReservationForbidden):
+			res.Error(w, err.Error(), http.StatusForbidden)
+		default:
+			h.logger.Error("error cancelling reservation", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getReservationFeed returns the calendar feed address of the current user.
+// The address carries a token so calendar apps can fetch it without signing in.
+func (h *ReservationHandler) getReservationFeed(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	token, err := h.reservationService.GetFeedToken(userID)
+	if err != nil {
+		h.logger.Error("error getting reservation feed token", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, dto.ReservationFeedResponse{URL: h.reservationFeedURL(r, userID, token)})
+}
+
+// rotateReservationFeed replaces the feed token of the current user, so calendar apps subscribed to the old
+// address lose access, and returns the new address.
+func (h *ReservationHandler) rotateReservationFeed(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	token, err := h.reservationService.RotateFeedToken(userID)
+	if err != nil {
+		h.logger.Error("error rotating reservation feed token", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, dto.ReservationFeedResponse{URL: h.reservationFeedURL(r, userID, token)})
+}
+
+// downloadReservationFeed serves the reservations of a user as an iCalendar feed.
+// It is authorized by the feed token rather than the session so that calendar apps can subscribe to it.
+func (h *ReservationHandler) downloadReservationFeed(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		res.Error(w, "invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := h.settingsService.Get()
+	if err != nil {
+		h.logger.Error("error getting settings", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	from := time.Now().Add(-reservationFeedHistory)
+	reservations, err := h.reservationService.ListFeed(userID, r.URL.Query().Get("token"), from)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFeedToken) {
+			res.Unauthorized(w)
+			return
+		}
+		h.logger.Error("error listing reservations", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="calendar.ics"`)
+	name := fmt.Sprintf("%s reservations", settings.Terminology.Item)
+	if err := dto.WriteReservationsICS(w, name, reservations, settings.Terminology); err != nil {
+		// The response has already started, so the error can only be logged.
+		h.logger.Error("error writing reservation feed", "error", err)
+	}
+}
+
+// reservationFeedURL returns the calendar feed address of the user with the token.
+// The address is based on the configured API base URL, or on the address the request was made to if there is none.
+func (h *ReservationHandler) reservationFeedURL(r *http.Request, userID uuid.UUID, token string) string {
+	base := strings.TrimSuffix(h.apiBaseURL, "/")
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + r.Host
+	}
+
+	query := url.Values{"token": {token}}.Encode()
+	return fmt.Sprintf("%s/api/v1/reservation/feed/%s/calendar.ics?%s", base, userID, query)
+}
+
+// getReservationFilterFromRequest reads the reservation filters from the query parameters:
+// itemId, userId, group, from and to.
+func getReservationFilterFromRequest(r *http.Request) (repository.ReservationFilter, error) {
+	var filter repository.ReservationFilter
+
+	var err error
+	if filter.ItemID, err = getUUIDQueryParam(r, "itemId"); err != nil {
+		return filter, ErrInvalidReservationItemID
+	}
+	if filter.UserID, err = getUUIDQueryParam(r, "userId"); err != nil {
+		return filter, ErrInvalidReservationUserID
+	}
+	if group := r.URL.Query().Get("group"); group != "" {
+		filter.GroupKey = &group
+	}
+	if filter.From, err = getTimeQueryParam(r, "from"); err != nil {
+		return filter, ErrInvalidReservationFrom
+	}
+	if filter.To, err = getTimeQueryParam(r, "to"); err != nil {
+		return filter, ErrInvalidReservationTo
+	}
+
+	return filter, nil
+}
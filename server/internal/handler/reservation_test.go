@@ -0,0 +1,337 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"quantum/internal/dto"
+	"quantum/internal/handler"
+	"quantum/internal/model"
+	"quantum/internal/permissions"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpReservationHandler(db *sqlx.DB, logger *slog.Logger) *handler.ReservationHandler {
+	reservationRepo := repository.NewReservationRepository(db)
+	itemRepo := repository.NewItemRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	settingsRepo := repository.NewPostgresSettingsRepository(db)
+
+	reservationService := service.NewReservationService(reservationRepo, itemRepo, userRepo)
+	settingsService := service.NewSettingsService(settingsRepo)
+
+	return handler.NewReservationHandler(reservationService, settingsService, application.Config.APIBaseURL, logger)
+}
+
+func insertHolderUser(t testing.TB, db *sqlx.DB) *model.User {
+	return testdata.NewUserBuilder(t, db).
+		WithName("Holder").
+		WithUsername("holder").
+		WithRole(permissions.TrackerRole).
+		Build()
+}
+
+func insertCamera(t testing.TB, db *sqlx.DB, userID uuid.UUID) *model.ItemModel {
+	store := testdata.NewLocationBuilder(t, db).WithName("Store").Build()
+	return testdata.NewItemBuilder(t, db).
+		WithIdentifier("Camera").
+		WithReference("CAM-1").
+		WithGroupKey("AV").
+		WithCreatedHistoryRecord(userID, store.ID).
+		Build()
+}
+
+func reservationBody(itemID uuid.UUID, startsAt, endsAt time.Time) string {
+	return fmt.Sprintf(`{"itemId": "%s", "purpose": "Shoot", "startsAt": "%s", "endsAt": "%s"}`,
+		itemID, startsAt.Format(time.RFC3339), endsAt.Format(time.RFC3339))
+}
+
+// getReservationFeedURL returns the calendar feed address of the user, relative to the API.
+func getReservationFeedURL(t *testing.T, h handler.HandlerBuilder, user *model.User, method, path string) string {
+	rr := testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, method, path, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var feed dto.ReservationFeedResponse
+	if err := json.NewDecoder(rr.Body).Decode(&feed); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	feedURL, err := url.Parse(feed.URL)
+	if err != nil {
+		t.Fatalf("failed to parse the feed url: %v", err)
+	}
+	return feedURL.RequestURI()
+}
+
+func TestCreateReservation(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpReservationHandler(application.DB, application.Logger)
+
+	holder := insertHolderUser(t, application.DB)
+	camera := insertCamera(t, application.DB, holder.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	body := reservationBody(camera.ID, now, now.Add(time.Hour))
+	rr := testutils.ServeRequestAs(t, h, holder, application.Config.SessionSecret, "POST", "/api/v1/reservation", body)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var reservation dto.ReservationResponse
+	if err := json.NewDecoder(rr.Body).Decode(&reservation); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	assert.Equal(t, holder.ID, reservation.UserID)
+	assert.Equal(t, "CAM-1", reservation.ItemReference)
+}
+
+func TestCreateReservation_ValidatesTheWindow(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpReservationHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	holder := insertHolderUser(t, application.DB)
+	camera := insertCamera(t, application.DB, tracker.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	testdata.NewReservationBuilder(t, application.DB, camera.ID, holder.ID).
+		Between(now.Add(-time.Hour), now.Add(2*time.Hour)).
+		Build()
+
+	testCases := []struct {
+		name         string
+		startsAt     time.Time
+		endsAt       time.Time
+		expectStatus int
+	}{
+		{"an overlapping window conflicts", now.Add(time.Hour), now.Add(3 * time.Hour), http.StatusConflict},
+		{"an empty window is rejected", now.Add(5 * time.Hour), now.Add(5 * time.Hour), http.StatusBadRequest},
+		{"a window ending before it starts is rejected", now.Add(6 * time.Hour), now.Add(5 * time.Hour), http.StatusBadRequest},
+		{"a window starting when the other ends is accepted", now.Add(2 * time.Hour), now.Add(3 * time.Hour), http.StatusCreated},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := reservationBody(camera.ID, tc.startsAt, tc.endsAt)
+			rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", "/api/v1/reservation", body)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+}
+
+func TestListReservations(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpReservationHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	holder := insertHolderUser(t, application.DB)
+	camera := insertCamera(t, application.DB, tracker.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	testdata.NewReservationBuilder(t, application.DB, camera.ID, holder.ID).
+		Between(now, now.Add(time.Hour)).
+		Build()
+	testdata.NewReservationBuilder(t, application.DB, camera.ID, tracker.ID).
+		Between(now.Add(time.Hour), now.Add(2*time.Hour)).
+		Build()
+
+	testCases := []struct {
+		name        string
+		query       string
+		expectCount int
+	}{
+		{"by item", fmt.Sprintf("itemId=%s", camera.ID), 2},
+		{"by user", fmt.Sprintf("userId=%s", holder.ID), 1},
+		{"by group", "group=AV", 2},
+		{"by a group without reservations", "group=OTHER", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "GET", "/api/v1/reservation?"+tc.query, "")
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var reservations []dto.ReservationResponse
+			if err := json.NewDecoder(rr.Body).Decode(&reservations); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			assert.Len(t, reservations, tc.expectCount)
+		})
+	}
+}
+
+func TestTrackItem_BlockedByAReservationWhenConfigured(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+	settingsService := service.NewSettingsService(repository.NewPostgresSettingsRepository(application.DB))
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	holder := insertHolderUser(t, application.DB)
+	camera := insertCamera(t, application.DB, tracker.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	testdata.NewReservationBuilder(t, application.DB, camera.ID, holder.ID).
+		Between(now.Add(-time.Hour), now.Add(time.Hour)).
+		Build()
+
+	settings, err := settingsService.Get()
+	assert.NoError(t, err)
+	settings.Reservations.BlockTracking = true
+	assert.NoError(t, settingsService.Update(settings))
+
+	trackURL := fmt.Sprintf("/api/v1/item/%s/track/user/%s", camera.ID, tracker.ID)
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", trackURL, "{}")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	var response dto.ItemReservedResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if assert.Len(t, response.Reservations, 1) {
+		assert.Equal(t, holder.ID, response.Reservations[0].UserID)
+	}
+}
+
+func TestTrackItem_WarnsOfAReservationByAnotherUser(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	holder := insertHolderUser(t, application.DB)
+	camera := insertCamera(t, application.DB, tracker.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	testdata.NewReservationBuilder(t, application.DB, camera.ID, holder.ID).
+		Between(now.Add(-time.Hour), now.Add(time.Hour)).
+		Build()
+
+	trackURL := fmt.Sprintf("/api/v1/item/%s/track/user/%s", camera.ID, tracker.ID)
+	rr := testutils.ServeRequestAs(t, h, tracker, application.Config.SessionSecret, "POST", trackURL, "{}")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Contains(t, rr.Header().Get("Warning"), "holder")
+}
+
+func TestCancelReservation(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpReservationHandler(application.DB, application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	holder := insertHolderUser(t, application.DB)
+	camera := insertCamera(t, application.DB, tracker.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	heldByHolder := testdata.NewReservationBuilder(t, application.DB, camera.ID, holder.ID).
+		Between(now, now.Add(time.Hour)).
+		Build()
+	madeByTracker := testdata.NewReservationBuilder(t, application.DB, camera.ID, holder.ID).
+		WithCreatedBy(tracker.ID).
+		Between(now.Add(time.Hour), now.Add(2*time.Hour)).
+		Build()
+	cancelledByAdmin := testdata.NewReservationBuilder(t, application.DB, camera.ID, holder.ID).
+		Between(now.Add(2*time.Hour), now.Add(3*time.Hour)).
+		Build()
+
+	testCases := []struct {
+		name          string
+		user          *model.User
+		reservationID uuid.UUID
+		expectStatus  int
+	}{
+		{"another user cannot cancel", tracker, heldByHolder.ID, http.StatusForbidden},
+		{"the holder can cancel", holder, heldByHolder.ID, http.StatusNoContent},
+		{"the creator can cancel", tracker, madeByTracker.ID, http.StatusNoContent},
+		{"an admin can cancel", admin, cancelledByAdmin.ID, http.StatusNoContent},
+		{"an unknown reservation is not found", holder, uuid.New(), http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			url := "/api/v1/reservation/" + tc.reservationID.String()
+			rr := testutils.ServeRequestAs(t, h, tc.user, application.Config.SessionSecret, "DELETE", url, "")
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+}
+
+func TestReservationFeed(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpReservationHandler(application.DB, application.Logger)
+
+	tracker := testdata.InsertTrackerUser(t, application.DB)
+	holder := insertHolderUser(t, application.DB)
+	camera := insertCamera(t, application.DB, tracker.ID)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	testdata.NewReservationBuilder(t, application.DB, camera.ID, holder.ID).
+		Between(now, now.Add(time.Hour)).
+		Build()
+
+	feedURL := getReservationFeedURL(t, h, holder, "GET", "/api/v1/reservation/feed")
+
+	t.Run("serves the reservations of the user", func(t *testing.T) {
+		rr := testutils.ServeRequestAs(t, h, nil, application.Config.SessionSecret, "GET", feedURL, "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Equal(t, 1, strings.Count(rr.Body.String(), "BEGIN:VEVENT"))
+		assert.Contains(t, rr.Body.String(), "SUMMARY:Item CAM-1 reserved\r\n")
+	})
+
+	t.Run("keeps the address until it is rotated", func(t *testing.T) {
+		assert.Equal(t, feedURL, getReservationFeedURL(t, h, holder, "GET", "/api/v1/reservation/feed"))
+	})
+
+	t.Run("rejects an invalid token", func(t *testing.T) {
+		path, _, _ := strings.Cut(feedURL, "?")
+		rr := testutils.ServeRequestAs(t, h, nil, application.Config.SessionSecret, "GET", path+"?token=invalid", "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+
+	t.Run("rejects the token for the feed of another user", func(t *testing.T) {
+		otherURL := strings.Replace(feedURL, holder.ID.String(), tracker.ID.String(), 1)
+		rr := testutils.ServeRequestAs(t, h, nil, application.Config.SessionSecret, "GET", otherURL, "")
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	})
+}
+
+func TestRotateReservationFeedToken(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpReservationHandler(application.DB, application.Logger)
+
+	holder := insertHolderUser(t, application.DB)
+
+	oldURL := getReservationFeedURL(t, h, holder, "GET", "/api/v1/reservation/feed")
+	rotatedURL := getReservationFeedURL(t, h, holder, "POST", "/api/v1/reservation/feed/rotate")
+
+	rr := testutils.ServeRequestAs(t, h, nil, application.Config.SessionSecret, "GET", oldURL, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = testutils.ServeRequestAs(t, h, nil, application.Config.SessionSecret, "GET", rotatedURL, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestReservationFeed_ClosedForDeletedUsers(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpReservationHandler(application.DB, application.Logger)
+
+	holder := insertHolderUser(t, application.DB)
+
+	feedURL := getReservationFeedURL(t, h, holder, "GET", "/api/v1/reservation/feed")
+	assert.NoError(t, repository.NewUserRepository(application.DB).Delete(holder.ID))
+
+	rr := testutils.ServeRequestAs(t, h, nil, application.Config.SessionSecret, "GET", feedURL, "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
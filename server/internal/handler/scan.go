@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"quantum/internal/dto"
+	"quantum/internal/service"
+	"quantum/pkg/res"
+)
+
+type ScanHandler struct {
+	scanService *service.ScanService
+	logger      *slog.Logger
+}
+
+func NewScanHandler(scanService *service.ScanService, logger *slog.Logger) *ScanHandler {
+	return &ScanHandler{
+		scanService: scanService,
+		logger:      logger,
+	}
+}
+
+func (h *ScanHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
+	mux.HandleFunc("GET /api/v1/scan", mf(h.resolveScan))
+}
+
+// resolveScan resolves the scanned code in the code query parameter to an item or a location.
+// Unknown codes are reported with a 404 along with a suggested reference for creating an item.
+func (h *ScanHandler) resolveScan(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	result, err := h.scanService.Resolve(r.URL.Query().Get("code"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmptyScanCode):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrAmbiguousScanCode):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error resolving scanned code", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	if result.Type == dto.ScanResultUnknown {
+		res.WithStatus(w, http.StatusNotFound).SendJSON(result)
+		return
+	}
+
+	res.JSON(w, result)
+}
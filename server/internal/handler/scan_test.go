@@ -0,0 +1,138 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"quantum/internal/dto"
+	"quantum/internal/handler"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpScanHandler(db *sqlx.DB, logger *slog.Logger) *handler.ScanHandler {
+	itemRepo := repository.NewItemRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+
+	return handler.NewScanHandler(service.NewScanService(itemRepo, locationRepo), logger)
+}
+
+func TestScan(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpScanHandler(application.DB, application.Logger)
+
+	user := testdata.InsertReaderUser(t, application.DB)
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	shelf := testdata.NewLocationBuilder(t, application.DB).WithName("Shelf A").WithParent(store.ID).Build()
+	drill := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("Drill").
+		WithReference("SN-42").
+		WithGroupKey("TOOLS").
+		WithCreatedHistoryRecord(user.ID, shelf.ID).
+		Build()
+	scanner := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("Handheld scanner").
+		WithReference("9501101530003").
+		WithGroupKey("TOOLS").
+		WithCreatedHistoryRecord(user.ID, store.ID).
+		Build()
+	for _, reference := range []string{"SAW-1", "SAW-2"} {
+		testdata.NewItemBuilder(t, application.DB).
+			WithIdentifier("Saw").
+			WithReference(reference).
+			WithGroupKey("TOOLS").
+			WithCreatedHistoryRecord(user.ID, store.ID).
+			Build()
+	}
+
+	scan := func(code string) (*httptest.ResponseRecorder, dto.ScanResponse) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/scan?code="+url.QueryEscape(code), nil)
+		testutils.RequestWithJWT(t, req, user, application.Config.SessionSecret)
+		rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+
+		var response dto.ScanResponse
+		if rr.Code == http.StatusOK || rr.Code == http.StatusNotFound {
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+		}
+		return rr, response
+	}
+
+	testCases := []struct {
+		name      string
+		code      string
+		itemID    string
+		matchedBy dto.ScanMatch
+	}{
+		{name: "reference", code: "SN-42", itemID: drill.ID.String(), matchedBy: dto.ScanMatchReference},
+		{name: "identifier", code: "Drill", itemID: drill.ID.String(), matchedBy: dto.ScanMatchIdentifier},
+		{name: "item ID", code: drill.ID.String(), itemID: drill.ID.String(), matchedBy: dto.ScanMatchID},
+		{name: "symbology identifier", code: "]Q1SN-42", itemID: drill.ID.String(), matchedBy: dto.ScanMatchReference},
+		{name: "GS1 serial number", code: "(01)09501101530003(21)SN-42", itemID: drill.ID.String(), matchedBy: dto.ScanMatchReference},
+		{name: "GS1 GTIN without padding", code: "]C1010950110153000310LOT1", itemID: scanner.ID.String(), matchedBy: dto.ScanMatchReference},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr, response := scan(tc.code)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, dto.ScanResultItem, response.Type)
+			assert.Equal(t, tc.matchedBy, response.MatchedBy)
+			if assert.NotNil(t, response.Item) {
+				assert.Equal(t, tc.itemID, response.Item.ID.String())
+			}
+		})
+	}
+
+	t.Run("GS1 elements are returned", func(t *testing.T) {
+		_, response := scan("(01)09501101530003(21)SN-42")
+		assert.Equal(t, []dto.GS1ElementResponse{
+			{AI: "01", Title: "GTIN", Value: "09501101530003"},
+			{AI: "21", Title: "SERIAL", Value: "SN-42"},
+		}, response.GS1)
+	})
+
+	t.Run("item with its current location", func(t *testing.T) {
+		_, response := scan("SN-42")
+		assert.Equal(t, shelf.ID, response.Item.CurrentLocation.ID)
+	})
+
+	t.Run("location by ID and by name", func(t *testing.T) {
+		for _, code := range []string{shelf.ID.String(), "Shelf A"} {
+			rr, response := scan(code)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, dto.ScanResultLocation, response.Type)
+			if assert.NotNil(t, response.Location) {
+				assert.Equal(t, shelf.ID, response.Location.ID)
+				assert.Len(t, response.Location.Path, 2)
+			}
+		}
+	})
+
+	t.Run("unknown code suggests a reference", func(t *testing.T) {
+		rr, response := scan("(01)04006381333931(21)NEW-1")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, dto.ScanResultUnknown, response.Type)
+		if assert.NotNil(t, response.Suggested) {
+			assert.Equal(t, "NEW-1", response.Suggested.Reference)
+		}
+	})
+
+	t.Run("identifier shared by several items", func(t *testing.T) {
+		rr, _ := scan("Saw")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("missing code", func(t *testing.T) {
+		rr, _ := scan(" ")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"log/slog"
 	"net/http"
 	"quantum/internal/dto"
@@ -67,6 +68,10 @@ func (h *SettingsHandler) updateSettings(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := h.settingsService.Update(settings); err != nil {
+		if errors.Is(err, dto.ErrInvalidStatusTransitions) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		h.logger.Error("failed to update settings", "error", err)
 		res.InternalServerError(w)
 		return
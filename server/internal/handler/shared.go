@@ -1,22 +1,84 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/types/pagination"
+)
+
+var (
+	ErrInvalidLimit  = errors.New("invalid limit, expected a positive integer")
+	ErrInvalidStatus = errors.New("invalid status, expected in-service, in-repair, on-loan, lost, retired or disposed")
 )
 
 type Filters struct {
 	Max            *int
 	Filter         string
 	IncludeDeleted bool
+	Page           pagination.Params
 }
 
-func getFiltersFromRequest(r *http.Request) Filters {
+// getFiltersFromRequest reads the common list filters and the cursor, limit, sort and order paging parameters.
+// An error is returned if any of the paging parameters are malformed.
+func getFiltersFromRequest(r *http.Request) (Filters, error) {
+	page, err := getPageQueryParams(r)
+	if err != nil {
+		return Filters{}, err
+	}
+
 	return Filters{
 		Max:            getMaxQueryParam(r),
 		Filter:         getFilterQueryParam(r),
 		IncludeDeleted: getIncludeDeletedQueryParam(r),
+		Page:           page,
+	}, nil
+}
+
+func getPageQueryParams(r *http.Request) (pagination.Params, error) {
+	query := r.URL.Query()
+
+	var page pagination.Params
+	if cursor := query.Get("cursor"); cursor != "" {
+		c, err := pagination.DecodeCursor(cursor)
+		if err != nil {
+			return pagination.Params{}, err
+		}
+		page.Cursor = &c
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		limitInt, err := strconv.Atoi(limit)
+		if err != nil || limitInt <= 0 {
+			return pagination.Params{}, ErrInvalidLimit
+		}
+		page.Limit = limitInt
 	}
+
+	direction, err := pagination.NewDirection(query.Get("order"))
+	if err != nil {
+		return pagination.Params{}, err
+	}
+
+	page.Sort = query.Get("sort")
+	page.Direction = direction
+	return page, nil
+}
+
+// isPageError checks if the error was caused by invalid paging parameters and should be reported as a bad request.
+func isPageError(err error) bool {
+	return errors.Is(err, pagination.ErrInvalidCursor) ||
+		errors.Is(err, pagination.ErrInvalidSort) ||
+		errors.Is(err, pagination.ErrInvalidDirection) ||
+		errors.Is(err, ErrInvalidLimit)
 }
 
 func getMaxQueryParam(r *http.Request) *int {
@@ -39,3 +101,69 @@ func getIncludeDeletedQueryParam(r *http.Request) bool {
 	includeDeleted, _ := strconv.ParseBool(r.URL.Query().Get("includeDeleted"))
 	return includeDeleted
 }
+
+// getTimeQueryParam parses the named query parameter as an RFC3339 timestamp.
+// Returns nil if the parameter is not present.
+func getTimeQueryParam(r *http.Request, name string) (*time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// getUUIDQueryParam parses the named query parameter as a UUID.
+// Returns nil if the parameter is not present.
+func getUUIDQueryParam(r *http.Request, name string) (*uuid.UUID, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// getItemStatusQueryParam parses the status query parameter as an item status.
+// Returns nil if the parameter is not present.
+func getItemStatusQueryParam(r *http.Request) (*model.ItemStatus, error) {
+	value := r.URL.Query().Get("status")
+	if value == "" {
+		return nil, nil
+	}
+	status := model.ItemStatus(value)
+	if !status.Valid() {
+		return nil, ErrInvalidStatus
+	}
+	return &status, nil
+}
+
+// customFieldQueryParamPrefix prefixes the query parameters that filter items by the value of a custom field,
+// for example field.serialNumber=SN123.
+const customFieldQueryParamPrefix = "field."
+
+// getCustomFieldQueryParams returns the value of each custom field filter query parameter keyed by field name.
+// Returns nil if there are none.
+func getCustomFieldQueryParams(r *http.Request) (map[string]string, error) {
+	var fields map[string]string
+	for key, values := range r.URL.Query() {
+		name, ok := strings.CutPrefix(key, customFieldQueryParamPrefix)
+		if !ok {
+			continue
+		}
+		if !dto.ValidCustomFieldName(name) {
+			return nil, fmt.Errorf("invalid custom field filter %q", key)
+		}
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[name] = values[0]
+	}
+	return fields, nil
+}
@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"quantum/internal/dto"
+	"quantum/internal/service"
+	"quantum/pkg/res"
+)
+
+type StockHandler struct {
+	stockService *service.StockService
+	logger       *slog.Logger
+}
+
+func NewStockHandler(stockService *service.StockService, logger *slog.Logger) *StockHandler {
+	return &StockHandler{
+		stockService: stockService,
+		logger:       logger,
+	}
+}
+
+func (h *StockHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
+	mux.HandleFunc("GET /api/v1/item/{itemId}/stock", mf(h.getItemStock))
+	mux.HandleFunc("POST /api/v1/stock/receive", mf(h.receiveStock))
+	mux.HandleFunc("POST /api/v1/stock/issue", mf(h.issueStock))
+	mux.HandleFunc("POST /api/v1/stock/transfer", mf(h.transferStock))
+	mux.HandleFunc("POST /api/v1/stock/adjust", mf(h.adjustStock))
+	mux.HandleFunc("GET /api/v1/stock/threshold", mf(h.listStockThresholds))
+	mux.HandleFunc("PUT /api/v1/stock/threshold", mf(h.setStockThreshold))
+	mux.HandleFunc("DELETE /api/v1/stock/threshold", mf(h.deleteStockThreshold))
+	mux.HandleFunc("GET /api/v1/stock/low", mf(h.listLowStock))
+}
+
+// getItemStock returns the quantity of a stock item held at each location.
+func (h *StockHandler) getItemStock(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	itemID, err := uuid.Parse(r.PathValue("itemId"))
+	if err != nil {
+		res.Error(w, "invalid item ID", http.StatusBadRequest)
+		return
+	}
+
+	stock, err := h.stockService.GetLevels(itemID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrItemNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrItemNotStock):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			h.logger.Error("error getting item stock", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, stock)
+}
+
+func (h *StockHandler) receiveStock(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	var request dto.StockMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	stock, err := h.stockService.Receive(userID, request)
+	if err != nil {
+		h.stockChangeError(w, err, "error receiving stock")
+		return
+	}
+
+	res.JSON(w, stock)
+}
+
+func (h *StockHandler) issueStock(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	var request dto.StockMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	stock, err := h.stockService.Issue(userID, request)
+	if err != nil {
+		h.stockChangeError(w, err, "error issuing stock")
+		return
+	}
+
+	res.JSON(w, stock)
+}
+
+func (h *StockHandler) transferStock(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	var request dto.StockTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	stock, err := h.stockService.Transfer(userID, request)
+	if err != nil {
+		h.stockChangeError(w, err, "error transferring stock")
+		return
+	}
+
+	res.JSON(w, stock)
+}
+
+func (h *StockHandler) adjustStock(w http.ResponseWriter, r *http.Request) {
+	userID, authed := currentUserID(r)
+	if !authed {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasTrackPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	var request dto.StockAdjustRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	stock, err := h.stockService.Adjust(userID, request)
+	if err != nil {
+		h.stockChangeError(w, err, "error adjusting stock")
+		return
+	}
+
+	res.JSON(w, stock)
+}
+
+// stockChangeError writes the response for an error returned by a change to stock levels.
+func (h *StockHandler) stockChangeError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, dto.ErrInvalidStockItem), errors.Is(err, dto.ErrInvalidStockLocation),
+		errors.Is(err, dto.ErrInvalidStockQuantity), errors.Is(err, dto.ErrInvalidStockDelta),
+		errors.Is(err, dto.ErrInvalidStockTransfer), errors.Is(err, service.ErrItemNotStock):
+		res.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrItemNotFound), errors.Is(err, service.ErrLocationNotFound):
+		res.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInsufficientStock), errors.Is(err, service.ErrItemDeleted),
+		errors.Is(err, service.ErrLocationDeleted):
+		res.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.logger.Error(msg, "error", err)
+		res.InternalServerError(w)
+	}
+}
+
+func (h *StockHandler) listStockThresholds(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	thresholds, err := h.stockService.ListThresholds()
+	if err != nil {
+		h.logger.Error("error listing stock thresholds", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, thresholds)
+}
+
+func (h *StockHandler) setStockThreshold(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasWritePermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	var request dto.StockThresholdRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	threshold, err := h.stockService.SetThreshold(request)
+	if err != nil {
+		switch {
+		case errors.Is(err, dto.ErrInvalidStockGroupKey), errors.Is(err, dto.ErrInvalidStockLocation),
+			errors.Is(err, dto.ErrInvalidStockThreshold):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrLocationNotFound):
+			res.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrLocationDeleted):
+			res.Error(w, err.Error(), http.StatusConflict)
+		default:
+			h.logger.Error("error setting stock threshold", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, threshold)
+}
+
+// deleteStockThreshold removes the reorder threshold named by the groupKey and locationId query parameters.
+func (h *StockHandler) deleteStockThreshold(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasWritePermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	groupKey := r.URL.Query().Get("groupKey")
+	if groupKey == "" {
+		res.Error(w, dto.ErrInvalidStockGroupKey.Error(), http.StatusBadRequest)
+		return
+	}
+
+	locationID, err := getUUIDQueryParam(r, "locationId")
+	if err != nil || locationID == nil {
+		res.Error(w, "invalid locationId, expected a UUID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.stockService.DeleteThreshold(groupKey, *locationID); err != nil {
+		if errors.Is(err, service.ErrStockThresholdNotFound) {
+			res.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		h.logger.Error("error deleting stock threshold", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listLowStock lists each group of stock items held at a location below its reorder threshold.
+func (h *StockHandler) listLowStock(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Unauthorized(w)
+		return
+	}
+
+	if !currentUserRoles(r).HasReadPermissions() {
+		res.Forbidden(w)
+		return
+	}
+
+	low, err := h.stockService.ListLow()
+	if err != nil {
+		h.logger.Error("error listing low stock", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, low)
+}
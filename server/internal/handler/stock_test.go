@@ -0,0 +1,272 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"quantum/internal/dto"
+	"quantum/internal/handler"
+	"quantum/internal/model"
+	"quantum/internal/permissions"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpStockHandler(db *sqlx.DB, logger *slog.Logger) *handler.StockHandler {
+	stockRepo := repository.NewStockRepository(db)
+	itemRepo := repository.NewItemRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+
+	return handler.NewStockHandler(service.NewStockService(stockRepo, itemRepo, locationRepo), logger)
+}
+
+// insertStockUser inserts a user who can both move stock, as a tracker, and set reorder thresholds, as a writer.
+func insertStockUser(t testing.TB, db *sqlx.DB) *model.User {
+	return testdata.NewUserBuilder(t, db).
+		WithName("Stock Admin").
+		WithUsername("stock.admin").
+		WithRole(permissions.AdminRole).
+		WithRole(permissions.TrackerRole).
+		Build()
+}
+
+// insertGloves inserts a stock item of the GLOVES group holding quantity at the location.
+func insertGloves(t testing.TB, db *sqlx.DB, reference string, userID, locationID uuid.UUID, quantity int) *model.ItemModel {
+	return testdata.NewItemBuilder(t, db).
+		WithIdentifier(reference).
+		WithReference(reference).
+		WithGroupKey("GLOVES").
+		WithKind(model.ItemKindStock).
+		WithCreatedHistoryRecord(userID, locationID).
+		WithStockReceivedHistoryRecord(userID, locationID, quantity).
+		Build()
+}
+
+func decodeItemStock(t testing.TB, rr *httptest.ResponseRecorder) dto.ItemStockResponse {
+	var response dto.ItemStockResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return response
+}
+
+func quantityAt(stock dto.ItemStockResponse, locationName string) int {
+	for _, level := range stock.Levels {
+		if level.LocationName == locationName {
+			return level.Quantity
+		}
+	}
+	return 0
+}
+
+func TestReceiveStock(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpStockHandler(application.DB, application.Logger)
+
+	user := insertStockUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+
+	gloves := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("GLOVES-M").
+		WithReference("GLOVES-M").
+		WithGroupKey("GLOVES").
+		WithKind(model.ItemKindStock).
+		WithCreatedHistoryRecord(user.ID, store.ID).
+		Build()
+	laptop := testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("LAPTOP-1").
+		WithReference("LAPTOP-1").
+		WithGroupKey("IT").
+		WithCreatedHistoryRecord(user.ID, store.ID).
+		Build()
+
+	t.Run("receives stock into a location", func(t *testing.T) {
+		body := fmt.Sprintf(`{"itemId": %q, "locationId": %q, "quantity": 100}`, gloves.ID, store.ID)
+		rr := testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, "POST", "/api/v1/stock/receive", body)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		stock := decodeItemStock(t, rr)
+		assert.Equal(t, 100, stock.Total)
+		assert.Equal(t, 100, quantityAt(stock, "Store"))
+	})
+
+	t.Run("rejects stock operations on unit items", func(t *testing.T) {
+		body := fmt.Sprintf(`{"itemId": %q, "locationId": %q, "quantity": 1}`, laptop.ID, store.ID)
+		rr := testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, "POST", "/api/v1/stock/receive", body)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestChangeStock(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpStockHandler(application.DB, application.Logger)
+
+	user := insertStockUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	lab := testdata.NewLocationBuilder(t, application.DB).WithName("Lab").Build()
+
+	transferred := insertGloves(t, application.DB, "GLOVES-S", user.ID, store.ID, 100)
+	issued := insertGloves(t, application.DB, "GLOVES-M", user.ID, store.ID, 100)
+	adjusted := insertGloves(t, application.DB, "GLOVES-L", user.ID, store.ID, 100)
+
+	testCases := []struct {
+		name         string
+		item         *model.ItemModel
+		url          string
+		body         string
+		expectTotal  int
+		expectStore  int
+		expectLab    int
+		expectDeltas []int
+	}{
+		{
+			name:         "transfers stock between locations",
+			item:         transferred,
+			url:          "/api/v1/stock/transfer",
+			body:         fmt.Sprintf(`{"itemId": %q, "fromLocationId": %q, "toLocationId": %q, "quantity": 30}`, transferred.ID, store.ID, lab.ID),
+			expectTotal:  100,
+			expectStore:  70,
+			expectLab:    30,
+			expectDeltas: []int{100, -30, 30},
+		},
+		{
+			name:         "issues stock from a location",
+			item:         issued,
+			url:          "/api/v1/stock/issue",
+			body:         fmt.Sprintf(`{"itemId": %q, "locationId": %q, "quantity": 25, "note": "used in lab"}`, issued.ID, store.ID),
+			expectTotal:  75,
+			expectStore:  75,
+			expectDeltas: []int{100, -25},
+		},
+		{
+			name:         "adjusts the stock held at a location",
+			item:         adjusted,
+			url:          "/api/v1/stock/adjust",
+			body:         fmt.Sprintf(`{"itemId": %q, "locationId": %q, "delta": -5}`, adjusted.ID, store.ID),
+			expectTotal:  95,
+			expectStore:  95,
+			expectDeltas: []int{100, -5},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, "POST", tc.url, tc.body)
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			stock := decodeItemStock(t, rr)
+			assert.Equal(t, tc.expectTotal, stock.Total)
+			assert.Equal(t, tc.expectStore, quantityAt(stock, "Store"))
+			assert.Equal(t, tc.expectLab, quantityAt(stock, "Lab"))
+
+			var deltas []int
+			stmt := `
+				select (data->'data'->>'delta')::int
+				from item_history
+				where item_id = $1 and data->>'type' like 'stock-%';`
+			assert.NoError(t, application.DB.Select(&deltas, stmt, tc.item.ID))
+			assert.ElementsMatch(t, tc.expectDeltas, deltas, "each change is recorded with a signed delta")
+		})
+	}
+}
+
+func TestIssueStock_RejectsMoreThanIsHeld(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpStockHandler(application.DB, application.Logger)
+
+	user := insertStockUser(t, application.DB)
+
+	lab := testdata.NewLocationBuilder(t, application.DB).WithName("Lab").Build()
+	gloves := insertGloves(t, application.DB, "GLOVES-M", user.ID, lab.ID, 5)
+
+	body := fmt.Sprintf(`{"itemId": %q, "locationId": %q, "quantity": 6}`, gloves.ID, lab.ID)
+	rr := testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, "POST", "/api/v1/stock/issue", body)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, "GET", fmt.Sprintf("/api/v1/item/%s/stock", gloves.ID), "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 5, quantityAt(decodeItemStock(t, rr), "Lab"))
+}
+
+func TestTrackItem_RejectsStockItems(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpItemHandler(application.DB, application.Logger)
+
+	user := insertStockUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	lab := testdata.NewLocationBuilder(t, application.DB).WithName("Lab").Build()
+	gloves := insertGloves(t, application.DB, "GLOVES-M", user.ID, store.ID, 100)
+
+	rr := testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, "POST", fmt.Sprintf("/api/v1/item/%s/track/%s", gloves.ID, lab.ID), "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestListLowStock(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpStockHandler(application.DB, application.Logger)
+
+	user := insertStockUser(t, application.DB)
+
+	store := testdata.NewLocationBuilder(t, application.DB).WithName("Store").Build()
+	lab := testdata.NewLocationBuilder(t, application.DB).WithName("Lab").Build()
+
+	testdata.NewItemBuilder(t, application.DB).
+		WithIdentifier("GLOVES-M").
+		WithReference("GLOVES-M").
+		WithGroupKey("GLOVES").
+		WithKind(model.ItemKindStock).
+		WithCreatedHistoryRecord(user.ID, store.ID).
+		WithStockReceivedHistoryRecord(user.ID, store.ID, 65).
+		WithStockReceivedHistoryRecord(user.ID, lab.ID, 5).
+		Build()
+
+	for _, location := range []*model.LocationModel{store, lab} {
+		body := fmt.Sprintf(`{"groupKey": "GLOVES", "locationId": %q, "threshold": 10}`, location.ID)
+		rr := testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, "PUT", "/api/v1/stock/threshold", body)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	rr := testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, "GET", "/api/v1/stock/low", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var low []dto.LowStockResponse
+	if err := json.NewDecoder(rr.Body).Decode(&low); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if assert.Len(t, low, 1) {
+		assert.Equal(t, lab.ID, low[0].LocationID)
+		assert.Equal(t, 5, low[0].Quantity)
+		assert.Equal(t, 5, low[0].Shortfall)
+	}
+}
+
+func TestDeleteStockThreshold(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+	h := setUpStockHandler(application.DB, application.Logger)
+	stockRepo := repository.NewStockRepository(application.DB)
+
+	user := insertStockUser(t, application.DB)
+
+	lab := testdata.NewLocationBuilder(t, application.DB).WithName("Lab").Build()
+	assert.NoError(t, stockRepo.SetThreshold(&model.StockThresholdModel{GroupKey: "GLOVES", LocationID: lab.ID, Threshold: 10}))
+
+	url := fmt.Sprintf("/api/v1/stock/threshold?groupKey=GLOVES&locationId=%s", lab.ID)
+	rr := testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, "DELETE", url, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = testutils.ServeRequestAs(t, h, user, application.Config.SessionSecret, "DELETE", url, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -51,8 +51,18 @@ func (h *UserHandler) list(w http.ResponseWriter, r *http.Request) {
 		roles = []string{"admin", "reader", "writer", "tracker"}
 	}
 
-	users, err := h.userService.List(roles)
+	filters, err := getFiltersFromRequest(r)
 	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, err := h.userService.List(roles, filters.Page)
+	if err != nil {
+		if isPageError(err) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		res.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"log/slog"
+	"net/http"
+	"quantum/internal/dto"
+	"quantum/internal/service"
+	"quantum/pkg/res"
+	"strconv"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+	logger         *slog.Logger
+}
+
+func NewWebhookHandler(webhookService *service.WebhookService, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+func (h *WebhookHandler) RegisterRoutes(mux *http.ServeMux, mf MiddlewareFunc) {
+	mux.HandleFunc("GET /api/v1/webhook", mf(h.listWebhooks))
+	mux.HandleFunc("POST /api/v1/webhook", mf(h.createWebhook))
+	mux.HandleFunc("GET /api/v1/webhook/{webhookId}", mf(h.getWebhook))
+	mux.HandleFunc("PUT /api/v1/webhook/{webhookId}", mf(h.updateWebhook))
+	mux.HandleFunc("DELETE /api/v1/webhook/{webhookId}", mf(h.deleteWebhook))
+	mux.HandleFunc("GET /api/v1/webhook/{webhookId}/deliveries", mf(h.listDeliveries))
+	mux.HandleFunc("POST /api/v1/webhook/{webhookId}/deliveries/{deliveryId}/redeliver", mf(h.redeliver))
+}
+
+func (h *WebhookHandler) listWebhooks(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	webhooks, err := h.webhookService.List()
+	if err != nil {
+		h.logger.Error("error listing webhooks", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, webhooks)
+}
+
+func (h *WebhookHandler) createWebhook(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	var request dto.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid webhook request", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.webhookService.Create(request)
+	if err != nil {
+		if isWebhookValidationError(err) {
+			res.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.logger.Error("error creating webhook", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.WithStatus(w, http.StatusCreated).SendJSON(webhook)
+}
+
+func (h *WebhookHandler) getWebhook(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookId"))
+	if err != nil {
+		res.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.webhookService.Get(webhookID)
+	if err != nil {
+		if errors.Is(err, service.ErrWebhookNotFound) {
+			res.Error(w, "webhook not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("error getting webhook", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	res.JSON(w, webhook)
+}
+
+func (h *WebhookHandler) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookId"))
+	if err != nil {
+		res.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	var request dto.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		res.Error(w, "invalid webhook request", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.webhookService.Update(webhookID, request)
+	if err != nil {
+		switch {
+		case isWebhookValidationError(err):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrWebhookNotFound):
+			res.Error(w, "webhook not found", http.StatusNotFound)
+		default:
+			h.logger.Error("error updating webhook", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, webhook)
+}
+
+func (h *WebhookHandler) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookId"))
+	if err != nil {
+		res.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webhookService.Delete(webhookID); err != nil {
+		if errors.Is(err, service.ErrWebhookNotFound) {
+			res.Error(w, "webhook not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("error deleting webhook", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookId"))
+	if err != nil {
+		res.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	page, err := getPageQueryParams(r)
+	if err != nil {
+		res.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deliveries, err := h.webhookService.ListDeliveries(webhookID, page)
+	if err != nil {
+		switch {
+		case isPageError(err):
+			res.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, service.ErrWebhookNotFound):
+			res.Error(w, "webhook not found", http.StatusNotFound)
+		default:
+			h.logger.Error("error listing webhook deliveries", "error", err)
+			res.InternalServerError(w)
+		}
+		return
+	}
+
+	res.JSON(w, deliveries)
+}
+
+func (h *WebhookHandler) redeliver(w http.ResponseWriter, r *http.Request) {
+	if !authenticated(r) {
+		res.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !currentUserRoles(r).IsAdmin() {
+		res.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	webhookID, err := uuid.Parse(r.PathValue("webhookId"))
+	if err != nil {
+		res.Error(w, "invalid webhook id", http.StatusBadRequest)
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(r.PathValue("deliveryId"), 10, 64)
+	if err != nil {
+		res.Error(w, "invalid delivery id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.webhookService.Redeliver(webhookID, deliveryID); err != nil {
+		if errors.Is(err, service.ErrWebhookDeliveryNotFound) {
+			res.Error(w, "webhook delivery not found", http.StatusNotFound)
+			return
+		}
+		h.logger.Error("error redelivering webhook", "error", err)
+		res.InternalServerError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func isWebhookValidationError(err error) bool {
+	return errors.Is(err, dto.ErrInvalidWebhookURL) || errors.Is(err, dto.ErrInvalidWebhookEventType)
+}
@@ -0,0 +1,145 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"quantum/internal/dto"
+	"quantum/internal/handler"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"quantum/internal/service"
+	"quantum/internal/types/pagination"
+	"quantum/tests/testdata"
+	"quantum/tests/testutils"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookReceiver records the deliveries it receives and responds with the next of its statuses.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	rcv.bodies = append(rcv.bodies, body)
+	rcv.headers = append(rcv.headers, r.Header.Clone())
+
+	status := http.StatusOK
+	if len(rcv.statuses) > 0 {
+		status, rcv.statuses = rcv.statuses[0], rcv.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	t.Cleanup(func() { testutils.CleanDatabase(t, application.DB) })
+
+	webhookRepo := repository.NewWebhookRepository(application.DB)
+	h := handler.NewWebhookHandler(service.NewWebhookService(webhookRepo), application.Logger)
+
+	admin := testdata.InsertAdminUser(t, application.DB)
+	location := testdata.NewLocationBuilder(t, application.DB).
+		WithName("Warehouse").
+		Build()
+
+	// The receiver fails the first delivery so that it is retried.
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError}}
+	srv := httptest.NewServer(receiver)
+	defer srv.Close()
+
+	body := fmt.Sprintf(`{"url": %q, "eventTypes": ["created"], "secret": "shh"}`, srv.URL)
+	req := httptest.NewRequest("POST", "/api/v1/webhook", strings.NewReader(body))
+	testutils.RequestWithJWT(t, req, admin, application.Config.SessionSecret)
+	rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var webhook dto.WebhookWithSecretResponse
+	if err := json.NewDecoder(rr.Body).Decode(&webhook); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	// Creating an item writes the delivery to the outbox, tracking it does not as the webhook only wants created events.
+	itemRepo := repository.NewItemRepository(application.DB)
+	item := model.ItemModel{Identifier: "ITEM-1", Reference: "REF-1", GroupKey: "XYZ"}
+	assert.NoError(t, itemRepo.Create(&item, admin.ID, location.ID))
+	assert.NoError(t, itemRepo.AppendNewItemTrackedToLocationHistory(admin.ID, []uuid.UUID{item.ID}, location.ID))
+
+	dispatcher := service.NewWebhookDispatcher(webhookRepo, srv.Client(), application.Logger)
+
+	count, err := dispatcher.DispatchDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// The failed delivery is not due again until its backoff has passed.
+	count, err = dispatcher.DispatchDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	deliveries := listWebhookDeliveries(t, h, admin, webhook.ID.String())
+	assert.Len(t, deliveries.Items, 1)
+	delivery := deliveries.Items[0]
+	assert.Equal(t, model.WebhookDeliveryStatusPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.NotNil(t, delivery.NextAttemptAt)
+
+	url := fmt.Sprintf("/api/v1/webhook/%s/deliveries/%d/redeliver", webhook.ID, delivery.ID)
+	req = httptest.NewRequest("POST", url, nil)
+	testutils.RequestWithJWT(t, req, admin, application.Config.SessionSecret)
+	rr = testutils.ServeRequest(h, req, application.Config.SessionSecret)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	count, err = dispatcher.DispatchDue(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	deliveries = listWebhookDeliveries(t, h, admin, webhook.ID.String())
+	delivery = deliveries.Items[0]
+	assert.Equal(t, model.WebhookDeliveryStatusDelivered, delivery.Status)
+	assert.Len(t, delivery.AttemptLog, 2)
+	assert.Equal(t, http.StatusInternalServerError, *delivery.AttemptLog[0].StatusCode)
+	assert.Equal(t, http.StatusOK, *delivery.AttemptLog[1].StatusCode)
+
+	assert.Len(t, receiver.bodies, 2)
+	last := receiver.headers[1]
+	timestamp, err := strconv.ParseInt(last.Get(service.WebhookTimestampHeader), 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, service.SignWebhookPayload("shh", timestamp, receiver.bodies[1]), last.Get(service.WebhookSignatureHeader))
+	assert.Equal(t, "created", last.Get(service.WebhookEventHeader))
+
+	var payload map[string]any
+	assert.NoError(t, json.Unmarshal(receiver.bodies[1], &payload))
+	assert.Equal(t, item.ID.String(), payload["itemId"])
+	assert.Equal(t, "REF-1", payload["itemReference"])
+}
+
+func listWebhookDeliveries(t *testing.T, h *handler.WebhookHandler, user *model.User, webhookID string) pagination.Page[dto.WebhookDeliveryResponse] {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/webhook/%s/deliveries", webhookID), nil)
+	testutils.RequestWithJWT(t, req, user, application.Config.SessionSecret)
+	rr := testutils.ServeRequest(h, req, application.Config.SessionSecret)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var page pagination.Page[dto.WebhookDeliveryResponse]
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return page
+}
@@ -0,0 +1,91 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type AuditStatus string
+
+const (
+	AuditStatusOpen   AuditStatus = "open"
+	AuditStatusClosed AuditStatus = "closed"
+)
+
+// AuditSessionModel represents a row in the audit_sessions table joined to the name of its location.
+type AuditSessionModel struct {
+	ID           uuid.UUID `db:"id"`
+	LocationID   uuid.UUID `db:"location_id"`
+	LocationName string    `db:"location_name"`
+	// IncludeChildren is true if the locations below the location are audited too.
+	IncludeChildren bool        `db:"include_children"`
+	Status          AuditStatus `db:"status"`
+	StartedByUserID uuid.UUID   `db:"started_by_user_id"`
+	StartedAt       time.Time   `db:"started_at"`
+	ClosedByUserID  *uuid.UUID  `db:"closed_by_user_id"`
+	ClosedAt        *time.Time  `db:"closed_at"`
+	// AppliedAt is when the corrections of a closed session were tracked, nil if they have not been.
+	AppliedByUserID *uuid.UUID `db:"applied_by_user_id"`
+	AppliedAt       *time.Time `db:"applied_at"`
+}
+
+// AuditScanModel is an item scanned into an audit session, joined to the location it was found at.
+type AuditScanModel struct {
+	ID        int64     `db:"id"`
+	SessionID uuid.UUID `db:"session_id"`
+	// ItemID is nil once the item has been purged, the reference and identifier are kept.
+	ItemID          *uuid.UUID `db:"item_id"`
+	ItemReference   string     `db:"item_reference"`
+	ItemIdentifier  string     `db:"item_identifier"`
+	LocationID      uuid.UUID  `db:"location_id"`
+	LocationName    string     `db:"location_name"`
+	Code            string     `db:"code"`
+	ScannedByUserID uuid.UUID  `db:"scanned_by_user_id"`
+	ScannedAt       time.Time  `db:"scanned_at"`
+}
+
+type AuditResultStatus string
+
+const (
+	// AuditResultFound is an item that was expected in the audited locations and scanned.
+	AuditResultFound AuditResultStatus = "found"
+	// AuditResultMissing is an item that was expected in the audited locations but not scanned.
+	AuditResultMissing AuditResultStatus = "missing"
+	// AuditResultUnexpected is an item that was scanned but recorded somewhere else.
+	AuditResultUnexpected AuditResultStatus = "unexpected"
+)
+
+// AuditResultModel is a row of the reconciliation of a closed audit session.
+type AuditResultModel struct {
+	ID        int64     `db:"id"`
+	SessionID uuid.UUID `db:"session_id"`
+	// ItemID is nil once the item has been purged, the reference and identifier are kept.
+	ItemID         *uuid.UUID        `db:"item_id"`
+	ItemReference  string            `db:"item_reference"`
+	ItemIdentifier string            `db:"item_identifier"`
+	Status         AuditResultStatus `db:"status"`
+	// ExpectedLocationID is the location or user the item was recorded at when the session closed.
+	ExpectedLocationID   *uuid.UUID `db:"expected_location_id"`
+	ExpectedLocationName *string    `db:"expected_location_name"`
+	// FoundLocationID is the location the item was scanned at, nil for missing items.
+	FoundLocationID   *uuid.UUID `db:"found_location_id"`
+	FoundLocationName *string    `db:"found_location_name"`
+	// Applied is true if the item was tracked to correct its location.
+	Applied bool `db:"applied"`
+}
+
+// AuditCorrectionsModel is the outcome of applying the corrections of a closed audit session.
+type AuditCorrectionsModel struct {
+	// Tracked is the number of items tracked to correct their location.
+	Tracked int
+	// Stale is the number of corrections skipped because the item was tracked after the session closed.
+	Stale int
+}
+
+// NeedsCorrection reports whether the item was found somewhere other than where it was recorded.
+func (r AuditResultModel) NeedsCorrection() bool {
+	if r.FoundLocationID == nil {
+		return false
+	}
+	return r.ExpectedLocationID == nil || *r.ExpectedLocationID != *r.FoundLocationID
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CustomFieldType is the type of the value of a custom field.
+type CustomFieldType string
+
+const (
+	CustomFieldTypeString CustomFieldType = "string"
+	CustomFieldTypeNumber CustomFieldType = "number"
+	// CustomFieldTypeDate is a calendar date in the form 2006-01-02.
+	CustomFieldTypeDate CustomFieldType = "date"
+	// CustomFieldTypeEnum is a string that must be one of the options of the field.
+	CustomFieldTypeEnum CustomFieldType = "enum"
+	// CustomFieldTypeRegex is a string that must match the pattern of the field.
+	CustomFieldTypeRegex CustomFieldType = "regex"
+)
+
+func (t CustomFieldType) Valid() bool {
+	switch t {
+	case CustomFieldTypeString, CustomFieldTypeNumber, CustomFieldTypeDate, CustomFieldTypeEnum, CustomFieldTypeRegex:
+		return true
+	}
+	return false
+}
+
+// CustomFieldDefinition defines a custom field of the items in a group.
+type CustomFieldDefinition struct {
+	Name     string          `json:"name"`
+	Type     CustomFieldType `json:"type"`
+	Required bool            `json:"required"`
+	// Options are the allowed values of an enum field.
+	Options []string `json:"options,omitempty"`
+	// Pattern is the regular expression the value of a regex field must match.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// GroupCustomFieldsModel represents a row in the group_custom_fields table.
+type GroupCustomFieldsModel struct {
+	GroupKey string `db:"group_key"`
+	// Fields is a JSON array of CustomFieldDefinition.
+	Fields    json.RawMessage `db:"fields"`
+	UpdatedAt time.Time       `db:"updated_at"`
+}
+
+func (m GroupCustomFieldsModel) ParseFields() ([]CustomFieldDefinition, error) {
+	var fields []CustomFieldDefinition
+	if err := json.Unmarshal(m.Fields, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
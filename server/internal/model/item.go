@@ -1,23 +1,66 @@
 package model
 
 import (
+	"encoding/json"
 	"github.com/google/uuid"
 	"time"
 )
 
+// ItemKind is whether an item is a single unit or stock held in quantities.
+type ItemKind string
+
+const (
+	// ItemKindUnit is an item tracked on its own, it is at exactly one location or user at a time.
+	ItemKindUnit ItemKind = "unit"
+	// ItemKindStock is fungible stock, such as cables or batteries, held in quantities at any number of locations.
+	ItemKindStock ItemKind = "stock"
+)
+
+// ItemStatus is where an item is in its lifecycle. Items move between statuses through the transitions allowed
+// in the settings, each recorded as a status-changed history record.
+type ItemStatus string
+
+const (
+	ItemStatusInService ItemStatus = "in-service"
+	ItemStatusInRepair  ItemStatus = "in-repair"
+	ItemStatusOnLoan    ItemStatus = "on-loan"
+	ItemStatusLost      ItemStatus = "lost"
+	ItemStatusRetired   ItemStatus = "retired"
+	ItemStatusDisposed  ItemStatus = "disposed"
+)
+
+func (s ItemStatus) Valid() bool {
+	switch s {
+	case ItemStatusInService, ItemStatusInRepair, ItemStatusOnLoan, ItemStatusLost, ItemStatusRetired, ItemStatusDisposed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Trackable reports whether items with the status can be tracked to a location or user.
+func (s ItemStatus) Trackable() bool {
+	return s != ItemStatusRetired && s != ItemStatusDisposed
+}
+
 // ItemModel represents a row in the items table.
 type ItemModel struct {
-	ID          uuid.UUID `db:"id"`
-	Identifier  string    `db:"identifier"`
-	Reference   string    `db:"reference"`
-	GroupKey    string    `db:"group_key"`
-	Description *string   `db:"description"`
-	Deleted     bool      `db:"deleted"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID          uuid.UUID  `db:"id"`
+	Identifier  string     `db:"identifier"`
+	Reference   string     `db:"reference"`
+	GroupKey    string     `db:"group_key"`
+	Description *string    `db:"description"`
+	Kind        ItemKind   `db:"kind"`
+	Status      ItemStatus `db:"status"`
+	// CustomFields is a JSON object of the values of the custom fields defined for the group of the item.
+	CustomFields json.RawMessage `db:"custom_fields"`
+	Deleted      bool            `db:"deleted"`
+	CreatedAt    time.Time       `db:"created_at"`
+	UpdatedAt    time.Time       `db:"updated_at"`
 }
 
-// ItemWithCurrentLocationModel represents a row in the items_with_current_location view.
+// ItemWithCurrentLocationModel represents a row in the items_with_current_location view,
+// which joins each item to its row in the item_current_location table.
 type ItemWithCurrentLocationModel struct {
 	ItemModel
 
@@ -32,3 +75,51 @@ type ItemWithCurrentLocationModel struct {
 	// TrackedToUser is true if the item is tracked to a user, false if it is tracked to a location.
 	TrackedToUser bool `db:"tracked_to_user"`
 }
+
+// DeletedItemModel represents a soft-deleted item along with the details of its most recent deletion.
+type DeletedItemModel struct {
+	ItemModel
+
+	DeletedByUserID       uuid.UUID `db:"deleted_by_user_id"`
+	DeletedByUserName     string    `db:"deleted_by_user_name"`
+	DeletedByUserUsername string    `db:"deleted_by_user_username"`
+	DeletedAt             time.Time `db:"deleted_at"`
+}
+
+type LocationDiffChange string
+
+const (
+	LocationDiffChangeArrived LocationDiffChange = "arrived"
+	LocationDiffChangeLeft    LocationDiffChange = "left"
+	LocationDiffChangeStayed  LocationDiffChange = "stayed"
+)
+
+// LocationDiffModel is an item that arrived at, left or stayed at a location between two points in time,
+// along with the history record of its movement.
+type LocationDiffModel struct {
+	ItemModel
+
+	Change LocationDiffChange `db:"change"`
+	// MovementType is the type of the history record that moved the item to the location or, if it left, away from it.
+	MovementType        ItemHistoryType `db:"movement_type"`
+	MovedAt             time.Time       `db:"moved_at"`
+	MovedByUserID       uuid.UUID       `db:"moved_by_user_id"`
+	MovedByUserName     string          `db:"moved_by_user_name"`
+	MovedByUserUsername string          `db:"moved_by_user_username"`
+	// DestinationID is the location or user the item left to, nil unless the item left by being tracked elsewhere.
+	DestinationID *uuid.UUID `db:"destination_id"`
+	// DestinationName is the name of the location or user the item left to.
+	DestinationName *string `db:"destination_name"`
+}
+
+// LoanModel is an item currently tracked to a user, along with the user holding it and when it is due back.
+// The user fields are nil when the user no longer exists.
+type LoanModel struct {
+	ItemModel
+
+	UserID       uuid.UUID  `db:"user_id"`
+	UserName     *string    `db:"user_name"`
+	UserUsername *string    `db:"user_username"`
+	CheckedOutAt time.Time  `db:"checked_out_at"`
+	DueAt        *time.Time `db:"due_at"`
+}
@@ -10,15 +10,35 @@ import (
 type ItemHistoryType string
 
 const (
-	ItemHistoryTypeUnknown     ItemHistoryType = "unknown"
-	ItemHistoryTypeCreated     ItemHistoryType = "created"
-	ItemHistoryTypeUpdated     ItemHistoryType = "updated"
-	ItemHistoryTypeDeleted     ItemHistoryType = "deleted"
-	ItemHistoryTypeRestored    ItemHistoryType = "restored"
-	ItemHistoryTypeTracked     ItemHistoryType = "tracked"
-	ItemHistoryTypeTrackedUser ItemHistoryType = "tracked-user"
+	ItemHistoryTypeUnknown          ItemHistoryType = "unknown"
+	ItemHistoryTypeCreated          ItemHistoryType = "created"
+	ItemHistoryTypeUpdated          ItemHistoryType = "updated"
+	ItemHistoryTypeDeleted          ItemHistoryType = "deleted"
+	ItemHistoryTypeRestored         ItemHistoryType = "restored"
+	ItemHistoryTypeTracked          ItemHistoryType = "tracked"
+	ItemHistoryTypeTrackedUser      ItemHistoryType = "tracked-user"
+	ItemHistoryTypeContained        ItemHistoryType = "contained"
+	ItemHistoryTypeUncontained      ItemHistoryType = "uncontained"
+	ItemHistoryTypeStockReceived    ItemHistoryType = "stock-received"
+	ItemHistoryTypeStockIssued      ItemHistoryType = "stock-issued"
+	ItemHistoryTypeStockTransferred ItemHistoryType = "stock-transferred"
+	ItemHistoryTypeStockAdjusted    ItemHistoryType = "stock-adjusted"
+	ItemHistoryTypeStatusChanged    ItemHistoryType = "status-changed"
 )
 
+// Valid reports whether the type is one of the known history types.
+func (t ItemHistoryType) Valid() bool {
+	switch t {
+	case ItemHistoryTypeCreated, ItemHistoryTypeUpdated, ItemHistoryTypeDeleted, ItemHistoryTypeRestored,
+		ItemHistoryTypeTracked, ItemHistoryTypeTrackedUser, ItemHistoryTypeContained, ItemHistoryTypeUncontained,
+		ItemHistoryTypeStockReceived, ItemHistoryTypeStockIssued, ItemHistoryTypeStockTransferred, ItemHistoryTypeStockAdjusted,
+		ItemHistoryTypeStatusChanged:
+		return true
+	default:
+		return false
+	}
+}
+
 func (t ItemHistoryType) String() string {
 	switch t {
 	case ItemHistoryTypeCreated:
@@ -31,6 +51,22 @@ func (t ItemHistoryType) String() string {
 		return "Restored"
 	case ItemHistoryTypeTracked:
 		return "Tracked"
+	case ItemHistoryTypeTrackedUser:
+		return "Tracked to user"
+	case ItemHistoryTypeContained:
+		return "Added to container"
+	case ItemHistoryTypeUncontained:
+		return "Removed from container"
+	case ItemHistoryTypeStockReceived:
+		return "Stock received"
+	case ItemHistoryTypeStockIssued:
+		return "Stock issued"
+	case ItemHistoryTypeStockTransferred:
+		return "Stock transferred"
+	case ItemHistoryTypeStockAdjusted:
+		return "Stock adjusted"
+	case ItemHistoryTypeStatusChanged:
+		return "Status changed"
 	default:
 		return "Unknown"
 	}
@@ -42,6 +78,23 @@ type ItemHistoryModel struct {
 	ItemID    uuid.UUID       `db:"item_id"`
 	Data      json.RawMessage `db:"data"`
 	CreatedAt time.Time       `db:"created_at"`
+	// TxID is the ID of the transaction that appended the record.
+	TxID uint64 `db:"txid"`
+}
+
+// ItemHistoryDetailModel is an item history record joined to the item, users and location it refers to.
+// The joined fields are nil when the referenced row no longer exists.
+type ItemHistoryDetailModel struct {
+	ItemHistoryModel
+	ItemReference         *string `db:"item_reference"`
+	UserName              *string `db:"user_name"`
+	UserUsername          *string `db:"user_username"`
+	LocationName          *string `db:"location_name"`
+	TrackedToUserName     *string `db:"tracked_to_user_name"`
+	TrackedToUserUsername *string `db:"tracked_to_user_username"`
+	ContainerReference    *string `db:"container_reference"`
+	// ItemStatus is the current status of the item.
+	ItemStatus *ItemStatus `db:"item_status"`
 }
 
 type HistoryDataContainer struct {
@@ -54,18 +107,52 @@ type ItemCreatedHistoryData struct {
 	GroupKey    string    `json:"group"`
 	Description *string   `json:"description"`
 	LocationID  uuid.UUID `json:"locationId"`
+	// CustomFields are the values of the custom fields the item was created with, omitted if there were none.
+	CustomFields json.RawMessage `json:"customFields,omitempty"`
+}
+
+// ItemFieldChange records the value of a single item field before and after an update.
+type ItemFieldChange struct {
+	Old *string `json:"old"`
+	New *string `json:"new"`
 }
 
 type ItemUpdatedHistoryData struct {
-	UpdatedFields map[string]string `json:"updatedFields"`
+	UpdatedFields map[string]ItemFieldChange `json:"updatedFields"`
 }
 
 type ItemTrackedHistoryData struct {
 	LocationID uuid.UUID `json:"locationId"`
 }
 
+// ItemTrackedUserHistoryData records an item being checked out to a user.
+// DueAt is when the item is expected to be checked back in, nil if there is no due date.
 type ItemTrackedUserHistoryData struct {
-	UserID uuid.UUID `json:"userId"`
+	UserID uuid.UUID  `json:"userId"`
+	DueAt  *time.Time `json:"dueAt,omitempty"`
+}
+
+// ItemContainmentHistoryData records an item being put inside, or taken out of, another item.
+type ItemContainmentHistoryData struct {
+	ContainerID uuid.UUID `json:"containerId"`
+}
+
+// ItemStockHistoryData records a change to the quantity of a stock item held at a location.
+// Delta is negative when stock left the location and Quantity is what the location held after the change.
+// A transfer is recorded at both ends, each naming the location at the other end as TransferLocationID.
+type ItemStockHistoryData struct {
+	LocationID         uuid.UUID  `json:"locationId"`
+	Delta              int        `json:"delta"`
+	Quantity           int        `json:"quantity"`
+	TransferLocationID *uuid.UUID `json:"transferLocationId,omitempty"`
+	Note               *string    `json:"note,omitempty"`
+}
+
+// ItemStatusChangedHistoryData records an item moving from one lifecycle status to another, and why.
+type ItemStatusChangedHistoryData struct {
+	From   ItemStatus `json:"from"`
+	To     ItemStatus `json:"to"`
+	Reason string     `json:"reason"`
 }
 
 func (h *ItemHistoryModel) ParseData() (ItemHistoryType, interface{}, error) {
@@ -99,6 +186,24 @@ func (h *ItemHistoryModel) ParseData() (ItemHistoryType, interface{}, error) {
 			return ItemHistoryTypeTrackedUser, nil, err
 		}
 		return ItemHistoryTypeTrackedUser, data, nil
+	case ItemHistoryTypeContained, ItemHistoryTypeUncontained:
+		var data ItemContainmentHistoryData
+		if err := json.Unmarshal(container.Data, &data); err != nil {
+			return container.Type, nil, err
+		}
+		return container.Type, data, nil
+	case ItemHistoryTypeStockReceived, ItemHistoryTypeStockIssued, ItemHistoryTypeStockTransferred, ItemHistoryTypeStockAdjusted:
+		var data ItemStockHistoryData
+		if err := json.Unmarshal(container.Data, &data); err != nil {
+			return container.Type, nil, err
+		}
+		return container.Type, data, nil
+	case ItemHistoryTypeStatusChanged:
+		var data ItemStatusChangedHistoryData
+		if err := json.Unmarshal(container.Data, &data); err != nil {
+			return ItemHistoryTypeStatusChanged, nil, err
+		}
+		return ItemHistoryTypeStatusChanged, data, nil
 	case ItemHistoryTypeDeleted:
 		return ItemHistoryTypeDeleted, nil, nil
 	case ItemHistoryTypeRestored:
@@ -107,3 +212,41 @@ func (h *ItemHistoryModel) ParseData() (ItemHistoryType, interface{}, error) {
 		return ItemHistoryTypeUnknown, nil, errors.New("unknown history type")
 	}
 }
+
+// ItemEventModel is an item history record describing an item being created, moved or deleted,
+// along with where the item moved from and to.
+type ItemEventModel struct {
+	ID            int64           `db:"id"`
+	Type          ItemHistoryType `db:"type"`
+	ItemID        uuid.UUID       `db:"item_id"`
+	ItemReference string          `db:"item_reference"`
+	GroupKey      string          `db:"group_key"`
+	UserID        uuid.UUID       `db:"user_id"`
+	UserName      *string         `db:"user_name"`
+	// ToID is the location, or for tracked-user events the user, the item moved to. It is nil for deleted events.
+	ToID *uuid.UUID `db:"to_id"`
+	// FromID is the location or user the item was at before the event, nil for created events.
+	FromID    *uuid.UUID `db:"from_id"`
+	CreatedAt time.Time  `db:"created_at"`
+	TxID      uint64     `db:"txid"`
+}
+
+func (e ItemEventModel) Cursor() ItemEventCursor {
+	return ItemEventCursor{TxID: e.TxID, ID: e.ID}
+}
+
+// ItemEventCursor is the position of an item event in the stream of item events, which is ordered by the
+// transaction that appended the history record and then by its ID. Unlike the ID alone, the position of a
+// record can only be passed once its transaction and every transaction before it have finished.
+type ItemEventCursor struct {
+	TxID uint64
+	ID   int64
+}
+
+// After reports whether the cursor is further along the stream than other.
+func (c ItemEventCursor) After(other ItemEventCursor) bool {
+	if c.TxID != other.TxID {
+		return c.TxID > other.TxID
+	}
+	return c.ID > other.ID
+}
@@ -9,8 +9,19 @@ type LocationModel struct {
 	ID          uuid.UUID `db:"id"`
 	Name        string    `db:"name"`
 	Description *string   `db:"description"`
-	IsDeleted   bool      `db:"is_deleted"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
-	IsUser      bool      `db:"is_user"`
+	// ParentID is the location this location is inside of, nil for a top level location.
+	ParentID  *uuid.UUID `db:"parent_id"`
+	IsDeleted bool       `db:"is_deleted"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	IsUser    bool       `db:"is_user"`
+}
+
+// LocationAncestorModel is a location in the path from the top level location down to the location with LocationID.
+// Depth is 0 for the location itself, 1 for its parent and so on.
+type LocationAncestorModel struct {
+	LocationID uuid.UUID `db:"location_id"`
+	ID         uuid.UUID `db:"id"`
+	Name       string    `db:"name"`
+	Depth      int       `db:"depth"`
 }
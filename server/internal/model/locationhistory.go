@@ -0,0 +1,54 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+type LocationHistoryType string
+
+const (
+	LocationHistoryTypeCreated  LocationHistoryType = "created"
+	LocationHistoryTypeUpdated  LocationHistoryType = "updated"
+	LocationHistoryTypeMoved    LocationHistoryType = "moved"
+	LocationHistoryTypeDeleted  LocationHistoryType = "deleted"
+	LocationHistoryTypeRestored LocationHistoryType = "restored"
+)
+
+// LocationHistoryModel is a single change to a location, along with the name of the user who made it.
+// UserName is nil when the user no longer exists.
+type LocationHistoryModel struct {
+	ID         int64           `db:"id"`
+	UserID     uuid.UUID       `db:"user_id"`
+	UserName   *string         `db:"user_name"`
+	LocationID uuid.UUID       `db:"location_id"`
+	Data       json.RawMessage `db:"data"`
+	CreatedAt  time.Time       `db:"created_at"`
+}
+
+type LocationHistoryDataContainer struct {
+	Type LocationHistoryType `json:"type"`
+	Data json.RawMessage     `json:"data"`
+}
+
+type LocationCreatedHistoryData struct {
+	Name        string     `json:"name"`
+	Description *string    `json:"description"`
+	ParentID    *uuid.UUID `json:"parentId"`
+}
+
+type LocationUpdatedHistoryData struct {
+	UpdatedFields map[string]ItemFieldChange `json:"updatedFields"`
+}
+
+type LocationMovedHistoryData struct {
+	FromParentID *uuid.UUID `json:"fromParentId"`
+	ToParentID   *uuid.UUID `json:"toParentId"`
+}
+
+// LocationDeletedHistoryData records where the items still at a location were moved to when it was deleted.
+type LocationDeletedHistoryData struct {
+	ItemsMovedTo *uuid.UUID `json:"itemsMovedTo"`
+	ItemsMoved   int        `json:"itemsMoved"`
+}
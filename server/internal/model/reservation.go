@@ -0,0 +1,28 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ReservationModel is an item booked by a user for the time window from StartsAt up to EndsAt.
+type ReservationModel struct {
+	ID              uuid.UUID `db:"id"`
+	ItemID          uuid.UUID `db:"item_id"`
+	UserID          uuid.UUID `db:"user_id"`
+	Purpose         string    `db:"purpose"`
+	StartsAt        time.Time `db:"starts_at"`
+	EndsAt          time.Time `db:"ends_at"`
+	CreatedByUserID uuid.UUID `db:"created_by_user_id"`
+	CreatedAt       time.Time `db:"created_at"`
+}
+
+// ReservationDetailModel is a reservation joined to the item and the user holding it.
+type ReservationDetailModel struct {
+	ReservationModel
+	ItemReference  string `db:"item_reference"`
+	ItemIdentifier string `db:"item_identifier"`
+	ItemGroupKey   string `db:"item_group_key"`
+	UserName       string `db:"user_name"`
+	UserUsername   string `db:"user_username"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// StockLevelModel is the quantity of a stock item held at a location, joined to the name of the location.
+type StockLevelModel struct {
+	ItemID       uuid.UUID `db:"item_id"`
+	LocationID   uuid.UUID `db:"location_id"`
+	LocationName string    `db:"location_name"`
+	Quantity     int       `db:"quantity"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+// StockThresholdModel is the quantity of a group of stock items below which a location should reorder,
+// joined to the name of the location.
+type StockThresholdModel struct {
+	GroupKey     string    `db:"group_key"`
+	LocationID   uuid.UUID `db:"location_id"`
+	LocationName string    `db:"location_name"`
+	Threshold    int       `db:"threshold"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+// LowStockModel is a group of stock items held at a location in a quantity below its reorder threshold.
+type LowStockModel struct {
+	GroupKey     string    `db:"group_key"`
+	LocationID   uuid.UUID `db:"location_id"`
+	LocationName string    `db:"location_name"`
+	Threshold    int       `db:"threshold"`
+	Quantity     int       `db:"quantity"`
+}
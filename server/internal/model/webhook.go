@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"time"
+)
+
+type WebhookModel struct {
+	ID  uuid.UUID `db:"id"`
+	URL string    `db:"url"`
+	// EventTypes are the item history types the webhook is sent, all types when empty.
+	EventTypes pq.StringArray `db:"event_types"`
+	Secret     string         `db:"secret"`
+	Active     bool           `db:"active"`
+	CreatedAt  time.Time      `db:"created_at"`
+	UpdatedAt  time.Time      `db:"updated_at"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDeliveryModel is an event waiting in, or sent from, the webhook outbox.
+type WebhookDeliveryModel struct {
+	ID            int64                 `db:"id"`
+	WebhookID     uuid.UUID             `db:"webhook_id"`
+	EventType     ItemHistoryType       `db:"event_type"`
+	Payload       json.RawMessage       `db:"payload"`
+	Status        WebhookDeliveryStatus `db:"status"`
+	Attempts      int                   `db:"attempts"`
+	NextAttemptAt time.Time             `db:"next_attempt_at"`
+	DeliveredAt   *time.Time            `db:"delivered_at"`
+	CreatedAt     time.Time             `db:"created_at"`
+}
+
+// WebhookDeliveryAttemptModel is a single attempt at sending a delivery.
+// StatusCode is nil when no response was received, in which case Error describes the failure.
+type WebhookDeliveryAttemptModel struct {
+	ID         int64     `db:"id"`
+	DeliveryID int64     `db:"delivery_id"`
+	StatusCode *int      `db:"status_code"`
+	Error      *string   `db:"error"`
+	DurationMS int       `db:"duration_ms"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// WebhookDeliveryWithSecretModel is a delivery claimed for sending along with where and how to send it.
+type WebhookDeliveryWithSecretModel struct {
+	WebhookDeliveryModel
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
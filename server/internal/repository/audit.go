@@ -0,0 +1,375 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"quantum/internal/model"
+	"time"
+)
+
+var (
+	ErrAuditSessionNotOpen     = errors.New("audit session is not open")
+	ErrAuditSessionNotClosed   = errors.New("audit session is not closed")
+	ErrAuditCorrectionsApplied = errors.New("audit corrections have already been applied")
+)
+
+type AuditRepository interface {
+	Get(id uuid.UUID) (model.AuditSessionModel, error)
+	List(locationID *uuid.UUID, status *model.AuditStatus) ([]model.AuditSessionModel, error)
+	Create(session *model.AuditSessionModel) error
+	ListScans(sessionID uuid.UUID) ([]model.AuditScanModel, error)
+	AddScan(scan *model.AuditScanModel) error
+	DeleteScan(sessionID, itemID uuid.UUID) error
+	Close(id, closedByUserID uuid.UUID) error
+	ListResults(sessionID uuid.UUID) ([]model.AuditResultModel, error)
+	ApplyCorrections(id, appliedByUserID uuid.UUID, missingLocationID *uuid.UUID) (model.AuditCorrectionsModel, error)
+}
+
+// auditSessionQuery selects audit sessions joined to the name of their location, to be followed by a where clause.
+const auditSessionQuery = `
+	select s.*, l.name as location_name
+	from audit_sessions s
+	join locations l on s.location_id = l.id`
+
+type postgresAuditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) AuditRepository {
+	return &postgresAuditRepository{
+		db: db,
+	}
+}
+
+func (r *postgresAuditRepository) Get(id uuid.UUID) (model.AuditSessionModel, error) {
+	var session model.AuditSessionModel
+	if err := r.db.Get(&session, auditSessionQuery+" where s.id = $1;", id); err != nil {
+		return model.AuditSessionModel{}, err
+	}
+	return session, nil
+}
+
+// List lists the audit sessions, most recently started first, optionally narrowed to a location and a status.
+func (r *postgresAuditRepository) List(locationID *uuid.UUID, status *model.AuditStatus) ([]model.AuditSessionModel, error) {
+	stmt := auditSessionQuery + `
+		where ($1::uuid is null or s.location_id = $1)
+			and ($2::text is null or s.status = $2)
+		order by s.started_at desc, s.id;`
+
+	var sessions = make([]model.AuditSessionModel, 0)
+	if err := r.db.Select(&sessions, stmt, locationID, status); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *postgresAuditRepository) Create(session *model.AuditSessionModel) error {
+	stmt := `
+		insert into audit_sessions (location_id, include_children, started_by_user_id)
+		values ($1, $2, $3)
+		returning id, status, started_at;`
+
+	if err := r.db.Get(session, stmt, session.LocationID, session.IncludeChildren, session.StartedByUserID); err != nil {
+		return fmt.Errorf("failed to insert audit session: %w", err)
+	}
+	return nil
+}
+
+// ListScans lists the items scanned into the session in the order they were scanned.
+func (r *postgresAuditRepository) ListScans(sessionID uuid.UUID) ([]model.AuditScanModel, error) {
+	stmt := `
+		select a.*, l.name as location_name
+		from audit_scans a
+		join locations l on a.location_id = l.id
+		where a.session_id = $1
+		order by a.scanned_at, a.id;`
+
+	var scans = make([]model.AuditScanModel, 0)
+	if err := r.db.Select(&scans, stmt, sessionID); err != nil {
+		return nil, err
+	}
+	return scans, nil
+}
+
+// AddScan records the item as found in the session. Scanning an item again replaces where it was found.
+// Returns ErrAuditSessionNotOpen if the session has been closed.
+func (r *postgresAuditRepository) AddScan(scan *model.AuditScanModel) (err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = lockOpenAuditSession(tx, scan.SessionID); err != nil {
+		return err
+	}
+
+	stmt := `
+		insert into audit_scans (session_id, item_id, item_reference, item_identifier, location_id, code, scanned_by_user_id)
+		values ($1, $2, $3, $4, $5, $6, $7)
+		on conflict on constraint audit_scans_session_item do update
+		set location_id = excluded.location_id,
+			code = excluded.code,
+			scanned_by_user_id = excluded.scanned_by_user_id,
+			scanned_at = now()
+		returning id, scanned_at;`
+
+	args := []any{scan.SessionID, scan.ItemID, scan.ItemReference, scan.ItemIdentifier, scan.LocationID, scan.Code, scan.ScannedByUserID}
+	if err = tx.Get(scan, stmt, args...); err != nil {
+		return fmt.Errorf("failed to insert audit scan: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// DeleteScan removes an item scanned into the session by mistake.
+// Returns sql.ErrNoRows if the item was not scanned and ErrAuditSessionNotOpen if the session has been closed.
+func (r *postgresAuditRepository) DeleteScan(sessionID, itemID uuid.UUID) (err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = lockOpenAuditSession(tx, sessionID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("delete from audit_scans where session_id = $1 and item_id = $2;", sessionID, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to delete audit scan: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		err = sql.ErrNoRows
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Close closes the session and writes its reconciliation, comparing the items scanned into the session with the
+// items recorded at the audited locations in items_with_current_location.
+// Returns ErrAuditSessionNotOpen if the session has already been closed.
+func (r *postgresAuditRepository) Close(id, closedByUserID uuid.UUID) (err error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = lockOpenAuditSession(tx, id); err != nil {
+		return err
+	}
+
+	var session model.AuditSessionModel
+	if err = tx.Get(&session, auditSessionQuery+" where s.id = $1;", id); err != nil {
+		return fmt.Errorf("failed to get audit session: %w", err)
+	}
+
+	scope := "select $2::uuid"
+	if session.IncludeChildren {
+		scope = locationSubtreeQuery("$2")
+	}
+
+	stmt := fmt.Sprintf(`
+		with expected as (
+			select id as item_id, location_id, location_name
+			from items_with_current_location
+			where deleted = false
+				and kind = 'unit'
+				and location_id in (%s)
+		), found as (
+			select item_id, location_id
+			from audit_scans
+			where session_id = $1
+				and item_id is not null
+		)
+		insert into audit_results (
+			session_id, item_id, item_reference, item_identifier, status,
+			expected_location_id, expected_location_name, found_location_id
+		)
+		select
+			$1,
+			i.id,
+			i.reference,
+			i.identifier,
+			case
+				when e.item_id is null then 'unexpected'
+				when f.item_id is null then 'missing'
+				else 'found'
+			end,
+			coalesce(e.location_id, c.location_id),
+			coalesce(e.location_name, c.location_name),
+			f.location_id
+		from expected e
+		full join found f on e.item_id = f.item_id
+		join items i on i.id = coalesce(e.item_id, f.item_id)
+		left join items_with_current_location c on e.item_id is null and c.id = f.item_id;`, scope)
+
+	if _, err = tx.Exec(stmt, id, session.LocationID); err != nil {
+		return fmt.Errorf("failed to insert audit results: %w", err)
+	}
+
+	stmt = `
+		update audit_sessions
+		set status = 'closed', closed_by_user_id = $2, closed_at = now()
+		where id = $1;`
+
+	if _, err = tx.Exec(stmt, id, closedByUserID); err != nil {
+		return fmt.Errorf("failed to close audit session: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ListResults lists the reconciliation of a closed session, grouped by status.
+func (r *postgresAuditRepository) ListResults(sessionID uuid.UUID) ([]model.AuditResultModel, error) {
+	stmt := `
+		select a.*, l.name as found_location_name
+		from audit_results a
+		left join locations l on a.found_location_id = l.id
+		where a.session_id = $1
+		order by a.status, a.item_reference;`
+
+	var results = make([]model.AuditResultModel, 0)
+	if err := r.db.Select(&results, stmt, sessionID); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ApplyCorrections tracks each item found somewhere other than where it was recorded to the location it was found at.
+// Missing items are tracked to missingLocationID when it is given and are left where they are otherwise.
+// Items deleted, retired or disposed since the session closed are skipped, as are items tracked since it closed,
+// which are counted as stale rather than moved back to where the audit found them.
+// Returns ErrAuditSessionNotClosed if the session is still open and ErrAuditCorrectionsApplied if this has been done.
+func (r *postgresAuditRepository) ApplyCorrections(id, appliedByUserID uuid.UUID, missingLocationID *uuid.UUID) (model.AuditCorrectionsModel, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return model.AuditCorrectionsModel{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var session struct {
+		Status    model.AuditStatus `db:"status"`
+		ClosedAt  *time.Time        `db:"closed_at"`
+		AppliedAt *time.Time        `db:"applied_at"`
+	}
+	if err = tx.Get(&session, "select status, closed_at, applied_at from audit_sessions where id = $1 for update;", id); err != nil {
+		return model.AuditCorrectionsModel{}, err
+	}
+	if session.Status != model.AuditStatusClosed {
+		err = ErrAuditSessionNotClosed
+		return model.AuditCorrectionsModel{}, err
+	}
+	if session.AppliedAt != nil {
+		err = ErrAuditCorrectionsApplied
+		return model.AuditCorrectionsModel{}, err
+	}
+
+	var results []struct {
+		model.AuditResultModel
+		// Stale is true if the item has been tracked since the session closed.
+		Stale bool `db:"stale"`
+	}
+	stmt := `
+		select a.*, coalesce(cl.tracked_at > $2, false) as stale
+		from audit_results a
+		join items i on a.item_id = i.id
+		left join item_current_location cl on a.item_id = cl.item_id
+		where a.session_id = $1
+			and i.deleted = false
+			and i.status not in ($3, $4)
+		for update of i;`
+
+	if err = tx.Select(&results, stmt, id, session.ClosedAt, model.ItemStatusRetired, model.ItemStatusDisposed); err != nil {
+		return model.AuditCorrectionsModel{}, fmt.Errorf("failed to list audit results: %w", err)
+	}
+
+	var corrections model.AuditCorrectionsModel
+	corrected := make([]uuid.UUID, 0, len(results))
+	for _, result := range results {
+		var locationID uuid.UUID
+		switch {
+		case result.NeedsCorrection():
+			locationID = *result.FoundLocationID
+		case result.Status == model.AuditResultMissing && missingLocationID != nil:
+			locationID = *missingLocationID
+		default:
+			continue
+		}
+
+		if result.Stale {
+			corrections.Stale++
+			continue
+		}
+
+		var data json.RawMessage
+		if data, err = itemTrackedHistoryData(locationID); err != nil {
+			return model.AuditCorrectionsModel{}, err
+		}
+		if err = insertItemHistoryRecord(tx, appliedByUserID, *result.ItemID, data); err != nil {
+			return model.AuditCorrectionsModel{}, fmt.Errorf("failed to insert item history record: %w", err)
+		}
+		corrected = append(corrected, *result.ItemID)
+	}
+	corrections.Tracked = len(corrected)
+
+	stmt = "update audit_results set applied = true where session_id = $1 and item_id = any($2);"
+	if _, err = tx.Exec(stmt, id, pq.Array(corrected)); err != nil {
+		return model.AuditCorrectionsModel{}, fmt.Errorf("failed to mark audit results applied: %w", err)
+	}
+
+	stmt = "update audit_sessions set applied_by_user_id = $2, applied_at = now() where id = $1;"
+	if _, err = tx.Exec(stmt, id, appliedByUserID); err != nil {
+		return model.AuditCorrectionsModel{}, fmt.Errorf("failed to mark audit session applied: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.AuditCorrectionsModel{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return corrections, nil
+}
+
+// lockOpenAuditSession locks the session row for the rest of the transaction so scans cannot race with closing it.
+// Returns sql.ErrNoRows if the session does not exist and ErrAuditSessionNotOpen if it has been closed.
+func lockOpenAuditSession(tx *sqlx.Tx, id uuid.UUID) error {
+	var status model.AuditStatus
+	if err := tx.Get(&status, "select status from audit_sessions where id = $1 for update;", id); err != nil {
+		return err
+	}
+	if status != model.AuditStatusOpen {
+		return ErrAuditSessionNotOpen
+	}
+	return nil
+}
@@ -1,25 +1,201 @@
 package repository
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"quantum/internal/model"
+	"quantum/internal/types/pagination"
+	"slices"
+	"strconv"
+	"time"
 )
 
+var (
+	ErrItemReferenceExists = errors.New("item reference already exists")
+	ErrContainmentCycle    = errors.New("item cannot be put inside itself or an item inside it")
+	ErrItemStatusConflict  = errors.New("item status was changed by another request")
+)
+
+// ItemHistoryChannel is the Postgres notification channel the ID of each new item history record is sent on.
+const ItemHistoryChannel = "item_history"
+
+// ItemToCreate is an item to be created along with the location it is created at.
+type ItemToCreate struct {
+	Item       *model.ItemModel
+	LocationID uuid.UUID
+}
+
+// ItemHistoryFilter filters the history of all items. Nil and empty fields do not filter.
+type ItemHistoryFilter struct {
+	Types []model.ItemHistoryType
+	// UserID is the user who made the change.
+	UserID *uuid.UUID
+	// TargetID is the location or user an item was created at or tracked to.
+	TargetID *uuid.UUID
+	GroupKey *string
+	From     *time.Time
+	To       *time.Time
+}
+
 type ItemRepository interface {
 	Get(id uuid.UUID) (model.ItemModel, error)
 	GetWithCurrentLocation(id uuid.UUID) (model.ItemWithCurrentLocationModel, error)
-	GetItemHistory(itemID uuid.UUID) ([]model.ItemHistoryModel, error)
-	List(groupKey *string) ([]model.ItemWithCurrentLocationModel, error)
-	ListByLocationID(locationID uuid.UUID) ([]model.ItemWithCurrentLocationModel, error)
+	GetWithLocationAt(id uuid.UUID, at time.Time) (model.ItemWithCurrentLocationModel, error)
+	GetItemHistory(itemID uuid.UUID) ([]model.ItemHistoryDetailModel, error)
+	ListItemHistory(itemID uuid.UUID, before *time.Time, page pagination.Params) (pagination.Page[model.ItemHistoryDetailModel], error)
+	ListHistory(filter ItemHistoryFilter, page pagination.Params) (pagination.Page[model.ItemHistoryDetailModel], error)
+	ListItemEventsAfter(after model.ItemEventCursor, limit int) ([]model.ItemEventModel, error)
+	GetItemEventCursor(historyID int64) (model.ItemEventCursor, error)
+	GetLatestItemEventCursor() (model.ItemEventCursor, error)
+	ListByIDsOrReferences(ids []uuid.UUID, references []string) ([]model.ItemModel, error)
+	ListByCodes(codes []string) ([]model.ItemModel, error)
+	List(groupKey *string, status *model.ItemStatus, customFields map[string]string, page pagination.Params) (pagination.Page[model.ItemWithCurrentLocationModel], error)
+	ListByLocationID(locationID uuid.UUID, recursive bool, status *model.ItemStatus, page pagination.Params) (pagination.Page[model.ItemWithCurrentLocationModel], error)
+	ListByLocationIDAt(locationID uuid.UUID, recursive bool, at time.Time, page pagination.Params) (pagination.Page[model.ItemWithCurrentLocationModel], error)
+	ListLocationDiff(locationID uuid.UUID, from, to time.Time) ([]model.LocationDiffModel, error)
 	ListItemGroups(max int, filter string) ([]string, error)
 	GroupKeyExists(groupKey string) (bool, error)
+	ListGroupCustomFields() ([]model.GroupCustomFieldsModel, error)
+	GetGroupCustomFields(groupKey string) (model.GroupCustomFieldsModel, error)
+	SetGroupCustomFields(fields *model.GroupCustomFieldsModel) error
+	DeleteGroupCustomFields(groupKey string) error
 	Create(item *model.ItemModel, createdByUserID, createdAtLocationID uuid.UUID) error
+	CreateBatch(items []ItemToCreate, createdByUserID uuid.UUID) error
+	ListExistingReferences(references []string) ([]string, error)
+	Update(item *model.ItemModel, updatedByUserID uuid.UUID) error
 	Delete(itemID, userID uuid.UUID) error
-	AppendNewItemTrackedToLocationHistory(userID, itemID, locationID uuid.UUID) error
-	AppendNewItemTrackedToUserHistory(trackingUser, toUserID, itemID uuid.UUID) error
+	Restore(itemID, userID uuid.UUID) error
+	ListDeleted(deletedByUserID *uuid.UUID, deletedFrom, deletedTo *time.Time, page pagination.Params) (pagination.Page[model.DeletedItemModel], error)
+	PurgeDeleted(deletedBefore time.Time) (int64, error)
+	AppendNewItemTrackedToLocationHistory(userID uuid.UUID, itemIDs []uuid.UUID, locationID uuid.UUID) error
+	AppendNewItemTrackedToUserHistory(trackingUser, toUserID uuid.UUID, itemIDs []uuid.UUID, dueAt *time.Time) error
+	GetLastLocationID(itemID uuid.UUID) (uuid.UUID, error)
+	ListLoans(userID *uuid.UUID, dueBefore *time.Time) ([]model.LoanModel, error)
+	RebuildCurrentLocations() (int64, error)
+	GetContainer(itemID uuid.UUID) (model.ItemModel, error)
+	ListContents(containerID uuid.UUID) ([]model.ItemModel, error)
+	SetContainer(itemID, containerID, userID uuid.UUID) error
+	RemoveFromContainer(itemID, userID uuid.UUID) error
+	ChangeStatus(itemID, userID uuid.UUID, from, to model.ItemStatus, reason string) (model.ItemModel, error)
+}
+
+// currentLocationColumns projects an item_history row onto the columns of the item_current_location table.
+const currentLocationColumns = `
+	item_id,
+	(data->>'type')::text,
+	case (data->>'type')::text
+		when 'tracked-user' then (data->'data'->>'userId')::uuid
+		else (data->'data'->>'locationId')::uuid
+	end,
+	created_at`
+
+// itemsWithLocationAtQuery returns a query selecting the same rows as the items_with_current_location view,
+// but as they were at the time given by the atArg placeholder, for example "$1".
+// The location of each item is taken from its latest created, tracked or tracked-user history record at that time,
+// and items that had been deleted, and not since restored, at that time are excluded.
+func itemsWithLocationAtQuery(atArg string) string {
+	return fmt.Sprintf(`
+	select
+		i.*,
+		ih.location_id as location_id,
+		case ih.type
+			when 'tracked' then l.name
+			when 'created' then l.name
+			when 'tracked-user' then u.name
+		end as location_name,
+		case ih.type
+			when 'tracked' then l.description
+			when 'created' then l.description
+			when 'tracked-user' then u.username
+		end as location_description,
+		ih.created_at as tracked_at,
+		ih.type = 'tracked-user' as tracked_to_user
+	from (
+		select distinct on (item_id)
+			item_id,
+			(data->>'type')::text as type,
+			case (data->>'type')::text
+				when 'tracked' then (data->'data'->>'locationId')::uuid
+				when 'created' then (data->'data'->>'locationId')::uuid
+				when 'tracked-user' then (data->'data'->>'userId')::uuid
+			end as location_id,
+			created_at
+		from item_history
+		where (data->>'type') in ('created', 'tracked', 'tracked-user')
+			and created_at <= %[1]s
+		order by item_id, created_at desc, id desc
+	) ih
+		join items i on ih.item_id = i.id
+		left join lateral (
+			select (data->>'type')::text as type
+			from item_history
+			where item_id = i.id
+				and (data->>'type') in ('deleted', 'restored')
+				and created_at <= %[1]s
+			order by created_at desc, id desc
+			limit 1
+		) dh on true
+		left join locations l
+			on ih.location_id = l.id and ih.type in ('tracked', 'created')
+		left join users u
+			on ih.location_id = u.id and ih.type = 'tracked-user'
+	where dh.type is distinct from 'deleted'`, atArg)
+}
+
+// itemsKeyset is the sorting and paging of lists of items with their current location.
+var itemsKeyset = pagination.Keyset[model.ItemWithCurrentLocationModel]{
+	Name: "items",
+	Fields: map[string]pagination.SortField[model.ItemWithCurrentLocationModel]{
+		"reference": {
+			Column: "reference",
+			Type:   "text",
+			Value:  func(i model.ItemWithCurrentLocationModel) string { return i.Reference },
+		},
+		"groupKey": {
+			Column: "group_key",
+			Type:   "text",
+			Value:  func(i model.ItemWithCurrentLocationModel) string { return i.GroupKey },
+		},
+		"trackedAt": {
+			Column: "tracked_at",
+			Type:   "timestamptz",
+			Value:  func(i model.ItemWithCurrentLocationModel) string { return i.TrackedAt.Format(time.RFC3339Nano) },
+		},
+		"createdAt": {
+			Column: "created_at",
+			Type:   "timestamptz",
+			Value:  func(i model.ItemWithCurrentLocationModel) string { return i.CreatedAt.Format(time.RFC3339Nano) },
+		},
+	},
+	DefaultSort: "reference",
+	IDColumn:    "id",
+	ID:          func(i model.ItemWithCurrentLocationModel) string { return i.ID.String() },
+}
+
+// deletedItemsKeyset is the sorting and paging of lists of deleted items, most recently deleted first by default.
+var deletedItemsKeyset = pagination.Keyset[model.DeletedItemModel]{
+	Name: "deleted-items",
+	Fields: map[string]pagination.SortField[model.DeletedItemModel]{
+		"deletedAt": {
+			Column: "deleted_at",
+			Type:   "timestamptz",
+			Value:  func(i model.DeletedItemModel) string { return i.DeletedAt.Format(time.RFC3339Nano) },
+		},
+		"reference": {
+			Column: "reference",
+			Type:   "text",
+			Value:  func(i model.DeletedItemModel) string { return i.Reference },
+		},
+	},
+	DefaultSort:      "deletedAt",
+	DefaultDirection: pagination.Descending,
+	IDColumn:         "id",
+	ID:               func(i model.DeletedItemModel) string { return i.ID.String() },
 }
 
 type postgresItemRepository struct {
@@ -50,18 +226,77 @@ func (r *postgresItemRepository) GetWithCurrentLocation(id uuid.UUID) (model.Ite
 	return item, nil
 }
 
-func (r *postgresItemRepository) List(groupKey *string) ([]model.ItemWithCurrentLocationModel, error) {
-	stmt := `
+// GetWithLocationAt returns the item with the location or user it was tracked to at the given time.
+// Returns sql.ErrNoRows if the item had not been created, or was deleted, at that time.
+func (r *postgresItemRepository) GetWithLocationAt(id uuid.UUID, at time.Time) (model.ItemWithCurrentLocationModel, error) {
+	stmt := fmt.Sprintf("select * from (%s) items_at where id = $2;", itemsWithLocationAtQuery("$1"))
+
+	var item model.ItemWithCurrentLocationModel
+	if err := r.db.Get(&item, stmt, at, id); err != nil {
+		return model.ItemWithCurrentLocationModel{}, err
+	}
+	return item, nil
+}
+
+// ListByIDsOrReferences returns the items, including deleted items, matching any of the given IDs or references.
+func (r *postgresItemRepository) ListByIDsOrReferences(ids []uuid.UUID, references []string) ([]model.ItemModel, error) {
+	stmt := "select * from items where id = any($1) or reference = any($2);"
+
+	var items = make([]model.ItemModel, 0)
+	if err := r.db.Select(&items, stmt, pq.Array(ids), pq.Array(references)); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ListByCodes lists the items whose reference or identifier is one of the codes, including deleted items.
+func (r *postgresItemRepository) ListByCodes(codes []string) ([]model.ItemModel, error) {
+	stmt := "select * from items where reference = any($1) or identifier = any($1) order by deleted, created_at;"
+
+	var items = make([]model.ItemModel, 0)
+	if err := r.db.Select(&items, stmt, pq.Array(codes)); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// List lists the items that are not deleted, optionally only those in the group, with the status and whose custom
+// fields have the given values. Custom field values are compared as text, so a number field of 18 matches "18".
+func (r *postgresItemRepository) List(groupKey *string, status *model.ItemStatus, customFields map[string]string, page pagination.Params) (pagination.Page[model.ItemWithCurrentLocationModel], error) {
+	clause, err := itemsKeyset.Clause(page, 4)
+	if err != nil {
+		return pagination.Page[model.ItemWithCurrentLocationModel]{}, err
+	}
+
+	if customFields == nil {
+		customFields = make(map[string]string)
+	}
+	customFieldsFilter, err := json.Marshal(customFields)
+	if err != nil {
+		return pagination.Page[model.ItemWithCurrentLocationModel]{}, err
+	}
+
+	stmt := fmt.Sprintf(`
 		select * 
 		from items_with_current_location
 		where ($1::text is null or group_key = $1)
-			and deleted = false;`
+			and ($3::text is null or status = $3)
+			and deleted = false
+			and not exists (
+				select 1
+				from jsonb_each_text($2::jsonb) f
+				where custom_fields->>f.key is distinct from f.value
+			)
+			and %s
+		order by %s
+		%s;`, clause.Where, clause.OrderBy, clause.Limit)
 
+	args := append([]any{groupKey, customFieldsFilter, status}, clause.Args...)
 	var items = make([]model.ItemWithCurrentLocationModel, 0)
-	if err := r.db.Select(&items, stmt, groupKey); err != nil {
-		return nil, err
+	if err := r.db.Select(&items, stmt, args...); err != nil {
+		return pagination.Page[model.ItemWithCurrentLocationModel]{}, err
 	}
-	return items, nil
+	return itemsKeyset.Page(items, page), nil
 }
 
 func (r *postgresItemRepository) ListItemGroups(max int, filter string) ([]string, error) {
@@ -79,11 +314,138 @@ func (r *postgresItemRepository) ListItemGroups(max int, filter string) ([]strin
 	return groups, nil
 }
 
-func (r *postgresItemRepository) ListByLocationID(locationID uuid.UUID) ([]model.ItemWithCurrentLocationModel, error) {
-	stmt := "select * from items_with_current_location where location_id = $1 and deleted = false;"
+// locationCondition matches rows whose location_id is the location in arg or, when recursive, any location below it.
+func locationCondition(arg string, recursive bool) string {
+	if !recursive {
+		return "location_id = " + arg
+	}
+	return fmt.Sprintf("location_id in (%s)", locationSubtreeQuery(arg))
+}
+
+// ListByLocationID lists the items currently at the location.
+// When recursive, items at any location below it are included too.
+func (r *postgresItemRepository) ListByLocationID(locationID uuid.UUID, recursive bool, status *model.ItemStatus, page pagination.Params) (pagination.Page[model.ItemWithCurrentLocationModel], error) {
+	clause, err := itemsKeyset.Clause(page, 3)
+	if err != nil {
+		return pagination.Page[model.ItemWithCurrentLocationModel]{}, err
+	}
+
+	stmt := fmt.Sprintf(`
+		select *
+		from items_with_current_location
+		where %s
+			and ($2::text is null or status = $2)
+			and deleted = false
+			and %s
+		order by %s
+		%s;`, locationCondition("$1", recursive), clause.Where, clause.OrderBy, clause.Limit)
+
+	var items = make([]model.ItemWithCurrentLocationModel, 0)
+	if err := r.db.Select(&items, stmt, append([]any{locationID, status}, clause.Args...)...); err != nil {
+		return pagination.Page[model.ItemWithCurrentLocationModel]{}, err
+	}
+	return itemsKeyset.Page(items, page), nil
+}
+
+// ListByLocationIDAt lists the items that were at the given location at the given time.
+// When recursive, items at any location below it are included too, using the current location hierarchy.
+func (r *postgresItemRepository) ListByLocationIDAt(locationID uuid.UUID, recursive bool, at time.Time, page pagination.Params) (pagination.Page[model.ItemWithCurrentLocationModel], error) {
+	clause, err := itemsKeyset.Clause(page, 3)
+	if err != nil {
+		return pagination.Page[model.ItemWithCurrentLocationModel]{}, err
+	}
+
+	stmt := fmt.Sprintf(`
+		select *
+		from (%s) items_at
+		where %s
+			and %s
+		order by %s
+		%s;`, itemsWithLocationAtQuery("$1"), locationCondition("$2", recursive), clause.Where, clause.OrderBy, clause.Limit)
 
 	var items = make([]model.ItemWithCurrentLocationModel, 0)
-	if err := r.db.Select(&items, stmt, locationID); err != nil {
+	if err := r.db.Select(&items, stmt, append([]any{at, locationID}, clause.Args...)...); err != nil {
+		return pagination.Page[model.ItemWithCurrentLocationModel]{}, err
+	}
+	return itemsKeyset.Page(items, page), nil
+}
+
+// ListLocationDiff compares the items at the location at the from and to times.
+// Items at the location only at the to time arrived, items there only at the from time left and items at both stayed.
+// Arrived and stayed items carry the latest history record that placed them at the location, left items carry the
+// first history record after the from time that tracked them elsewhere or deleted them.
+func (r *postgresItemRepository) ListLocationDiff(locationID uuid.UUID, from, to time.Time) ([]model.LocationDiffModel, error) {
+	stmt := fmt.Sprintf(`
+		with items_before as (
+			select id from (%s) items_at where location_id = $3
+		), items_after as (
+			select id from (%s) items_at where location_id = $3
+		), diff as (
+			select a.id, case when b.id is null then 'arrived' else 'stayed' end as change
+			from items_after a
+			left join items_before b on a.id = b.id
+
+			union all
+
+			select b.id, 'left' as change
+			from items_before b
+			left join items_after a on a.id = b.id
+			where a.id is null
+		)
+		select
+			i.*,
+			d.change,
+			m.type as movement_type,
+			m.created_at as moved_at,
+			m.user_id as moved_by_user_id,
+			u.name as moved_by_user_name,
+			u.username as moved_by_user_username,
+			m.destination_id,
+			coalesce(dl.name, du.name) as destination_name
+		from diff d
+		join items i on d.id = i.id
+		join lateral (
+			(
+				select (h.data->>'type')::text as type, h.created_at, h.user_id, null::uuid as destination_id
+				from item_history h
+				where d.change <> 'left'
+					and h.item_id = d.id
+					and (h.data->>'type') in ('created', 'tracked')
+					and (h.data->'data'->>'locationId')::uuid = $3
+					and h.created_at <= $2
+				order by h.created_at desc, h.id desc
+				limit 1
+			)
+			union all
+			(
+				select
+					(h.data->>'type')::text as type,
+					h.created_at,
+					h.user_id,
+					case (h.data->>'type')::text
+						when 'tracked' then (h.data->'data'->>'locationId')::uuid
+						when 'tracked-user' then (h.data->'data'->>'userId')::uuid
+					end as destination_id
+				from item_history h
+				where d.change = 'left'
+					and h.item_id = d.id
+					and h.created_at > $1
+					and h.created_at <= $2
+					and (
+						(h.data->>'type') in ('deleted', 'tracked-user')
+						or ((h.data->>'type') = 'tracked' and (h.data->'data'->>'locationId')::uuid <> $3)
+					)
+				order by h.created_at, h.id
+				limit 1
+			)
+		) m on true
+		join users u on m.user_id = u.id
+		left join locations dl on m.type = 'tracked' and m.destination_id = dl.id
+		left join users du on m.type = 'tracked-user' and m.destination_id = du.id
+		order by d.change, i.reference;`, itemsWithLocationAtQuery("$1"), itemsWithLocationAtQuery("$2"))
+
+	var items = make([]model.LocationDiffModel, 0)
+	if err := r.db.Select(&items, stmt, from, to, locationID); err != nil {
 		return nil, err
 	}
 	return items, nil
@@ -98,12 +460,55 @@ func (r *postgresItemRepository) GroupKeyExists(groupKey string) (bool, error) {
 	return exists, nil
 }
 
-func (r *postgresItemRepository) Create(item *model.ItemModel, createdByUserID, createdAtLocationID uuid.UUID) error {
+// ListGroupCustomFields lists the custom field definitions of every group that has them, ordered by group key.
+func (r *postgresItemRepository) ListGroupCustomFields() ([]model.GroupCustomFieldsModel, error) {
+	var fields = make([]model.GroupCustomFieldsModel, 0)
+	if err := r.db.Select(&fields, "select * from group_custom_fields order by group_key;"); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// GetGroupCustomFields returns the custom field definitions of the group, or sql.ErrNoRows if it has none.
+func (r *postgresItemRepository) GetGroupCustomFields(groupKey string) (model.GroupCustomFieldsModel, error) {
+	var fields model.GroupCustomFieldsModel
+	if err := r.db.Get(&fields, "select * from group_custom_fields where group_key = $1;", groupKey); err != nil {
+		return model.GroupCustomFieldsModel{}, err
+	}
+	return fields, nil
+}
+
+// SetGroupCustomFields creates or replaces the custom field definitions of the group.
+// The custom fields of the items already in the group are left as they are.
+func (r *postgresItemRepository) SetGroupCustomFields(fields *model.GroupCustomFieldsModel) error {
 	stmt := `
-		insert into items (identifier, reference, description, group_key) 
-		values ($1, $2, $3, $4)
-		returning id, created_at, updated_at;`
+		insert into group_custom_fields (group_key, fields)
+		values ($1, $2)
+		on conflict (group_key) do update
+		set fields = excluded.fields,
+			updated_at = now()
+		returning *;`
+
+	if err := r.db.Get(fields, stmt, fields.GroupKey, fields.Fields); err != nil {
+		return fmt.Errorf("failed to set group custom fields: %w", err)
+	}
+	return nil
+}
 
+// DeleteGroupCustomFields removes the custom field definitions of the group.
+// Returns sql.ErrNoRows if the group has none.
+func (r *postgresItemRepository) DeleteGroupCustomFields(groupKey string) error {
+	result, err := r.db.Exec("delete from group_custom_fields where group_key = $1;", groupKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete group custom fields: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *postgresItemRepository) Create(item *model.ItemModel, createdByUserID, createdAtLocationID uuid.UUID) error {
 	tx, err := r.db.Beginx()
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
@@ -114,12 +519,8 @@ func (r *postgresItemRepository) Create(item *model.ItemModel, createdByUserID,
 		}
 	}()
 
-	if err = tx.Get(item, stmt, item.Identifier, item.Reference, item.Description, item.GroupKey); err != nil {
-		return fmt.Errorf("failed to insert item: %w", err)
-	}
-
-	if err = r.updateHistoryOnItemCreation(tx, createdByUserID, createdAtLocationID, *item); err != nil {
-		return fmt.Errorf("failed to update history: %w", err)
+	if err = r.insertItem(tx, item, createdByUserID, createdAtLocationID); err != nil {
+		return err
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -129,9 +530,9 @@ func (r *postgresItemRepository) Create(item *model.ItemModel, createdByUserID,
 	return nil
 }
 
-func (r *postgresItemRepository) Delete(itemID, userID uuid.UUID) error {
-	stmt := "update items set deleted = true where id = $1;"
-
+// CreateBatch creates all the given items, and their created history records, in a single transaction.
+// If any item fails to insert, none of the items are created.
+func (r *postgresItemRepository) CreateBatch(items []ItemToCreate, createdByUserID uuid.UUID) error {
 	tx, err := r.db.Beginx()
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
@@ -142,13 +543,10 @@ func (r *postgresItemRepository) Delete(itemID, userID uuid.UUID) error {
 		}
 	}()
 
-	_, err = tx.Exec(stmt, itemID)
-	if err != nil {
-		return fmt.Errorf("failed to delete item: %w", err)
-	}
-
-	if err = r.updateHistoryOnItemDeletion(tx, userID, itemID); err != nil {
-		return fmt.Errorf("failed to update history: %w", err)
+	for _, item := range items {
+		if err = r.insertItem(tx, item.Item, createdByUserID, item.LocationID); err != nil {
+			return err
+		}
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -158,144 +556,1207 @@ func (r *postgresItemRepository) Delete(itemID, userID uuid.UUID) error {
 	return nil
 }
 
-func (r *postgresItemRepository) GetItemHistory(itemID uuid.UUID) ([]model.ItemHistoryModel, error) {
-	stmt := `
-		select *
-		from item_history
-		where item_id = $1
-		order by created_at desc;`
+// ListExistingReferences returns the subset of the given references that are already in use, including by deleted items.
+func (r *postgresItemRepository) ListExistingReferences(references []string) ([]string, error) {
+	stmt := "select reference from items where reference = any($1);"
 
-	var histories = make([]model.ItemHistoryModel, 0)
-	if err := r.db.Select(&histories, stmt, itemID); err != nil {
+	var existing = make([]string, 0)
+	if err := r.db.Select(&existing, stmt, pq.Array(references)); err != nil {
 		return nil, err
 	}
-	return histories, nil
+	return existing, nil
 }
 
-func (r *postgresItemRepository) AppendNewItemTrackedToLocationHistory(userID, itemID, locationID uuid.UUID) error {
-	historyData := model.ItemTrackedHistoryData{
-		LocationID: locationID,
-	}
+// Update updates the identifier, reference, group key, description and custom fields of the given item.
+// The old and new values of each changed field are recorded in an updated history record within the same transaction.
+// If nothing has changed, the item is left untouched and no history is recorded.
+func (r *postgresItemRepository) Update(item *model.ItemModel, updatedByUserID uuid.UUID) error {
+	selectStmt := "select * from items where id = $1 for update;"
+	updateStmt := `
+		update items
+		set identifier = $1, reference = $2, group_key = $3, description = $4, custom_fields = $5, updated_at = now()
+		where id = $6
+		returning *;`
 
-	jsonData, err := json.Marshal(historyData)
+	tx, err := r.db.Beginx()
 	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var existing model.ItemModel
+	if err = tx.Get(&existing, selectStmt, item.ID); err != nil {
 		return err
 	}
 
-	history := model.HistoryDataContainer{
-		Type: model.ItemHistoryTypeTracked,
-		Data: jsonData,
+	if len(item.CustomFields) == 0 {
+		item.CustomFields = existing.CustomFields
 	}
 
-	jsonHistoryData, err := json.Marshal(history)
+	changes, err := diffItemFields(existing, *item)
 	if err != nil {
 		return err
 	}
+	if len(changes) == 0 {
+		*item = existing
+		err = tx.Commit()
+		return err
+	}
 
-	stmt := `
-		insert into item_history (user_id, item_id, data)
-		values ($1, $2, $3);`
+	if err = tx.Get(item, updateStmt, item.Identifier, item.Reference, item.GroupKey, item.Description, item.CustomFields, item.ID); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" && pqErr.Constraint == "items_reference_key" {
+			return ErrItemReferenceExists
+		}
+		return fmt.Errorf("failed to update item: %w", err)
+	}
 
-	_, err = r.db.Exec(stmt, userID, itemID, jsonHistoryData)
-	if err != nil {
-		return err
+	if err = r.updateHistoryOnItemUpdate(tx, updatedByUserID, item.ID, changes); err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+
 	return nil
 }
 
-func (r *postgresItemRepository) AppendNewItemTrackedToUserHistory(trackingUser, toUserID, itemID uuid.UUID) error {
-	historyData := model.ItemTrackedUserHistoryData{
-		UserID: toUserID,
-	}
+func (r *postgresItemRepository) Delete(itemID, userID uuid.UUID) error {
+	stmt := "update items set deleted = true where id = $1;"
 
-	jsonData, err := json.Marshal(historyData)
+	tx, err := r.db.Beginx()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to start transaction: %w", err)
 	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
 
-	history := model.HistoryDataContainer{
-		Type: model.ItemHistoryTypeTrackedUser,
-		Data: jsonData,
+	_, err = tx.Exec(stmt, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to delete item: %w", err)
 	}
 
-	jsonHistoryData, err := json.Marshal(history)
-	if err != nil {
+	// A deleted item is taken out of its container and anything inside it is taken out of it.
+	containmentStmt := `
+		delete from item_containment
+		where item_id = $1 or container_id = $1
+		returning item_id, container_id;`
+
+	if err = removeContainments(tx, userID, containmentStmt, itemID); err != nil {
 		return err
 	}
 
-	stmt := `
-		insert into item_history (user_id, item_id, data)
-		values ($1, $2, $3);`
+	if err = r.updateHistoryOnItemDeletion(tx, userID, itemID); err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
 
-	_, err = r.db.Exec(stmt, trackingUser, itemID, jsonHistoryData)
-	if err != nil {
-		return err
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
+
 	return nil
 }
 
-func (r *postgresItemRepository) insertHistoryRecord(tx *sqlx.Tx, userID, itemID uuid.UUID, data json.RawMessage) error {
-	stmt := `
-		insert into item_history (user_id, item_id, data)
-		values ($1, $2, $3);`
+func (r *postgresItemRepository) Restore(itemID, userID uuid.UUID) error {
+	stmt := "update items set deleted = false where id = $1 and deleted = true;"
 
-	_, err := tx.Exec(stmt, userID, itemID, data)
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	result, err := tx.Exec(stmt, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to restore item: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
+		return fmt.Errorf("failed to restore item: %w", err)
+	}
+	if rowsAffected == 0 {
+		err = sql.ErrNoRows
 		return err
 	}
 
+	if err = r.updateHistoryOnItemRestoration(tx, userID, itemID); err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
-func (r *postgresItemRepository) updateHistoryOnItemCreation(tx *sqlx.Tx, userID, locationID uuid.UUID, item model.ItemModel) error {
-	historyData := model.ItemCreatedHistoryData{
-		Reference:   item.Reference,
-		GroupKey:    item.GroupKey,
-		Description: item.Description,
-		LocationID:  locationID,
+// ListDeleted lists the soft-deleted items, most recently deleted first unless another sort is requested.
+// The deletion details are taken from the latest deleted history record of each item and can optionally
+// be filtered by the user who deleted the item and the time range in which it was deleted.
+func (r *postgresItemRepository) ListDeleted(deletedByUserID *uuid.UUID, deletedFrom, deletedTo *time.Time, page pagination.Params) (pagination.Page[model.DeletedItemModel], error) {
+	clause, err := deletedItemsKeyset.Clause(page, 4)
+	if err != nil {
+		return pagination.Page[model.DeletedItemModel]{}, err
 	}
 
-	jsonData, err := json.Marshal(historyData)
+	stmt := fmt.Sprintf(`
+		with deleted_items as (
+			select
+				i.*,
+				dh.user_id as deleted_by_user_id,
+				u.name as deleted_by_user_name,
+				u.username as deleted_by_user_username,
+				dh.created_at as deleted_at
+			from items i
+			join lateral (
+				select user_id, created_at
+				from item_history
+				where item_id = i.id
+					and (data->>'type') = 'deleted'
+				order by created_at desc, id desc
+				limit 1
+			) dh on true
+			join users u on dh.user_id = u.id
+			where i.deleted = true
+				and ($1::uuid is null or dh.user_id = $1)
+				and ($2::timestamptz is null or dh.created_at >= $2)
+				and ($3::timestamptz is null or dh.created_at < $3)
+		)
+		select *
+		from deleted_items
+		where %s
+		order by %s
+		%s;`, clause.Where, clause.OrderBy, clause.Limit)
+
+	var items = make([]model.DeletedItemModel, 0)
+	args := append([]any{deletedByUserID, deletedFrom, deletedTo}, clause.Args...)
+	if err := r.db.Select(&items, stmt, args...); err != nil {
+		return pagination.Page[model.DeletedItemModel]{}, err
+	}
+	return deletedItemsKeyset.Page(items, page), nil
+}
+
+// PurgeDeleted permanently removes the items, and their history, that were soft-deleted before the given time.
+// Returns the number of items removed.
+func (r *postgresItemRepository) PurgeDeleted(deletedBefore time.Time) (int64, error) {
+	selectStmt := `
+		select i.id
+		from items i
+		join lateral (
+			select created_at
+			from item_history
+			where item_id = i.id
+				and (data->>'type') = 'deleted'
+			order by created_at desc, id desc
+			limit 1
+		) dh on true
+		where i.deleted = true
+			and dh.created_at < $1
+		for update of i;`
+
+	deleteHistoryStmt := "delete from item_history where item_id = any($1);"
+	deleteItemsStmt := "delete from items where id = any($1);"
+
+	tx, err := r.db.Beginx()
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var itemIDs []uuid.UUID
+	if err = tx.Select(&itemIDs, selectStmt, deletedBefore); err != nil {
+		return 0, fmt.Errorf("failed to select items to purge: %w", err)
 	}
 
-	history := model.HistoryDataContainer{
-		Type: model.ItemHistoryTypeCreated,
-		Data: jsonData,
+	if len(itemIDs) == 0 {
+		err = tx.Commit()
+		return 0, err
 	}
 
-	jsonHistoryData, err := json.Marshal(history)
+	if _, err = tx.Exec(deleteHistoryStmt, pq.Array(itemIDs)); err != nil {
+		return 0, fmt.Errorf("failed to purge item history: %w", err)
+	}
+
+	result, err := tx.Exec(deleteItemsStmt, pq.Array(itemIDs))
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("failed to purge items: %w", err)
 	}
 
-	if err := r.insertHistoryRecord(tx, userID, item.ID, jsonHistoryData); err != nil {
-		return err
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge items: %w", err)
 	}
 
-	return nil
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return purged, nil
 }
 
-func (r *postgresItemRepository) updateHistoryOnItemDeletion(tx *sqlx.Tx, userID, itemID uuid.UUID) error {
-	emptyJSONObject, err := json.Marshal(struct{}{})
+// itemHistoryDetailQuery selects item history records joined to the item, users and location each record refers to.
+const itemHistoryDetailQuery = `
+	select
+		h.*,
+		i.reference as item_reference,
+		i.status as item_status,
+		u.name as user_name,
+		u.username as user_username,
+		l.name as location_name,
+		tu.name as tracked_to_user_name,
+		tu.username as tracked_to_user_username,
+		ci.reference as container_reference
+	from item_history h
+	left join items i on h.item_id = i.id
+	left join users u on h.user_id = u.id
+	left join locations l
+		on (h.data->>'type') in ('created', 'tracked', 'stock-received', 'stock-issued', 'stock-transferred', 'stock-adjusted')
+		and l.id = (h.data->'data'->>'locationId')::uuid
+	left join users tu
+		on (h.data->>'type') = 'tracked-user'
+		and tu.id = (h.data->'data'->>'userId')::uuid
+	left join items ci
+		on (h.data->>'type') in ('contained', 'uncontained')
+		and ci.id = (h.data->'data'->>'containerId')::uuid`
+
+// itemHistoryKeyset is the sorting and paging of item history, newest first.
+var itemHistoryKeyset = pagination.Keyset[model.ItemHistoryDetailModel]{
+	Name: "item-history",
+	Fields: map[string]pagination.SortField[model.ItemHistoryDetailModel]{
+		"date": {
+			Column: "created_at",
+			Type:   "timestamptz",
+			Value:  func(h model.ItemHistoryDetailModel) string { return h.CreatedAt.Format(time.RFC3339Nano) },
+		},
+	},
+	DefaultSort:      "date",
+	DefaultDirection: pagination.Descending,
+	IDColumn:         "id",
+	IDType:           "bigint",
+	ID:               func(h model.ItemHistoryDetailModel) string { return strconv.FormatInt(h.ID, 10) },
+}
+
+// GetItemHistory returns the history of the item, newest first, joined to the users and location each record refers to.
+func (r *postgresItemRepository) GetItemHistory(itemID uuid.UUID) ([]model.ItemHistoryDetailModel, error) {
+	stmt := itemHistoryDetailQuery + `
+		where h.item_id = $1
+		order by h.created_at desc, h.id desc;`
+
+	var histories = make([]model.ItemHistoryDetailModel, 0)
+	if err := r.db.Select(&histories, stmt, itemID); err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
+// ListItemHistory lists a page of the history of the item, newest first.
+// When before is given only the records created before that time are listed.
+func (r *postgresItemRepository) ListItemHistory(itemID uuid.UUID, before *time.Time, page pagination.Params) (pagination.Page[model.ItemHistoryDetailModel], error) {
+	clause, err := itemHistoryKeyset.Clause(page, 3)
 	if err != nil {
-		return err
+		return pagination.Page[model.ItemHistoryDetailModel]{}, err
 	}
 
-	history := model.HistoryDataContainer{
-		Type: model.ItemHistoryTypeDeleted,
-		Data: emptyJSONObject,
+	stmt := fmt.Sprintf(`
+		with history as (
+			%s
+			where h.item_id = $1
+				and ($2::timestamptz is null or h.created_at < $2)
+		)
+		select *
+		from history
+		where %s
+		order by %s
+		%s;`, itemHistoryDetailQuery, clause.Where, clause.OrderBy, clause.Limit)
+
+	var histories = make([]model.ItemHistoryDetailModel, 0)
+	args := append([]any{itemID, before}, clause.Args...)
+	if err := r.db.Select(&histories, stmt, args...); err != nil {
+		return pagination.Page[model.ItemHistoryDetailModel]{}, err
 	}
+	return itemHistoryKeyset.Page(histories, page), nil
+}
 
-	jsonHistoryData, err := json.Marshal(history)
+// ListHistory lists the history of all items matching the filter, newest first.
+func (r *postgresItemRepository) ListHistory(filter ItemHistoryFilter, page pagination.Params) (pagination.Page[model.ItemHistoryDetailModel], error) {
+	clause, err := itemHistoryKeyset.Clause(page, 7)
 	if err != nil {
-		return err
+		return pagination.Page[model.ItemHistoryDetailModel]{}, err
 	}
 
-	if err := r.insertHistoryRecord(tx, userID, itemID, jsonHistoryData); err != nil {
-		return err
+	stmt := fmt.Sprintf(`
+		with history as (
+			%s
+			where ($1::text[] is null or (h.data->>'type') = any($1))
+				and ($2::uuid is null or h.user_id = $2)
+				and ($3::text is null
+					or (h.data->'data'->>'locationId') = $3
+					or (h.data->'data'->>'userId') = $3)
+				and ($4::text is null or i.group_key = $4)
+				and ($5::timestamptz is null or h.created_at >= $5)
+				and ($6::timestamptz is null or h.created_at < $6)
+		)
+		select *
+		from history
+		where %s
+		order by %s
+		%s;`, itemHistoryDetailQuery, clause.Where, clause.OrderBy, clause.Limit)
+
+	var types []string
+	for _, t := range filter.Types {
+		types = append(types, string(t))
 	}
 
-	return nil
+	var targetID *string
+	if filter.TargetID != nil {
+		id := filter.TargetID.String()
+		targetID = &id
+	}
+
+	var histories = make([]model.ItemHistoryDetailModel, 0)
+	args := append([]any{pq.Array(types), filter.UserID, targetID, filter.GroupKey, filter.From, filter.To}, clause.Args...)
+	if err := r.db.Select(&histories, stmt, args...); err != nil {
+		return pagination.Page[model.ItemHistoryDetailModel]{}, err
+	}
+	return itemHistoryKeyset.Page(histories, page), nil
+}
+
+// itemEventQuery selects the created, tracked, tracked-user and deleted item history records as item events.
+// The location or user the item moved from is taken from the latest movement of the item before the record.
+const itemEventQuery = `
+	select
+		h.id,
+		(h.data->>'type')::text as type,
+		h.item_id,
+		i.reference as item_reference,
+		i.group_key,
+		h.user_id,
+		u.name as user_name,
+		case (h.data->>'type')::text
+			when 'tracked-user' then (h.data->'data'->>'userId')::uuid
+			when 'deleted' then null
+			else (h.data->'data'->>'locationId')::uuid
+		end as to_id,
+		prev.location_id as from_id,
+		h.created_at,
+		h.txid
+	from item_history h
+	join items i on h.item_id = i.id
+	left join users u on h.user_id = u.id
+	left join lateral (
+		select
+			case (p.data->>'type')::text
+				when 'tracked-user' then (p.data->'data'->>'userId')::uuid
+				else (p.data->'data'->>'locationId')::uuid
+			end as location_id
+		from item_history p
+		where p.item_id = h.item_id
+			and p.id < h.id
+			and (p.data->>'type') in ('created', 'tracked', 'tracked-user')
+		order by p.created_at desc, p.id desc
+		limit 1
+	) prev on true
+	where (h.data->>'type') in ('created', 'tracked', 'tracked-user', 'deleted')`
+
+// ListItemEventsAfter returns up to limit item events after the cursor, in cursor order.
+// Only the records of transactions older than every transaction still in progress are returned,
+// so no record can later be committed before the last event returned.
+func (r *postgresItemRepository) ListItemEventsAfter(after model.ItemEventCursor, limit int) ([]model.ItemEventModel, error) {
+	stmt := itemEventQuery + `
+		and (h.txid, h.id) > ($1::xid8, $2)
+		and h.txid < pg_snapshot_xmin(pg_current_snapshot())
+		order by h.txid, h.id
+		limit $3;`
+
+	var events = make([]model.ItemEventModel, 0)
+	if err := r.db.Select(&events, stmt, after.TxID, after.ID, limit); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// GetItemEventCursor returns the cursor of the history record with the ID. If the record no longer exists
+// the cursor of the closest earlier record is returned, or the start of the stream if there is none.
+func (r *postgresItemRepository) GetItemEventCursor(historyID int64) (model.ItemEventCursor, error) {
+	stmt := "select txid, id from item_history where id <= $1 order by id desc limit 1;"
+
+	var cursor model.ItemEventCursor
+	if err := r.db.QueryRowx(stmt, historyID).Scan(&cursor.TxID, &cursor.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ItemEventCursor{}, nil
+		}
+		return model.ItemEventCursor{}, err
+	}
+	return cursor, nil
+}
+
+// GetLatestItemEventCursor returns the cursor of the last history record that ListItemEventsAfter can return,
+// or the start of the stream if there is none.
+func (r *postgresItemRepository) GetLatestItemEventCursor() (model.ItemEventCursor, error) {
+	stmt := `
+		select txid, id
+		from item_history
+		where txid < pg_snapshot_xmin(pg_current_snapshot())
+		order by txid desc, id desc
+		limit 1;`
+
+	var cursor model.ItemEventCursor
+	if err := r.db.QueryRowx(stmt).Scan(&cursor.TxID, &cursor.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ItemEventCursor{}, nil
+		}
+		return model.ItemEventCursor{}, err
+	}
+	return cursor, nil
+}
+
+// AppendNewItemTrackedToLocationHistory tracks each of the given items to the location.
+// The history records for all items are inserted in a single transaction, either all items are tracked or none are.
+func (r *postgresItemRepository) AppendNewItemTrackedToLocationHistory(userID uuid.UUID, itemIDs []uuid.UUID, locationID uuid.UUID) error {
+	jsonHistoryData, err := itemTrackedHistoryData(locationID)
+	if err != nil {
+		return err
+	}
+
+	return r.insertHistoryRecordForItems(userID, itemIDs, jsonHistoryData)
+}
+
+// itemTrackedHistoryData builds the data of an item history record tracking an item to the location.
+func itemTrackedHistoryData(locationID uuid.UUID) (json.RawMessage, error) {
+	jsonData, err := json.Marshal(model.ItemTrackedHistoryData{LocationID: locationID})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeTracked,
+		Data: jsonData,
+	})
+}
+
+// GetLastLocationID returns the location the item was most recently created or tracked at, ignoring users.
+// Returns sql.ErrNoRows if the item has never been at a location.
+func (r *postgresItemRepository) GetLastLocationID(itemID uuid.UUID) (uuid.UUID, error) {
+	stmt := `
+		select (data->'data'->>'locationId')::uuid
+		from item_history
+		where item_id = $1
+			and data->>'type' in ('created', 'tracked')
+		order by created_at desc, id desc
+		limit 1;`
+
+	var locationID uuid.UUID
+	if err := r.db.Get(&locationID, stmt, itemID); err != nil {
+		return uuid.Nil, err
+	}
+	return locationID, nil
+}
+
+// ListLoans lists the items that are not deleted and are currently tracked to a user, ordered by user and due date.
+// The loans can be narrowed to those held by a user and to those due before a time, loans without a due date are
+// never due.
+func (r *postgresItemRepository) ListLoans(userID *uuid.UUID, dueBefore *time.Time) ([]model.LoanModel, error) {
+	stmt := `
+		select *
+		from (
+			select
+				i.*,
+				c.location_id as user_id,
+				u.name as user_name,
+				u.username as user_username,
+				c.tracked_at as checked_out_at,
+				(h.data->'data'->>'dueAt')::timestamptz as due_at
+			from item_current_location c
+			join items i on c.item_id = i.id
+			left join users u on c.location_id = u.id
+			left join lateral (
+				select data
+				from item_history
+				where item_id = c.item_id
+					and data->>'type' = 'tracked-user'
+				order by created_at desc, id desc
+				limit 1
+			) h on true
+			where c.type = 'tracked-user'
+				and i.deleted = false
+				and ($1::uuid is null or c.location_id = $1)
+		) loans
+		where $2::timestamptz is null or due_at < $2
+		order by user_name, user_id, due_at nulls last, reference;`
+
+	var loans = make([]model.LoanModel, 0)
+	if err := r.db.Select(&loans, stmt, userID, dueBefore); err != nil {
+		return nil, err
+	}
+	return loans, nil
+}
+
+// AppendNewItemTrackedToUserHistory tracks each of the given items to the user.
+// The history records for all items are inserted in a single transaction, either all items are tracked or none are.
+func (r *postgresItemRepository) AppendNewItemTrackedToUserHistory(trackingUser, toUserID uuid.UUID, itemIDs []uuid.UUID, dueAt *time.Time) error {
+	jsonHistoryData, err := itemTrackedUserHistoryData(toUserID, dueAt)
+	if err != nil {
+		return err
+	}
+
+	return r.insertHistoryRecordForItems(trackingUser, itemIDs, jsonHistoryData)
+}
+
+// itemTrackedUserHistoryData builds the data of an item history record checking an item out to the user.
+func itemTrackedUserHistoryData(userID uuid.UUID, dueAt *time.Time) (json.RawMessage, error) {
+	jsonData, err := json.Marshal(model.ItemTrackedUserHistoryData{UserID: userID, DueAt: dueAt})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeTrackedUser,
+		Data: jsonData,
+	})
+}
+
+// RebuildCurrentLocations replaces the contents of the item_current_location table with the latest
+// created, tracked or tracked-user history record of each item, returning the number of items projected.
+// History appended while the rebuild runs waits for it to finish and is applied on top.
+func (r *postgresItemRepository) RebuildCurrentLocations() (int64, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.Exec("lock table item_current_location in exclusive mode;"); err != nil {
+		return 0, fmt.Errorf("failed to lock item_current_location: %w", err)
+	}
+
+	if _, err = tx.Exec("delete from item_current_location;"); err != nil {
+		return 0, fmt.Errorf("failed to clear item_current_location: %w", err)
+	}
+
+	stmt := fmt.Sprintf(`
+		insert into item_current_location (item_id, type, location_id, tracked_at)
+		select distinct on (item_id) %s
+		from item_history
+		where (data->>'type') in ('created', 'tracked', 'tracked-user')
+		order by item_id, created_at desc, id desc;`, currentLocationColumns)
+
+	result, err := tx.Exec(stmt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to project item history: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetContainer returns the item the item is inside of.
+// Returns sql.ErrNoRows if the item is not inside another item.
+func (r *postgresItemRepository) GetContainer(itemID uuid.UUID) (model.ItemModel, error) {
+	stmt := `
+		select i.*
+		from item_containment c
+		join items i on c.container_id = i.id
+		where c.item_id = $1;`
+
+	var container model.ItemModel
+	if err := r.db.Get(&container, stmt, itemID); err != nil {
+		return model.ItemModel{}, err
+	}
+	return container, nil
+}
+
+// ListContents lists the items directly inside the container, ordered by reference.
+func (r *postgresItemRepository) ListContents(containerID uuid.UUID) ([]model.ItemModel, error) {
+	stmt := `
+		select i.*
+		from item_containment c
+		join items i on c.item_id = i.id
+		where c.container_id = $1
+		order by i.reference;`
+
+	var contents = make([]model.ItemModel, 0)
+	if err := r.db.Select(&contents, stmt, containerID); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// SetContainer puts the item inside the container, taking it out of any other container first.
+// The item and the items inside it are tracked to wherever the container is if they are somewhere else.
+// Returns ErrContainmentCycle if the container is the item itself or is inside it.
+func (r *postgresItemRepository) SetContainer(itemID, containerID, userID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Locking both items in a fixed order stops two items from concurrently being put inside each other.
+	lockStmt := "select id from items where id = any($1) order by id for update;"
+	var locked []uuid.UUID
+	if err = tx.Select(&locked, lockStmt, pq.Array([]uuid.UUID{itemID, containerID})); err != nil {
+		return fmt.Errorf("failed to lock items: %w", err)
+	}
+
+	var withContents []uuid.UUID
+	if err = tx.Select(&withContents, itemContentsQuery("$1")+";", pq.Array([]uuid.UUID{itemID})); err != nil {
+		return fmt.Errorf("failed to list item contents: %w", err)
+	}
+	if slices.Contains(withContents, containerID) {
+		err = ErrContainmentCycle
+		return err
+	}
+
+	var currentID uuid.UUID
+	err = tx.Get(&currentID, "select container_id from item_containment where item_id = $1;", itemID)
+	switch {
+	case err == nil && currentID == containerID:
+		return tx.Commit()
+	case err == nil:
+		if err = insertContainmentHistoryRecord(tx, userID, itemID, model.ItemHistoryTypeUncontained, currentID); err != nil {
+			return err
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("failed to get current container: %w", err)
+	}
+
+	stmt := `
+		insert into item_containment (item_id, container_id)
+		values ($1, $2)
+		on conflict (item_id) do update
+		set container_id = excluded.container_id,
+			contained_at = now();`
+
+	if _, err = tx.Exec(stmt, itemID, containerID); err != nil {
+		return fmt.Errorf("failed to insert item containment: %w", err)
+	}
+
+	if err = insertContainmentHistoryRecord(tx, userID, itemID, model.ItemHistoryTypeContained, containerID); err != nil {
+		return err
+	}
+
+	var location struct {
+		Type       model.ItemHistoryType `db:"type"`
+		LocationID uuid.UUID             `db:"location_id"`
+	}
+	err = tx.Get(&location, "select type, location_id from item_current_location where item_id = $1;", containerID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get container location: %w", err)
+	}
+
+	if err == nil {
+		var data json.RawMessage
+		if location.Type == model.ItemHistoryTypeTrackedUser {
+			data, err = itemTrackedUserHistoryData(location.LocationID, nil)
+		} else {
+			data, err = itemTrackedHistoryData(location.LocationID)
+		}
+		if err != nil {
+			return err
+		}
+
+		stmt = `
+			select c.item_id
+			from (` + trackableItemContentsQuery("$1") + `) c
+			left join item_current_location l on c.item_id = l.item_id
+			where l.location_id is distinct from $2;`
+
+		var elsewhere []uuid.UUID
+		if err = tx.Select(&elsewhere, stmt, pq.Array([]uuid.UUID{itemID}), location.LocationID); err != nil {
+			return fmt.Errorf("failed to list items to move: %w", err)
+		}
+
+		for _, id := range elsewhere {
+			if err = insertItemHistoryRecord(tx, userID, id, data); err != nil {
+				return fmt.Errorf("failed to insert history for item %s: %w", id, err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveFromContainer takes the item out of the container it is inside of, leaving it where it is.
+// Returns sql.ErrNoRows if the item is not inside another item.
+func (r *postgresItemRepository) RemoveFromContainer(itemID, userID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var containerID uuid.UUID
+	if err = tx.Get(&containerID, "delete from item_containment where item_id = $1 returning container_id;", itemID); err != nil {
+		return err
+	}
+
+	if err = insertContainmentHistoryRecord(tx, userID, itemID, model.ItemHistoryTypeUncontained, containerID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// itemContentsQuery selects the IDs of the items in arg, an array of item IDs, and of every item nested inside them.
+func itemContentsQuery(arg string) string {
+	return fmt.Sprintf(`
+		with recursive contents as (
+			select unnest(%s::uuid[]) as item_id
+			union
+			select c.item_id from item_containment c join contents p on c.container_id = p.item_id
+		)
+		select item_id from contents`, arg)
+}
+
+// trackableItemContentsQuery selects the IDs of the items in arg, an array of item IDs, and of every item nested
+// inside them that can be tracked, leaving out stock and retired or disposed items so they do not move with a container.
+func trackableItemContentsQuery(arg string) string {
+	return fmt.Sprintf(`
+		select c.item_id
+		from (%s) c
+		join items i on c.item_id = i.id
+		where i.kind = '%s'
+			and i.status not in ('%s', '%s')`,
+		itemContentsQuery(arg), model.ItemKindUnit, model.ItemStatusRetired, model.ItemStatusDisposed)
+}
+
+// removeContainments runs stmt, a delete from item_containment returning the item_id and container_id columns,
+// and appends an uncontained history record for each item taken out of its container.
+func removeContainments(tx *sqlx.Tx, userID uuid.UUID, stmt string, args ...any) error {
+	var removed []struct {
+		ItemID      uuid.UUID `db:"item_id"`
+		ContainerID uuid.UUID `db:"container_id"`
+	}
+	if err := tx.Select(&removed, stmt, args...); err != nil {
+		return fmt.Errorf("failed to remove item containment: %w", err)
+	}
+
+	for _, c := range removed {
+		if err := insertContainmentHistoryRecord(tx, userID, c.ItemID, model.ItemHistoryTypeUncontained, c.ContainerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertContainmentHistoryRecord appends a contained or uncontained history record for the item and container.
+func insertContainmentHistoryRecord(tx *sqlx.Tx, userID, itemID uuid.UUID, historyType model.ItemHistoryType, containerID uuid.UUID) error {
+	jsonData, err := json.Marshal(model.ItemContainmentHistoryData{ContainerID: containerID})
+	if err != nil {
+		return err
+	}
+
+	jsonHistoryData, err := json.Marshal(model.HistoryDataContainer{
+		Type: historyType,
+		Data: jsonData,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := insertItemHistoryRecord(tx, userID, itemID, jsonHistoryData); err != nil {
+		return fmt.Errorf("failed to insert %s history for item %s: %w", historyType, itemID, err)
+	}
+	return nil
+}
+
+// ChangeStatus moves the item from one status to another and records the change, with its reason, in the history.
+// An item moved to a status that cannot be tracked is taken out of the container it is inside of.
+// Returns ErrItemStatusConflict, and changes nothing, if the item no longer has the from status.
+func (r *postgresItemRepository) ChangeStatus(itemID, userID uuid.UUID, from, to model.ItemStatus, reason string) (model.ItemModel, error) {
+	stmt := `
+		update items
+		set status = $1, updated_at = now()
+		where id = $2 and status = $3
+		returning *;`
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return model.ItemModel{}, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var item model.ItemModel
+	if err = tx.Get(&item, stmt, to, itemID, from); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = ErrItemStatusConflict
+		}
+		return model.ItemModel{}, err
+	}
+
+	jsonData, err := json.Marshal(model.ItemStatusChangedHistoryData{
+		From:   from,
+		To:     to,
+		Reason: reason,
+	})
+	if err != nil {
+		return model.ItemModel{}, err
+	}
+
+	var data json.RawMessage
+	if data, err = json.Marshal(model.HistoryDataContainer{Type: model.ItemHistoryTypeStatusChanged, Data: jsonData}); err != nil {
+		return model.ItemModel{}, err
+	}
+
+	if err = insertItemHistoryRecord(tx, userID, itemID, data); err != nil {
+		return model.ItemModel{}, fmt.Errorf("failed to insert history for item %s: %w", itemID, err)
+	}
+
+	if !to.Trackable() {
+		stmt = "delete from item_containment where item_id = $1 returning item_id, container_id;"
+		if err = removeContainments(tx, userID, stmt, itemID); err != nil {
+			return model.ItemModel{}, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return model.ItemModel{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return item, nil
+}
+
+// insertHistoryRecordForItems inserts the same history record for each of the given items in a single transaction.
+// The items inside each item are given the record too, so that tracking a container moves its contents with it,
+// except for retired and disposed items, which stay where they are.
+// An item given without the container it is inside of is taken out of the container first.
+func (r *postgresItemRepository) insertHistoryRecordForItems(userID uuid.UUID, itemIDs []uuid.UUID, data json.RawMessage) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var withContents []uuid.UUID
+	if err = tx.Select(&withContents, trackableItemContentsQuery("$1")+";", pq.Array(itemIDs)); err != nil {
+		return fmt.Errorf("failed to list item contents: %w", err)
+	}
+
+	stmt := `
+		delete from item_containment
+		where item_id = any($1)
+			and not (container_id = any($2))
+		returning item_id, container_id;`
+
+	if err = removeContainments(tx, userID, stmt, pq.Array(itemIDs), pq.Array(withContents)); err != nil {
+		return err
+	}
+
+	for _, itemID := range withContents {
+		if err = insertItemHistoryRecord(tx, userID, itemID, data); err != nil {
+			return fmt.Errorf("failed to insert history for item %s: %w", itemID, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresItemRepository) insertItem(tx *sqlx.Tx, item *model.ItemModel, userID, locationID uuid.UUID) error {
+	stmt := `
+		insert into items (identifier, reference, description, group_key, kind, status, custom_fields) 
+		values ($1, $2, $3, $4, $5, $6, $7)
+		returning id, created_at, updated_at;`
+
+	if item.Kind == "" {
+		item.Kind = model.ItemKindUnit
+	}
+	if item.Status == "" {
+		item.Status = model.ItemStatusInService
+	}
+	if len(item.CustomFields) == 0 {
+		item.CustomFields = json.RawMessage("{}")
+	}
+
+	if err := tx.Get(item, stmt, item.Identifier, item.Reference, item.Description, item.GroupKey, item.Kind, item.Status, item.CustomFields); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" && pqErr.Constraint == "items_reference_key" {
+			return ErrItemReferenceExists
+		}
+		return fmt.Errorf("failed to insert item: %w", err)
+	}
+
+	if err := r.updateHistoryOnItemCreation(tx, userID, locationID, *item); err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
+
+	return nil
+}
+
+// insertItemHistoryRecord appends the item history record and, if it moves the item, updates the item_current_location table.
+// A record only replaces the current location if it is at least as recent, so the latest appended record wins.
+// A webhook delivery is added to the outbox for each active webhook subscribed to the type of the record,
+// and the ID of the record is sent on the ItemHistoryChannel when the transaction commits.
+func insertItemHistoryRecord(tx *sqlx.Tx, userID, itemID uuid.UUID, data json.RawMessage) error {
+	stmt := fmt.Sprintf(`
+		with history as (
+			insert into item_history (user_id, item_id, data)
+			values ($1, $2, $3)
+			returning id, user_id, item_id, data, created_at
+		), webhook_outbox as (
+			insert into webhook_deliveries (webhook_id, event_type, payload)
+			select
+				w.id,
+				h.data->>'type',
+				jsonb_build_object(
+					'id', h.id,
+					'type', h.data->>'type',
+					'itemId', h.item_id,
+					'itemReference', i.reference,
+					'userId', h.user_id,
+					'data', h.data->'data',
+					'occurredAt', h.created_at
+				)
+			from history h
+			join items i on h.item_id = i.id
+			join webhooks w
+				on w.active = true
+				and (cardinality(w.event_types) = 0 or (h.data->>'type') = any(w.event_types))
+		), current_location as (
+			insert into item_current_location (item_id, type, location_id, tracked_at)
+			select %s
+			from history
+			where (data->>'type') in ('created', 'tracked', 'tracked-user')
+			on conflict (item_id) do update
+			set type = excluded.type,
+				location_id = excluded.location_id,
+				tracked_at = excluded.tracked_at
+			where item_current_location.tracked_at <= excluded.tracked_at
+		)
+		select pg_notify('%s', id::text)
+		from history;`, currentLocationColumns, ItemHistoryChannel)
+
+	_, err := tx.Exec(stmt, userID, itemID, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *postgresItemRepository) updateHistoryOnItemCreation(tx *sqlx.Tx, userID, locationID uuid.UUID, item model.ItemModel) error {
+	historyData := model.ItemCreatedHistoryData{
+		Reference:   item.Reference,
+		GroupKey:    item.GroupKey,
+		Description: item.Description,
+		LocationID:  locationID,
+	}
+	if string(item.CustomFields) != "{}" {
+		historyData.CustomFields = item.CustomFields
+	}
+
+	jsonData, err := json.Marshal(historyData)
+	if err != nil {
+		return err
+	}
+
+	history := model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeCreated,
+		Data: jsonData,
+	}
+
+	jsonHistoryData, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+
+	if err := insertItemHistoryRecord(tx, userID, item.ID, jsonHistoryData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *postgresItemRepository) updateHistoryOnItemUpdate(tx *sqlx.Tx, userID, itemID uuid.UUID, changes map[string]model.ItemFieldChange) error {
+	historyData := model.ItemUpdatedHistoryData{
+		UpdatedFields: changes,
+	}
+
+	jsonData, err := json.Marshal(historyData)
+	if err != nil {
+		return err
+	}
+
+	history := model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeUpdated,
+		Data: jsonData,
+	}
+
+	jsonHistoryData, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+
+	if err := insertItemHistoryRecord(tx, userID, itemID, jsonHistoryData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *postgresItemRepository) updateHistoryOnItemDeletion(tx *sqlx.Tx, userID, itemID uuid.UUID) error {
+	emptyJSONObject, err := json.Marshal(struct{}{})
+	if err != nil {
+		return err
+	}
+
+	history := model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeDeleted,
+		Data: emptyJSONObject,
+	}
+
+	jsonHistoryData, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+
+	if err := insertItemHistoryRecord(tx, userID, itemID, jsonHistoryData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *postgresItemRepository) updateHistoryOnItemRestoration(tx *sqlx.Tx, userID, itemID uuid.UUID) error {
+	emptyJSONObject, err := json.Marshal(struct{}{})
+	if err != nil {
+		return err
+	}
+
+	history := model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeRestored,
+		Data: emptyJSONObject,
+	}
+
+	jsonHistoryData, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+
+	if err := insertItemHistoryRecord(tx, userID, itemID, jsonHistoryData); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// diffItemFields returns the old and new values of each editable field that differs between the two items.
+// The map is keyed by the JSON name of the field, and each custom field by customFields.<name>.
+func diffItemFields(before, after model.ItemModel) (map[string]model.ItemFieldChange, error) {
+	changes := make(map[string]model.ItemFieldChange)
+
+	compare := func(field string, oldValue, newValue *string) {
+		if oldValue == nil && newValue == nil {
+			return
+		}
+		if oldValue != nil && newValue != nil && *oldValue == *newValue {
+			return
+		}
+		changes[field] = model.ItemFieldChange{Old: oldValue, New: newValue}
+	}
+
+	compare("identifier", &before.Identifier, &after.Identifier)
+	compare("reference", &before.Reference, &after.Reference)
+	compare("groupKey", &before.GroupKey, &after.GroupKey)
+	compare("description", before.Description, after.Description)
+
+	oldCustomFields, err := customFieldText(before.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	newCustomFields, err := customFieldText(after.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	for name, oldValue := range oldCustomFields {
+		if newValue, ok := newCustomFields[name]; ok {
+			compare("customFields."+name, &oldValue, &newValue)
+		} else {
+			compare("customFields."+name, &oldValue, nil)
+		}
+	}
+	for name, newValue := range newCustomFields {
+		if _, ok := oldCustomFields[name]; !ok {
+			compare("customFields."+name, nil, &newValue)
+		}
+	}
+
+	return changes, nil
+}
+
+// customFieldText returns the value of each custom field as text, strings unquoted and other values as JSON.
+func customFieldText(customFields json.RawMessage) (map[string]string, error) {
+	values := make(map[string]json.RawMessage)
+	if len(customFields) > 0 {
+		if err := json.Unmarshal(customFields, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse custom fields: %w", err)
+		}
+	}
+
+	text := make(map[string]string, len(values))
+	for name, value := range values {
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			text[name] = s
+		} else {
+			text[name] = string(value)
+		}
+	}
+	return text, nil
 }
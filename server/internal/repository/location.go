@@ -1,16 +1,71 @@
 package repository
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"quantum/internal/model"
+	"quantum/internal/types/pagination"
+	"slices"
+	"time"
 )
 
+var (
+	ErrLocationCycle          = errors.New("location cannot be moved inside itself")
+	ErrLocationNameExists     = errors.New("location name already exists")
+	ErrLocationHasChildren    = errors.New("location has active child locations")
+	ErrParentLocationNotFound = errors.New("parent location not found")
+)
+
+// maxLocationDepth bounds how far up the hierarchy breadcrumbs are followed.
+const maxLocationDepth = 32
+
 type LocationRepository interface {
-	List(max *int, filter string, includeDeleted bool) ([]model.LocationModel, error)
+	List(filter string, includeDeleted bool, page pagination.Params) (pagination.Page[model.LocationModel], error)
+	ListActive() ([]model.LocationModel, error)
 	Get(id uuid.UUID) (model.LocationModel, error)
-	Create(location *model.LocationModel) error
-	MarkDeleted(id uuid.UUID) error
+	ListByNames(names []string) ([]model.LocationModel, error)
+	ListAncestors(ids []uuid.UUID) ([]model.LocationAncestorModel, error)
+	ListHistory(id uuid.UUID) ([]model.LocationHistoryModel, error)
+	Create(location *model.LocationModel, createdByUserID uuid.UUID) error
+	Update(location *model.LocationModel, updatedByUserID uuid.UUID) error
+	SetParent(id uuid.UUID, parentID *uuid.UUID, movedByUserID uuid.UUID) error
+	MarkDeleted(id uuid.UUID, destinationID *uuid.UUID, deletedByUserID uuid.UUID) error
+	Restore(id uuid.UUID, restoredByUserID uuid.UUID) error
+}
+
+// locationSubtreeQuery selects the ID of the location in arg and of every location below it.
+func locationSubtreeQuery(arg string) string {
+	return fmt.Sprintf(`
+		with recursive subtree as (
+			select id from locations where id = %s
+			union
+			select l.id from locations l join subtree s on l.parent_id = s.id
+		)
+		select id from subtree`, arg)
+}
+
+// locationsKeyset is the sorting and paging of lists of locations.
+var locationsKeyset = pagination.Keyset[model.LocationModel]{
+	Name: "locations",
+	Fields: map[string]pagination.SortField[model.LocationModel]{
+		"name": {
+			Column: "name",
+			Type:   "text",
+			Value:  func(l model.LocationModel) string { return l.Name },
+		},
+		"createdAt": {
+			Column: "created_at",
+			Type:   "timestamptz",
+			Value:  func(l model.LocationModel) string { return l.CreatedAt.Format(time.RFC3339Nano) },
+		},
+	},
+	DefaultSort: "name",
+	IDColumn:    "id",
+	ID:          func(l model.LocationModel) string { return l.ID.String() },
 }
 
 type postgresLocationRepository struct {
@@ -23,19 +78,26 @@ func NewLocationRepository(db *sqlx.DB) LocationRepository {
 	}
 }
 
-func (r *postgresLocationRepository) List(max *int, filter string, includeDeleted bool) ([]model.LocationModel, error) {
-	stmt := `
+// List lists the locations and the tracker users that items can be tracked to.
+func (r *postgresLocationRepository) List(filter string, includeDeleted bool, page pagination.Params) (pagination.Page[model.LocationModel], error) {
+	clause, err := locationsKeyset.Clause(page, 3)
+	if err != nil {
+		return pagination.Page[model.LocationModel]{}, err
+	}
+
+	stmt := fmt.Sprintf(`
 		with trackable_locations as (
 			select
 				id,
 				name,
 				description,
+				parent_id,
 				is_deleted,
 				created_at,
 				updated_at,
 				false as is_user
 			from locations
-			where name ilike '%' || $1 || '%'
+			where name ilike '%%' || $1 || '%%'
 				and ($2 = true or is_deleted = false)
 		
 			union
@@ -44,6 +106,7 @@ func (r *postgresLocationRepository) List(max *int, filter string, includeDelete
 				u.id,
 				u.name,
 				u.username as description,
+				null::uuid as parent_id,
 				false as is_deleted,
 				u.created_at,
 				u.updated_at,
@@ -55,10 +118,24 @@ func (r *postgresLocationRepository) List(max *int, filter string, includeDelete
 		)
 		select *
 		from trackable_locations
-		order by name;`
+		where %s
+		order by %s
+		%s;`, clause.Where, clause.OrderBy, clause.Limit)
+
+	var locations = make([]model.LocationModel, 0)
+	args := append([]any{filter, includeDeleted}, clause.Args...)
+	if err := r.db.Select(&locations, stmt, args...); err != nil {
+		return pagination.Page[model.LocationModel]{}, err
+	}
+	return locationsKeyset.Page(locations, page), nil
+}
+
+// ListActive lists all the locations that are not deleted, ordered by name.
+func (r *postgresLocationRepository) ListActive() ([]model.LocationModel, error) {
+	stmt := "select * from locations where is_deleted = false order by name, id;"
 
 	var locations = make([]model.LocationModel, 0)
-	if err := r.db.Select(&locations, stmt, filter, includeDeleted); err != nil {
+	if err := r.db.Select(&locations, stmt); err != nil {
 		return nil, err
 	}
 	return locations, nil
@@ -73,17 +150,470 @@ func (r *postgresLocationRepository) Get(id uuid.UUID) (model.LocationModel, err
 	return location, nil
 }
 
-func (r *postgresLocationRepository) Create(location *model.LocationModel) error {
+// ListByNames returns the locations, excluding deleted locations, whose name matches one of the given names.
+func (r *postgresLocationRepository) ListByNames(names []string) ([]model.LocationModel, error) {
+	stmt := "select * from locations where name = any($1) and is_deleted = false;"
+
+	var locations = make([]model.LocationModel, 0)
+	if err := r.db.Select(&locations, stmt, pq.Array(names)); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// ListAncestors returns the path from the top level location down to each of the given locations.
+// The rows of each location are ordered from the top level location, with the location itself last.
+func (r *postgresLocationRepository) ListAncestors(ids []uuid.UUID) ([]model.LocationAncestorModel, error) {
 	stmt := `
-		insert into locations (name, description) 
-		values ($1, $2)
+		with recursive path as (
+			select id as location_id, id, name, parent_id, 0 as depth
+			from locations
+			where id = any($1)
+
+			union all
+
+			select p.location_id, l.id, l.name, l.parent_id, p.depth + 1
+			from path p
+			join locations l on l.id = p.parent_id
+			where p.depth < $2
+		)
+		select location_id, id, name, depth
+		from path
+		order by location_id, depth desc;`
+
+	var ancestors = make([]model.LocationAncestorModel, 0)
+	if err := r.db.Select(&ancestors, stmt, pq.Array(ids), maxLocationDepth); err != nil {
+		return nil, err
+	}
+	return ancestors, nil
+}
+
+// lockLocationHierarchy serialises changes to the hierarchy of locations until the transaction ends,
+// so the parents and children checked under the lock cannot change before the transaction commits.
+func lockLocationHierarchy(tx *sqlx.Tx) error {
+	if _, err := tx.Exec("select pg_advisory_xact_lock(hashtext('locations.parent_id'));"); err != nil {
+		return fmt.Errorf("failed to lock locations: %w", err)
+	}
+	return nil
+}
+
+// checkActiveParent returns ErrParentLocationNotFound unless the parent exists and is not deleted.
+// It must be called while holding the lock taken by lockLocationHierarchy.
+func checkActiveParent(tx *sqlx.Tx, parentID uuid.UUID) error {
+	var active bool
+	if err := tx.Get(&active, "select exists (select 1 from locations where id = $1 and is_deleted = false);", parentID); err != nil {
+		return fmt.Errorf("failed to check parent location: %w", err)
+	}
+	if !active {
+		return ErrParentLocationNotFound
+	}
+	return nil
+}
+
+// ListHistory lists the changes made to the location, most recent first.
+func (r *postgresLocationRepository) ListHistory(id uuid.UUID) ([]model.LocationHistoryModel, error) {
+	stmt := `
+		select h.id, h.user_id, u.name as user_name, h.location_id, h.data, h.created_at
+		from location_history h
+		left join users u on h.user_id = u.id
+		where h.location_id = $1
+		order by h.created_at desc, h.id desc;`
+
+	var history = make([]model.LocationHistoryModel, 0)
+	if err := r.db.Select(&history, stmt, id); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (r *postgresLocationRepository) Create(location *model.LocationModel, createdByUserID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if location.ParentID != nil {
+		if err = lockLocationHierarchy(tx); err != nil {
+			return err
+		}
+		if err = checkActiveParent(tx, *location.ParentID); err != nil {
+			return err
+		}
+	}
+
+	stmt := `
+		insert into locations (name, description, parent_id) 
+		values ($1, $2, $3)
 		returning id, created_at, updated_at;`
 
-	return r.db.Get(location, stmt, location.Name, location.Description)
+	if err = tx.Get(location, stmt, location.Name, location.Description, location.ParentID); err != nil {
+		if isLocationNameViolation(err) {
+			err = ErrLocationNameExists
+			return err
+		}
+		return fmt.Errorf("failed to insert location: %w", err)
+	}
+
+	historyData := model.LocationCreatedHistoryData{
+		Name:        location.Name,
+		Description: location.Description,
+		ParentID:    location.ParentID,
+	}
+	if err = r.insertHistoryRecord(tx, createdByUserID, location.ID, model.LocationHistoryTypeCreated, historyData); err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
 }
 
-func (r *postgresLocationRepository) MarkDeleted(id uuid.UUID) error {
-	stmt := "update locations set is_deleted = true where id = $1;"
-	_, err := r.db.Exec(stmt, id)
+// Update updates the name and description of the location.
+// The old and new values of each changed field are recorded in an updated history record within the same transaction.
+// If nothing has changed, the location is left untouched and no history is recorded.
+// Returns ErrLocationNameExists if another location has the name,
+// and sql.ErrNoRows if the location does not exist or is deleted.
+func (r *postgresLocationRepository) Update(location *model.LocationModel, updatedByUserID uuid.UUID) error {
+	selectStmt := "select * from locations where id = $1 and is_deleted = false for update;"
+	updateStmt := `
+		update locations
+		set name = $1, description = $2, updated_at = now()
+		where id = $3
+		returning *;`
+
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var existing model.LocationModel
+	if err = tx.Get(&existing, selectStmt, location.ID); err != nil {
+		return err
+	}
+
+	changes := diffLocationFields(existing, *location)
+	if len(changes) == 0 {
+		*location = existing
+		return tx.Commit()
+	}
+
+	if err = tx.Get(location, updateStmt, location.Name, location.Description, location.ID); err != nil {
+		if isLocationNameViolation(err) {
+			err = ErrLocationNameExists
+			return err
+		}
+		return fmt.Errorf("failed to update location: %w", err)
+	}
+
+	historyData := model.LocationUpdatedHistoryData{UpdatedFields: changes}
+	if err = r.insertHistoryRecord(tx, updatedByUserID, location.ID, model.LocationHistoryTypeUpdated, historyData); err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SetParent moves the location, and every location below it, under the given parent.
+// A nil parent makes it a top level location. Items keep their location so no item history is recorded.
+// Returns ErrParentLocationNotFound if the parent does not exist or is deleted,
+// ErrLocationCycle if the parent is the location itself or below it,
+// and sql.ErrNoRows if the location does not exist or is deleted.
+func (r *postgresLocationRepository) SetParent(id uuid.UUID, parentID *uuid.UUID, movedByUserID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Concurrent moves are serialised so two moves cannot each pass the cycle check and create a cycle together.
+	if err = lockLocationHierarchy(tx); err != nil {
+		return err
+	}
+
+	if parentID != nil {
+		if err = checkActiveParent(tx, *parentID); err != nil {
+			return err
+		}
+
+		cycleStmt := `
+			with recursive ancestors as (
+				select id, parent_id from locations where id = $2
+				union
+				select l.id, l.parent_id from locations l join ancestors a on l.id = a.parent_id
+			)
+			select exists (select 1 from ancestors where id = $1);`
+
+		var cycle bool
+		if err = tx.Get(&cycle, cycleStmt, id, *parentID); err != nil {
+			return fmt.Errorf("failed to check for cycle: %w", err)
+		}
+		if cycle {
+			err = ErrLocationCycle
+			return err
+		}
+	}
+
+	updateStmt := `
+		update locations l
+		set parent_id = $2, updated_at = now()
+		from (select id, parent_id from locations where id = $1 for update) old
+		where l.id = old.id
+			and l.is_deleted = false
+		returning old.parent_id;`
+
+	var fromParentID *uuid.UUID
+	if err = tx.Get(&fromParentID, updateStmt, id, parentID); err != nil {
+		return err
+	}
+
+	historyData := model.LocationMovedHistoryData{FromParentID: fromParentID, ToParentID: parentID}
+	if err = r.insertHistoryRecord(tx, movedByUserID, id, model.LocationHistoryTypeMoved, historyData); err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// LocationHasItemsError is returned when deleting a location that items, or stock, are still at without a destination for them.
+type LocationHasItemsError struct {
+	Items []model.ItemModel
+}
+
+func (e *LocationHasItemsError) Error() string {
+	return fmt.Sprintf("location still has %d items", len(e.Items))
+}
+
+// MarkDeleted soft deletes the location and records a deleted history record.
+// Items still at the location are tracked to the destination, each with its own tracked history record, and stock
+// held there is transferred to the destination, within the same transaction.
+// Without a destination a LocationHasItemsError is returned instead.
+// Returns ErrLocationHasChildren while any location directly inside it is not deleted,
+// and sql.ErrNoRows if the location does not exist or is already deleted.
+func (r *postgresLocationRepository) MarkDeleted(id uuid.UUID, destinationID *uuid.UUID, deletedByUserID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Holding the lock stops a location being moved or restored into this one once it has been checked for children.
+	if err = lockLocationHierarchy(tx); err != nil {
+		return err
+	}
+
+	var location model.LocationModel
+	if err = tx.Get(&location, "select * from locations where id = $1 and is_deleted = false for update;", id); err != nil {
+		return err
+	}
+
+	var hasChildren bool
+	if err = tx.Get(&hasChildren, "select exists (select 1 from locations where parent_id = $1 and is_deleted = false);", id); err != nil {
+		return fmt.Errorf("failed to check for child locations: %w", err)
+	}
+	if hasChildren {
+		err = ErrLocationHasChildren
+		return err
+	}
+
+	// Locking the current location rows makes tracking the items elsewhere wait until the deletion has finished.
+	itemsStmt := `
+		select i.*
+		from items i
+		join item_current_location c on i.id = c.item_id
+		where c.location_id = $1
+			and i.deleted = false
+		order by i.reference
+		for update of c;`
+
+	var items = make([]model.ItemModel, 0)
+	if err = tx.Select(&items, itemsStmt, id); err != nil {
+		return fmt.Errorf("failed to list items at location: %w", err)
+	}
+
+	// Locking the stock items makes changes to their stock wait until the deletion has finished.
+	stockStmt := `
+		select i.*
+		from items i
+		join stock_levels s on i.id = s.item_id
+		where s.location_id = $1
+			and s.quantity > 0
+			and i.deleted = false
+		order by i.reference
+		for update of i;`
+
+	var stockItems = make([]model.ItemModel, 0)
+	if err = tx.Select(&stockItems, stockStmt, id); err != nil {
+		return fmt.Errorf("failed to list stock at location: %w", err)
+	}
+
+	// Stock items created at the location are in both lists, but are only reported and counted once.
+	held := slices.Clone(items)
+	for _, item := range stockItems {
+		if !slices.ContainsFunc(items, func(i model.ItemModel) bool { return i.ID == item.ID }) {
+			held = append(held, item)
+		}
+	}
+
+	if len(held) > 0 && destinationID == nil {
+		err = &LocationHasItemsError{Items: held}
+		return err
+	}
+
+	if len(items) > 0 {
+		var trackedData json.RawMessage
+		if trackedData, err = itemTrackedHistoryData(*destinationID); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err = insertItemHistoryRecord(tx, deletedByUserID, item.ID, trackedData); err != nil {
+				return fmt.Errorf("failed to insert history for item %s: %w", item.ID, err)
+			}
+		}
+	}
+
+	if _, err = tx.Exec("update locations set is_deleted = true, updated_at = now() where id = $1;", id); err != nil {
+		return fmt.Errorf("failed to update location: %w", err)
+	}
+
+	for _, item := range stockItems {
+		var quantity int
+		if err = tx.Get(&quantity, "select quantity from stock_levels where item_id = $1 and location_id = $2;", item.ID, id); err != nil {
+			return fmt.Errorf("failed to get stock level of item %s: %w", item.ID, err)
+		}
+		changes := []StockChange{
+			{Type: model.ItemHistoryTypeStockTransferred, LocationID: id, Delta: -quantity, TransferLocationID: destinationID},
+			{Type: model.ItemHistoryTypeStockTransferred, LocationID: *destinationID, Delta: quantity, TransferLocationID: &id},
+		}
+		if err = applyStockChanges(tx, item.ID, deletedByUserID, changes); err != nil {
+			return fmt.Errorf("failed to transfer stock of item %s: %w", item.ID, err)
+		}
+	}
+
+	historyData := model.LocationDeletedHistoryData{ItemsMovedTo: destinationID, ItemsMoved: len(held)}
+	if err = r.insertHistoryRecord(tx, deletedByUserID, id, model.LocationHistoryTypeDeleted, historyData); err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Restore brings back a soft deleted location and records a restored history record.
+// Returns ErrParentLocationNotFound if the location is inside a deleted location,
+// and sql.ErrNoRows if the location does not exist or is not deleted.
+func (r *postgresLocationRepository) Restore(id uuid.UUID, restoredByUserID uuid.UUID) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = lockLocationHierarchy(tx); err != nil {
+		return err
+	}
+
+	var parentID *uuid.UUID
+	if err = tx.Get(&parentID, "select parent_id from locations where id = $1 and is_deleted = true for update;", id); err != nil {
+		return err
+	}
+	if parentID != nil {
+		if err = checkActiveParent(tx, *parentID); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.Exec("update locations set is_deleted = false, updated_at = now() where id = $1;", id); err != nil {
+		return fmt.Errorf("failed to update location: %w", err)
+	}
+
+	if err = r.insertHistoryRecord(tx, restoredByUserID, id, model.LocationHistoryTypeRestored, struct{}{}); err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *postgresLocationRepository) insertHistoryRecord(tx *sqlx.Tx, userID, locationID uuid.UUID, historyType model.LocationHistoryType, data any) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	jsonHistoryData, err := json.Marshal(model.LocationHistoryDataContainer{
+		Type: historyType,
+		Data: jsonData,
+	})
+	if err != nil {
+		return err
+	}
+
+	stmt := "insert into location_history (user_id, location_id, data) values ($1, $2, $3);"
+	_, err = tx.Exec(stmt, userID, locationID, jsonHistoryData)
 	return err
 }
+
+// diffLocationFields returns the old and new values of each editable field that differs between the two locations.
+// The map is keyed by the JSON name of the field.
+func diffLocationFields(before, after model.LocationModel) map[string]model.ItemFieldChange {
+	changes := make(map[string]model.ItemFieldChange)
+
+	compare := func(field string, oldValue, newValue *string) {
+		if oldValue == nil && newValue == nil {
+			return
+		}
+		if oldValue != nil && newValue != nil && *oldValue == *newValue {
+			return
+		}
+		changes[field] = model.ItemFieldChange{Old: oldValue, New: newValue}
+	}
+
+	compare("name", &before.Name, &after.Name)
+	compare("description", before.Description, after.Description)
+
+	return changes
+}
+
+// isLocationNameViolation reports whether the error is caused by the unique constraint on location names.
+func isLocationNameViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" && pqErr.Constraint == "locations_name_key"
+}
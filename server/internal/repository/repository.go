@@ -3,17 +3,25 @@ package repository
 import "github.com/jmoiron/sqlx"
 
 type Repositories struct {
-	UserRepository     UserRepository
-	ItemRepository     ItemRepository
-	LocationRepository LocationRepository
-	SettingsRepository SettingsRepository
+	UserRepository        UserRepository
+	ItemRepository        ItemRepository
+	LocationRepository    LocationRepository
+	SettingsRepository    SettingsRepository
+	WebhookRepository     WebhookRepository
+	ReservationRepository ReservationRepository
+	AuditRepository       AuditRepository
+	StockRepository       StockRepository
 }
 
 func NewRepositories(db *sqlx.DB) *Repositories {
 	return &Repositories{
-		UserRepository:     NewUserRepository(db),
-		ItemRepository:     NewItemRepository(db),
-		LocationRepository: NewLocationRepository(db),
-		SettingsRepository: NewPostgresSettingsRepository(db),
+		UserRepository:        NewUserRepository(db),
+		ItemRepository:        NewItemRepository(db),
+		LocationRepository:    NewLocationRepository(db),
+		SettingsRepository:    NewPostgresSettingsRepository(db),
+		WebhookRepository:     NewWebhookRepository(db),
+		ReservationRepository: NewReservationRepository(db),
+		AuditRepository:       NewAuditRepository(db),
+		StockRepository:       NewStockRepository(db),
 	}
 }
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"quantum/internal/model"
+	"time"
+)
+
+var ErrReservationOverlaps = errors.New("reservation overlaps an existing reservation of the item")
+
+// ReservationFilter narrows a list of reservations. Nil fields are not filtered on.
+// From and To select the reservations that overlap the window between them.
+type ReservationFilter struct {
+	ItemID   *uuid.UUID
+	UserID   *uuid.UUID
+	GroupKey *string
+	From     *time.Time
+	To       *time.Time
+}
+
+type ReservationRepository interface {
+	Get(id uuid.UUID) (model.ReservationDetailModel, error)
+	List(filter ReservationFilter) ([]model.ReservationDetailModel, error)
+	ListActive(itemIDs []uuid.UUID, at time.Time) ([]model.ReservationDetailModel, error)
+	Create(reservation *model.ReservationModel) error
+	Delete(id uuid.UUID) error
+	GetOrCreateFeedToken(userID uuid.UUID, token string) (string, error)
+	SetFeedToken(userID uuid.UUID, token string) error
+	GetFeedTokenUserID(token string) (uuid.UUID, error)
+}
+
+// reservationDetailQuery selects reservations joined to their item and user, to be followed by a where clause.
+const reservationDetailQuery = `
+	select
+		r.*,
+		i.reference as item_reference,
+		i.identifier as item_identifier,
+		i.group_key as item_group_key,
+		u.name as user_name,
+		u.username as user_username
+	from reservations r
+	join items i on r.item_id = i.id
+	join users u on r.user_id = u.id`
+
+type postgresReservationRepository struct {
+	db *sqlx.DB
+}
+
+func NewReservationRepository(db *sqlx.DB) ReservationRepository {
+	return &postgresReservationRepository{
+		db: db,
+	}
+}
+
+func (r *postgresReservationRepository) Get(id uuid.UUID) (model.ReservationDetailModel, error) {
+	stmt := reservationDetailQuery + " where r.id = $1;"
+
+	var reservation model.ReservationDetailModel
+	if err := r.db.Get(&reservation, stmt, id); err != nil {
+		return model.ReservationDetailModel{}, err
+	}
+	return reservation, nil
+}
+
+// List lists the reservations matching the filter in the order they start.
+func (r *postgresReservationRepository) List(filter ReservationFilter) ([]model.ReservationDetailModel, error) {
+	stmt := reservationDetailQuery + `
+		where ($1::uuid is null or r.item_id = $1)
+			and ($2::uuid is null or r.user_id = $2)
+			and ($3::text is null or i.group_key = $3)
+			and ($4::timestamptz is null or r.ends_at > $4)
+			and ($5::timestamptz is null or r.starts_at < $5)
+		order by r.starts_at, r.id;`
+
+	var reservations = make([]model.ReservationDetailModel, 0)
+	if err := r.db.Select(&reservations, stmt, filter.ItemID, filter.UserID, filter.GroupKey, filter.From, filter.To); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// ListActive lists the reservations of the items that are in progress at the given time.
+func (r *postgresReservationRepository) ListActive(itemIDs []uuid.UUID, at time.Time) ([]model.ReservationDetailModel, error) {
+	stmt := reservationDetailQuery + `
+		where r.item_id = any($1)
+			and r.starts_at <= $2
+			and r.ends_at > $2
+		order by r.starts_at, r.id;`
+
+	var reservations = make([]model.ReservationDetailModel, 0)
+	if err := r.db.Select(&reservations, stmt, pq.Array(itemIDs), at); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// Create inserts the reservation.
+// Returns ErrReservationOverlaps if the item is already reserved for any part of the time window.
+func (r *postgresReservationRepository) Create(reservation *model.ReservationModel) error {
+	stmt := `
+		insert into reservations (item_id, user_id, purpose, starts_at, ends_at, created_by_user_id)
+		values ($1, $2, $3, $4, $5, $6)
+		returning id, created_at;`
+
+	err := r.db.Get(reservation, stmt,
+		reservation.ItemID,
+		reservation.UserID,
+		reservation.Purpose,
+		reservation.StartsAt,
+		reservation.EndsAt,
+		reservation.CreatedByUserID,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "exclusion_violation" && pqErr.Constraint == "reservations_no_overlap" {
+			return ErrReservationOverlaps
+		}
+		return fmt.Errorf("failed to insert reservation: %w", err)
+	}
+	return nil
+}
+
+func (r *postgresReservationRepository) Delete(id uuid.UUID) error {
+	_, err := r.db.Exec("delete from reservations where id = $1;", id)
+	return err
+}
+
+// GetOrCreateFeedToken returns the calendar feed token of the user, storing token as the token if the user has none.
+// The conflict is resolved with a no-op update rather than do nothing, so that when two first requests race
+// the second waits for the first to commit and returns the token it stored.
+func (r *postgresReservationRepository) GetOrCreateFeedToken(userID uuid.UUID, token string) (string, error) {
+	stmt := `
+		insert into reservation_feed_tokens (user_id, token)
+		values ($1, $2)
+		on conflict (user_id) do update
+		set token = reservation_feed_tokens.token
+		returning token;`
+
+	var existing string
+	if err := r.db.Get(&existing, stmt, userID, token); err != nil {
+		return "", err
+	}
+	return existing, nil
+}
+
+// SetFeedToken replaces the calendar feed token of the user.
+func (r *postgresReservationRepository) SetFeedToken(userID uuid.UUID, token string) error {
+	stmt := `
+		insert into reservation_feed_tokens (user_id, token)
+		values ($1, $2)
+		on conflict (user_id) do update
+		set token = excluded.token,
+			created_at = now();`
+
+	_, err := r.db.Exec(stmt, userID, token)
+	return err
+}
+
+// GetFeedTokenUserID returns the ID of the user the calendar feed token belongs to.
+// Returns sql.ErrNoRows if no user has the token or the user is deleted.
+func (r *postgresReservationRepository) GetFeedTokenUserID(token string) (uuid.UUID, error) {
+	stmt := `
+		select t.user_id
+		from reservation_feed_tokens t
+		join users u on t.user_id = u.id
+		where t.token = $1
+			and u.deleted_at is null;`
+
+	var userID uuid.UUID
+	if err := r.db.Get(&userID, stmt, token); err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
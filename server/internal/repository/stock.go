@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"quantum/internal/model"
+)
+
+var ErrInsufficientStock = errors.New("not enough stock held at the location")
+
+// StockChange is a change to the quantity of a stock item held at a location, recorded as a history record of Type.
+// Delta is negative when stock leaves the location.
+type StockChange struct {
+	Type               model.ItemHistoryType
+	LocationID         uuid.UUID
+	Delta              int
+	TransferLocationID *uuid.UUID
+	Note               *string
+}
+
+type StockRepository interface {
+	ListLevels(itemID uuid.UUID) ([]model.StockLevelModel, error)
+	Apply(itemID, userID uuid.UUID, changes []StockChange) error
+	ListThresholds() ([]model.StockThresholdModel, error)
+	SetThreshold(threshold *model.StockThresholdModel) error
+	DeleteThreshold(groupKey string, locationID uuid.UUID) error
+	ListLow() ([]model.LowStockModel, error)
+}
+
+type postgresStockRepository struct {
+	db *sqlx.DB
+}
+
+func NewStockRepository(db *sqlx.DB) StockRepository {
+	return &postgresStockRepository{
+		db: db,
+	}
+}
+
+// ListLevels lists the quantity of the item held at each location that has held it, ordered by location name.
+func (r *postgresStockRepository) ListLevels(itemID uuid.UUID) ([]model.StockLevelModel, error) {
+	stmt := `
+		select s.*, l.name as location_name
+		from stock_levels s
+		join locations l on s.location_id = l.id
+		where s.item_id = $1
+		order by l.name;`
+
+	var levels = make([]model.StockLevelModel, 0)
+	if err := r.db.Select(&levels, stmt, itemID); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// Apply applies the changes to the stock levels of the item in a single transaction,
+// appending a history record with the signed delta and the resulting quantity for each.
+// Returns ErrInsufficientStock, and changes nothing, if any change would leave a location holding less than none.
+func (r *postgresStockRepository) Apply(itemID, userID uuid.UUID, changes []StockChange) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// Locking the item serializes concurrent changes to its stock, so each sees the quantity left by the last.
+	var locked uuid.UUID
+	if err = tx.Get(&locked, "select id from items where id = $1 for update;", itemID); err != nil {
+		return err
+	}
+
+	if err = applyStockChanges(tx, itemID, userID, changes); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// applyStockChanges applies the changes to the stock levels of the item within tx, which must hold a lock on the item.
+func applyStockChanges(tx *sqlx.Tx, itemID, userID uuid.UUID, changes []StockChange) error {
+	for _, change := range changes {
+		var quantity int
+		stmt := "select quantity from stock_levels where item_id = $1 and location_id = $2;"
+		if err := tx.Get(&quantity, stmt, itemID, change.LocationID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get stock level: %w", err)
+		}
+
+		quantity += change.Delta
+		if quantity < 0 {
+			return ErrInsufficientStock
+		}
+
+		stmt = `
+			insert into stock_levels (item_id, location_id, quantity)
+			values ($1, $2, $3)
+			on conflict (item_id, location_id) do update
+			set quantity = excluded.quantity,
+				updated_at = now();`
+
+		if _, err := tx.Exec(stmt, itemID, change.LocationID, quantity); err != nil {
+			return fmt.Errorf("failed to update stock level: %w", err)
+		}
+
+		data, err := itemStockHistoryData(change, quantity)
+		if err != nil {
+			return err
+		}
+		if err := insertItemHistoryRecord(tx, userID, itemID, data); err != nil {
+			return fmt.Errorf("failed to insert history for item %s: %w", itemID, err)
+		}
+	}
+	return nil
+}
+
+// itemStockHistoryData builds the data of an item history record for the change, leaving quantity at its location.
+func itemStockHistoryData(change StockChange, quantity int) (json.RawMessage, error) {
+	jsonData, err := json.Marshal(model.ItemStockHistoryData{
+		LocationID:         change.LocationID,
+		Delta:              change.Delta,
+		Quantity:           quantity,
+		TransferLocationID: change.TransferLocationID,
+		Note:               change.Note,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(model.HistoryDataContainer{
+		Type: change.Type,
+		Data: jsonData,
+	})
+}
+
+// ListThresholds lists the reorder thresholds ordered by group key and location name.
+func (r *postgresStockRepository) ListThresholds() ([]model.StockThresholdModel, error) {
+	stmt := `
+		select t.*, l.name as location_name
+		from stock_thresholds t
+		join locations l on t.location_id = l.id
+		order by t.group_key, l.name;`
+
+	var thresholds = make([]model.StockThresholdModel, 0)
+	if err := r.db.Select(&thresholds, stmt); err != nil {
+		return nil, err
+	}
+	return thresholds, nil
+}
+
+// SetThreshold creates or replaces the reorder threshold of the group key at the location.
+func (r *postgresStockRepository) SetThreshold(threshold *model.StockThresholdModel) error {
+	stmt := `
+		insert into stock_thresholds (group_key, location_id, threshold)
+		values ($1, $2, $3)
+		on conflict (group_key, location_id) do update
+		set threshold = excluded.threshold,
+			updated_at = now()
+		returning updated_at;`
+
+	if err := r.db.Get(threshold, stmt, threshold.GroupKey, threshold.LocationID, threshold.Threshold); err != nil {
+		return fmt.Errorf("failed to set stock threshold: %w", err)
+	}
+	return nil
+}
+
+// DeleteThreshold removes the reorder threshold of the group key at the location.
+// Returns sql.ErrNoRows if there is no such threshold.
+func (r *postgresStockRepository) DeleteThreshold(groupKey string, locationID uuid.UUID) error {
+	result, err := r.db.Exec("delete from stock_thresholds where group_key = $1 and location_id = $2;", groupKey, locationID)
+	if err != nil {
+		return fmt.Errorf("failed to delete stock threshold: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListLow lists each group key held at a location, across all its stock items that are not deleted, in a quantity
+// below the reorder threshold of the group at that location. Locations that are deleted are left out.
+func (r *postgresStockRepository) ListLow() ([]model.LowStockModel, error) {
+	stmt := `
+		select
+			t.group_key,
+			t.location_id,
+			l.name as location_name,
+			t.threshold,
+			coalesce(sum(s.quantity), 0) as quantity
+		from stock_thresholds t
+		join locations l on t.location_id = l.id
+		left join items i
+			on i.group_key = t.group_key
+			and i.kind = 'stock'
+			and i.deleted = false
+		left join stock_levels s
+			on s.item_id = i.id
+			and s.location_id = t.location_id
+		where l.is_deleted = false
+		group by t.group_key, t.location_id, l.name, t.threshold
+		having coalesce(sum(s.quantity), 0) < t.threshold
+		order by t.group_key, l.name;`
+
+	var low = make([]model.LowStockModel, 0)
+	if err := r.db.Select(&low, stmt); err != nil {
+		return nil, err
+	}
+	return low, nil
+}
@@ -10,12 +10,14 @@ import (
 	"github.com/lib/pq"
 	"quantum/internal/model"
 	"quantum/internal/permissions"
+	"quantum/internal/types/pagination"
+	"time"
 )
 
 var ErrUserUsernameExists = errors.New("username already exists")
 
 type UserRepository interface {
-	List(roleFilters []string) ([]model.User, error)
+	List(roleFilters []string, page pagination.Params) (pagination.Page[model.User], error)
 	Get(id uuid.UUID) (model.User, error)
 	GetByUsername(username string) (model.User, error)
 	Create(user *model.User) error
@@ -26,6 +28,31 @@ type UserRepository interface {
 	Count() (int, error)
 }
 
+// usersKeyset is the sorting and paging of lists of users.
+var usersKeyset = pagination.Keyset[model.User]{
+	Name: "users",
+	Fields: map[string]pagination.SortField[model.User]{
+		"name": {
+			Column: "u.name",
+			Type:   "text",
+			Value:  func(u model.User) string { return u.Name },
+		},
+		"username": {
+			Column: "u.username",
+			Type:   "text",
+			Value:  func(u model.User) string { return u.Username },
+		},
+		"createdAt": {
+			Column: "u.created_at",
+			Type:   "timestamptz",
+			Value:  func(u model.User) string { return u.CreatedAt.Format(time.RFC3339Nano) },
+		},
+	},
+	DefaultSort: "name",
+	IDColumn:    "u.id",
+	ID:          func(u model.User) string { return u.ID.String() },
+}
+
 type postgresUserRepository struct {
 	db *sqlx.DB
 }
@@ -42,46 +69,57 @@ type userRoleJoin struct {
 	Role permissions.Role `db:"role"`
 }
 
-func (r *postgresUserRepository) List(roleFilters []string) ([]model.User, error) {
-	query, args, err := sqlx.In(`
+func (r *postgresUserRepository) List(roleFilters []string, page pagination.Params) (pagination.Page[model.User], error) {
+	clause, err := usersKeyset.Clause(page, 2)
+	if err != nil {
+		return pagination.Page[model.User]{}, err
+	}
+
+	stmt := fmt.Sprintf(`
 		with matched_users as (
-			select distinct u.id
+			select u.id
 			from users u
-			join user_roles ur
-			on u.id = ur.user_id
-			where ur.role in (?)
+			where exists (
+				select 1
+				from user_roles ur
+				where ur.user_id = u.id
+					and ur.role::text = any($1)
+			)
+				and %[1]s
+			order by %[2]s
+			%[3]s
 		)
 		select u.id, u.name, u.username, u.password, u.created_at, u.updated_at, u.deleted_at, u.last_logged_in_at, ur.role
 		from users u left join user_roles ur on u.id = ur.user_id
 		where u.id in (select id from matched_users)
-		order by u.name, ur.role;`, roleFilters)
-
-	if err != nil {
-		return nil, err
-	}
-
-	query = r.db.Rebind(query)
+		order by %[2]s, ur.role;`, clause.Where, clause.OrderBy, clause.Limit)
 
 	var usersWithRoles []userRoleJoin
-	if err := r.db.Select(&usersWithRoles, query, args...); err != nil {
-		return nil, err
+	args := append([]any{pq.Array(roleFilters)}, clause.Args...)
+	if err := r.db.Select(&usersWithRoles, stmt, args...); err != nil {
+		return pagination.Page[model.User]{}, err
 	}
 
+	// Group the roles of each user, keeping the users in the order they were returned.
+	userIDs := make([]uuid.UUID, 0)
 	userMap := make(map[uuid.UUID][]userRoleJoin)
 	for _, u := range usersWithRoles {
+		if _, ok := userMap[u.ID]; !ok {
+			userIDs = append(userIDs, u.ID)
+		}
 		userMap[u.ID] = append(userMap[u.ID], u)
 	}
 
-	users := make([]model.User, 0, len(userMap))
-	for _, u := range userMap {
-		user, err := r.userRoleJoinToUserModel(u)
+	users := make([]model.User, 0, len(userIDs))
+	for _, id := range userIDs {
+		user, err := r.userRoleJoinToUserModel(userMap[id])
 		if err != nil {
-			return nil, err
+			return pagination.Page[model.User]{}, err
 		}
 		users = append(users, user)
 	}
 
-	return users, nil
+	return usersKeyset.Page(users, page), nil
 }
 
 func (r *postgresUserRepository) Get(id uuid.UUID) (model.User, error) {
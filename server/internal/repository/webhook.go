@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"quantum/internal/model"
+	"quantum/internal/types/pagination"
+	"strconv"
+	"time"
+)
+
+type WebhookRepository interface {
+	List() ([]model.WebhookModel, error)
+	Get(id uuid.UUID) (model.WebhookModel, error)
+	Create(webhook *model.WebhookModel) error
+	Update(webhook *model.WebhookModel) error
+	Delete(id uuid.UUID) error
+	ListDeliveries(webhookID uuid.UUID, page pagination.Params) (pagination.Page[model.WebhookDeliveryModel], error)
+	ListDeliveryAttempts(deliveryIDs []int64) ([]model.WebhookDeliveryAttemptModel, error)
+	Redeliver(webhookID uuid.UUID, deliveryID int64) error
+	ClaimDueDeliveries(limit int, lease time.Duration) ([]model.WebhookDeliveryWithSecretModel, error)
+	RecordAttempt(attempt *model.WebhookDeliveryAttemptModel, status model.WebhookDeliveryStatus, nextAttemptAt time.Time) error
+}
+
+// webhookDeliveriesKeyset is the sorting and paging of the deliveries of a webhook, newest first.
+var webhookDeliveriesKeyset = pagination.Keyset[model.WebhookDeliveryModel]{
+	Name: "webhook-deliveries",
+	Fields: map[string]pagination.SortField[model.WebhookDeliveryModel]{
+		"createdAt": {
+			Column: "created_at",
+			Type:   "timestamptz",
+			Value:  func(d model.WebhookDeliveryModel) string { return d.CreatedAt.Format(time.RFC3339Nano) },
+		},
+	},
+	DefaultSort:      "createdAt",
+	DefaultDirection: pagination.Descending,
+	IDColumn:         "id",
+	IDType:           "bigint",
+	ID:               func(d model.WebhookDeliveryModel) string { return strconv.FormatInt(d.ID, 10) },
+}
+
+type postgresWebhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) WebhookRepository {
+	return &postgresWebhookRepository{
+		db: db,
+	}
+}
+
+func (r *postgresWebhookRepository) List() ([]model.WebhookModel, error) {
+	stmt := "select * from webhooks order by created_at;"
+
+	var webhooks = make([]model.WebhookModel, 0)
+	if err := r.db.Select(&webhooks, stmt); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *postgresWebhookRepository) Get(id uuid.UUID) (model.WebhookModel, error) {
+	stmt := "select * from webhooks where id = $1;"
+
+	var webhook model.WebhookModel
+	if err := r.db.Get(&webhook, stmt, id); err != nil {
+		return model.WebhookModel{}, err
+	}
+	return webhook, nil
+}
+
+func (r *postgresWebhookRepository) Create(webhook *model.WebhookModel) error {
+	stmt := `
+		insert into webhooks (url, event_types, secret, active)
+		values ($1, $2, $3, $4)
+		returning id, created_at, updated_at;`
+
+	return r.db.Get(webhook, stmt, webhook.URL, webhook.EventTypes, webhook.Secret, webhook.Active)
+}
+
+// Update updates the URL, event types, secret and active state of the webhook.
+// Returns sql.ErrNoRows if the webhook does not exist.
+func (r *postgresWebhookRepository) Update(webhook *model.WebhookModel) error {
+	stmt := `
+		update webhooks
+		set url = $2, event_types = $3, secret = $4, active = $5, updated_at = now()
+		where id = $1
+		returning created_at, updated_at;`
+
+	return r.db.Get(webhook, stmt, webhook.ID, webhook.URL, webhook.EventTypes, webhook.Secret, webhook.Active)
+}
+
+// Delete removes the webhook along with its deliveries.
+// Returns sql.ErrNoRows if the webhook does not exist.
+func (r *postgresWebhookRepository) Delete(id uuid.UUID) error {
+	result, err := r.db.Exec("delete from webhooks where id = $1;", id)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *postgresWebhookRepository) ListDeliveries(webhookID uuid.UUID, page pagination.Params) (pagination.Page[model.WebhookDeliveryModel], error) {
+	clause, err := webhookDeliveriesKeyset.Clause(page, 2)
+	if err != nil {
+		return pagination.Page[model.WebhookDeliveryModel]{}, err
+	}
+
+	stmt := fmt.Sprintf(`
+		select *
+		from webhook_deliveries
+		where webhook_id = $1 and %s
+		order by %s
+		%s;`, clause.Where, clause.OrderBy, clause.Limit)
+
+	var deliveries = make([]model.WebhookDeliveryModel, 0)
+	args := append([]any{webhookID}, clause.Args...)
+	if err := r.db.Select(&deliveries, stmt, args...); err != nil {
+		return pagination.Page[model.WebhookDeliveryModel]{}, err
+	}
+	return webhookDeliveriesKeyset.Page(deliveries, page), nil
+}
+
+// ListDeliveryAttempts returns the attempts of the given deliveries, oldest first.
+func (r *postgresWebhookRepository) ListDeliveryAttempts(deliveryIDs []int64) ([]model.WebhookDeliveryAttemptModel, error) {
+	stmt := `
+		select *
+		from webhook_delivery_attempts
+		where delivery_id = any($1)
+		order by created_at, id;`
+
+	var attempts = make([]model.WebhookDeliveryAttemptModel, 0)
+	if err := r.db.Select(&attempts, stmt, pq.Array(deliveryIDs)); err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// Redeliver queues the delivery to be sent again straight away, with a fresh set of retries.
+// Returns sql.ErrNoRows if the delivery does not exist for the webhook.
+func (r *postgresWebhookRepository) Redeliver(webhookID uuid.UUID, deliveryID int64) error {
+	stmt := `
+		update webhook_deliveries
+		set status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = null
+		where id = $1 and webhook_id = $2;`
+
+	result, err := r.db.Exec(stmt, deliveryID, webhookID)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries of active webhooks that are due to be sent.
+// The claimed deliveries are pushed back by the lease so that no other dispatcher claims them while they are sent,
+// if the dispatcher stops before recording an attempt the delivery is picked up again once the lease expires.
+func (r *postgresWebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]model.WebhookDeliveryWithSecretModel, error) {
+	stmt := `
+		with due as (
+			select d.id
+			from webhook_deliveries d
+			join webhooks w on d.webhook_id = w.id
+			where d.status = 'pending'
+				and d.next_attempt_at <= now()
+				and w.active = true
+			order by d.next_attempt_at
+			limit $1
+			for update of d skip locked
+		)
+		update webhook_deliveries d
+		set next_attempt_at = now() + make_interval(secs => $2)
+		from due, webhooks w
+		where d.id = due.id and d.webhook_id = w.id
+		returning d.*, w.url, w.secret;`
+
+	var deliveries = make([]model.WebhookDeliveryWithSecretModel, 0)
+	if err := r.db.Select(&deliveries, stmt, limit, lease.Seconds()); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// RecordAttempt stores the attempt and moves the delivery to the given status.
+// A pending delivery is next attempted at nextAttemptAt.
+func (r *postgresWebhookRepository) RecordAttempt(attempt *model.WebhookDeliveryAttemptModel, status model.WebhookDeliveryStatus, nextAttemptAt time.Time) error {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	insertStmt := `
+		insert into webhook_delivery_attempts (delivery_id, status_code, error, duration_ms)
+		values ($1, $2, $3, $4)
+		returning id, created_at;`
+
+	if err = tx.Get(attempt, insertStmt, attempt.DeliveryID, attempt.StatusCode, attempt.Error, attempt.DurationMS); err != nil {
+		return fmt.Errorf("failed to insert attempt: %w", err)
+	}
+
+	updateStmt := `
+		update webhook_deliveries
+		set attempts = attempts + 1,
+			status = $2,
+			next_attempt_at = $3,
+			delivered_at = case when $2 = 'delivered' then now() end
+		where id = $1;`
+
+	if _, err = tx.Exec(updateStmt, attempt.DeliveryID, status, nextAttemptAt); err != nil {
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"slices"
+)
+
+var (
+	ErrAuditNotFound          = errors.New("audit session not found")
+	ErrAuditClosed            = errors.New("audit session is closed")
+	ErrAuditNotClosed         = errors.New("audit session must be closed before its corrections are applied")
+	ErrAuditAlreadyApplied    = errors.New("audit corrections have already been applied")
+	ErrAuditScanNotFound      = errors.New("item was not scanned in this audit session")
+	ErrAuditCodeNotItem       = errors.New("code does not match an item")
+	ErrAuditLocationOutOfArea = errors.New("location is not part of the audited area")
+)
+
+type AuditService struct {
+	auditRepo    repository.AuditRepository
+	locationRepo repository.LocationRepository
+	scanService  *ScanService
+}
+
+func NewAuditService(
+	auditRepo repository.AuditRepository,
+	itemRepo repository.ItemRepository,
+	locationRepo repository.LocationRepository,
+) *AuditService {
+	return &AuditService{
+		auditRepo:    auditRepo,
+		locationRepo: locationRepo,
+		scanService:  NewScanService(itemRepo, locationRepo),
+	}
+}
+
+// Start opens an audit session for the location in the request.
+func (s *AuditService) Start(request dto.StartAuditRequest, startedByUserID uuid.UUID) (dto.AuditSessionResponse, error) {
+	if err := request.Validate(); err != nil {
+		return dto.AuditSessionResponse{}, err
+	}
+
+	location, err := s.getAuditableLocation(request.LocationID)
+	if err != nil {
+		return dto.AuditSessionResponse{}, err
+	}
+
+	session := model.AuditSessionModel{
+		LocationID:      location.ID,
+		LocationName:    location.Name,
+		IncludeChildren: request.IncludeChildren,
+		StartedByUserID: startedByUserID,
+	}
+	if err := s.auditRepo.Create(&session); err != nil {
+		return dto.AuditSessionResponse{}, err
+	}
+	return dto.NewAuditSessionResponseFromModel(session), nil
+}
+
+// List lists the audit sessions, most recently started first, optionally narrowed to a location and a status.
+func (s *AuditService) List(locationID *uuid.UUID, status *model.AuditStatus) ([]dto.AuditSessionResponse, error) {
+	sessions, err := s.auditRepo.List(locationID, status)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.AuditSessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = dto.NewAuditSessionResponseFromModel(session)
+	}
+	return responses, nil
+}
+
+// Get returns the session with its scans and, once it is closed, its reconciliation.
+func (s *AuditService) Get(id uuid.UUID) (dto.AuditSessionDetailResponse, error) {
+	session, err := s.getSession(id)
+	if err != nil {
+		return dto.AuditSessionDetailResponse{}, err
+	}
+
+	scans, err := s.auditRepo.ListScans(id)
+	if err != nil {
+		return dto.AuditSessionDetailResponse{}, err
+	}
+
+	response := dto.AuditSessionDetailResponse{
+		AuditSessionResponse: dto.NewAuditSessionResponseFromModel(session),
+		Scans:                make([]dto.AuditScanResponse, len(scans)),
+	}
+	for i, scan := range scans {
+		response.Scans[i] = dto.NewAuditScanResponseFromModel(scan)
+	}
+
+	if session.Status == model.AuditStatusClosed {
+		results, err := s.auditRepo.ListResults(id)
+		if err != nil {
+			return dto.AuditSessionDetailResponse{}, err
+		}
+		reconciliation := dto.NewAuditReconciliationResponse(results)
+		response.Reconciliation = &reconciliation
+	}
+	return response, nil
+}
+
+// Scan resolves the code to an item and records it as found in the session.
+// The item is found at the location in the request, which must be the audited location or, when the session
+// includes children, a location below it. The audited location is used when none is given.
+func (s *AuditService) Scan(id uuid.UUID, request dto.AuditScanRequest, scannedByUserID uuid.UUID) (dto.AuditScanResponse, error) {
+	if err := request.Validate(); err != nil {
+		return dto.AuditScanResponse{}, err
+	}
+
+	session, err := s.getSession(id)
+	if err != nil {
+		return dto.AuditScanResponse{}, err
+	}
+	if session.Status != model.AuditStatusOpen {
+		return dto.AuditScanResponse{}, ErrAuditClosed
+	}
+
+	location := model.LocationModel{ID: session.LocationID, Name: session.LocationName}
+	if request.LocationID != nil && *request.LocationID != session.LocationID {
+		if location, err = s.getScanLocation(session, *request.LocationID); err != nil {
+			return dto.AuditScanResponse{}, err
+		}
+	}
+
+	resolved, err := s.scanService.Resolve(request.Code)
+	if err != nil {
+		return dto.AuditScanResponse{}, err
+	}
+	if resolved.Type != dto.ScanResultItem {
+		return dto.AuditScanResponse{}, ErrAuditCodeNotItem
+	}
+	if resolved.Item.Deleted {
+		return dto.AuditScanResponse{}, ErrItemDeleted
+	}
+	if resolved.Item.Kind == model.ItemKindStock {
+		return dto.AuditScanResponse{}, ErrItemIsStock
+	}
+
+	scan := model.AuditScanModel{
+		SessionID:       session.ID,
+		ItemID:          &resolved.Item.ID,
+		ItemReference:   resolved.Item.Reference,
+		ItemIdentifier:  resolved.Item.Identifier,
+		LocationID:      location.ID,
+		LocationName:    location.Name,
+		Code:            resolved.Code,
+		ScannedByUserID: scannedByUserID,
+	}
+	if err := s.auditRepo.AddScan(&scan); err != nil {
+		return dto.AuditScanResponse{}, mapAuditError(err)
+	}
+	return dto.NewAuditScanResponseFromModel(scan), nil
+}
+
+// DeleteScan removes an item scanned into an open session by mistake.
+func (s *AuditService) DeleteScan(id, itemID uuid.UUID) error {
+	if err := s.auditRepo.DeleteScan(id, itemID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if _, err := s.getSession(id); err != nil {
+				return err
+			}
+			return ErrAuditScanNotFound
+		}
+		return mapAuditError(err)
+	}
+	return nil
+}
+
+// Close closes the session and returns it with its reconciliation.
+func (s *AuditService) Close(id, closedByUserID uuid.UUID) (dto.AuditSessionDetailResponse, error) {
+	if err := s.auditRepo.Close(id, closedByUserID); err != nil {
+		return dto.AuditSessionDetailResponse{}, mapAuditError(err)
+	}
+	return s.Get(id)
+}
+
+// Apply tracks every item found somewhere other than where it was recorded to where it was found, and the missing
+// items to the location in the request if one is given. Retired and disposed items are left where they are,
+// as are items tracked since the session closed, which are reported as stale. Corrections can only be applied once
+// per session.
+func (s *AuditService) Apply(id uuid.UUID, request dto.ApplyAuditRequest, appliedByUserID uuid.UUID) (dto.ApplyAuditResponse, error) {
+	if request.MissingLocationID != nil {
+		if _, err := s.getAuditableLocation(*request.MissingLocationID); err != nil {
+			return dto.ApplyAuditResponse{}, err
+		}
+	}
+
+	corrections, err := s.auditRepo.ApplyCorrections(id, appliedByUserID, request.MissingLocationID)
+	if err != nil {
+		return dto.ApplyAuditResponse{}, mapAuditError(err)
+	}
+	return dto.ApplyAuditResponse{Tracked: corrections.Tracked, Stale: corrections.Stale}, nil
+}
+
+func (s *AuditService) getSession(id uuid.UUID) (model.AuditSessionModel, error) {
+	session, err := s.auditRepo.Get(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.AuditSessionModel{}, ErrAuditNotFound
+		}
+		return model.AuditSessionModel{}, err
+	}
+	return session, nil
+}
+
+// getAuditableLocation returns the location if it exists and is not deleted.
+func (s *AuditService) getAuditableLocation(id uuid.UUID) (model.LocationModel, error) {
+	location, err := s.locationRepo.Get(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.LocationModel{}, ErrLocationNotFound
+		}
+		return model.LocationModel{}, err
+	}
+	if location.IsDeleted {
+		return model.LocationModel{}, ErrLocationDeleted
+	}
+	return location, nil
+}
+
+// getScanLocation returns the location an item was scanned at if it is part of the area audited by the session.
+func (s *AuditService) getScanLocation(session model.AuditSessionModel, id uuid.UUID) (model.LocationModel, error) {
+	location, err := s.getAuditableLocation(id)
+	if err != nil {
+		return model.LocationModel{}, err
+	}
+	if !session.IncludeChildren {
+		return model.LocationModel{}, ErrAuditLocationOutOfArea
+	}
+
+	ancestors, err := s.locationRepo.ListAncestors([]uuid.UUID{id})
+	if err != nil {
+		return model.LocationModel{}, err
+	}
+	if !slices.ContainsFunc(ancestors, func(a model.LocationAncestorModel) bool { return a.ID == session.LocationID }) {
+		return model.LocationModel{}, ErrAuditLocationOutOfArea
+	}
+	return location, nil
+}
+
+func mapAuditError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrAuditNotFound
+	case errors.Is(err, repository.ErrAuditSessionNotOpen):
+		return ErrAuditClosed
+	case errors.Is(err, repository.ErrAuditSessionNotClosed):
+		return ErrAuditNotClosed
+	case errors.Is(err, repository.ErrAuditCorrectionsApplied):
+		return ErrAuditAlreadyApplied
+	}
+	return err
+}
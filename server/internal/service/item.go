@@ -2,30 +2,87 @@ package service
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"quantum/internal/dto"
 	"quantum/internal/model"
+	"quantum/internal/permissions"
 	"quantum/internal/repository"
+	"quantum/internal/types/pagination"
+	"time"
 )
 
-var ErrItemNotFound = errors.New("item not found")
+// importBatchSize is the number of items created per transaction when importing items.
+const importBatchSize = 500
+
+// Placeholder names used in item history for users, locations and items that no longer exist.
+const (
+	unknownUserName      = "Unknown user"
+	unknownLocationName  = "Unknown location"
+	unknownItemReference = "Unknown item"
+)
+
+var (
+	ErrItemNotFound        = errors.New("item not found")
+	ErrItemReferenceExists = errors.New("item reference already exists")
+	ErrItemNotDeleted      = errors.New("item is not deleted")
+	ErrItemsNotTracked     = errors.New("one or more items could not be tracked")
+	ErrItemNotPresentAt    = errors.New("item did not exist at the given time")
+	ErrInvalidDateRange    = errors.New("from date must be before to date")
+)
+
+// Errors returned when items cannot be tracked to a location or user.
+var (
+	ErrItemDeleted     = errors.New("item is deleted")
+	ErrItemIsStock     = errors.New("stock items are moved by quantity and cannot be tracked as a unit")
+	ErrLocationDeleted = errors.New("location is deleted")
+	ErrUserDeleted     = errors.New("user is deleted")
+	ErrUserNotTracker  = errors.New("user does not have the tracker role")
+)
+
+var (
+	ErrItemNotCheckedOut = errors.New("item is not checked out to a user")
+	ErrInvalidDueDate    = errors.New("due date must be in the future")
+)
+
+// Errors returned when items cannot be put inside or taken out of other items.
+var (
+	ErrContainerNotFound    = errors.New("container item not found")
+	ErrContainerDeleted     = errors.New("container item is deleted")
+	ErrItemContainmentCycle = errors.New("item cannot be put inside itself or an item inside it")
+	ErrItemNotContained     = errors.New("item is not inside another item")
+)
+
+var ErrGroupCustomFieldsNotFound = errors.New("group has no custom fields")
+
+// Errors returned when the lifecycle status of an item cannot be changed, or does not allow it to be tracked.
+var (
+	ErrItemNotTrackable            = errors.New("item is retired or disposed and cannot be tracked")
+	ErrItemStatusUnchanged         = errors.New("item already has the status")
+	ErrItemStatusTransitionDenied  = errors.New("item cannot be changed from its current status to the status")
+	ErrItemStatusChangedConcurrent = errors.New("item status was changed by another request, try again")
+)
 
 type ItemService struct {
-	itemRepo     repository.ItemRepository
-	locationRepo repository.LocationRepository
-	userRepo     repository.UserRepository
+	itemRepo        repository.ItemRepository
+	locationRepo    repository.LocationRepository
+	userRepo        repository.UserRepository
+	reservationRepo repository.ReservationRepository
 }
 
 func NewItemService(
 	itemRepo repository.ItemRepository,
 	locationRepo repository.LocationRepository,
 	userRepo repository.UserRepository,
+	reservationRepo repository.ReservationRepository,
 ) *ItemService {
 	return &ItemService{
-		itemRepo:     itemRepo,
-		locationRepo: locationRepo,
-		userRepo:     userRepo,
+		itemRepo:        itemRepo,
+		locationRepo:    locationRepo,
+		userRepo:        userRepo,
+		reservationRepo: reservationRepo,
 	}
 }
 
@@ -52,48 +109,127 @@ func (s *ItemService) Get(id uuid.UUID) (dto.ItemWithCurrentLocationResponse, er
 	return item, nil
 }
 
-func (s *ItemService) List(groupKeyFilter *string) ([]dto.ItemWithCurrentLocationResponse, error) {
-	items, err := s.itemRepo.List(groupKeyFilter)
+// GetDetail returns the item with the item it is inside of and the items directly inside it.
+func (s *ItemService) GetDetail(id uuid.UUID) (dto.ItemDetailResponse, error) {
+	item, err := s.Get(id)
 	if err != nil {
-		return nil, err
+		return dto.ItemDetailResponse{}, err
 	}
 
-	var itemsResponse = make([]dto.ItemWithCurrentLocationResponse, len(items))
-	for i, item := range items {
-		itemsResponse[i] = dto.ItemWithCurrentLocationResponse{
-			ItemResponse: dto.NewItemResponseFromModel(item.ItemModel, nil),
-			CurrentLocation: dto.CurrentLocation{
-				ID:          item.LocationID,
-				Name:        item.LocationName,
-				Description: item.LocationDescription,
-				TrackedAt:   item.TrackedAt,
-			},
+	response := dto.ItemDetailResponse{ItemWithCurrentLocationResponse: item}
+
+	container, err := s.itemRepo.GetContainer(id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return dto.ItemDetailResponse{}, err
+	}
+	if err == nil {
+		containerResponse := dto.NewItemResponseFromModel(container, nil)
+		response.Container = &containerResponse
+	}
+
+	contents, err := s.itemRepo.ListContents(id)
+	if err != nil {
+		return dto.ItemDetailResponse{}, err
+	}
+	response.Contents = make([]dto.ItemResponse, len(contents))
+	for i, content := range contents {
+		response.Contents[i] = dto.NewItemResponseFromModel(content, nil)
+	}
+
+	return response, nil
+}
+
+// List lists the items that are not deleted, optionally filtered by group, status and the text of custom field values.
+func (s *ItemService) List(groupKeyFilter *string, statusFilter *model.ItemStatus, customFieldFilter map[string]string, page pagination.Params) (pagination.Page[dto.ItemWithCurrentLocationResponse], error) {
+	items, err := s.itemRepo.List(groupKeyFilter, statusFilter, customFieldFilter, page)
+	if err != nil {
+		return pagination.Page[dto.ItemWithCurrentLocationResponse]{}, err
+	}
+
+	return pagination.MapPage(items, newItemWithCurrentLocationResponse), nil
+}
+
+// ListByLocationID lists the items currently at the location, or anywhere below it when recursive.
+func (s *ItemService) ListByLocationID(locationID uuid.UUID, recursive bool, statusFilter *model.ItemStatus, page pagination.Params) (pagination.Page[dto.ItemWithCurrentLocationResponse], error) {
+	items, err := s.itemRepo.ListByLocationID(locationID, recursive, statusFilter, page)
+	if err != nil {
+		return pagination.Page[dto.ItemWithCurrentLocationResponse]{}, err
+	}
+
+	return pagination.MapPage(items, newItemWithCurrentLocationResponse), nil
+}
+
+// GetLocationAt returns the location or user the item was tracked to at the given time.
+func (s *ItemService) GetLocationAt(itemID uuid.UUID, at time.Time) (dto.ItemLocationAtResponse, error) {
+	if _, err := s.itemRepo.Get(itemID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.ItemLocationAtResponse{}, ErrItemNotFound
 		}
+		return dto.ItemLocationAtResponse{}, err
 	}
 
-	return itemsResponse, nil
+	item, err := s.itemRepo.GetWithLocationAt(itemID, at)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.ItemLocationAtResponse{}, ErrItemNotPresentAt
+		}
+		return dto.ItemLocationAtResponse{}, err
+	}
+
+	return dto.ItemLocationAtResponse{
+		ItemID: item.ID,
+		At:     at,
+		Location: dto.CurrentLocation{
+			ID:            item.LocationID,
+			Name:          item.LocationName,
+			Description:   item.LocationDescription,
+			TrackedAt:     item.TrackedAt,
+			TrackedToUser: item.TrackedToUser,
+		},
+	}, nil
 }
 
-func (s *ItemService) ListByLocationID(locationID uuid.UUID) ([]dto.ItemWithCurrentLocationResponse, error) {
-	items, err := s.itemRepo.ListByLocationID(locationID)
+// ListByLocationIDAt lists the items that were at the location, or anywhere below it when recursive, at the given time.
+func (s *ItemService) ListByLocationIDAt(locationID uuid.UUID, recursive bool, at time.Time, page pagination.Params) (pagination.Page[dto.ItemWithCurrentLocationResponse], error) {
+	items, err := s.itemRepo.ListByLocationIDAt(locationID, recursive, at, page)
 	if err != nil {
-		return nil, err
+		return pagination.Page[dto.ItemWithCurrentLocationResponse]{}, err
 	}
 
-	var itemsResponse = make([]dto.ItemWithCurrentLocationResponse, len(items))
-	for i, item := range items {
-		itemsResponse[i] = dto.ItemWithCurrentLocationResponse{
-			ItemResponse: dto.NewItemResponseFromModel(item.ItemModel, nil),
-			CurrentLocation: dto.CurrentLocation{
-				ID:          item.LocationID,
-				Name:        item.LocationName,
-				Description: item.LocationDescription,
-				TrackedAt:   item.TrackedAt,
-			},
+	return pagination.MapPage(items, newItemWithCurrentLocationResponse), nil
+}
+
+// LocationDiff compares the contents of the location between the from and to times.
+func (s *ItemService) LocationDiff(locationID uuid.UUID, from, to time.Time) (dto.LocationDiffResponse, error) {
+	if !from.Before(to) {
+		return dto.LocationDiffResponse{}, ErrInvalidDateRange
+	}
+
+	if _, err := s.locationRepo.Get(locationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.LocationDiffResponse{}, ErrLocationNotFound
 		}
+		return dto.LocationDiffResponse{}, err
 	}
 
-	return itemsResponse, nil
+	items, err := s.itemRepo.ListLocationDiff(locationID, from, to)
+	if err != nil {
+		return dto.LocationDiffResponse{}, err
+	}
+
+	return dto.NewLocationDiffResponse(locationID, from, to, items), nil
+}
+
+func newItemWithCurrentLocationResponse(item model.ItemWithCurrentLocationModel) dto.ItemWithCurrentLocationResponse {
+	return dto.ItemWithCurrentLocationResponse{
+		ItemResponse: dto.NewItemResponseFromModel(item.ItemModel, nil),
+		CurrentLocation: dto.CurrentLocation{
+			ID:          item.LocationID,
+			Name:        item.LocationName,
+			Description: item.LocationDescription,
+			TrackedAt:   item.TrackedAt,
+		},
+	}
 }
 
 func (s *ItemService) ListItemGroups(max int, filter string) ([]string, error) {
@@ -108,17 +244,129 @@ func (s *ItemService) GroupKeyExists(groupKey string) (bool, error) {
 	return s.itemRepo.GroupKeyExists(groupKey)
 }
 
+// ListGroupCustomFields lists the custom field definitions of every group that has them.
+func (s *ItemService) ListGroupCustomFields() ([]dto.GroupCustomFieldsResponse, error) {
+	groups, err := s.itemRepo.ListGroupCustomFields()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.GroupCustomFieldsResponse, len(groups))
+	for i, group := range groups {
+		fields, err := group.ParseFields()
+		if err != nil {
+			return nil, err
+		}
+		response[i] = dto.GroupCustomFieldsResponse{
+			GroupKey:  group.GroupKey,
+			Fields:    fields,
+			UpdatedAt: &group.UpdatedAt,
+		}
+	}
+	return response, nil
+}
+
+// GetGroupCustomFields returns the custom field definitions of the group, which are empty if it has none.
+func (s *ItemService) GetGroupCustomFields(groupKey string) (dto.GroupCustomFieldsResponse, error) {
+	group, err := s.itemRepo.GetGroupCustomFields(groupKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.GroupCustomFieldsResponse{
+				GroupKey: groupKey,
+				Fields:   make([]model.CustomFieldDefinition, 0),
+			}, nil
+		}
+		return dto.GroupCustomFieldsResponse{}, err
+	}
+
+	fields, err := group.ParseFields()
+	if err != nil {
+		return dto.GroupCustomFieldsResponse{}, err
+	}
+	return dto.GroupCustomFieldsResponse{
+		GroupKey:  group.GroupKey,
+		Fields:    fields,
+		UpdatedAt: &group.UpdatedAt,
+	}, nil
+}
+
+// SetGroupCustomFields replaces the custom field definitions of the group.
+// They are checked when items in the group are next created or updated, existing values are not revalidated.
+func (s *ItemService) SetGroupCustomFields(groupKey string, req dto.SetGroupCustomFieldsRequest) (dto.GroupCustomFieldsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return dto.GroupCustomFieldsResponse{}, err
+	}
+
+	fields, err := json.Marshal(req.Fields)
+	if err != nil {
+		return dto.GroupCustomFieldsResponse{}, err
+	}
+
+	group := model.GroupCustomFieldsModel{
+		GroupKey: groupKey,
+		Fields:   fields,
+	}
+	if err := s.itemRepo.SetGroupCustomFields(&group); err != nil {
+		return dto.GroupCustomFieldsResponse{}, err
+	}
+
+	return dto.GroupCustomFieldsResponse{
+		GroupKey:  group.GroupKey,
+		Fields:    req.Fields,
+		UpdatedAt: &group.UpdatedAt,
+	}, nil
+}
+
+func (s *ItemService) DeleteGroupCustomFields(groupKey string) error {
+	if err := s.itemRepo.DeleteGroupCustomFields(groupKey); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrGroupCustomFieldsNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// customFieldDefinitions returns the custom field definitions of the group, nil if it has none.
+func (s *ItemService) customFieldDefinitions(groupKey string) ([]model.CustomFieldDefinition, error) {
+	group, err := s.itemRepo.GetGroupCustomFields(groupKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return group.ParseFields()
+}
+
+// Create validates the request, including its custom fields against those defined for its group, and creates the item.
 func (s *ItemService) Create(userID uuid.UUID, req dto.CreateItemRequest) (dto.ItemResponse, error) {
+	definitions, err := s.customFieldDefinitions(req.GroupKey)
+	if err != nil {
+		return dto.ItemResponse{}, err
+	}
+
+	if err := req.Validate(definitions); err != nil {
+		return dto.ItemResponse{}, err
+	}
+
 	location, err := s.locationRepo.Get(req.LocationID)
 	if err != nil {
 		return dto.ItemResponse{}, ErrLocationNotFound
 	}
 
+	customFields, err := json.Marshal(req.CustomFields)
+	if err != nil {
+		return dto.ItemResponse{}, err
+	}
+
 	itemModel := model.ItemModel{
-		Identifier:  req.Identifier,
-		Reference:   req.Reference,
-		GroupKey:    req.GroupKey,
-		Description: req.Description,
+		Identifier:   req.Identifier,
+		Reference:    req.Reference,
+		GroupKey:     req.GroupKey,
+		Description:  req.Description,
+		Kind:         req.Kind,
+		CustomFields: customFields,
 	}
 
 	if err := s.itemRepo.Create(&itemModel, userID, location.ID); err != nil {
@@ -126,13 +374,16 @@ func (s *ItemService) Create(userID uuid.UUID, req dto.CreateItemRequest) (dto.I
 	}
 
 	return dto.ItemResponse{
-		ID:          itemModel.ID,
-		Identifier:  itemModel.Identifier,
-		Reference:   itemModel.Reference,
-		GroupKey:    itemModel.GroupKey,
-		Description: itemModel.Description,
-		CreatedAt:   itemModel.CreatedAt,
-		UpdatedAt:   itemModel.UpdatedAt,
+		ID:           itemModel.ID,
+		Identifier:   itemModel.Identifier,
+		Reference:    itemModel.Reference,
+		GroupKey:     itemModel.GroupKey,
+		Description:  itemModel.Description,
+		Kind:         itemModel.Kind,
+		Status:       itemModel.Status,
+		CustomFields: itemModel.CustomFields,
+		CreatedAt:    itemModel.CreatedAt,
+		UpdatedAt:    itemModel.UpdatedAt,
 		CurrentLocation: &dto.ItemCurrentLocation{
 			ID:   location.ID,
 			Name: location.Name,
@@ -140,11 +391,198 @@ func (s *ItemService) Create(userID uuid.UUID, req dto.CreateItemRequest) (dto.I
 	}, nil
 }
 
+// Import validates the given rows and, unless dryRun is set, creates an item for every valid row.
+// Rows are checked for a reference, that the reference is unique in both the file and the database,
+// and that the named location exists. Invalid rows are reported and skipped, valid rows are created in batches.
+func (s *ItemService) Import(userID uuid.UUID, rows []dto.ImportItemRow, dryRun bool) (dto.ImportItemsResponse, error) {
+	references := make([]string, 0, len(rows))
+	locationNames := make([]string, 0, len(rows))
+	for _, row := range rows {
+		references = append(references, row.Reference)
+		locationNames = append(locationNames, row.LocationName)
+	}
+
+	existingReferences, err := s.itemRepo.ListExistingReferences(references)
+	if err != nil {
+		return dto.ImportItemsResponse{}, err
+	}
+
+	existingReferenceSet := make(map[string]struct{}, len(existingReferences))
+	for _, reference := range existingReferences {
+		existingReferenceSet[reference] = struct{}{}
+	}
+
+	locations, err := s.locationRepo.ListByNames(locationNames)
+	if err != nil {
+		return dto.ImportItemsResponse{}, err
+	}
+
+	locationIDsByName := make(map[string]uuid.UUID, len(locations))
+	for _, location := range locations {
+		locationIDsByName[location.Name] = location.ID
+	}
+
+	// Imported items have no custom fields, so rows in groups with required custom fields are invalid.
+	groups, err := s.itemRepo.ListGroupCustomFields()
+	if err != nil {
+		return dto.ImportItemsResponse{}, err
+	}
+
+	customFieldsByGroup := make(map[string][]model.CustomFieldDefinition, len(groups))
+	for _, group := range groups {
+		if customFieldsByGroup[group.GroupKey], err = group.ParseFields(); err != nil {
+			return dto.ImportItemsResponse{}, err
+		}
+	}
+
+	response := dto.ImportItemsResponse{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]dto.ImportItemRowResult, len(rows)),
+	}
+
+	// Indexes into response.Rows of the rows to be created.
+	validRows := make([]int, 0, len(rows))
+	toCreate := make([]repository.ItemToCreate, 0, len(rows))
+	seenReferences := make(map[string]int, len(rows))
+
+	for i, row := range rows {
+		errs := make([]string, 0)
+
+		if row.Reference == "" {
+			errs = append(errs, "reference is required")
+		} else if firstRow, seen := seenReferences[row.Reference]; seen {
+			errs = append(errs, fmt.Sprintf("reference %q is duplicated on row %d", row.Reference, firstRow))
+		} else {
+			seenReferences[row.Reference] = row.Row
+			if _, exists := existingReferenceSet[row.Reference]; exists {
+				errs = append(errs, fmt.Sprintf("reference %q already exists", row.Reference))
+			}
+		}
+
+		locationID, locationFound := locationIDsByName[row.LocationName]
+		if row.LocationName == "" {
+			errs = append(errs, "location is required")
+		} else if !locationFound {
+			errs = append(errs, fmt.Sprintf("location %q does not exist", row.LocationName))
+		}
+
+		if _, err := dto.ValidateCustomFields(customFieldsByGroup[row.GroupKey], nil); err != nil {
+			errs = append(errs, err.Error())
+		}
+
+		response.Rows[i] = dto.ImportItemRowResult{
+			Row:       row.Row,
+			Reference: row.Reference,
+			Valid:     len(errs) == 0,
+			Errors:    errs,
+		}
+
+		if len(errs) > 0 {
+			response.Invalid++
+			continue
+		}
+
+		response.Valid++
+		validRows = append(validRows, i)
+		toCreate = append(toCreate, repository.ItemToCreate{
+			Item: &model.ItemModel{
+				Identifier:  row.Identifier,
+				Reference:   row.Reference,
+				GroupKey:    row.GroupKey,
+				Description: row.Description,
+			},
+			LocationID: locationID,
+		})
+	}
+
+	if dryRun {
+		return response, nil
+	}
+
+	for start := 0; start < len(toCreate); start += importBatchSize {
+		end := min(start+importBatchSize, len(toCreate))
+
+		if err := s.itemRepo.CreateBatch(toCreate[start:end], userID); err != nil {
+			// A reference created since the rows were checked only fails its own batch, anything else fails the import.
+			if !errors.Is(err, repository.ErrItemReferenceExists) {
+				return dto.ImportItemsResponse{}, fmt.Errorf("failed to import batch starting at row %d, %d items were imported before it: %w",
+					response.Rows[validRows[start]].Row, response.Imported, err)
+			}
+			for _, rowIndex := range validRows[start:end] {
+				response.Rows[rowIndex].Errors = append(response.Rows[rowIndex].Errors, "failed to import, a reference in this batch already exists")
+			}
+			continue
+		}
+
+		for i, rowIndex := range validRows[start:end] {
+			itemID := toCreate[start+i].Item.ID
+			response.Rows[rowIndex].Imported = true
+			response.Rows[rowIndex].ItemID = &itemID
+			response.Imported++
+		}
+	}
+
+	return response, nil
+}
+
+// Update validates the request and replaces the fields of the item. When the request has no custom fields
+// the current ones are kept, and are validated against the custom fields defined for the group of the request.
+func (s *ItemService) Update(itemID, userID uuid.UUID, req dto.UpdateItemRequest) (dto.ItemResponse, error) {
+	if req.CustomFields == nil {
+		existing, err := s.itemRepo.Get(itemID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return dto.ItemResponse{}, ErrItemNotFound
+			}
+			return dto.ItemResponse{}, err
+		}
+		if err := json.Unmarshal(existing.CustomFields, &req.CustomFields); err != nil {
+			return dto.ItemResponse{}, err
+		}
+	}
+
+	definitions, err := s.customFieldDefinitions(req.GroupKey)
+	if err != nil {
+		return dto.ItemResponse{}, err
+	}
+
+	if err := req.Validate(definitions); err != nil {
+		return dto.ItemResponse{}, err
+	}
+
+	customFields, err := json.Marshal(req.CustomFields)
+	if err != nil {
+		return dto.ItemResponse{}, err
+	}
+
+	itemModel := model.ItemModel{
+		ID:           itemID,
+		Identifier:   req.Identifier,
+		Reference:    req.Reference,
+		GroupKey:     req.GroupKey,
+		Description:  req.Description,
+		CustomFields: customFields,
+	}
+
+	if err := s.itemRepo.Update(&itemModel, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.ItemResponse{}, ErrItemNotFound
+		}
+		if errors.Is(err, repository.ErrItemReferenceExists) {
+			return dto.ItemResponse{}, ErrItemReferenceExists
+		}
+		return dto.ItemResponse{}, err
+	}
+
+	return dto.NewItemResponseFromModel(itemModel, nil), nil
+}
+
 func (s *ItemService) Delete(itemID, userID uuid.UUID) error {
 	return s.itemRepo.Delete(itemID, userID)
 }
 
-func (s *ItemService) TrackItem(userID, itemID, locationID uuid.UUID) error {
+func (s *ItemService) Restore(itemID, userID uuid.UUID) error {
 	item, err := s.itemRepo.Get(itemID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -153,155 +591,713 @@ func (s *ItemService) TrackItem(userID, itemID, locationID uuid.UUID) error {
 		return err
 	}
 
-	if err := s.itemRepo.AppendNewItemTrackedToLocationHistory(userID, item.ID, locationID); err != nil {
+	if !item.Deleted {
+		return ErrItemNotDeleted
+	}
+
+	if err := s.itemRepo.Restore(itemID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrItemNotDeleted
+		}
 		return err
 	}
 
 	return nil
 }
 
-func (s *ItemService) TrackItemToUser(trackingUserID, toUserID, itemID uuid.UUID) error {
-	item, err := s.itemRepo.Get(itemID)
+// ListDeleted lists the items in the trash, optionally filtered by the user who deleted them and when.
+func (s *ItemService) ListDeleted(deletedByUserID *uuid.UUID, deletedFrom, deletedTo *time.Time, page pagination.Params) (pagination.Page[dto.DeletedItemResponse], error) {
+	items, err := s.itemRepo.ListDeleted(deletedByUserID, deletedFrom, deletedTo, page)
+	if err != nil {
+		return pagination.Page[dto.DeletedItemResponse]{}, err
+	}
+
+	return pagination.MapPage(items, dto.NewDeletedItemResponseFromModel), nil
+}
+
+// PurgeDeleted permanently removes the items that have been in the trash for longer than the retention period.
+func (s *ItemService) PurgeDeleted(retention time.Duration) (int64, error) {
+	return s.itemRepo.PurgeDeleted(time.Now().Add(-retention))
+}
+
+// TrackItem tracks the item to the location.
+func (s *ItemService) TrackItem(userID, itemID, locationID uuid.UUID) error {
+	if err := s.validateTrackingTarget(&locationID, nil); err != nil {
+		return err
+	}
+
+	item, err := s.getTrackableItem(itemID)
+	if err != nil {
+		return err
+	}
+
+	return s.itemRepo.AppendNewItemTrackedToLocationHistory(userID, []uuid.UUID{item.ID}, locationID)
+}
+
+// TrackItemToUser checks the item out to the user, who must be a tracker, optionally due back at dueAt.
+// If another user holds a reservation of the item in progress, the item is still tracked and the reservation is
+// returned as a warning, unless blockReserved is set in which case an ItemReservedError is returned instead.
+func (s *ItemService) TrackItemToUser(trackingUserID, toUserID, itemID uuid.UUID, dueAt *time.Time, blockReserved bool) ([]dto.ReservationResponse, error) {
+	if err := validateDueAt(dueAt); err != nil {
+		return nil, err
+	}
+
+	if err := s.validateTrackingTarget(nil, &toUserID); err != nil {
+		return nil, err
+	}
+
+	item, err := s.getTrackableItem(itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts, err := reservationConflicts(s.reservationRepo, []uuid.UUID{item.ID}, toUserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 && blockReserved {
+		return nil, &ItemReservedError{Reservations: conflicts}
+	}
+
+	if err := s.itemRepo.AppendNewItemTrackedToUserHistory(trackingUserID, toUserID, []uuid.UUID{item.ID}, dueAt); err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
+
+// PutInContainer puts the item inside the container item, moving it and its contents to where the container is.
+// An item can only be inside one container at a time and is taken out of any other container first.
+func (s *ItemService) PutInContainer(userID, itemID uuid.UUID, req dto.PutInContainerRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.getTrackableItem(itemID); err != nil {
+		return err
+	}
+
+	if _, err := s.getTrackableItem(req.ContainerID); err != nil {
+		switch {
+		case errors.Is(err, ErrItemNotFound):
+			return ErrContainerNotFound
+		case errors.Is(err, ErrItemDeleted):
+			return ErrContainerDeleted
+		}
+		return err
+	}
+
+	if err := s.itemRepo.SetContainer(itemID, req.ContainerID, userID); err != nil {
+		if errors.Is(err, repository.ErrContainmentCycle) {
+			return ErrItemContainmentCycle
+		}
+		return err
+	}
+	return nil
+}
+
+// RemoveFromContainer takes the item out of the item it is inside of, leaving it where it is.
+func (s *ItemService) RemoveFromContainer(userID, itemID uuid.UUID) error {
+	if _, err := s.getTrackableItem(itemID); err != nil {
+		return err
+	}
+
+	if err := s.itemRepo.RemoveFromContainer(itemID, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrItemNotContained
+		}
+		return err
+	}
+	return nil
+}
+
+// CheckIn returns an item checked out to a user to the location, or to the location it was at before being checked
+// out when no location is given.
+func (s *ItemService) CheckIn(userID, itemID uuid.UUID, locationID *uuid.UUID) error {
+	if _, err := s.getTrackableItem(itemID); err != nil {
+		return err
+	}
+
+	item, err := s.itemRepo.GetWithCurrentLocation(itemID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ErrItemNotFound
 		}
+		return err
+	}
+	if !item.TrackedToUser {
+		return ErrItemNotCheckedOut
+	}
+
+	if locationID == nil {
+		lastLocationID, err := s.itemRepo.GetLastLocationID(itemID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrLocationNotFound
+			}
+			return err
+		}
+		locationID = &lastLocationID
 	}
 
-	if err := s.itemRepo.AppendNewItemTrackedToUserHistory(trackingUserID, toUserID, item.ID); err != nil {
+	if err := s.validateTrackingTarget(locationID, nil); err != nil {
 		return err
 	}
 
+	return s.itemRepo.AppendNewItemTrackedToLocationHistory(userID, []uuid.UUID{itemID}, *locationID)
+}
+
+// ListUserLoans lists the items currently tracked to the user.
+func (s *ItemService) ListUserLoans(userID uuid.UUID) ([]dto.LoanResponse, error) {
+	loans, err := s.itemRepo.ListLoans(&userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	response := make([]dto.LoanResponse, 0, len(loans))
+	for _, l := range loans {
+		response = append(response, dto.NewLoanResponseFromModel(l, now))
+	}
+	return response, nil
+}
+
+// ListOverdueLoans lists the items past their due date, grouped by the user holding them.
+func (s *ItemService) ListOverdueLoans() ([]dto.LoanHolderResponse, error) {
+	now := time.Now()
+	loans, err := s.itemRepo.ListLoans(nil, &now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Loans are ordered by user, so each holder's loans are consecutive.
+	holders := make([]dto.LoanHolderResponse, 0)
+	for _, l := range loans {
+		if len(holders) == 0 || holders[len(holders)-1].UserID != l.UserID {
+			holders = append(holders, dto.LoanHolderResponse{
+				UserID:   l.UserID,
+				Name:     l.UserName,
+				Username: l.UserUsername,
+				Loans:    make([]dto.LoanResponse, 0, 1),
+			})
+		}
+		holder := &holders[len(holders)-1]
+		holder.Loans = append(holder.Loans, dto.NewLoanResponseFromModel(l, now))
+	}
+	return holders, nil
+}
+
+// validateDueAt returns ErrInvalidDueDate if the due date is not in the future.
+func validateDueAt(dueAt *time.Time) error {
+	if dueAt != nil && !dueAt.After(time.Now()) {
+		return ErrInvalidDueDate
+	}
 	return nil
 }
 
+// validateTrackingTarget checks that items can be tracked to the location, or to the user when no location is given.
+// The location must exist and not be deleted. The user must exist, not be deleted and hold the tracker role.
+func (s *ItemService) validateTrackingTarget(locationID, userID *uuid.UUID) error {
+	if locationID != nil {
+		location, err := s.locationRepo.Get(*locationID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrLocationNotFound
+			}
+			return err
+		}
+		if location.IsDeleted {
+			return ErrLocationDeleted
+		}
+		return nil
+	}
+
+	user, err := s.userRepo.Get(*userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	if user.DeletedAt != nil {
+		return ErrUserDeleted
+	}
+	if !user.Roles.HasRole(permissions.TrackerRole) {
+		return ErrUserNotTracker
+	}
+	return nil
+}
+
+// getTrackableItem returns the item if it exists, is not deleted, is a unit and its status allows tracking.
+func (s *ItemService) getTrackableItem(itemID uuid.UUID) (model.ItemModel, error) {
+	item, err := s.itemRepo.Get(itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ItemModel{}, ErrItemNotFound
+		}
+		return model.ItemModel{}, err
+	}
+	if item.Deleted {
+		return model.ItemModel{}, ErrItemDeleted
+	}
+	if item.Kind == model.ItemKindStock {
+		return model.ItemModel{}, ErrItemIsStock
+	}
+	if !item.Status.Trackable() {
+		return model.ItemModel{}, ErrItemNotTrackable
+	}
+	return item, nil
+}
+
+// ChangeStatus moves the item to the status in the request, if the status settings allow the transition
+// from its current status, recording the change and its reason in the history of the item.
+func (s *ItemService) ChangeStatus(userID, itemID uuid.UUID, req dto.ChangeItemStatusRequest, statuses dto.StatusSettingsResponse) (dto.ItemResponse, error) {
+	if err := req.Validate(); err != nil {
+		return dto.ItemResponse{}, err
+	}
+
+	item, err := s.itemRepo.Get(itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.ItemResponse{}, ErrItemNotFound
+		}
+		return dto.ItemResponse{}, err
+	}
+	if item.Deleted {
+		return dto.ItemResponse{}, ErrItemDeleted
+	}
+	if item.Status == req.Status {
+		return dto.ItemResponse{}, ErrItemStatusUnchanged
+	}
+	if !statuses.Allowed(item.Status, req.Status) {
+		return dto.ItemResponse{}, fmt.Errorf("%w: %s to %s", ErrItemStatusTransitionDenied, item.Status, req.Status)
+	}
+
+	item, err = s.itemRepo.ChangeStatus(item.ID, userID, item.Status, req.Status, req.Reason)
+	if err != nil {
+		if errors.Is(err, repository.ErrItemStatusConflict) {
+			return dto.ItemResponse{}, ErrItemStatusChangedConcurrent
+		}
+		return dto.ItemResponse{}, err
+	}
+
+	return dto.NewItemResponseFromModel(item, nil), nil
+}
+
+// TrackItems tracks many items to a single location or user in one transaction.
+// Every requested item is resolved and checked before anything is written. Unless the request is partial,
+// ErrItemsNotTracked is returned alongside the per-item results if any item cannot be tracked and nothing is written.
+// Items reserved by another user are tracked with a warning, or fail to track when blockReserved is set.
+func (s *ItemService) TrackItems(userID uuid.UUID, req dto.BulkTrackItemsRequest, blockReserved bool) (dto.BulkTrackItemsResponse, error) {
+	if err := validateDueAt(req.DueAt); err != nil {
+		return dto.BulkTrackItemsResponse{}, err
+	}
+
+	if err := s.validateTrackingTarget(req.LocationID, req.UserID); err != nil {
+		return dto.BulkTrackItemsResponse{}, err
+	}
+
+	items, err := s.itemRepo.ListByIDsOrReferences(req.ItemIDs, req.References)
+	if err != nil {
+		return dto.BulkTrackItemsResponse{}, err
+	}
+
+	itemsByID := make(map[uuid.UUID]model.ItemModel, len(items))
+	itemsByReference := make(map[string]model.ItemModel, len(items))
+	for _, item := range items {
+		itemsByID[item.ID] = item
+		itemsByReference[item.Reference] = item
+	}
+
+	response := dto.BulkTrackItemsResponse{
+		Results: make([]dto.BulkTrackItemResult, 0, len(req.ItemIDs)+len(req.References)),
+	}
+
+	reserved := make(map[uuid.UUID]bool)
+	if req.UserID != nil && len(items) > 0 {
+		ids := make([]uuid.UUID, 0, len(items))
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		conflicts, err := reservationConflicts(s.reservationRepo, ids, *req.UserID)
+		if err != nil {
+			return dto.BulkTrackItemsResponse{}, err
+		}
+		if blockReserved {
+			for _, c := range conflicts {
+				reserved[c.ItemID] = true
+			}
+		} else if len(conflicts) > 0 {
+			response.ReservationWarnings = conflicts
+		}
+	}
+
+	itemIDs := make([]uuid.UUID, 0, len(items))
+	seen := make(map[uuid.UUID]struct{}, len(items))
+
+	resolve := func(item model.ItemModel, found bool, result dto.BulkTrackItemResult) dto.BulkTrackItemResult {
+		switch {
+		case !found:
+			result.Error = ErrItemNotFound.Error()
+		case item.Deleted:
+			result.Error = ErrItemDeleted.Error()
+		case item.Kind == model.ItemKindStock:
+			result.Error = ErrItemIsStock.Error()
+		case !item.Status.Trackable():
+			result.Error = ErrItemNotTrackable.Error()
+		case reserved[item.ID]:
+			result.Error = ErrItemReserved.Error()
+		default:
+			if _, duplicate := seen[item.ID]; duplicate {
+				result.Error = "item is included more than once"
+				break
+			}
+			seen[item.ID] = struct{}{}
+			itemIDs = append(itemIDs, item.ID)
+			result.Tracked = true
+		}
+
+		if found {
+			result.ItemID = &item.ID
+			result.Reference = item.Reference
+		}
+		return result
+	}
+
+	for _, id := range req.ItemIDs {
+		item, found := itemsByID[id]
+		response.Results = append(response.Results, resolve(item, found, dto.BulkTrackItemResult{ItemID: &id}))
+	}
+	for _, reference := range req.References {
+		item, found := itemsByReference[reference]
+		response.Results = append(response.Results, resolve(item, found, dto.BulkTrackItemResult{Reference: reference}))
+	}
+
+	response.Tracked = len(itemIDs)
+	response.Failed = len(response.Results) - len(itemIDs)
+
+	if response.Failed > 0 && !req.Partial {
+		for i := range response.Results {
+			response.Results[i].Tracked = false
+		}
+		response.Tracked = 0
+		return response, ErrItemsNotTracked
+	}
+
+	if len(itemIDs) == 0 {
+		return response, nil
+	}
+
+	if req.LocationID != nil {
+		err = s.itemRepo.AppendNewItemTrackedToLocationHistory(userID, itemIDs, *req.LocationID)
+	} else {
+		err = s.itemRepo.AppendNewItemTrackedToUserHistory(userID, *req.UserID, itemIDs, req.DueAt)
+	}
+	if err != nil {
+		return dto.BulkTrackItemsResponse{}, err
+	}
+
+	return response, nil
+}
+
+// GetItemHistory returns the history of the item, newest first.
+// Records referring to a user or location that no longer exists are returned with a placeholder name.
 func (s *ItemService) GetItemHistory(itemID uuid.UUID) ([]dto.ItemHistoryRecord, error) {
 	historyModel, err := s.itemRepo.GetItemHistory(itemID)
 	if err != nil {
 		return nil, err
 	}
 
-	var results = make([]dto.ItemHistoryRecord, 0)
+	var results = make([]dto.ItemHistoryRecord, 0, len(historyModel))
 	for _, h := range historyModel {
-		historyType, data, err := h.ParseData()
+		record, err := newItemHistoryRecord(h)
 		if err != nil {
 			return nil, err
 		}
+		if record != nil {
+			results = append(results, record)
+		}
+	}
 
-		switch historyType {
-		case model.ItemHistoryTypeCreated:
-			d := data.(model.ItemCreatedHistoryData)
-			user, err := s.userRepo.Get(h.UserID)
-			if err != nil {
-				return nil, err
-			}
-			location, err := s.locationRepo.Get(d.LocationID)
-			if err != nil {
-				return nil, err
+	return results, nil
+}
+
+// ListItemHistory lists a page of the history of the item, newest first.
+// When before is given only the records created before that time are listed.
+func (s *ItemService) ListItemHistory(itemID uuid.UUID, before *time.Time, page pagination.Params) (pagination.Page[dto.ItemHistoryRecord], error) {
+	historyModel, err := s.itemRepo.ListItemHistory(itemID, before, page)
+	if err != nil {
+		return pagination.Page[dto.ItemHistoryRecord]{}, err
+	}
+	return newItemHistoryRecordPage(historyModel)
+}
+
+// ListHistory lists the history of all items matching the filter, newest first.
+func (s *ItemService) ListHistory(filter repository.ItemHistoryFilter, page pagination.Params) (pagination.Page[dto.ItemHistoryRecord], error) {
+	historyModel, err := s.itemRepo.ListHistory(filter, page)
+	if err != nil {
+		return pagination.Page[dto.ItemHistoryRecord]{}, err
+	}
+	return newItemHistoryRecordPage(historyModel)
+}
+
+// newItemHistoryRecordPage converts a page of history records, leaving out records of unknown types.
+func newItemHistoryRecordPage(historyModel pagination.Page[model.ItemHistoryDetailModel]) (pagination.Page[dto.ItemHistoryRecord], error) {
+	results := pagination.Page[dto.ItemHistoryRecord]{
+		Items:      make([]dto.ItemHistoryRecord, 0, len(historyModel.Items)),
+		NextCursor: historyModel.NextCursor,
+	}
+	for _, h := range historyModel.Items {
+		record, err := newItemHistoryRecord(h)
+		if err != nil {
+			return pagination.Page[dto.ItemHistoryRecord]{}, err
+		}
+		if record != nil {
+			results.Items = append(results.Items, record)
+		}
+	}
+
+	return results, nil
+}
+
+// ExportHistory calls fn with each history record of all items matching the filter, newest first.
+// The history is read a page at a time so that the whole history is never held in memory.
+func (s *ItemService) ExportHistory(filter repository.ItemHistoryFilter, fn func(dto.ItemHistoryRecord) error) error {
+	page := pagination.Params{Limit: pagination.MaxLimit}
+	for {
+		history, err := s.ListHistory(filter, page)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range history.Items {
+			if err := fn(record); err != nil {
+				return err
 			}
+		}
+
+		if history.NextCursor == nil {
+			return nil
+		}
 
-			hr := dto.CreatedItemHistoryRecord{
-				ItemHistoryHeader: dto.ItemHistoryHeader[dto.CreatedItemHistoryRecordData]{
-					Type:         historyType,
-					UserID:       h.UserID,
-					UserName:     user.Name,
-					UserUsername: user.Username,
-					Date:         h.CreatedAt,
-					Data: dto.CreatedItemHistoryRecordData{
-						Reference:    d.Reference,
-						GroupKey:     d.GroupKey,
-						Description:  d.Description,
-						LocationID:   d.LocationID,
-						LocationName: location.Name,
-					},
+		cursor, err := pagination.DecodeCursor(*history.NextCursor)
+		if err != nil {
+			return err
+		}
+		page.Cursor = &cursor
+	}
+}
+
+// newItemHistoryRecord converts the history record to its response type.
+// Returns nil if the record is of an unknown type.
+func newItemHistoryRecord(h model.ItemHistoryDetailModel) (dto.ItemHistoryRecord, error) {
+	historyType, data, err := h.ParseData()
+	if err != nil {
+		return nil, err
+	}
+
+	userName := valueOrDefault(h.UserName, unknownUserName)
+	userUsername := valueOrDefault(h.UserUsername, unknownUserName)
+	itemReference := valueOrDefault(h.ItemReference, "")
+	var itemStatus model.ItemStatus
+	if h.ItemStatus != nil {
+		itemStatus = *h.ItemStatus
+	}
+
+	switch historyType {
+	case model.ItemHistoryTypeCreated:
+		d := data.(model.ItemCreatedHistoryData)
+		hr := dto.CreatedItemHistoryRecord{
+			ItemHistoryHeader: dto.ItemHistoryHeader[dto.CreatedItemHistoryRecordData]{
+				ItemID:        h.ItemID,
+				ItemReference: itemReference,
+				ItemStatus:    itemStatus,
+				Type:          historyType,
+				UserID:        h.UserID,
+				UserName:      userName,
+				UserUsername:  userUsername,
+				Date:          h.CreatedAt,
+				Data: dto.CreatedItemHistoryRecordData{
+					Reference:    d.Reference,
+					GroupKey:     d.GroupKey,
+					Description:  d.Description,
+					LocationID:   d.LocationID,
+					LocationName: valueOrDefault(h.LocationName, unknownLocationName),
 				},
-			}
+			},
+		}
 
-			results = append(results, hr)
-		case model.ItemHistoryTypeTracked:
-			d := data.(model.ItemTrackedHistoryData)
-			item, err := s.itemRepo.Get(itemID)
-			if err != nil {
-				return nil, err
-			}
-			user, err := s.userRepo.Get(h.UserID)
-			if err != nil {
-				return nil, err
-			}
-			location, err := s.locationRepo.Get(d.LocationID)
-			if err != nil {
-				return nil, err
-			}
+		return hr, nil
+	case model.ItemHistoryTypeUpdated:
+		d := data.(model.ItemUpdatedHistoryData)
+		hr := dto.UpdatedItemHistoryRecord{
+			ItemHistoryHeader: dto.ItemHistoryHeader[dto.UpdatedItemHistoryRecordData]{
+				ItemID:        h.ItemID,
+				ItemReference: itemReference,
+				ItemStatus:    itemStatus,
+				Type:          historyType,
+				UserID:        h.UserID,
+				UserName:      userName,
+				UserUsername:  userUsername,
+				Date:          h.CreatedAt,
+				Data: dto.UpdatedItemHistoryRecordData{
+					ItemReference: itemReference,
+					UpdatedFields: d.UpdatedFields,
+				},
+			},
+		}
 
-			hr := dto.TrackedItemHistoryRecord{
-				ItemHistoryHeader: dto.ItemHistoryHeader[dto.TrackedItemHistoryRecordData]{
-					Type:         historyType,
-					UserID:       h.UserID,
-					UserName:     user.Name,
-					UserUsername: user.Username,
-					Date:         h.CreatedAt,
-					Data: dto.TrackedItemHistoryRecordData{
-						ItemReference: item.Reference,
-						LocationID:    d.LocationID,
-						LocationName:  location.Name,
-					},
+		return hr, nil
+	case model.ItemHistoryTypeTracked:
+		d := data.(model.ItemTrackedHistoryData)
+		hr := dto.TrackedItemHistoryRecord{
+			ItemHistoryHeader: dto.ItemHistoryHeader[dto.TrackedItemHistoryRecordData]{
+				ItemID:        h.ItemID,
+				ItemReference: itemReference,
+				ItemStatus:    itemStatus,
+				Type:          historyType,
+				UserID:        h.UserID,
+				UserName:      userName,
+				UserUsername:  userUsername,
+				Date:          h.CreatedAt,
+				Data: dto.TrackedItemHistoryRecordData{
+					ItemReference: itemReference,
+					LocationID:    d.LocationID,
+					LocationName:  valueOrDefault(h.LocationName, unknownLocationName),
 				},
-			}
+			},
+		}
 
-			results = append(results, hr)
-		case model.ItemHistoryTypeTrackedUser:
-			d := data.(model.ItemTrackedUserHistoryData)
-			item, err := s.itemRepo.Get(itemID)
-			if err != nil {
-				return nil, err
-			}
-			trackingUser, err := s.userRepo.Get(h.UserID)
-			if err != nil {
-				return nil, err
-			}
-			trackedToUser, err := s.userRepo.Get(d.UserID)
-			if err != nil {
-				return nil, err
-			}
+		return hr, nil
+	case model.ItemHistoryTypeTrackedUser:
+		d := data.(model.ItemTrackedUserHistoryData)
+		hr := dto.TrackedItemUserHistoryRecord{
+			ItemHistoryHeader: dto.ItemHistoryHeader[dto.TrackedItemUserHistoryRecordData]{
+				ItemID:        h.ItemID,
+				ItemReference: itemReference,
+				ItemStatus:    itemStatus,
+				Type:          historyType,
+				UserID:        h.UserID,
+				UserName:      userName,
+				UserUsername:  userUsername,
+				Date:          h.CreatedAt,
+				Data: dto.TrackedItemUserHistoryRecordData{
+					ItemReference: itemReference,
+					UserID:        d.UserID,
+					UserName:      valueOrDefault(h.TrackedToUserName, unknownUserName),
+					UserUsername:  valueOrDefault(h.TrackedToUserUsername, unknownUserName),
+				},
+			},
+		}
 
-			hr := dto.TrackedItemUserHistoryRecord{
-				ItemHistoryHeader: dto.ItemHistoryHeader[dto.TrackedItemUserHistoryRecordData]{
-					Type:         historyType,
-					UserID:       h.UserID,
-					UserName:     trackingUser.Name,
-					UserUsername: trackingUser.Username,
-					Date:         h.CreatedAt,
-					Data: dto.TrackedItemUserHistoryRecordData{
-						ItemReference: item.Reference,
-						UserID:        trackedToUser.ID,
-						UserName:      trackedToUser.Name,
-						UserUsername:  trackedToUser.Username,
-					},
+		return hr, nil
+	case model.ItemHistoryTypeContained, model.ItemHistoryTypeUncontained:
+		d := data.(model.ItemContainmentHistoryData)
+		hr := dto.ContainmentItemHistoryRecord{
+			ItemHistoryHeader: dto.ItemHistoryHeader[dto.ContainmentItemHistoryRecordData]{
+				ItemID:        h.ItemID,
+				ItemReference: itemReference,
+				ItemStatus:    itemStatus,
+				Type:          historyType,
+				UserID:        h.UserID,
+				UserName:      userName,
+				UserUsername:  userUsername,
+				Date:          h.CreatedAt,
+				Data: dto.ContainmentItemHistoryRecordData{
+					ItemReference:      itemReference,
+					ContainerID:        d.ContainerID,
+					ContainerReference: valueOrDefault(h.ContainerReference, unknownItemReference),
 				},
-			}
+			},
+		}
 
-			results = append(results, hr)
-		case model.ItemHistoryTypeDeleted:
-			user, err := s.userRepo.Get(h.UserID)
-			if err != nil {
-				return nil, err
-			}
-			hr := dto.DeletedItemHistoryRecord{
-				ItemHistoryHeader: dto.ItemHistoryHeader[dto.DeletedItemHistoryRecordData]{
-					Type:         historyType,
-					UserID:       h.UserID,
-					UserName:     user.Name,
-					UserUsername: user.Username,
-					Date:         h.CreatedAt,
-					Data:         dto.DeletedItemHistoryRecordData{},
+		return hr, nil
+	case model.ItemHistoryTypeStockReceived, model.ItemHistoryTypeStockIssued,
+		model.ItemHistoryTypeStockTransferred, model.ItemHistoryTypeStockAdjusted:
+		d := data.(model.ItemStockHistoryData)
+		hr := dto.StockItemHistoryRecord{
+			ItemHistoryHeader: dto.ItemHistoryHeader[dto.StockItemHistoryRecordData]{
+				ItemID:        h.ItemID,
+				ItemReference: itemReference,
+				ItemStatus:    itemStatus,
+				Type:          historyType,
+				UserID:        h.UserID,
+				UserName:      userName,
+				UserUsername:  userUsername,
+				Date:          h.CreatedAt,
+				Data: dto.StockItemHistoryRecordData{
+					ItemReference:      itemReference,
+					LocationID:         d.LocationID,
+					LocationName:       valueOrDefault(h.LocationName, unknownLocationName),
+					Delta:              d.Delta,
+					Quantity:           d.Quantity,
+					TransferLocationID: d.TransferLocationID,
+					Note:               d.Note,
 				},
-			}
+			},
+		}
 
-			results = append(results, hr)
-		default:
-			continue
+		return hr, nil
+	case model.ItemHistoryTypeStatusChanged:
+		d := data.(model.ItemStatusChangedHistoryData)
+		hr := dto.StatusChangedItemHistoryRecord{
+			ItemHistoryHeader: dto.ItemHistoryHeader[dto.StatusChangedItemHistoryRecordData]{
+				ItemID:        h.ItemID,
+				ItemReference: itemReference,
+				ItemStatus:    itemStatus,
+				Type:          historyType,
+				UserID:        h.UserID,
+				UserName:      userName,
+				UserUsername:  userUsername,
+				Date:          h.CreatedAt,
+				Data: dto.StatusChangedItemHistoryRecordData{
+					From:   d.From,
+					To:     d.To,
+					Reason: d.Reason,
+				},
+			},
+		}
+
+		return hr, nil
+	case model.ItemHistoryTypeDeleted:
+		hr := dto.DeletedItemHistoryRecord{
+			ItemHistoryHeader: dto.ItemHistoryHeader[dto.DeletedItemHistoryRecordData]{
+				ItemID:        h.ItemID,
+				ItemReference: itemReference,
+				ItemStatus:    itemStatus,
+				Type:          historyType,
+				UserID:        h.UserID,
+				UserName:      userName,
+				UserUsername:  userUsername,
+				Date:          h.CreatedAt,
+				Data:          dto.DeletedItemHistoryRecordData{},
+			},
 		}
+
+		return hr, nil
+	case model.ItemHistoryTypeRestored:
+		hr := dto.RestoredItemHistoryRecord{
+			ItemHistoryHeader: dto.ItemHistoryHeader[dto.RestoredItemHistoryRecordData]{
+				ItemID:        h.ItemID,
+				ItemReference: itemReference,
+				ItemStatus:    itemStatus,
+				Type:          historyType,
+				UserID:        h.UserID,
+				UserName:      userName,
+				UserUsername:  userUsername,
+				Date:          h.CreatedAt,
+				Data:          dto.RestoredItemHistoryRecordData{},
+			},
+		}
+
+		return hr, nil
+	default:
+		return nil, nil
 	}
+}
 
-	return results, nil
+func valueOrDefault(s *string, defaultValue string) string {
+	if s == nil {
+		return defaultValue
+	}
+	return *s
 }
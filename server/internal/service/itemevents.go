@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"log/slog"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"sync"
+	"time"
+)
+
+const (
+	// itemEventBufferSize is the number of events buffered per subscriber.
+	// A subscriber that falls further behind is dropped and has to resume from its last event ID.
+	itemEventBufferSize   = 64
+	itemEventReplayBatch  = 500
+	itemEventPingInterval = 90 * time.Second
+	// itemEventPollInterval is how often new events are read without a notification. Events are held back while
+	// an older transaction is still in progress, and are read on the next poll if that transaction sends nothing.
+	itemEventPollInterval = 2 * time.Second
+)
+
+// ItemEventFilter filters the item events sent to a subscriber. Nil fields do not filter.
+type ItemEventFilter struct {
+	// LocationID matches events moving items to or from the location or user.
+	LocationID *uuid.UUID
+	GroupKey   *string
+}
+
+func (f ItemEventFilter) Matches(event model.ItemEventModel) bool {
+	if f.GroupKey != nil && event.GroupKey != *f.GroupKey {
+		return false
+	}
+	if f.LocationID != nil {
+		to := event.ToID != nil && *event.ToID == *f.LocationID
+		from := event.FromID != nil && *event.FromID == *f.LocationID
+		if !to && !from {
+			return false
+		}
+	}
+	return true
+}
+
+type itemEventSubscriber struct {
+	filter ItemEventFilter
+	events chan model.ItemEventModel
+}
+
+// ItemEventBroker listens for new item history records on the repository.ItemHistoryChannel and
+// fans the resulting item events out to its subscribers. Every API instance runs its own broker,
+// so events appended through any instance reach the subscribers of all of them.
+// Notifications only prompt the broker to read the events after its cursor, so events are published
+// in cursor order whatever order their transactions commit in.
+type ItemEventBroker struct {
+	connectionString string
+	itemRepo         repository.ItemRepository
+	logger           *slog.Logger
+
+	mu          sync.Mutex
+	subscribers map[*itemEventSubscriber]struct{}
+	cursor      model.ItemEventCursor
+}
+
+func NewItemEventBroker(connectionString string, itemRepo repository.ItemRepository, logger *slog.Logger) *ItemEventBroker {
+	return &ItemEventBroker{
+		connectionString: connectionString,
+		itemRepo:         itemRepo,
+		logger:           logger,
+		subscribers:      make(map[*itemEventSubscriber]struct{}),
+	}
+}
+
+// Subscribe returns a channel of the events matching the filter and a function to stop the subscription.
+// The channel is closed if the subscriber falls too far behind.
+func (b *ItemEventBroker) Subscribe(filter ItemEventFilter) (<-chan model.ItemEventModel, func()) {
+	sub := &itemEventSubscriber{
+		filter: filter,
+		events: make(chan model.ItemEventModel, itemEventBufferSize),
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[sub]; ok {
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+
+	return sub.events, unsubscribe
+}
+
+// CursorAt returns the cursor of the event with the history record ID, as sent to a subscriber as its event ID.
+func (b *ItemEventBroker) CursorAt(id int64) (model.ItemEventCursor, error) {
+	return b.itemRepo.GetItemEventCursor(id)
+}
+
+// Replay calls fn with each event matching the filter after the cursor, in cursor order.
+// Returns the cursor of the last event read, or after if there were none.
+func (b *ItemEventBroker) Replay(after model.ItemEventCursor, filter ItemEventFilter, fn func(model.ItemEventModel) error) (model.ItemEventCursor, error) {
+	for {
+		events, err := b.itemRepo.ListItemEventsAfter(after, itemEventReplayBatch)
+		if err != nil {
+			return after, err
+		}
+
+		for _, event := range events {
+			after = event.Cursor()
+			if !filter.Matches(event) {
+				continue
+			}
+			if err := fn(event); err != nil {
+				return after, err
+			}
+		}
+
+		if len(events) < itemEventReplayBatch {
+			return after, nil
+		}
+	}
+}
+
+// Run listens for notifications until the context is cancelled.
+// Events appended while the connection was lost are caught up once it is re-established,
+// and if Run is called again after returning an error, it carries on from the last event published.
+func (b *ItemEventBroker) Run(ctx context.Context) error {
+	b.mu.Lock()
+	started := b.cursor != (model.ItemEventCursor{})
+	b.mu.Unlock()
+
+	if !started {
+		cursor, err := b.itemRepo.GetLatestItemEventCursor()
+		if err != nil {
+			return fmt.Errorf("failed to get latest item event: %w", err)
+		}
+		b.mu.Lock()
+		b.cursor = cursor
+		b.mu.Unlock()
+	}
+
+	listener := pq.NewListener(b.connectionString, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			b.logger.Error("item event listener error", "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(repository.ItemHistoryChannel); err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", repository.ItemHistoryChannel, err)
+	}
+
+	ping := time.NewTicker(itemEventPingInterval)
+	defer ping.Stop()
+	poll := time.NewTicker(itemEventPollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			go func() { _ = listener.Ping() }()
+		case <-poll.C:
+			b.readNew()
+		case <-listener.Notify:
+			// A nil notification means the connection was re-established and notifications sent while
+			// it was down are lost, which reading from the cursor catches up on as well.
+			b.readNew()
+		}
+	}
+}
+
+// readNew publishes the events after the cursor of the broker.
+func (b *ItemEventBroker) readNew() {
+	b.mu.Lock()
+	cursor := b.cursor
+	b.mu.Unlock()
+
+	for {
+		events, err := b.itemRepo.ListItemEventsAfter(cursor, itemEventReplayBatch)
+		if err != nil {
+			b.logger.Error("error reading item events", "error", err)
+			return
+		}
+
+		b.publish(events)
+		if len(events) < itemEventReplayBatch {
+			return
+		}
+		cursor = events[len(events)-1].Cursor()
+	}
+}
+
+// publish sends the events to each subscriber whose filter they match.
+// Subscribers whose buffer is full are dropped.
+func (b *ItemEventBroker) publish(events []model.ItemEventModel) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, event := range events {
+		b.cursor = event.Cursor()
+
+		for sub := range b.subscribers {
+			if !sub.filter.Matches(event) {
+				continue
+			}
+
+			select {
+			case sub.events <- event:
+			default:
+				delete(b.subscribers, sub)
+				close(sub.events)
+			}
+		}
+	}
+}
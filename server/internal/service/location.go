@@ -7,9 +7,28 @@ import (
 	"quantum/internal/dto"
 	"quantum/internal/model"
 	"quantum/internal/repository"
+	"quantum/internal/types/pagination"
 )
 
-var ErrLocationNotFound = errors.New("location not found")
+var (
+	ErrLocationNotFound       = errors.New("location not found")
+	ErrParentLocationNotFound = repository.ErrParentLocationNotFound
+	ErrLocationCycle          = repository.ErrLocationCycle
+	ErrLocationHasChildren    = repository.ErrLocationHasChildren
+	ErrLocationNameExists     = errors.New("location name already exists")
+	ErrLocationNotDeleted     = errors.New("location is not deleted")
+	ErrLocationParentDeleted  = errors.New("parent location is deleted, restore it first")
+	ErrInvalidDestination     = errors.New("destination must be another location that is not deleted")
+)
+
+// LocationHasItemsError is returned when deleting a location that items are still at without naming a destination.
+type LocationHasItemsError struct {
+	Items []dto.ItemResponse
+}
+
+func (e *LocationHasItemsError) Error() string {
+	return "location still has items, choose a destination location to move them to"
+}
 
 type LocationService struct {
 	locationRepo repository.LocationRepository
@@ -30,33 +49,103 @@ func (s *LocationService) Get(locationID uuid.UUID) (dto.LocationResponse, error
 		return dto.LocationResponse{}, err
 	}
 
-	location := dto.LocationResponse{
-		ID:          l.ID,
-		Name:        l.Name,
-		Description: l.Description,
-		CreatedAt:   l.CreatedAt,
-		UpdatedAt:   l.UpdatedAt,
+	locations := []dto.LocationResponse{dto.NewLocationResponseFromModel(l)}
+	if err := setLocationPaths(s.locationRepo, locations); err != nil {
+		return dto.LocationResponse{}, err
 	}
 
-	return location, nil
+	return locations[0], nil
 }
 
-func (s *LocationService) List(max *int, filter string, includeDeleted bool) ([]dto.LocationResponse, error) {
-	locations, err := s.locationRepo.List(max, filter, includeDeleted)
+// Tree returns the locations that are not deleted nested under their parents, with top level locations at the root.
+func (s *LocationService) Tree() ([]dto.LocationTreeNode, error) {
+	locations, err := s.locationRepo.ListActive()
 	if err != nil {
 		return nil, err
 	}
 
-	var locationResponses = make([]dto.LocationResponse, 0)
-	for _, locationModel := range locations {
-		location := dto.NewLocationResponseFromModel(locationModel)
-		locationResponses = append(locationResponses, location)
+	active := make(map[uuid.UUID]bool, len(locations))
+	for _, l := range locations {
+		active[l.ID] = true
+	}
+
+	children := make(map[uuid.UUID][]model.LocationModel)
+	var roots []model.LocationModel
+	for _, l := range locations {
+		if l.ParentID == nil || !active[*l.ParentID] {
+			roots = append(roots, l)
+			continue
+		}
+		children[*l.ParentID] = append(children[*l.ParentID], l)
+	}
+
+	var build func(l model.LocationModel) dto.LocationTreeNode
+	build = func(l model.LocationModel) dto.LocationTreeNode {
+		node := dto.LocationTreeNode{
+			ID:          l.ID,
+			Name:        l.Name,
+			Description: l.Description,
+			Children:    make([]dto.LocationTreeNode, 0, len(children[l.ID])),
+		}
+		for _, child := range children[l.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	tree := make([]dto.LocationTreeNode, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+	return tree, nil
+}
+
+// setLocationPaths replaces the path of each location with its breadcrumb from the top level location.
+// Tracker users are not part of the hierarchy and keep the path of just themselves.
+func setLocationPaths(locationRepo repository.LocationRepository, locations []dto.LocationResponse) error {
+	ids := make([]uuid.UUID, 0, len(locations))
+	for _, l := range locations {
+		if !l.IsUser {
+			ids = append(ids, l.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ancestors, err := locationRepo.ListAncestors(ids)
+	if err != nil {
+		return err
+	}
+
+	paths := make(map[uuid.UUID][]dto.LocationPathItem, len(ids))
+	for _, a := range ancestors {
+		paths[a.LocationID] = append(paths[a.LocationID], dto.LocationPathItem{ID: a.ID, Name: a.Name})
+	}
+
+	for i := range locations {
+		if path, ok := paths[locations[i].ID]; ok {
+			locations[i].Path = path
+		}
+	}
+	return nil
+}
+
+func (s *LocationService) List(filter string, includeDeleted bool, page pagination.Params) (pagination.Page[dto.LocationResponse], error) {
+	locations, err := s.locationRepo.List(filter, includeDeleted, page)
+	if err != nil {
+		return pagination.Page[dto.LocationResponse]{}, err
+	}
+
+	response := pagination.MapPage(locations, dto.NewLocationResponseFromModel)
+	if err := setLocationPaths(s.locationRepo, response.Items); err != nil {
+		return pagination.Page[dto.LocationResponse]{}, err
 	}
 
-	return locationResponses, nil
+	return response, nil
 }
 
-func (s *LocationService) Create(request dto.CreateLocationRequest) (dto.LocationResponse, error) {
+func (s *LocationService) Create(request dto.CreateLocationRequest, createdByUserID uuid.UUID) (dto.LocationResponse, error) {
 	if err := request.Validate(); err != nil {
 		return dto.LocationResponse{}, err
 	}
@@ -64,23 +153,158 @@ func (s *LocationService) Create(request dto.CreateLocationRequest) (dto.Locatio
 	locationModel := model.LocationModel{
 		Name:        request.Name,
 		Description: request.Description,
+		ParentID:    request.ParentID,
 	}
 
-	if err := s.locationRepo.Create(&locationModel); err != nil {
+	if err := s.locationRepo.Create(&locationModel, createdByUserID); err != nil {
+		if errors.Is(err, repository.ErrLocationNameExists) {
+			return dto.LocationResponse{}, ErrLocationNameExists
+		}
 		return dto.LocationResponse{}, err
 	}
 
-	locationResponse := dto.NewLocationResponseFromModel(locationModel)
-	return locationResponse, nil
+	locations := []dto.LocationResponse{dto.NewLocationResponseFromModel(locationModel)}
+	if err := setLocationPaths(s.locationRepo, locations); err != nil {
+		return dto.LocationResponse{}, err
+	}
+
+	return locations[0], nil
 }
 
-func (s *LocationService) Delete(locationID uuid.UUID) error {
-	if err := s.locationRepo.MarkDeleted(locationID); err != nil {
+// Update changes the name and description of the location.
+func (s *LocationService) Update(locationID uuid.UUID, request dto.UpdateLocationRequest, updatedByUserID uuid.UUID) (dto.LocationResponse, error) {
+	if err := request.Validate(); err != nil {
+		return dto.LocationResponse{}, err
+	}
+
+	locationModel := model.LocationModel{
+		ID:          locationID,
+		Name:        request.Name,
+		Description: request.Description,
+	}
+
+	if err := s.locationRepo.Update(&locationModel, updatedByUserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.LocationResponse{}, ErrLocationNotFound
+		}
+		if errors.Is(err, repository.ErrLocationNameExists) {
+			return dto.LocationResponse{}, ErrLocationNameExists
+		}
+		return dto.LocationResponse{}, err
+	}
+
+	return s.Get(locationID)
+}
+
+// Move moves the location, and every location inside it, under the parent in the request.
+// Items stay at their locations, so their history is untouched.
+func (s *LocationService) Move(locationID uuid.UUID, request dto.MoveLocationRequest, movedByUserID uuid.UUID) (dto.LocationResponse, error) {
+	if err := s.locationRepo.SetParent(locationID, request.ParentID, movedByUserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.LocationResponse{}, ErrLocationNotFound
+		}
+		return dto.LocationResponse{}, err
+	}
+
+	return s.Get(locationID)
+}
+
+// checkDestination returns ErrInvalidDestination unless the destination is an existing location that is not deleted.
+func (s *LocationService) checkDestination(destinationID uuid.UUID) error {
+	destination, err := s.locationRepo.Get(destinationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidDestination
+		}
+		return err
+	}
+	if destination.IsDeleted {
+		return ErrInvalidDestination
+	}
+	return nil
+}
+
+// Delete marks the location as deleted.
+// Items still at the location are tracked, and stock held there is transferred, to the destination, when one is given,
+// in the same transaction.
+// Returns ErrLocationHasChildren while any location inside it is not deleted,
+// and a LocationHasItemsError if items are at the location and there is no destination.
+func (s *LocationService) Delete(locationID uuid.UUID, destinationID *uuid.UUID, deletedByUserID uuid.UUID) error {
+	if destinationID != nil {
+		if *destinationID == locationID {
+			return ErrInvalidDestination
+		}
+		if err := s.checkDestination(*destinationID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.locationRepo.MarkDeleted(locationID, destinationID, deletedByUserID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return ErrLocationNotFound
 		}
+		var hasItemsErr *repository.LocationHasItemsError
+		if errors.As(err, &hasItemsErr) {
+			items := make([]dto.ItemResponse, 0, len(hasItemsErr.Items))
+			for _, item := range hasItemsErr.Items {
+				items = append(items, dto.NewItemResponseFromModel(item, nil))
+			}
+			return &LocationHasItemsError{Items: items}
+		}
 		return err
 	}
 
-	return s.locationRepo.MarkDeleted(locationID)
+	return nil
+}
+
+// Restore brings back a deleted location.
+// A location inside a deleted parent cannot be restored until the parent is.
+func (s *LocationService) Restore(locationID, restoredByUserID uuid.UUID) (dto.LocationResponse, error) {
+	location, err := s.locationRepo.Get(locationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.LocationResponse{}, ErrLocationNotFound
+		}
+		return dto.LocationResponse{}, err
+	}
+	if !location.IsDeleted {
+		return dto.LocationResponse{}, ErrLocationNotDeleted
+	}
+
+	if err := s.locationRepo.Restore(locationID, restoredByUserID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.LocationResponse{}, ErrLocationNotDeleted
+		}
+		if errors.Is(err, repository.ErrParentLocationNotFound) {
+			return dto.LocationResponse{}, ErrLocationParentDeleted
+		}
+		return dto.LocationResponse{}, err
+	}
+
+	return s.Get(locationID)
+}
+
+// History lists the changes made to the location, most recent first.
+func (s *LocationService) History(locationID uuid.UUID) ([]dto.LocationHistoryResponse, error) {
+	if _, err := s.locationRepo.Get(locationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLocationNotFound
+		}
+		return nil, err
+	}
+
+	history, err := s.locationRepo.ListHistory(locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.LocationHistoryResponse, 0, len(history))
+	for _, h := range history {
+		record, err := dto.NewLocationHistoryResponseFromModel(h)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, record)
+	}
+	return response, nil
 }
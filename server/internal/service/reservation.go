@@ -0,0 +1,199 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"time"
+)
+
+var (
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationOverlaps  = errors.New("item is already reserved for part of that time")
+	ErrReservationForbidden = errors.New("only the holder, the creator or an admin can cancel a reservation")
+	ErrItemReserved         = errors.New("item is reserved by another user")
+	ErrInvalidFeedToken     = errors.New("invalid reservation feed token")
+)
+
+type ReservationService struct {
+	reservationRepo repository.ReservationRepository
+	itemRepo        repository.ItemRepository
+	userRepo        repository.UserRepository
+}
+
+func NewReservationService(
+	reservationRepo repository.ReservationRepository,
+	itemRepo repository.ItemRepository,
+	userRepo repository.UserRepository,
+) *ReservationService {
+	return &ReservationService{
+		reservationRepo: reservationRepo,
+		itemRepo:        itemRepo,
+		userRepo:        userRepo,
+	}
+}
+
+// List lists the reservations matching the filter in the order they start.
+func (s *ReservationService) List(filter repository.ReservationFilter) ([]dto.ReservationResponse, error) {
+	reservations, err := s.reservationRepo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	return newReservationResponses(reservations), nil
+}
+
+// Create reserves the item for the user in the request, or the creating user if none is given.
+// Returns ErrReservationOverlaps if the item is already reserved for any part of the time window.
+func (s *ReservationService) Create(request dto.CreateReservationRequest, createdByUserID uuid.UUID) (dto.ReservationResponse, error) {
+	if err := request.Validate(); err != nil {
+		return dto.ReservationResponse{}, err
+	}
+
+	item, err := s.itemRepo.Get(request.ItemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.ReservationResponse{}, ErrItemNotFound
+		}
+		return dto.ReservationResponse{}, err
+	}
+	if item.Deleted {
+		return dto.ReservationResponse{}, ErrItemDeleted
+	}
+
+	userID := createdByUserID
+	if request.UserID != nil {
+		userID = *request.UserID
+	}
+
+	user, err := s.userRepo.Get(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.ReservationResponse{}, ErrUserNotFound
+		}
+		return dto.ReservationResponse{}, err
+	}
+	if user.DeletedAt != nil {
+		return dto.ReservationResponse{}, ErrUserDeleted
+	}
+
+	reservation := model.ReservationModel{
+		ItemID:          item.ID,
+		UserID:          user.ID,
+		Purpose:         request.Purpose,
+		StartsAt:        request.StartsAt,
+		EndsAt:          request.EndsAt,
+		CreatedByUserID: createdByUserID,
+	}
+
+	if err := s.reservationRepo.Create(&reservation); err != nil {
+		if errors.Is(err, repository.ErrReservationOverlaps) {
+			return dto.ReservationResponse{}, ErrReservationOverlaps
+		}
+		return dto.ReservationResponse{}, err
+	}
+
+	return dto.NewReservationResponseFromModel(model.ReservationDetailModel{
+		ReservationModel: reservation,
+		ItemReference:    item.Reference,
+		ItemIdentifier:   item.Identifier,
+		ItemGroupKey:     item.GroupKey,
+		UserName:         user.Name,
+		UserUsername:     user.Username,
+	}), nil
+}
+
+// Cancel removes the reservation. Only the user holding it, the user who made it or an admin can cancel it.
+func (s *ReservationService) Cancel(reservationID, userID uuid.UUID, isAdmin bool) error {
+	reservation, err := s.reservationRepo.Get(reservationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrReservationNotFound
+		}
+		return err
+	}
+
+	if !isAdmin && reservation.UserID != userID && reservation.CreatedByUserID != userID {
+		return ErrReservationForbidden
+	}
+
+	return s.reservationRepo.Delete(reservationID)
+}
+
+func newReservationResponses(reservations []model.ReservationDetailModel) []dto.ReservationResponse {
+	response := make([]dto.ReservationResponse, 0, len(reservations))
+	for _, r := range reservations {
+		response = append(response, dto.NewReservationResponseFromModel(r))
+	}
+	return response
+}
+
+// ItemReservedError is returned when items are tracked to a user while someone else holds an active reservation.
+type ItemReservedError struct {
+	Reservations []dto.ReservationResponse
+}
+
+func (e *ItemReservedError) Error() string {
+	return ErrItemReserved.Error()
+}
+
+func (e *ItemReservedError) Is(target error) bool {
+	return target == ErrItemReserved
+}
+
+// reservationConflicts returns the reservations in progress now on any of the items that are held by a user other
+// than the one the items are being tracked to.
+func reservationConflicts(reservationRepo repository.ReservationRepository, itemIDs []uuid.UUID, toUserID uuid.UUID) ([]dto.ReservationResponse, error) {
+	active, err := reservationRepo.ListActive(itemIDs, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	conflicts := make([]model.ReservationDetailModel, 0, len(active))
+	for _, r := range active {
+		if r.UserID != toUserID {
+			conflicts = append(conflicts, r)
+		}
+	}
+	return newReservationResponses(conflicts), nil
+}
+
+// GetFeedToken returns the token of the calendar feed of the reservations of the user, creating it the first time.
+func (s *ReservationService) GetFeedToken(userID uuid.UUID) (string, error) {
+	token, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+	return s.reservationRepo.GetOrCreateFeedToken(userID, token)
+}
+
+// RotateFeedToken replaces the calendar feed token of the user, so the address with the old token stops working.
+func (s *ReservationService) RotateFeedToken(userID uuid.UUID) (string, error) {
+	token, err := generateSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := s.reservationRepo.SetFeedToken(userID, token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ListFeed lists the reservations of the user that overlap the time since from, for their calendar feed.
+// Returns ErrInvalidFeedToken unless the token is the current feed token of the user and the user is not deleted.
+func (s *ReservationService) ListFeed(userID uuid.UUID, token string, from time.Time) ([]dto.ReservationResponse, error) {
+	ownerID, err := s.reservationRepo.GetFeedTokenUserID(token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidFeedToken
+		}
+		return nil, err
+	}
+	if ownerID != userID {
+		return nil, ErrInvalidFeedToken
+	}
+
+	return s.List(repository.ReservationFilter{UserID: &userID, From: &from})
+}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"quantum/pkg/gs1"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrEmptyScanCode     = errors.New("code is required")
+	ErrAmbiguousScanCode = errors.New("code matches the identifier of more than one item, scan the reference instead")
+)
+
+// scanPriority is the order the values of a GS1 element string are tried in, most specific first.
+// Values of any other AIs are tried after these, in the order they were scanned.
+var scanPriority = []string{"21", "8004", "240", "250", "01", "00", "10"}
+
+type ScanService struct {
+	itemRepo     repository.ItemRepository
+	locationRepo repository.LocationRepository
+}
+
+func NewScanService(itemRepo repository.ItemRepository, locationRepo repository.LocationRepository) *ScanService {
+	return &ScanService{
+		itemRepo:     itemRepo,
+		locationRepo: locationRepo,
+	}
+}
+
+// Resolve finds the item or location a scanned code refers to.
+// The code is tried as the ID of an item or location, then as an item reference or identifier and last as a
+// location name. When the code is a GS1 element string its values are tried the same way after the code itself.
+// A response of type unknown is returned when nothing matches.
+func (s *ScanService) Resolve(code string) (dto.ScanResponse, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return dto.ScanResponse{}, ErrEmptyScanCode
+	}
+
+	response := dto.ScanResponse{Code: code, Type: dto.ScanResultUnknown}
+	candidates := []string{gs1.StripSymbologyIdentifier(code)}
+	if elements, err := gs1.Parse(code); err == nil {
+		for _, e := range elements {
+			response.GS1 = append(response.GS1, dto.GS1ElementResponse{AI: e.AI, Title: e.Title(), Value: e.Value})
+		}
+		for _, c := range scanCandidates(elements) {
+			if !slices.Contains(candidates, c) {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+
+	for _, c := range candidates {
+		id, err := uuid.Parse(c)
+		if err != nil {
+			continue
+		}
+		if found, err := s.resolveID(&response, id, c); err != nil || found {
+			return response, err
+		}
+	}
+
+	items, err := s.itemRepo.ListByCodes(candidates)
+	if err != nil {
+		return dto.ScanResponse{}, err
+	}
+	locations, err := s.locationRepo.ListByNames(candidates)
+	if err != nil {
+		return dto.ScanResponse{}, err
+	}
+
+	for _, c := range candidates {
+		if i := slices.IndexFunc(items, func(item model.ItemModel) bool { return item.Reference == c }); i >= 0 {
+			return response, s.setItem(&response, items[i].ID, dto.ScanMatchReference, c)
+		}
+
+		var matches []model.ItemModel
+		for _, item := range items {
+			if item.Identifier == c && !item.Deleted {
+				matches = append(matches, item)
+			}
+		}
+		if len(matches) > 1 {
+			return dto.ScanResponse{}, ErrAmbiguousScanCode
+		}
+		if len(matches) == 1 {
+			return response, s.setItem(&response, matches[0].ID, dto.ScanMatchIdentifier, c)
+		}
+
+		if i := slices.IndexFunc(locations, func(l model.LocationModel) bool { return l.Name == c }); i >= 0 {
+			return response, s.setLocation(&response, locations[i], dto.ScanMatchName, c)
+		}
+	}
+
+	// Suggest the most specific GS1 value, if there is one, as the reference of a new item.
+	response.Suggested = &dto.ScanSuggestion{Reference: candidates[min(1, len(candidates)-1)]}
+	return response, nil
+}
+
+// resolveID looks the ID up as an item and then as a location, reporting whether either was found.
+func (s *ScanService) resolveID(response *dto.ScanResponse, id uuid.UUID, value string) (bool, error) {
+	err := s.setItem(response, id, dto.ScanMatchID, value)
+	if err == nil || !errors.Is(err, ErrItemNotFound) {
+		return err == nil, err
+	}
+
+	location, err := s.locationRepo.Get(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, s.setLocation(response, location, dto.ScanMatchID, value)
+}
+
+func (s *ScanService) setItem(response *dto.ScanResponse, itemID uuid.UUID, match dto.ScanMatch, value string) error {
+	item, err := s.itemRepo.GetWithCurrentLocation(itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrItemNotFound
+		}
+		return err
+	}
+
+	itemResponse := newItemWithCurrentLocationResponse(item)
+	itemResponse.CurrentLocation.TrackedToUser = item.TrackedToUser
+	response.Type = dto.ScanResultItem
+	response.MatchedBy = match
+	response.MatchedValue = value
+	response.Item = &itemResponse
+	return nil
+}
+
+func (s *ScanService) setLocation(response *dto.ScanResponse, location model.LocationModel, match dto.ScanMatch, value string) error {
+	locations := []dto.LocationResponse{dto.NewLocationResponseFromModel(location)}
+	if err := setLocationPaths(s.locationRepo, locations); err != nil {
+		return err
+	}
+
+	response.Type = dto.ScanResultLocation
+	response.MatchedBy = match
+	response.MatchedValue = value
+	response.Location = &locations[0]
+	return nil
+}
+
+// scanCandidates returns the values of the elements in the order they are tried.
+// A GTIN is also tried without its leading zeros, as GTIN-8, GTIN-12 and GTIN-13 are padded to 14 digits.
+func scanCandidates(elements []gs1.Element) []string {
+	ordered := slices.Clone(elements)
+	slices.SortStableFunc(ordered, func(a, b gs1.Element) int {
+		return scanRank(a.AI) - scanRank(b.AI)
+	})
+
+	candidates := make([]string, 0, len(ordered)+1)
+	for _, e := range ordered {
+		candidates = append(candidates, e.Value)
+		if e.AI == "01" {
+			if short := strings.TrimLeft(e.Value, "0"); short != e.Value && len(short) >= 8 {
+				candidates = append(candidates, short)
+			}
+		}
+	}
+	return candidates
+}
+
+func scanRank(ai string) int {
+	if i := slices.Index(scanPriority, ai); i >= 0 {
+		return i
+	}
+	return len(scanPriority)
+}
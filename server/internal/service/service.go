@@ -3,17 +3,27 @@ package service
 import "quantum/internal/repository"
 
 type Services struct {
-	UserService     *UserService
-	ItemService     *ItemService
-	LocationService *LocationService
-	SettingsService *SettingsService
+	UserService        *UserService
+	ItemService        *ItemService
+	LocationService    *LocationService
+	SettingsService    *SettingsService
+	WebhookService     *WebhookService
+	ReservationService *ReservationService
+	ScanService        *ScanService
+	AuditService       *AuditService
+	StockService       *StockService
 }
 
 func NewServices(repos *repository.Repositories) *Services {
 	return &Services{
-		UserService:     NewUserService(repos.UserRepository),
-		ItemService:     NewItemService(repos.ItemRepository, repos.LocationRepository, repos.UserRepository),
-		LocationService: NewLocationService(repos.LocationRepository),
-		SettingsService: NewSettingsService(repos.SettingsRepository),
+		UserService:        NewUserService(repos.UserRepository),
+		ItemService:        NewItemService(repos.ItemRepository, repos.LocationRepository, repos.UserRepository, repos.ReservationRepository),
+		LocationService:    NewLocationService(repos.LocationRepository),
+		SettingsService:    NewSettingsService(repos.SettingsRepository),
+		WebhookService:     NewWebhookService(repos.WebhookRepository),
+		ReservationService: NewReservationService(repos.ReservationRepository, repos.ItemRepository, repos.UserRepository),
+		ScanService:        NewScanService(repos.ItemRepository, repos.LocationRepository),
+		AuditService:       NewAuditService(repos.AuditRepository, repos.ItemRepository, repos.LocationRepository),
+		StockService:       NewStockService(repos.StockRepository, repos.ItemRepository, repos.LocationRepository),
 	}
 }
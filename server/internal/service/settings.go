@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"quantum/internal/dto"
+	"quantum/internal/model"
 	"quantum/internal/repository"
 )
 
@@ -27,6 +28,18 @@ var defaultSettings = dto.SettingsResponse{
 		Group:     "Group",
 		Groups:    "Groups",
 	},
+	Trash: dto.TrashSettingsResponse{
+		RetentionDays: 30,
+	},
+	Statuses: dto.StatusSettingsResponse{
+		Transitions: map[model.ItemStatus][]model.ItemStatus{
+			model.ItemStatusInService: {model.ItemStatusInRepair, model.ItemStatusOnLoan, model.ItemStatusLost, model.ItemStatusRetired},
+			model.ItemStatusInRepair:  {model.ItemStatusInService, model.ItemStatusLost, model.ItemStatusRetired, model.ItemStatusDisposed},
+			model.ItemStatusOnLoan:    {model.ItemStatusInService, model.ItemStatusLost},
+			model.ItemStatusLost:      {model.ItemStatusInService, model.ItemStatusRetired, model.ItemStatusDisposed},
+			model.ItemStatusRetired:   {model.ItemStatusInService, model.ItemStatusDisposed},
+		},
+	},
 }
 
 func (s *SettingsService) Get() (dto.SettingsResponse, error) {
@@ -44,6 +57,10 @@ func (s *SettingsService) Get() (dto.SettingsResponse, error) {
 }
 
 func (s *SettingsService) Update(settings dto.SettingsResponse) error {
+	if err := settings.Statuses.Validate(); err != nil {
+		return err
+	}
+
 	jsonData, err := json.Marshal(settings)
 	if err != nil {
 		return err
@@ -70,4 +87,10 @@ func ensureSettingsDefaults(s *dto.SettingsResponse) {
 	if s.Terminology.Groups == "" {
 		s.Terminology.Groups = defaultSettings.Terminology.Groups
 	}
+	if s.Trash.RetentionDays <= 0 {
+		s.Trash.RetentionDays = defaultSettings.Trash.RetentionDays
+	}
+	if s.Statuses.Transitions == nil {
+		s.Statuses.Transitions = defaultSettings.Statuses.Transitions
+	}
 }
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+)
+
+var (
+	ErrItemNotStock           = errors.New("item is not a stock item")
+	ErrInsufficientStock      = errors.New("not enough stock held at the location")
+	ErrStockThresholdNotFound = errors.New("stock threshold not found")
+)
+
+type StockService struct {
+	stockRepo    repository.StockRepository
+	itemRepo     repository.ItemRepository
+	locationRepo repository.LocationRepository
+}
+
+func NewStockService(
+	stockRepo repository.StockRepository,
+	itemRepo repository.ItemRepository,
+	locationRepo repository.LocationRepository,
+) *StockService {
+	return &StockService{
+		stockRepo:    stockRepo,
+		itemRepo:     itemRepo,
+		locationRepo: locationRepo,
+	}
+}
+
+// GetLevels returns the quantity of the stock item held at each location.
+func (s *StockService) GetLevels(itemID uuid.UUID) (dto.ItemStockResponse, error) {
+	item, err := s.itemRepo.Get(itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.ItemStockResponse{}, ErrItemNotFound
+		}
+		return dto.ItemStockResponse{}, err
+	}
+	if item.Kind != model.ItemKindStock {
+		return dto.ItemStockResponse{}, ErrItemNotStock
+	}
+	return s.levels(item)
+}
+
+// Receive adds the quantity in the request to the stock held at the location.
+func (s *StockService) Receive(userID uuid.UUID, req dto.StockMovementRequest) (dto.ItemStockResponse, error) {
+	if err := req.Validate(); err != nil {
+		return dto.ItemStockResponse{}, err
+	}
+	return s.apply(userID, req.ItemID, []repository.StockChange{{
+		Type:       model.ItemHistoryTypeStockReceived,
+		LocationID: req.LocationID,
+		Delta:      req.Quantity,
+		Note:       req.Note,
+	}})
+}
+
+// Issue takes the quantity in the request out of the stock held at the location.
+func (s *StockService) Issue(userID uuid.UUID, req dto.StockMovementRequest) (dto.ItemStockResponse, error) {
+	if err := req.Validate(); err != nil {
+		return dto.ItemStockResponse{}, err
+	}
+	return s.apply(userID, req.ItemID, []repository.StockChange{{
+		Type:       model.ItemHistoryTypeStockIssued,
+		LocationID: req.LocationID,
+		Delta:      -req.Quantity,
+		Note:       req.Note,
+	}})
+}
+
+// Transfer moves the quantity in the request from one location to another, recording the move at both ends.
+func (s *StockService) Transfer(userID uuid.UUID, req dto.StockTransferRequest) (dto.ItemStockResponse, error) {
+	if err := req.Validate(); err != nil {
+		return dto.ItemStockResponse{}, err
+	}
+	return s.apply(userID, req.ItemID, []repository.StockChange{
+		{
+			Type:               model.ItemHistoryTypeStockTransferred,
+			LocationID:         req.FromLocationID,
+			Delta:              -req.Quantity,
+			TransferLocationID: &req.ToLocationID,
+			Note:               req.Note,
+		},
+		{
+			Type:               model.ItemHistoryTypeStockTransferred,
+			LocationID:         req.ToLocationID,
+			Delta:              req.Quantity,
+			TransferLocationID: &req.FromLocationID,
+			Note:               req.Note,
+		},
+	})
+}
+
+// Adjust corrects the stock held at the location by the signed delta in the request.
+func (s *StockService) Adjust(userID uuid.UUID, req dto.StockAdjustRequest) (dto.ItemStockResponse, error) {
+	if err := req.Validate(); err != nil {
+		return dto.ItemStockResponse{}, err
+	}
+	return s.apply(userID, req.ItemID, []repository.StockChange{{
+		Type:       model.ItemHistoryTypeStockAdjusted,
+		LocationID: req.LocationID,
+		Delta:      req.Delta,
+		Note:       req.Note,
+	}})
+}
+
+// apply checks that the item is a stock item that is not deleted and that stock can be held at each location,
+// then applies the changes and returns the resulting stock levels of the item.
+func (s *StockService) apply(userID, itemID uuid.UUID, changes []repository.StockChange) (dto.ItemStockResponse, error) {
+	item, err := s.getStockItem(itemID)
+	if err != nil {
+		return dto.ItemStockResponse{}, err
+	}
+
+	for _, change := range changes {
+		if err := s.validateStockLocation(change.LocationID); err != nil {
+			return dto.ItemStockResponse{}, err
+		}
+	}
+
+	if err := s.stockRepo.Apply(item.ID, userID, changes); err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return dto.ItemStockResponse{}, ErrInsufficientStock
+		}
+		return dto.ItemStockResponse{}, err
+	}
+
+	return s.levels(item)
+}
+
+func (s *StockService) levels(item model.ItemModel) (dto.ItemStockResponse, error) {
+	levels, err := s.stockRepo.ListLevels(item.ID)
+	if err != nil {
+		return dto.ItemStockResponse{}, err
+	}
+	return dto.NewItemStockResponse(item, levels), nil
+}
+
+// getStockItem returns the item if it exists, is not deleted and is a stock item.
+func (s *StockService) getStockItem(itemID uuid.UUID) (model.ItemModel, error) {
+	item, err := s.itemRepo.Get(itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ItemModel{}, ErrItemNotFound
+		}
+		return model.ItemModel{}, err
+	}
+	if item.Deleted {
+		return model.ItemModel{}, ErrItemDeleted
+	}
+	if item.Kind != model.ItemKindStock {
+		return model.ItemModel{}, ErrItemNotStock
+	}
+	return item, nil
+}
+
+// validateStockLocation checks that the location exists and is not deleted.
+func (s *StockService) validateStockLocation(locationID uuid.UUID) error {
+	location, err := s.locationRepo.Get(locationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLocationNotFound
+		}
+		return err
+	}
+	if location.IsDeleted {
+		return ErrLocationDeleted
+	}
+	return nil
+}
+
+// ListThresholds lists the reorder thresholds of every group key and location.
+func (s *StockService) ListThresholds() ([]dto.StockThresholdResponse, error) {
+	thresholds, err := s.stockRepo.ListThresholds()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.StockThresholdResponse, len(thresholds))
+	for i, t := range thresholds {
+		response[i] = dto.NewStockThresholdResponseFromModel(t)
+	}
+	return response, nil
+}
+
+// SetThreshold creates or replaces the reorder threshold of the group key at the location.
+func (s *StockService) SetThreshold(req dto.StockThresholdRequest) (dto.StockThresholdResponse, error) {
+	if err := req.Validate(); err != nil {
+		return dto.StockThresholdResponse{}, err
+	}
+
+	location, err := s.locationRepo.Get(req.LocationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.StockThresholdResponse{}, ErrLocationNotFound
+		}
+		return dto.StockThresholdResponse{}, err
+	}
+	if location.IsDeleted {
+		return dto.StockThresholdResponse{}, ErrLocationDeleted
+	}
+
+	threshold := model.StockThresholdModel{
+		GroupKey:     req.GroupKey,
+		LocationID:   location.ID,
+		LocationName: location.Name,
+		Threshold:    req.Threshold,
+	}
+	if err := s.stockRepo.SetThreshold(&threshold); err != nil {
+		return dto.StockThresholdResponse{}, err
+	}
+	return dto.NewStockThresholdResponseFromModel(threshold), nil
+}
+
+// DeleteThreshold removes the reorder threshold of the group key at the location.
+func (s *StockService) DeleteThreshold(groupKey string, locationID uuid.UUID) error {
+	if err := s.stockRepo.DeleteThreshold(groupKey, locationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrStockThresholdNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListLow lists each group of stock items held at a location in a quantity below its reorder threshold.
+func (s *StockService) ListLow() ([]dto.LowStockResponse, error) {
+	low, err := s.stockRepo.ListLow()
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]dto.LowStockResponse, len(low))
+	for i, l := range low {
+		response[i] = dto.NewLowStockResponseFromModel(l)
+	}
+	return response, nil
+}
@@ -10,6 +10,7 @@ import (
 	"quantum/internal/model"
 	"quantum/internal/permissions"
 	"quantum/internal/repository"
+	"quantum/internal/types/pagination"
 )
 
 var (
@@ -28,18 +29,13 @@ func NewUserService(userRepo repository.UserRepository) *UserService {
 	}
 }
 
-func (s *UserService) List(roleFilters []string) ([]dto.UserResponse, error) {
-	users, err := s.userRepo.List(roleFilters)
+func (s *UserService) List(roleFilters []string, page pagination.Params) (pagination.Page[dto.UserResponse], error) {
+	users, err := s.userRepo.List(roleFilters, page)
 	if err != nil {
-		return nil, err
+		return pagination.Page[dto.UserResponse]{}, err
 	}
 
-	userResponses := make([]dto.UserResponse, 0, len(users))
-	for _, user := range users {
-		userResponses = append(userResponses, dto.NewUserResponseFromModel(user))
-	}
-
-	return userResponses, nil
+	return pagination.MapPage(users, dto.NewUserResponseFromModel), nil
 }
 
 func (s *UserService) Get(id uuid.UUID) (dto.UserResponse, error) {
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"quantum/internal/dto"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"quantum/internal/types/pagination"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+type WebhookService struct {
+	webhookRepo repository.WebhookRepository
+}
+
+func NewWebhookService(webhookRepo repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+	}
+}
+
+func (s *WebhookService) List() ([]dto.WebhookResponse, error) {
+	webhooks, err := s.webhookRepo.List()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]dto.WebhookResponse, len(webhooks))
+	for i, w := range webhooks {
+		results[i] = dto.NewWebhookResponseFromModel(w)
+	}
+	return results, nil
+}
+
+func (s *WebhookService) Get(id uuid.UUID) (dto.WebhookResponse, error) {
+	webhook, err := s.webhookRepo.Get(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.WebhookResponse{}, ErrWebhookNotFound
+		}
+		return dto.WebhookResponse{}, err
+	}
+	return dto.NewWebhookResponseFromModel(webhook), nil
+}
+
+// Create creates the webhook, generating a secret if one is not given.
+func (s *WebhookService) Create(req dto.WebhookRequest) (dto.WebhookWithSecretResponse, error) {
+	if err := req.Validate(); err != nil {
+		return dto.WebhookWithSecretResponse{}, err
+	}
+
+	webhook := model.WebhookModel{
+		URL:        req.URL,
+		EventTypes: pq.StringArray(req.EventTypes),
+		Active:     true,
+	}
+
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if req.Secret != nil && *req.Secret != "" {
+		webhook.Secret = *req.Secret
+	} else {
+		secret, err := generateSecret()
+		if err != nil {
+			return dto.WebhookWithSecretResponse{}, err
+		}
+		webhook.Secret = secret
+	}
+
+	if webhook.EventTypes == nil {
+		webhook.EventTypes = pq.StringArray{}
+	}
+
+	if err := s.webhookRepo.Create(&webhook); err != nil {
+		return dto.WebhookWithSecretResponse{}, err
+	}
+
+	return dto.WebhookWithSecretResponse{
+		WebhookResponse: dto.NewWebhookResponseFromModel(webhook),
+		Secret:          webhook.Secret,
+	}, nil
+}
+
+// Update replaces the URL and event types of the webhook.
+// The secret and active state are only changed when given in the request.
+func (s *WebhookService) Update(id uuid.UUID, req dto.WebhookRequest) (dto.WebhookResponse, error) {
+	if err := req.Validate(); err != nil {
+		return dto.WebhookResponse{}, err
+	}
+
+	webhook, err := s.webhookRepo.Get(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.WebhookResponse{}, ErrWebhookNotFound
+		}
+		return dto.WebhookResponse{}, err
+	}
+
+	webhook.URL = req.URL
+	webhook.EventTypes = pq.StringArray(req.EventTypes)
+	if webhook.EventTypes == nil {
+		webhook.EventTypes = pq.StringArray{}
+	}
+	if req.Secret != nil && *req.Secret != "" {
+		webhook.Secret = *req.Secret
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := s.webhookRepo.Update(&webhook); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.WebhookResponse{}, ErrWebhookNotFound
+		}
+		return dto.WebhookResponse{}, err
+	}
+
+	return dto.NewWebhookResponseFromModel(webhook), nil
+}
+
+func (s *WebhookService) Delete(id uuid.UUID) error {
+	if err := s.webhookRepo.Delete(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebhookNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ListDeliveries lists the deliveries of the webhook, newest first, each with its log of attempts.
+func (s *WebhookService) ListDeliveries(webhookID uuid.UUID, page pagination.Params) (pagination.Page[dto.WebhookDeliveryResponse], error) {
+	if _, err := s.webhookRepo.Get(webhookID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return pagination.Page[dto.WebhookDeliveryResponse]{}, ErrWebhookNotFound
+		}
+		return pagination.Page[dto.WebhookDeliveryResponse]{}, err
+	}
+
+	deliveries, err := s.webhookRepo.ListDeliveries(webhookID, page)
+	if err != nil {
+		return pagination.Page[dto.WebhookDeliveryResponse]{}, err
+	}
+
+	deliveryIDs := make([]int64, len(deliveries.Items))
+	for i, d := range deliveries.Items {
+		deliveryIDs[i] = d.ID
+	}
+
+	attempts, err := s.webhookRepo.ListDeliveryAttempts(deliveryIDs)
+	if err != nil {
+		return pagination.Page[dto.WebhookDeliveryResponse]{}, err
+	}
+
+	attemptsByDelivery := make(map[int64][]model.WebhookDeliveryAttemptModel)
+	for _, a := range attempts {
+		attemptsByDelivery[a.DeliveryID] = append(attemptsByDelivery[a.DeliveryID], a)
+	}
+
+	return pagination.MapPage(deliveries, func(d model.WebhookDeliveryModel) dto.WebhookDeliveryResponse {
+		return dto.NewWebhookDeliveryResponseFromModel(d, attemptsByDelivery[d.ID])
+	}), nil
+}
+
+// Redeliver queues the delivery to be sent again by the dispatcher straight away.
+func (s *WebhookService) Redeliver(webhookID uuid.UUID, deliveryID int64) error {
+	if err := s.webhookRepo.Redeliver(webhookID, deliveryID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebhookDeliveryNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// generateSecret returns 32 random bytes, hex encoded, used for webhook secrets and reservation feed tokens.
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"quantum/internal/model"
+	"quantum/internal/repository"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader holds the HMAC-SHA256 signature of a delivery, see SignWebhookPayload.
+	WebhookSignatureHeader = "X-Quantum-Signature"
+	// WebhookTimestampHeader holds the unix time the delivery was signed at.
+	WebhookTimestampHeader = "X-Quantum-Timestamp"
+	WebhookEventHeader     = "X-Quantum-Event"
+	WebhookDeliveryHeader  = "X-Quantum-Delivery"
+)
+
+const (
+	webhookPollInterval = 5 * time.Second
+	webhookBatchSize    = 50
+	// webhookSendTimeout is how long a webhook receiver has to respond before the delivery is retried.
+	webhookSendTimeout = 10 * time.Second
+	// webhookLease is how long claimed deliveries are held by a dispatcher. The deliveries of a batch are sent
+	// concurrently, so the lease only has to outlast a single send and recording the attempts.
+	webhookLease             = time.Minute
+	webhookMaxAttempts       = 10
+	webhookBaseBackoff       = 30 * time.Second
+	webhookMaxBackoff        = 6 * time.Hour
+	webhookMaxResponseLength = 64 << 10
+)
+
+// WebhookDispatcher sends the deliveries in the webhook outbox.
+// Failed deliveries are retried with exponential backoff until webhookMaxAttempts is reached.
+type WebhookDispatcher struct {
+	webhookRepo repository.WebhookRepository
+	client      *http.Client
+	logger      *slog.Logger
+}
+
+func NewWebhookDispatcher(webhookRepo repository.WebhookRepository, client *http.Client, logger *slog.Logger) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		webhookRepo: webhookRepo,
+		client:      client,
+		logger:      logger,
+	}
+}
+
+// Run dispatches due deliveries every poll interval until the context is cancelled.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			count, err := d.DispatchDue(ctx)
+			if err != nil {
+				d.logger.Error("error dispatching webhooks", "error", err)
+			}
+			// Keep going while there is a backlog, otherwise wait for the next tick.
+			if err != nil || count < webhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue sends a batch of the deliveries that are due and returns how many were attempted.
+// The deliveries are sent concurrently so the whole batch is sent well within the lease.
+func (d *WebhookDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	deliveries, err := d.webhookRepo.ClaimDueDeliveries(webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.deliver(ctx, delivery); err != nil {
+				d.logger.Error("error recording webhook attempt", "error", err, "deliveryID", delivery.ID)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery model.WebhookDeliveryWithSecretModel) error {
+	attempt := model.WebhookDeliveryAttemptModel{DeliveryID: delivery.ID}
+
+	start := time.Now()
+	statusCode, err := d.send(ctx, delivery)
+	attempt.DurationMS = int(time.Since(start).Milliseconds())
+
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	if err != nil {
+		message := err.Error()
+		attempt.Error = &message
+	}
+
+	if err == nil {
+		return d.webhookRepo.RecordAttempt(&attempt, model.WebhookDeliveryStatusDelivered, time.Now())
+	}
+
+	attempts := delivery.Attempts + 1
+	if attempts >= webhookMaxAttempts {
+		return d.webhookRepo.RecordAttempt(&attempt, model.WebhookDeliveryStatusFailed, time.Now())
+	}
+	return d.webhookRepo.RecordAttempt(&attempt, model.WebhookDeliveryStatusPending, time.Now().Add(webhookBackoff(attempts)))
+}
+
+// send posts the signed payload to the webhook URL.
+// Returns the response status code, if a response was received, and an error unless it is a 2xx status.
+func (d *WebhookDispatcher) send(ctx context.Context, delivery model.WebhookDeliveryWithSecretModel) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookSendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Quantum-Webhooks")
+	req.Header.Set(WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookMaxResponseLength))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload returns the signature of a webhook delivery in the form "sha256=<hex>".
+// The HMAC-SHA256 is computed with the webhook secret over the timestamp header, a full stop and the body,
+// so receivers can reject replayed deliveries by checking the timestamp.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff returns how long to wait before the next attempt after the given number of failed attempts.
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, webhookMaxBackoff)
+}
@@ -0,0 +1,260 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit int = 50
+	MaxLimit     int = 500
+)
+
+var (
+	ErrInvalidCursor    = errors.New("invalid cursor")
+	ErrInvalidSort      = errors.New("invalid sort field")
+	ErrInvalidDirection = errors.New("invalid sort direction, expected asc or desc")
+)
+
+type Direction string
+
+const (
+	Ascending  Direction = "asc"
+	Descending Direction = "desc"
+)
+
+func NewDirection(direction string) (Direction, error) {
+	switch direction {
+	case "":
+		return "", nil
+	case string(Ascending):
+		return Ascending, nil
+	case string(Descending):
+		return Descending, nil
+	default:
+		return "", ErrInvalidDirection
+	}
+}
+
+// Cursor points at the last row of a page.
+// It holds the list and sort the page was built with, the sort value of the row and the row ID as a tie-breaker.
+type Cursor struct {
+	List      string    `json:"l"`
+	Sort      string    `json:"s"`
+	Direction Direction `json:"d"`
+	Value     string    `json:"v"`
+	ID        string    `json:"i"`
+}
+
+// Encode returns the opaque string form of the cursor handed to clients.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor previously returned by Cursor.Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if c.Direction != Ascending && c.Direction != Descending {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Params are the paging and sorting options requested for a list.
+// When a Cursor is present its sort and direction take precedence over Sort and Direction,
+// so a client only needs to pass the cursor to fetch the next page.
+type Params struct {
+	Cursor    *Cursor
+	Limit     int
+	Sort      string
+	Direction Direction
+}
+
+// PageSize returns the requested limit clamped between 1 and MaxLimit, defaulting to DefaultLimit.
+func (p Params) PageSize() int {
+	if p.Limit <= 0 {
+		return DefaultLimit
+	}
+	return min(p.Limit, MaxLimit)
+}
+
+// Page is a single page of a list along with the cursor for the following page.
+// NextCursor is nil when there are no more rows.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"nextCursor"`
+}
+
+// MapPage converts the items of a page, keeping the cursor.
+func MapPage[T, U any](page Page[T], fn func(T) U) Page[U] {
+	items := make([]U, len(page.Items))
+	for i, item := range page.Items {
+		items[i] = fn(item)
+	}
+	return Page[U]{Items: items, NextCursor: page.NextCursor}
+}
+
+// SortField is a whitelisted field a list can be sorted by.
+type SortField[T any] struct {
+	// Column is the SQL expression the rows are ordered by.
+	Column string
+	// Type is the SQL type the cursor value is cast to when comparing against Column.
+	// Cursor values are checked against uuid, bigint and timestamptz, any other type is treated as text.
+	Type string
+	// Value returns the value of the field for a row, as stored in the cursor.
+	Value func(T) string
+}
+
+// Keyset describes how a list query is sorted and paged.
+// Rows are ordered by the sort field and then by ID so that the order is stable even when sort values repeat.
+type Keyset[T any] struct {
+	// Name identifies the list, cursors built for another list are rejected.
+	Name             string
+	Fields           map[string]SortField[T]
+	DefaultSort      string
+	DefaultDirection Direction
+	IDColumn         string
+	// IDType is the SQL type the cursor ID is cast to when comparing against IDColumn, defaulting to uuid.
+	IDType string
+	ID     func(T) string
+}
+
+// Clause is the SQL needed to fetch a page of a keyset paged query.
+type Clause struct {
+	// Where is the condition selecting the rows after the cursor, it is "true" on the first page.
+	Where string
+	// OrderBy is the order by expression, without the "order by" keyword.
+	OrderBy string
+	// Limit is the limit clause, one more row than the page size is fetched to know if there is a next page.
+	Limit string
+	// Args are the arguments referenced by Where and Limit.
+	Args []any
+}
+
+func (k Keyset[T]) resolve(p Params) (string, Direction, SortField[T], error) {
+	sort, direction := p.Sort, p.Direction
+	if p.Cursor != nil {
+		sort, direction = p.Cursor.Sort, p.Cursor.Direction
+	}
+	if sort == "" {
+		sort = k.DefaultSort
+	}
+	if direction == "" {
+		direction = k.DefaultDirection
+	}
+	if direction == "" {
+		direction = Ascending
+	}
+
+	field, ok := k.Fields[sort]
+	if !ok {
+		if p.Cursor != nil {
+			return "", "", SortField[T]{}, ErrInvalidCursor
+		}
+		return "", "", SortField[T]{}, ErrInvalidSort
+	}
+
+	if p.Cursor != nil {
+		if p.Cursor.List != k.Name || !validCursorValue(field.Type, p.Cursor.Value) || !validCursorValue(k.idType(), p.Cursor.ID) {
+			return "", "", SortField[T]{}, ErrInvalidCursor
+		}
+	}
+	return sort, direction, field, nil
+}
+
+func (k Keyset[T]) idType() string {
+	if k.IDType == "" {
+		return "uuid"
+	}
+	return k.IDType
+}
+
+// validCursorValue reports whether the value of a cursor can be cast to the SQL type,
+// so that a tampered cursor is rejected instead of failing the query.
+func validCursorValue(sqlType, value string) bool {
+	switch sqlType {
+	case "uuid":
+		_, err := uuid.Parse(value)
+		return err == nil
+	case "bigint":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case "timestamptz":
+		_, err := time.Parse(time.RFC3339Nano, value)
+		return err == nil
+	default:
+		return utf8.ValidString(value) && !strings.ContainsRune(value, 0)
+	}
+}
+
+// Clause builds the SQL for the page requested by the params.
+// The arguments are numbered from firstArg so the clause can be appended to a query with existing arguments.
+func (k Keyset[T]) Clause(p Params, firstArg int) (Clause, error) {
+	_, direction, field, err := k.resolve(p)
+	if err != nil {
+		return Clause{}, err
+	}
+
+	operator := ">"
+	if direction == Descending {
+		operator = "<"
+	}
+
+	clause := Clause{
+		Where:   "true",
+		OrderBy: fmt.Sprintf("%s %s, %s %s", field.Column, direction, k.IDColumn, direction),
+	}
+
+	if p.Cursor != nil {
+		clause.Where = fmt.Sprintf("(%s, %s) %s ($%d::%s, $%d::%s)", field.Column, k.IDColumn, operator, firstArg, field.Type, firstArg+1, k.idType())
+		clause.Args = append(clause.Args, p.Cursor.Value, p.Cursor.ID)
+		firstArg += 2
+	}
+
+	clause.Limit = fmt.Sprintf("limit $%d", firstArg)
+	clause.Args = append(clause.Args, p.PageSize()+1)
+
+	return clause, nil
+}
+
+// Page trims the rows fetched with Clause to the page size and builds the cursor for the next page.
+func (k Keyset[T]) Page(rows []T, p Params) Page[T] {
+	size := p.PageSize()
+	if len(rows) <= size {
+		return Page[T]{Items: rows}
+	}
+
+	rows = rows[:size]
+	sort, direction, field, err := k.resolve(p)
+	if err != nil {
+		return Page[T]{Items: rows}
+	}
+
+	last := rows[len(rows)-1]
+	next := Cursor{
+		List:      k.Name,
+		Sort:      sort,
+		Direction: direction,
+		Value:     field.Value(last),
+		ID:        k.ID(last),
+	}.Encode()
+
+	return Page[T]{Items: rows, NextCursor: &next}
+}
@@ -0,0 +1,148 @@
+package pagination_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"quantum/internal/types/pagination"
+)
+
+type row struct {
+	ID   string
+	Name string
+}
+
+const (
+	aliceID = "7b0d4d1e-8f5a-4c59-9f0e-1d6f4c2a0001"
+	bobID   = "7b0d4d1e-8f5a-4c59-9f0e-1d6f4c2a0002"
+	carolID = "7b0d4d1e-8f5a-4c59-9f0e-1d6f4c2a0003"
+)
+
+var keyset = pagination.Keyset[row]{
+	Name: "rows",
+	Fields: map[string]pagination.SortField[row]{
+		"name":      {Column: "name", Type: "text", Value: func(r row) string { return r.Name }},
+		"createdAt": {Column: "created_at", Type: "timestamptz", Value: func(r row) string { return "" }},
+	},
+	DefaultSort: "name",
+	IDColumn:    "id",
+	ID:          func(r row) string { return r.ID },
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := pagination.Cursor{List: "rows", Sort: "name", Direction: pagination.Descending, Value: "Bob", ID: bobID}
+
+	decoded, err := pagination.DecodeCursor(c.Encode())
+
+	assert.NoError(t, err)
+	assert.Equal(t, c, decoded)
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	_, err := pagination.DecodeCursor("not a cursor")
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
+
+func TestKeysetClause(t *testing.T) {
+	testCases := []struct {
+		name        string
+		params      pagination.Params
+		expectWhere string
+		expectOrder string
+		expectLimit string
+		expectArgs  []any
+		expectErr   error
+	}{
+		{
+			name:        "first page uses the default sort",
+			params:      pagination.Params{Limit: 10},
+			expectWhere: "true",
+			expectOrder: "name asc, id asc",
+			expectLimit: "limit $3",
+			expectArgs:  []any{11},
+		},
+		{
+			name: "cursor sort takes precedence",
+			params: pagination.Params{
+				Sort:   "other",
+				Cursor: &pagination.Cursor{List: "rows", Sort: "name", Direction: pagination.Descending, Value: "Bob", ID: bobID},
+			},
+			expectWhere: "(name, id) < ($3::text, $4::uuid)",
+			expectOrder: "name desc, id desc",
+			expectLimit: "limit $5",
+			expectArgs:  []any{"Bob", bobID, pagination.DefaultLimit + 1},
+		},
+		{
+			name:      "cursor from another list",
+			params:    pagination.Params{Cursor: &pagination.Cursor{List: "locations", Sort: "name", Direction: pagination.Ascending, Value: "Bob", ID: bobID}},
+			expectErr: pagination.ErrInvalidCursor,
+		},
+		{
+			name:      "cursor with an invalid id",
+			params:    pagination.Params{Cursor: &pagination.Cursor{List: "rows", Sort: "name", Direction: pagination.Ascending, Value: "Bob", ID: "2"}},
+			expectErr: pagination.ErrInvalidCursor,
+		},
+		{
+			name:      "cursor with an invalid timestamp",
+			params:    pagination.Params{Cursor: &pagination.Cursor{List: "rows", Sort: "createdAt", Direction: pagination.Ascending, Value: "yesterday", ID: bobID}},
+			expectErr: pagination.ErrInvalidCursor,
+		},
+		{
+			name:      "cursor with a NUL byte in a text value",
+			params:    pagination.Params{Cursor: &pagination.Cursor{List: "rows", Sort: "name", Direction: pagination.Ascending, Value: "Bob\x00", ID: bobID}},
+			expectErr: pagination.ErrInvalidCursor,
+		},
+		{
+			name:      "unknown sort field",
+			params:    pagination.Params{Sort: "password"},
+			expectErr: pagination.ErrInvalidSort,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clause, err := keyset.Clause(tc.params, 3)
+			if tc.expectErr != nil {
+				assert.ErrorIs(t, err, tc.expectErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectWhere, clause.Where)
+			assert.Equal(t, tc.expectOrder, clause.OrderBy)
+			assert.Equal(t, tc.expectLimit, clause.Limit)
+			assert.Equal(t, tc.expectArgs, clause.Args)
+		})
+	}
+}
+
+func TestKeysetPage(t *testing.T) {
+	rows := []row{{aliceID, "Alice"}, {bobID, "Bob"}, {carolID, "Carol"}}
+
+	page := keyset.Page(rows, pagination.Params{Limit: 2})
+
+	assert.Len(t, page.Items, 2)
+	if assert.NotNil(t, page.NextCursor) {
+		next, err := pagination.DecodeCursor(*page.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, "rows", next.List)
+		assert.Equal(t, "Bob", next.Value)
+		assert.Equal(t, bobID, next.ID)
+	}
+
+	lastPage := keyset.Page(rows[2:], pagination.Params{Limit: 2})
+	assert.Nil(t, lastPage.NextCursor)
+}
+
+func TestKeysetClause_IDType(t *testing.T) {
+	k := keyset
+	k.IDType = "bigint"
+
+	clause, err := k.Clause(pagination.Params{
+		Cursor: &pagination.Cursor{List: "rows", Sort: "name", Direction: pagination.Ascending, Value: "Bob", ID: "2"},
+	}, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "(name, id) > ($1::text, $2::bigint)", clause.Where)
+}
@@ -0,0 +1,43 @@
+// Package barcode encodes text as QR codes and Code 128 barcodes.
+// The symbols are returned as a grid of modules, leaving the drawing to the caller.
+package barcode
+
+import "errors"
+
+var (
+	ErrEmptyData   = errors.New("barcode data is empty")
+	ErrDataTooLong = errors.New("barcode data is too long")
+)
+
+// Code is an encoded symbol as a grid of dark and light modules.
+// One-dimensional barcodes have a height of one and are stretched vertically when drawn.
+type Code struct {
+	Width  int
+	Height int
+	// QuietZone is the number of light modules to leave around the symbol so that it can be scanned.
+	QuietZone int
+	modules   []bool
+}
+
+func newCode(width, height, quietZone int) *Code {
+	return &Code{
+		Width:     width,
+		Height:    height,
+		QuietZone: quietZone,
+		modules:   make([]bool, width*height),
+	}
+}
+
+// Dark reports whether the module at the column x and row y is dark.
+func (c *Code) Dark(x, y int) bool {
+	return c.modules[y*c.Width+x]
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y*c.Width+x] = dark
+}
+
+// Linear reports whether the code is a one-dimensional barcode.
+func (c *Code) Linear() bool {
+	return c.Height == 1
+}
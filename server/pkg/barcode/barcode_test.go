@@ -0,0 +1,223 @@
+package barcode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// The data and error correction codewords of HELLO WORLD encoded as a version 1-M QR code.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	assert.Equal(t, expected, reedSolomonRemainder(data, reedSolomonDivisor(10)))
+}
+
+func TestEncodeQR(t *testing.T) {
+	testCases := []struct {
+		name    string
+		text    string
+		version int
+	}{
+		{name: "short reference", text: "REF-1", version: 1},
+		{name: "location ID", text: "2f1c6a9e-3b1d-4b59-9a8e-6f0d2c9b7e11", version: 3},
+		{name: "multiple blocks", text: strings.Repeat("ABC123", 22), version: 8},
+		{name: "largest version", text: strings.Repeat("x", 213), version: 10},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := EncodeQR(tc.text)
+			assert.NoError(t, err)
+			assert.Equal(t, 17+4*tc.version, code.Width)
+			assert.Equal(t, code.Width, code.Height)
+			assert.Equal(t, tc.text, decodeQR(t, code, tc.version))
+		})
+	}
+}
+
+func TestEncodeQR_TooLong(t *testing.T) {
+	_, err := EncodeQR(strings.Repeat("x", 214))
+	assert.ErrorIs(t, err, ErrDataTooLong)
+
+	_, err = EncodeQR("")
+	assert.ErrorIs(t, err, ErrEmptyData)
+}
+
+// decodeQR reads the text back from a QR code, checking the format information and error correction on the way.
+func decodeQR(t *testing.T, code *Code, version int) string {
+	t.Helper()
+
+	// The first copy of the format information, most significant bit first.
+	formatModules := [][2]int{
+		{0, 8}, {1, 8}, {2, 8}, {3, 8}, {4, 8}, {5, 8}, {7, 8}, {8, 8},
+		{8, 7}, {8, 5}, {8, 4}, {8, 3}, {8, 2}, {8, 1}, {8, 0},
+	}
+	format := 0
+	for _, m := range formatModules {
+		format <<= 1
+		if code.Dark(m[0], m[1]) {
+			format |= 1
+		}
+	}
+	format ^= 0x5412
+	assert.Equal(t, qrFormatBitsM, format>>13, "error correction level")
+	mask := format >> 10 & 7
+
+	// Lay the function patterns of the version over a copy of the code to find the data modules, then unmask them.
+	size := code.Width
+	s := &qrSymbol{
+		Code:     newCode(size, size, qrQuietZone),
+		version:  version,
+		function: make([]bool, size*size),
+	}
+	s.drawFunctionPatterns()
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if !s.function[y*size+x] {
+				s.set(x, y, code.Dark(x, y))
+			}
+		}
+	}
+	s.applyMask(mask)
+
+	var bits bitBuffer
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vertical := 0; vertical < size; vertical++ {
+			y := vertical
+			if (right+1)&2 == 0 {
+				y = size - 1 - vertical
+			}
+			for x := right; x > right-2; x-- {
+				if !s.function[y*size+x] {
+					bits = append(bits, s.Dark(x, y))
+				}
+			}
+		}
+	}
+	codewords := bits[:len(bits)/8*8].bytes()
+
+	// Undo the interleaving and check that every block is a valid Reed-Solomon codeword.
+	blocks := qrVersionBlocks[version]
+	count := blocks.shortBlocks + blocks.longBlocks
+	dataBlocks := make([][]byte, count)
+	offset := 0
+	for i := 0; i <= blocks.shortData; i++ {
+		for b := range dataBlocks {
+			if i < blocks.shortData || b >= blocks.shortBlocks {
+				dataBlocks[b] = append(dataBlocks[b], codewords[offset])
+				offset++
+			}
+		}
+	}
+	ecBlocks := make([][]byte, count)
+	for i := 0; i < blocks.ecPerBlock; i++ {
+		for b := range ecBlocks {
+			ecBlocks[b] = append(ecBlocks[b], codewords[offset])
+			offset++
+		}
+	}
+
+	var data []byte
+	for b := range dataBlocks {
+		block := append(append([]byte{}, dataBlocks[b]...), ecBlocks[b]...)
+		root := byte(1)
+		for i := 0; i < blocks.ecPerBlock; i++ {
+			// A valid codeword evaluates to zero at every root of the generator polynomial.
+			var syndrome byte
+			for _, c := range block {
+				syndrome = gfMultiply(syndrome, root) ^ c
+			}
+			assert.Zero(t, syndrome, "syndrome %d of block %d", i, b)
+			root = gfMultiply(root, 0x02)
+		}
+		data = append(data, dataBlocks[b]...)
+	}
+
+	var stream bitBuffer
+	for _, d := range data {
+		stream.append(int(d), 8)
+	}
+	read := func(n int) int {
+		value := 0
+		for i := 0; i < n; i++ {
+			value <<= 1
+			if stream[i] {
+				value |= 1
+			}
+		}
+		stream = stream[n:]
+		return value
+	}
+	assert.Equal(t, 0b0100, read(4), "byte mode")
+	length := read(qrCountBits(version))
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = byte(read(8))
+	}
+	return string(text)
+}
+
+func TestEncodeCode128(t *testing.T) {
+	code, err := EncodeCode128("AB")
+	assert.NoError(t, err)
+	assert.True(t, code.Linear())
+
+	// Read the widths of the bars and spaces back and look them up in the pattern table.
+	var widths []byte
+	run := 1
+	for x := 1; x <= code.Width; x++ {
+		if x < code.Width && code.Dark(x, 0) == code.Dark(x-1, 0) {
+			run++
+			continue
+		}
+		widths = append(widths, byte('0'+run))
+		run = 1
+	}
+	assert.True(t, code.Dark(0, 0), "starts with a bar")
+
+	var values []int
+	for len(widths) > 7 {
+		values = append(values, code128Value(t, string(widths[:6])))
+		widths = widths[6:]
+	}
+	values = append(values, code128Value(t, string(widths)))
+
+	// The check symbol is (104 + 1*33 + 2*34) mod 103.
+	assert.Equal(t, []int{code128StartB, 33, 34, 102, code128Stop}, values)
+}
+
+func TestEncodeCode128_Invalid(t *testing.T) {
+	_, err := EncodeCode128("café")
+	assert.ErrorIs(t, err, ErrInvalidCode128Data)
+}
+
+func TestCode128Patterns(t *testing.T) {
+	for value, pattern := range code128Patterns {
+		width := 0
+		for _, w := range pattern {
+			width += int(w - '0')
+		}
+		if value == code128Stop {
+			assert.Equal(t, 13, width)
+		} else {
+			assert.Equal(t, 11, width, "pattern %d", value)
+		}
+	}
+}
+
+func code128Value(t *testing.T, pattern string) int {
+	t.Helper()
+	for value, p := range code128Patterns {
+		if p == pattern {
+			return value
+		}
+	}
+	t.Fatalf("unknown pattern %s", pattern)
+	return -1
+}
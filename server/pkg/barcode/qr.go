@@ -0,0 +1,469 @@
+package barcode
+
+// QR codes are encoded in byte mode with the medium error correction level, which recovers from about 15% damage.
+// Versions 1 to 10 are supported, which hold up to 213 bytes and is plenty for references and IDs on a label.
+
+const (
+	qrMaxVersion = 10
+	// qrQuietZone is the required quiet zone of four modules.
+	qrQuietZone = 4
+	// qrFormatBitsM are the error correction level bits of the format information for level M.
+	qrFormatBitsM = 0
+)
+
+// qrBlocks describes how the codewords of a version are split into error correction blocks at level M.
+type qrBlocks struct {
+	ecPerBlock  int
+	shortBlocks int
+	shortData   int
+	longBlocks  int
+}
+
+func (b qrBlocks) dataCodewords() int {
+	return b.shortBlocks*b.shortData + b.longBlocks*(b.shortData+1)
+}
+
+var qrVersionBlocks = [qrMaxVersion + 1]qrBlocks{
+	1:  {ecPerBlock: 10, shortBlocks: 1, shortData: 16},
+	2:  {ecPerBlock: 16, shortBlocks: 1, shortData: 28},
+	3:  {ecPerBlock: 26, shortBlocks: 1, shortData: 44},
+	4:  {ecPerBlock: 18, shortBlocks: 2, shortData: 32},
+	5:  {ecPerBlock: 24, shortBlocks: 2, shortData: 43},
+	6:  {ecPerBlock: 16, shortBlocks: 4, shortData: 27},
+	7:  {ecPerBlock: 18, shortBlocks: 4, shortData: 31},
+	8:  {ecPerBlock: 22, shortBlocks: 2, shortData: 38, longBlocks: 2},
+	9:  {ecPerBlock: 22, shortBlocks: 3, shortData: 36, longBlocks: 2},
+	10: {ecPerBlock: 26, shortBlocks: 4, shortData: 43, longBlocks: 1},
+}
+
+// qrAlignmentPositions are the row and column centres of the alignment patterns of each version.
+var qrAlignmentPositions = [qrMaxVersion + 1][]int{
+	2:  {6, 18},
+	3:  {6, 22},
+	4:  {6, 26},
+	5:  {6, 30},
+	6:  {6, 34},
+	7:  {6, 22, 38},
+	8:  {6, 24, 42},
+	9:  {6, 26, 46},
+	10: {6, 28, 50},
+}
+
+// qrSymbol is a QR code under construction, tracking which modules belong to function patterns.
+type qrSymbol struct {
+	*Code
+	version  int
+	function []bool
+}
+
+// EncodeQR encodes the text as a QR code.
+func EncodeQR(text string) (*Code, error) {
+	if text == "" {
+		return nil, ErrEmptyData
+	}
+
+	data := []byte(text)
+	version := 0
+	for v := 1; v <= qrMaxVersion; v++ {
+		if 4+qrCountBits(v)+8*len(data) <= qrVersionBlocks[v].dataCodewords()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrDataTooLong
+	}
+
+	size := 17 + 4*version
+	s := &qrSymbol{
+		Code:     newCode(size, size, qrQuietZone),
+		version:  version,
+		function: make([]bool, size*size),
+	}
+	s.drawFunctionPatterns()
+	s.drawCodewords(qrAddErrorCorrection(qrDataCodewords(data, version), qrVersionBlocks[version]))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		s.applyMask(mask)
+		s.drawFormatBits(mask)
+		if penalty := s.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		// Masking is undone by applying the same mask again.
+		s.applyMask(mask)
+	}
+	s.applyMask(best)
+	s.drawFormatBits(best)
+
+	return s.Code, nil
+}
+
+func qrCountBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+// qrDataCodewords builds the byte mode segment for the data, padded to the data capacity of the version.
+func qrDataCodewords(data []byte, version int) []byte {
+	capacity := qrVersionBlocks[version].dataCodewords() * 8
+
+	var bits bitBuffer
+	bits.append(0b0100, 4)
+	bits.append(len(data), qrCountBits(version))
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	bits.append(0, min(4, capacity-len(bits)))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	return bits.bytes()
+}
+
+// qrAddErrorCorrection splits the data into blocks, adds the error correction codewords to each block
+// and interleaves the blocks.
+func qrAddErrorCorrection(data []byte, blocks qrBlocks) []byte {
+	divisor := reedSolomonDivisor(blocks.ecPerBlock)
+
+	var dataBlocks, ecBlocks [][]byte
+	offset := 0
+	for i := 0; i < blocks.shortBlocks+blocks.longBlocks; i++ {
+		length := blocks.shortData
+		if i >= blocks.shortBlocks {
+			length++
+		}
+		block := data[offset : offset+length]
+		offset += length
+		dataBlocks = append(dataBlocks, block)
+		ecBlocks = append(ecBlocks, reedSolomonRemainder(block, divisor))
+	}
+
+	result := make([]byte, 0, len(data)+len(ecBlocks)*blocks.ecPerBlock)
+	for i := 0; i <= blocks.shortData; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < blocks.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+func (s *qrSymbol) setFunction(x, y int, dark bool) {
+	s.set(x, y, dark)
+	s.function[y*s.Width+x] = true
+}
+
+func (s *qrSymbol) drawFunctionPatterns() {
+	size := s.Width
+
+	for i := 0; i < size; i++ {
+		s.setFunction(6, i, i%2 == 0)
+		s.setFunction(i, 6, i%2 == 0)
+	}
+
+	s.drawFinderPattern(3, 3)
+	s.drawFinderPattern(size-4, 3)
+	s.drawFinderPattern(3, size-4)
+
+	positions := qrAlignmentPositions[s.version]
+	last := len(positions) - 1
+	for i, y := range positions {
+		for j, x := range positions {
+			// The corners are taken by the finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			s.drawAlignmentPattern(x, y)
+		}
+	}
+
+	// Reserve the format information area, it is drawn once the mask is known.
+	s.drawFormatBits(0)
+	s.drawVersionBits()
+}
+
+// drawFinderPattern draws the finder pattern centred on x and y along with its light separator.
+func (s *qrSymbol) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= s.Width || yy < 0 || yy >= s.Height {
+				continue
+			}
+			distance := max(abs(dx), abs(dy))
+			s.setFunction(xx, yy, distance != 2 && distance != 4)
+		}
+	}
+}
+
+func (s *qrSymbol) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			s.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the format information for the mask, along with the dark module.
+func (s *qrSymbol) drawFormatBits(mask int) {
+	data := qrFormatBitsM<<3 | mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = remainder<<1 ^ (remainder>>9)*0x537
+	}
+	bits := (data<<10 | remainder) ^ 0x5412
+	size := s.Width
+
+	for i := 0; i <= 5; i++ {
+		s.setFunction(8, i, bit(bits, i))
+	}
+	s.setFunction(8, 7, bit(bits, 6))
+	s.setFunction(8, 8, bit(bits, 7))
+	s.setFunction(7, 8, bit(bits, 8))
+	for i := 9; i < 15; i++ {
+		s.setFunction(14-i, 8, bit(bits, i))
+	}
+
+	for i := 0; i < 8; i++ {
+		s.setFunction(size-1-i, 8, bit(bits, i))
+	}
+	for i := 8; i < 15; i++ {
+		s.setFunction(8, size-15+i, bit(bits, i))
+	}
+	s.setFunction(8, size-8, true)
+}
+
+// drawVersionBits draws both copies of the version information, which only versions 7 and up carry.
+func (s *qrSymbol) drawVersionBits() {
+	if s.version < 7 {
+		return
+	}
+
+	remainder := s.version
+	for i := 0; i < 12; i++ {
+		remainder = remainder<<1 ^ (remainder>>11)*0x1F25
+	}
+	bits := s.version<<12 | remainder
+
+	for i := 0; i < 18; i++ {
+		a, b := s.Width-11+i%3, i/3
+		s.setFunction(a, b, bit(bits, i))
+		s.setFunction(b, a, bit(bits, i))
+	}
+}
+
+// drawCodewords places the codewords in the two module wide columns that zigzag up and down from the bottom right,
+// skipping the function patterns. Modules left over are remainder bits and stay light.
+func (s *qrSymbol) drawCodewords(codewords []byte) {
+	size := s.Width
+	i := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		// The vertical timing pattern takes a whole column.
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vertical := 0; vertical < size; vertical++ {
+			y := vertical
+			if upward {
+				y = size - 1 - vertical
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if s.function[y*size+x] || i >= len(codewords)*8 {
+					continue
+				}
+				s.set(x, y, bit(int(codewords[i>>3]), 7-i&7))
+				i++
+			}
+		}
+	}
+}
+
+func (s *qrSymbol) applyMask(mask int) {
+	for y := 0; y < s.Height; y++ {
+		for x := 0; x < s.Width; x++ {
+			if s.function[y*s.Width+x] {
+				continue
+			}
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				s.set(x, y, !s.Dark(x, y))
+			}
+		}
+	}
+}
+
+// penalty scores how hard the masked symbol is to scan, lower is better.
+func (s *qrSymbol) penalty() int {
+	size := s.Width
+	result := 0
+
+	// Runs of five or more modules of the same colour and patterns that look like finder patterns,
+	// in both rows and columns.
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for _, transpose := range []bool{false, true} {
+		at := func(i, j int) bool {
+			if transpose {
+				return s.Dark(i, j)
+			}
+			return s.Dark(j, i)
+		}
+		for i := 0; i < size; i++ {
+			run := 1
+			for j := 1; j <= size; j++ {
+				if j < size && at(i, j) == at(i, j-1) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += 3 + run - 5
+				}
+				run = 1
+			}
+
+			for j := 0; j+11 <= size; j++ {
+				for _, pattern := range finderLike {
+					matches := true
+					for k, dark := range pattern {
+						if at(i, j+k) != dark {
+							matches = false
+							break
+						}
+					}
+					if matches {
+						result += 40
+					}
+				}
+			}
+		}
+	}
+
+	// Blocks of two by two modules of the same colour.
+	for y := 0; y < size-1; y++ {
+		for x := 0; x < size-1; x++ {
+			dark := s.Dark(x, y)
+			if dark == s.Dark(x+1, y) && dark == s.Dark(x, y+1) && dark == s.Dark(x+1, y+1) {
+				result += 3
+			}
+		}
+	}
+
+	// The balance of dark and light modules, penalised for each 5% away from half.
+	dark := 0
+	for _, m := range s.modules {
+		if m {
+			dark++
+		}
+	}
+	total := size * size
+	result += ((abs(dark*20-total*10)+total-1)/total - 1) * 10
+
+	return result
+}
+
+// reedSolomonDivisor returns the generator polynomial of the given degree, without its leading term.
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder returns the error correction codewords of the data.
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies two elements of the Galois field GF(2^8) with the QR code polynomial 0x11D.
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		if y>>i&1 == 1 {
+			z ^= int(x)
+		}
+	}
+	return byte(z)
+}
+
+// bitBuffer is a sequence of bits, most significant first.
+type bitBuffer []bool
+
+func (b *bitBuffer) append(value, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, bit(value, i))
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	result := make([]byte, len(b)/8)
+	for i, set := range b {
+		if set {
+			result[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return result
+}
+
+func bit(value, i int) bool {
+	return value>>i&1 == 1
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,231 @@
+// Package gs1 parses GS1 element strings, the Application Identifier (AI) prefixed data carried by
+// GS1-128 barcodes, GS1 DataMatrix and GS1 QR codes.
+//
+// Both the human readable form, where each AI is in brackets such as (01)09501101530003(21)ABC,
+// and the scanned form, where variable length values are ended by the FNC1 group separator, are supported.
+package gs1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// GroupSeparator is the character scanners send for FNC1 between a variable length value and the next AI.
+const GroupSeparator = '\x1d'
+
+var (
+	ErrNotElementString  = errors.New("not a GS1 element string")
+	ErrInvalidCheckDigit = errors.New("invalid GS1 check digit")
+)
+
+// Element is a single Application Identifier and its value.
+type Element struct {
+	AI    string
+	Value string
+}
+
+// Title returns the GS1 data title of the AI, such as GTIN or SERIAL, or an empty string for AIs not known here.
+func (e Element) Title() string {
+	return titles[e.AI]
+}
+
+var titles = map[string]string{
+	"00":   "SSCC",
+	"01":   "GTIN",
+	"02":   "CONTENT",
+	"10":   "BATCH/LOT",
+	"11":   "PROD DATE",
+	"13":   "PACK DATE",
+	"15":   "BEST BEFORE",
+	"17":   "USE BY",
+	"21":   "SERIAL",
+	"22":   "CPV",
+	"30":   "VAR. COUNT",
+	"37":   "COUNT",
+	"240":  "ADDITIONAL ID",
+	"241":  "CUST. PART No.",
+	"250":  "SECONDARY SERIAL",
+	"400":  "ORDER NUMBER",
+	"414":  "LOC No.",
+	"8004": "GIAI",
+}
+
+// aiLengths is the number of digits of the AIs starting with each two digit prefix.
+var aiLengths = map[string]int{
+	"00": 2, "01": 2, "02": 2, "10": 2, "11": 2, "12": 2, "13": 2, "15": 2, "16": 2, "17": 2,
+	"20": 2, "21": 2, "22": 2, "30": 2, "37": 2,
+	"90": 2, "91": 2, "92": 2, "93": 2, "94": 2, "95": 2, "96": 2, "97": 2, "98": 2, "99": 2,
+	"23": 3, "24": 3, "25": 3, "40": 3, "41": 3, "42": 3,
+	"31": 4, "32": 4, "33": 4, "34": 4, "35": 4, "36": 4, "39": 4, "43": 4,
+	"70": 4, "71": 4, "72": 4, "80": 4, "81": 4, "82": 4,
+}
+
+// fixedLengths is the length of the values of the AIs starting with each two digit prefix whose values have a
+// predefined length. These values are all numeric and are not followed by a group separator.
+var fixedLengths = map[string]int{
+	"00": 18, "01": 14, "02": 14, "03": 14, "04": 16,
+	"11": 6, "12": 6, "13": 6, "14": 6, "15": 6, "16": 6, "17": 6, "18": 6, "19": 6,
+	"20": 2, "31": 6, "32": 6, "33": 6, "34": 6, "35": 6, "36": 6, "41": 13,
+}
+
+// checkDigitAIs are the AIs whose value ends in a GS1 check digit.
+var checkDigitAIs = map[string]bool{
+	"00": true, "01": true, "02": true, "410": true, "411": true, "412": true, "413": true, "414": true, "415": true, "416": true,
+}
+
+// maxValueLength is the longest value any AI allows.
+const maxValueLength = 90
+
+// Parse splits a GS1 element string into its elements. An AIM symbology identifier at the start of the code,
+// such as ]C1 for GS1-128 or ]Q3 for GS1 QR, is ignored.
+// ErrNotElementString is returned if the code is not a valid element string.
+func Parse(code string) ([]Element, error) {
+	code = StripSymbologyIdentifier(code)
+	code = strings.TrimPrefix(code, string(GroupSeparator))
+	if code == "" {
+		return nil, ErrNotElementString
+	}
+
+	var elements []Element
+	var err error
+	if code[0] == '(' {
+		elements, err = parseBracketed(code)
+	} else {
+		elements, err = parseRaw(code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range elements {
+		if err := validate(e); err != nil {
+			return nil, err
+		}
+	}
+	return elements, nil
+}
+
+// StripSymbologyIdentifier removes the three character AIM symbology identifier some scanners prefix codes with.
+func StripSymbologyIdentifier(code string) string {
+	if len(code) >= 3 && code[0] == ']' && isLetter(code[1]) && isDigit(code[2]) {
+		return code[3:]
+	}
+	return code
+}
+
+func parseBracketed(code string) ([]Element, error) {
+	var elements []Element
+	for code != "" {
+		if code[0] != '(' {
+			return nil, ErrNotElementString
+		}
+		end := strings.IndexByte(code, ')')
+		if end < 0 {
+			return nil, ErrNotElementString
+		}
+		ai := code[1:end]
+		if length, ok := aiLength(ai); !ok || length != len(ai) {
+			return nil, fmt.Errorf("%w: unknown AI %q", ErrNotElementString, ai)
+		}
+
+		code = code[end+1:]
+		next := strings.IndexByte(code, '(')
+		if next < 0 {
+			next = len(code)
+		}
+		elements = append(elements, Element{AI: ai, Value: code[:next]})
+		code = code[next:]
+	}
+	return elements, nil
+}
+
+func parseRaw(code string) ([]Element, error) {
+	var elements []Element
+	for code != "" {
+		length, ok := aiLength(code)
+		if !ok || len(code) < length {
+			return nil, ErrNotElementString
+		}
+		ai := code[:length]
+		code = code[length:]
+
+		var value string
+		if fixed, ok := fixedLengths[ai[:2]]; ok {
+			if len(code) < fixed {
+				return nil, fmt.Errorf("%w: value of AI %s is too short", ErrNotElementString, ai)
+			}
+			value, code = code[:fixed], code[fixed:]
+			// Some encoders send a separator after fixed length values too.
+			code = strings.TrimPrefix(code, string(GroupSeparator))
+		} else {
+			end := strings.IndexRune(code, GroupSeparator)
+			if end < 0 {
+				end = len(code)
+			}
+			value, code = code[:end], code[end:]
+			code = strings.TrimPrefix(code, string(GroupSeparator))
+		}
+		elements = append(elements, Element{AI: ai, Value: value})
+	}
+	return elements, nil
+}
+
+// aiLength returns the number of digits of the AI at the start of the code.
+func aiLength(code string) (int, bool) {
+	if len(code) < 2 || !isDigits(code[:2]) {
+		return 0, false
+	}
+	length, ok := aiLengths[code[:2]]
+	if !ok || len(code) < length || !isDigits(code[:length]) {
+		return 0, false
+	}
+	return length, true
+}
+
+func validate(e Element) error {
+	if e.Value == "" || len(e.Value) > maxValueLength {
+		return fmt.Errorf("%w: invalid value for AI %s", ErrNotElementString, e.AI)
+	}
+	if fixed, ok := fixedLengths[e.AI[:2]]; ok && (len(e.Value) != fixed || !isDigits(e.Value)) {
+		return fmt.Errorf("%w: AI %s needs %d digits", ErrNotElementString, e.AI, fixed)
+	}
+	if checkDigitAIs[e.AI] && !ValidCheckDigit(e.Value) {
+		return fmt.Errorf("%w: %w for AI %s", ErrNotElementString, ErrInvalidCheckDigit, e.AI)
+	}
+	return nil
+}
+
+// ValidCheckDigit reports whether the last digit of the number is its GS1 modulo 10 check digit.
+func ValidCheckDigit(number string) bool {
+	if len(number) < 2 || !isDigits(number) {
+		return false
+	}
+	sum := 0
+	// Weights of 3 and 1 alternate leftwards from the digit before the check digit.
+	for i := len(number) - 2; i >= 0; i-- {
+		digit := int(number[i] - '0')
+		if (len(number)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10-sum%10)%10 == int(number[len(number)-1]-'0')
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
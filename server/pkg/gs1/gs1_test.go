@@ -0,0 +1,86 @@
+package gs1_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"quantum/pkg/gs1"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		code     string
+		expected []gs1.Element
+	}{
+		{
+			name: "bracketed",
+			code: "(01)09501101530003(21)ABC123",
+			expected: []gs1.Element{
+				{AI: "01", Value: "09501101530003"},
+				{AI: "21", Value: "ABC123"},
+			},
+		},
+		{
+			name: "scanned with a group separator after a variable length value",
+			code: "]C1" + "10LOT42\x1d" + "0109501101530003" + "21SN-9",
+			expected: []gs1.Element{
+				{AI: "10", Value: "LOT42"},
+				{AI: "01", Value: "09501101530003"},
+				{AI: "21", Value: "SN-9"},
+			},
+		},
+		{
+			name: "SSCC with a leading FNC1",
+			code: "\x1d00106141411234567897",
+			expected: []gs1.Element{
+				{AI: "00", Value: "106141411234567897"},
+			},
+		},
+		{
+			name: "three and four digit AIs",
+			code: "(240)KIT-7(8004)950110153ASSET1",
+			expected: []gs1.Element{
+				{AI: "240", Value: "KIT-7"},
+				{AI: "8004", Value: "950110153ASSET1"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			elements, err := gs1.Parse(tc.code)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, elements)
+		})
+	}
+}
+
+func TestParse_NotElementString(t *testing.T) {
+	for _, code := range []string{
+		"",
+		"REF-1",
+		"1234",
+		"(01)0950110153000",
+		"(01)09501101530004",
+		"(9)ABC",
+		"]Q1REF-1",
+	} {
+		_, err := gs1.Parse(code)
+		assert.ErrorIs(t, err, gs1.ErrNotElementString, code)
+	}
+}
+
+func TestValidCheckDigit(t *testing.T) {
+	assert.True(t, gs1.ValidCheckDigit("09501101530003"))
+	assert.True(t, gs1.ValidCheckDigit("4006381333931"))
+	assert.True(t, gs1.ValidCheckDigit("106141411234567897"))
+	assert.False(t, gs1.ValidCheckDigit("4006381333932"))
+	assert.False(t, gs1.ValidCheckDigit("40063813339A1"))
+}
+
+func TestStripSymbologyIdentifier(t *testing.T) {
+	assert.Equal(t, "REF-1", gs1.StripSymbologyIdentifier("]Q1REF-1"))
+	assert.Equal(t, "]REF-1", gs1.StripSymbologyIdentifier("]REF-1"))
+}
@@ -0,0 +1,131 @@
+// Package label draws printable labels holding a barcode and a few lines of text,
+// as single SVG or PNG labels or as A4 PDF sheets of many labels.
+package label
+
+import (
+	"errors"
+	"quantum/pkg/barcode"
+)
+
+var (
+	ErrInvalidSymbology = errors.New("invalid code, expected qr or code128")
+	ErrInvalidFormat    = errors.New("invalid format, expected svg or png")
+)
+
+// Symbology is the kind of barcode printed on a label.
+type Symbology string
+
+const (
+	QR      Symbology = "qr"
+	Code128 Symbology = "code128"
+)
+
+// ParseSymbology parses the name of a symbology, defaulting to QR.
+func ParseSymbology(s string) (Symbology, error) {
+	switch Symbology(s) {
+	case "", QR:
+		return QR, nil
+	case Code128:
+		return Code128, nil
+	default:
+		return "", ErrInvalidSymbology
+	}
+}
+
+// Format is the image format of a single label.
+type Format string
+
+const (
+	SVG Format = "svg"
+	PNG Format = "png"
+)
+
+// ParseFormat parses the name of an image format, defaulting to SVG.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", SVG:
+		return SVG, nil
+	case PNG:
+		return PNG, nil
+	default:
+		return "", ErrInvalidFormat
+	}
+}
+
+func (f Format) ContentType() string {
+	if f == PNG {
+		return "image/png"
+	}
+	return "image/svg+xml"
+}
+
+// Label is a barcode of Data along with a title and smaller lines of text printed for people to read.
+type Label struct {
+	Symbology Symbology
+	Data      string
+	Title     string
+	Lines     []string
+}
+
+// Encode encodes the data of the label as a barcode of its symbology.
+func (l Label) Encode() (*barcode.Code, error) {
+	if l.Symbology == Code128 {
+		return barcode.EncodeCode128(l.Data)
+	}
+	return barcode.EncodeQR(l.Data)
+}
+
+// IsEncodingError reports whether the error was caused by data that cannot be encoded in the symbology of a label.
+func IsEncodingError(err error) bool {
+	return errors.Is(err, barcode.ErrEmptyData) ||
+		errors.Is(err, barcode.ErrDataTooLong) ||
+		errors.Is(err, barcode.ErrInvalidCode128Data)
+}
+
+// rect is a filled rectangle in the units of the drawing it belongs to.
+type rect struct {
+	x, y, width, height float64
+}
+
+// codeRects returns the dark areas of the code drawn at x and y with the given module size,
+// merging neighbouring dark modules of a row into a single rectangle.
+// Linear codes are drawn barHeight tall, the quiet zone is left around the code.
+func codeRects(code *barcode.Code, x, y, module, barHeight float64) []rect {
+	rowHeight := module
+	if code.Linear() {
+		rowHeight = barHeight
+	}
+	x += float64(code.QuietZone) * module
+	if !code.Linear() {
+		y += float64(code.QuietZone) * module
+	}
+
+	var rects []rect
+	for row := 0; row < code.Height; row++ {
+		for col := 0; col < code.Width; col++ {
+			if !code.Dark(col, row) {
+				continue
+			}
+			start := col
+			for col+1 < code.Width && code.Dark(col+1, row) {
+				col++
+			}
+			rects = append(rects, rect{
+				x:      x + float64(start)*module,
+				y:      y + float64(row)*rowHeight,
+				width:  float64(col-start+1) * module,
+				height: rowHeight,
+			})
+		}
+	}
+	return rects
+}
+
+// codeSize returns the width and height of the code including its quiet zone.
+func codeSize(code *barcode.Code, module, barHeight float64) (float64, float64) {
+	width := float64(code.Width+2*code.QuietZone) * module
+	if code.Linear() {
+		return width, barHeight
+	}
+	return width, float64(code.Height+2*code.QuietZone) * module
+}
@@ -0,0 +1,75 @@
+package label_test
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"quantum/pkg/label"
+)
+
+func TestWriteSVG(t *testing.T) {
+	var buf bytes.Buffer
+	err := label.WriteSVG(&buf, label.Label{
+		Symbology: label.QR,
+		Data:      "REF-1",
+		Title:     "Drill <cordless>",
+		Lines:     []string{"Item REF-1"},
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(buf.String(), "<svg "))
+	assert.Contains(t, buf.String(), "Drill &lt;cordless&gt;")
+	assert.Contains(t, buf.String(), ">Item REF-1</text>")
+}
+
+func TestWritePNG(t *testing.T) {
+	var buf bytes.Buffer
+	err := label.WritePNG(&buf, label.Label{Symbology: label.QR, Data: "REF-1"})
+	assert.NoError(t, err)
+
+	img, err := png.Decode(&buf)
+	assert.NoError(t, err)
+	// A version 1 QR code is 21 modules wide with a quiet zone of 4 modules on each side, at 8 pixels per module.
+	assert.Equal(t, (21+8)*8, img.Bounds().Dx())
+	assert.Equal(t, img.Bounds().Dx(), img.Bounds().Dy())
+}
+
+func TestWriteSVG_InvalidCode128(t *testing.T) {
+	err := label.WriteSVG(&bytes.Buffer{}, label.Label{Symbology: label.Code128, Data: "café"})
+	assert.True(t, label.IsEncodingError(err))
+}
+
+func TestWriteSheetPDF(t *testing.T) {
+	labels := make([]label.Label, label.LabelsPerSheet+1)
+	for i := range labels {
+		labels[i] = label.Label{
+			Symbology: label.Code128,
+			Data:      fmt.Sprintf("REF-%d", i),
+			Title:     "Camera (spare)",
+			Lines:     []string{"Group: AV"},
+		}
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, label.WriteSheetPDF(&buf, labels))
+	pdf := buf.String()
+
+	assert.True(t, strings.HasPrefix(pdf, "%PDF-1.4"))
+	assert.True(t, strings.HasSuffix(pdf, "%%EOF\n"))
+	assert.Contains(t, pdf, "/Count 2")
+
+	// Every entry of the cross-reference table points at the start of its object.
+	xref := regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`).FindAllStringSubmatch(pdf, -1)
+	assert.Len(t, xref, 8)
+	for i, entry := range xref {
+		offset, _ := strconv.Atoi(entry[1])
+		assert.True(t, strings.HasPrefix(pdf[offset:], fmt.Sprintf("%d 0 obj", i+1)), "object %d", i+1)
+	}
+}
@@ -0,0 +1,184 @@
+package label
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"quantum/pkg/barcode"
+)
+
+// The sheet is an A4 page of 3 by 8 labels, measured in points.
+const (
+	a4Width       = 595.28
+	a4Height      = 841.89
+	sheetColumns  = 3
+	sheetRows     = 8
+	sheetMarginX  = 20
+	sheetMarginY  = 30
+	cellWidth     = (a4Width - 2*sheetMarginX) / sheetColumns
+	cellHeight    = (a4Height - 2*sheetMarginY) / sheetRows
+	cellPadding   = 6
+	pdfBarHeight  = 48
+	pdfTitleSize  = 10
+	pdfLineSize   = 7
+	pdfLineHeight = 1.25
+	// pdfCharWidth is the average width of a Helvetica character as a fraction of the font size,
+	// used to shorten text that would overflow a label.
+	pdfCharWidth = 0.56
+)
+
+// LabelsPerSheet is the number of labels that fit on a page of a sheet.
+const LabelsPerSheet = sheetColumns * sheetRows
+
+// WriteSheetPDF lays the labels out on A4 pages as a PDF document.
+// QR codes are drawn to the left of the text and barcodes above it. Faint guides are drawn around each label for cutting.
+func WriteSheetPDF(w io.Writer, labels []Label) error {
+	codes := make([]*barcode.Code, len(labels))
+	for i, l := range labels {
+		code, err := l.Encode()
+		if err != nil {
+			return fmt.Errorf("failed to encode label %q: %w", l.Data, err)
+		}
+		codes[i] = code
+	}
+
+	var pages []string
+	for start := 0; start < len(labels) || start == 0; start += LabelsPerSheet {
+		end := min(start+LabelsPerSheet, len(labels))
+		var content strings.Builder
+		for i := start; i < end; i++ {
+			position := i - start
+			x := sheetMarginX + float64(position%sheetColumns)*cellWidth
+			// PDF measures from the bottom of the page, this is the bottom of the cell.
+			y := a4Height - sheetMarginY - float64(position/sheetColumns+1)*cellHeight
+			writeCell(&content, labels[i], codes[i], x, y)
+		}
+		pages = append(pages, content.String())
+	}
+
+	return writePDF(w, pages)
+}
+
+func writeCell(b *strings.Builder, l Label, code *barcode.Code, x, y float64) {
+	fmt.Fprintf(b, "0.85 G 0.25 w %.2f %.2f %.2f %.2f re S\n", x, y, cellWidth, cellHeight)
+
+	top := y + cellHeight - cellPadding
+	var textX, textTop, textWidth float64
+	if code.Linear() {
+		codeWidth := cellWidth - 2*cellPadding
+		module := codeWidth / float64(code.Width+2*code.QuietZone)
+		writeRects(b, codeRects(code, x+cellPadding, top-pdfBarHeight, module, pdfBarHeight))
+		textX, textTop, textWidth = x+cellPadding, top-pdfBarHeight-cellPadding, codeWidth
+	} else {
+		side := cellHeight - 2*cellPadding
+		module := side / float64(code.Width+2*code.QuietZone)
+		// Rows are drawn downwards from the top of the code, so flip them into PDF coordinates.
+		rects := codeRects(code, x+cellPadding, 0, module, 0)
+		for i := range rects {
+			rects[i].y = top - rects[i].y - rects[i].height
+		}
+		writeRects(b, rects)
+		textX, textTop, textWidth = x+cellPadding+side, top-cellPadding, cellWidth-2*cellPadding-side
+	}
+
+	b.WriteString("0 g BT\n")
+	baseline := textTop
+	if l.Title != "" {
+		baseline -= pdfTitleSize
+		fmt.Fprintf(b, "/F2 %d Tf 1 0 0 1 %.2f %.2f Tm (%s) Tj\n", pdfTitleSize, textX, baseline, pdfText(l.Title, textWidth, pdfTitleSize))
+		baseline -= pdfTitleSize * (pdfLineHeight - 1)
+	}
+	for _, line := range l.Lines {
+		baseline -= pdfLineSize * pdfLineHeight
+		if baseline < y+cellPadding {
+			break
+		}
+		fmt.Fprintf(b, "/F1 %d Tf 1 0 0 1 %.2f %.2f Tm (%s) Tj\n", pdfLineSize, textX, baseline, pdfText(line, textWidth, pdfLineSize))
+	}
+	b.WriteString("ET\n")
+}
+
+func writeRects(b *strings.Builder, rects []rect) {
+	b.WriteString("0 g\n")
+	for _, r := range rects {
+		fmt.Fprintf(b, "%.3f %.3f %.3f %.3f re\n", r.x, r.y, r.width, r.height)
+	}
+	b.WriteString("f\n")
+}
+
+// pdfText shortens the text to fit the width, converts it to the WinAnsi encoding of the standard fonts
+// and escapes it for use in a PDF string. Characters the encoding does not have are replaced with a question mark.
+func pdfText(s string, width float64, size int) string {
+	runes := []rune(s)
+	if limit := int(width / (float64(size) * pdfCharWidth)); len(runes) > limit {
+		runes = append(runes[:max(limit-3, 0)], []rune("...")...)
+	}
+
+	var b strings.Builder
+	for _, r := range runes {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= ' ' && r <= '~':
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// writePDF writes a PDF document with a page for each content stream, using the Helvetica standard fonts.
+func writePDF(w io.Writer, pages []string) error {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(format string, args ...any) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n", len(offsets))
+		fmt.Fprintf(&buf, format, args...)
+		buf.WriteString("\nendobj\n")
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1 to 4 are the catalog, the page tree and the fonts, followed by a page and its content for each page.
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, content := range pages {
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write([]byte(content)); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			a4Width, a4Height, 6+2*i)
+		object("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes())
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,43 @@
+package label
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+const (
+	pngQRModule      = 8
+	pngCode128Module = 3
+	pngBarHeight     = 120
+)
+
+// WritePNG draws the code of the label as a PNG image.
+// The image holds only the code and its quiet zone, so it can be placed in documents next to other text.
+func WritePNG(w io.Writer, l Label) error {
+	code, err := l.Encode()
+	if err != nil {
+		return err
+	}
+
+	module := float64(pngQRModule)
+	if code.Linear() {
+		module = pngCode128Module
+	}
+	width, height := codeSize(code, module, pngBarHeight)
+
+	img := image.NewGray(image.Rect(0, 0, int(width), int(height)))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for _, r := range codeRects(code, 0, 0, module, pngBarHeight) {
+		for y := int(r.y); y < int(r.y+r.height); y++ {
+			for x := int(r.x); x < int(r.x+r.width); x++ {
+				img.SetGray(x, y, color.Gray{})
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
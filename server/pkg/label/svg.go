@@ -0,0 +1,74 @@
+package label
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+const (
+	svgQRModule      = 4
+	svgCode128Module = 2
+	svgBarHeight     = 60
+	svgMinWidth      = 240
+	svgPadding       = 8
+	svgTitleSize     = 16
+	svgLineSize      = 12
+)
+
+// WriteSVG draws the label as an SVG image with the code above the centred text.
+func WriteSVG(w io.Writer, l Label) error {
+	code, err := l.Encode()
+	if err != nil {
+		return err
+	}
+
+	module := float64(svgQRModule)
+	if code.Linear() {
+		module = svgCode128Module
+	}
+	codeWidth, codeHeight := codeSize(code, module, svgBarHeight)
+
+	// QR codes carry their own quiet zone above them, barcodes are only padded at the sides.
+	codeY := 0.0
+	if code.Linear() {
+		codeY = svgPadding
+	}
+
+	width := max(codeWidth, svgMinWidth)
+	height := codeY + codeHeight + svgPadding
+	if l.Title != "" {
+		height += svgTitleSize + svgPadding/2
+	}
+	height += float64(len(l.Lines)) * (svgLineSize + svgPadding/2)
+	height += svgPadding
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`, width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="%g" height="%g" fill="#fff"/>`, width, height)
+
+	b.WriteString(`<path fill="#000" shape-rendering="crispEdges" d="`)
+	for _, r := range codeRects(code, (width-codeWidth)/2, codeY, module, svgBarHeight) {
+		fmt.Fprintf(&b, "M%g %gh%gv%gh%gz", r.x, r.y, r.width, r.height, -r.width)
+	}
+	b.WriteString(`"/>`)
+
+	y := codeY + codeHeight + svgPadding
+	text := func(s string, size float64, weight string) {
+		y += size
+		fmt.Fprintf(&b, `<text x="%g" y="%g" font-family="Helvetica, Arial, sans-serif" font-size="%g" font-weight="%s" text-anchor="middle">%s</text>`,
+			width/2, y, size, weight, html.EscapeString(s))
+		y += svgPadding / 2
+	}
+	if l.Title != "" {
+		text(l.Title, svgTitleSize, "bold")
+	}
+	for _, line := range l.Lines {
+		text(line, svgLineSize, "normal")
+	}
+
+	b.WriteString("</svg>")
+	_, err = io.WriteString(w, b.String())
+	return err
+}
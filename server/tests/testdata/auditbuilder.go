@@ -0,0 +1,65 @@
+package testdata
+
+import (
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"quantum/internal/model"
+	"testing"
+)
+
+type AuditSessionBuilder struct {
+	t       testing.TB
+	db      *sqlx.DB
+	model   *model.AuditSessionModel
+	scanFns []func() error
+}
+
+// NewAuditSessionBuilder builds an open audit session of the location started by the user.
+func NewAuditSessionBuilder(t testing.TB, db *sqlx.DB, locationID, startedByUserID uuid.UUID) *AuditSessionBuilder {
+	return &AuditSessionBuilder{
+		t:  t,
+		db: db,
+		model: &model.AuditSessionModel{
+			LocationID:      locationID,
+			Status:          model.AuditStatusOpen,
+			StartedByUserID: startedByUserID,
+		},
+		scanFns: make([]func() error, 0),
+	}
+}
+
+func (b *AuditSessionBuilder) IncludingChildren() *AuditSessionBuilder {
+	b.model.IncludeChildren = true
+	return b
+}
+
+// WithScan adds a scan of the item by its reference at the location, made by the user who started the session.
+func (b *AuditSessionBuilder) WithScan(item *model.ItemModel, locationID uuid.UUID) *AuditSessionBuilder {
+	b.scanFns = append(b.scanFns, func() error {
+		stmt := `
+			insert into audit_scans (session_id, item_id, item_reference, item_identifier, location_id, code, scanned_by_user_id)
+			values ($1, $2, $3, $4, $5, $3, $6);`
+		_, err := b.db.Exec(stmt, b.model.ID, item.ID, item.Reference, item.Identifier, locationID, b.model.StartedByUserID)
+		return err
+	})
+	return b
+}
+
+func (b *AuditSessionBuilder) Build() *model.AuditSessionModel {
+	insert := `
+		insert into audit_sessions (location_id, include_children, status, started_by_user_id)
+		values ($1, $2, $3, $4)
+		returning id, started_at;`
+
+	err := b.db.Get(b.model, insert, b.model.LocationID, b.model.IncludeChildren, b.model.Status, b.model.StartedByUserID)
+	if err != nil {
+		b.t.Fatalf("failed to insert audit session: %v", err)
+	}
+
+	for _, fn := range b.scanFns {
+		if err := fn(); err != nil {
+			b.t.Fatalf("failed to insert audit scan: %v", err)
+		}
+	}
+	return b.model
+}
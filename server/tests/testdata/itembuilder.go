@@ -6,16 +6,17 @@ import (
 	"github.com/jmoiron/sqlx"
 	"quantum/internal/model"
 	"testing"
+	"time"
 )
 
 type ItemBuilder struct {
-	t          *testing.T
+	t          testing.TB
 	db         *sqlx.DB
 	model      *model.ItemModel
 	historyFns []func() error
 }
 
-func NewItemBuilder(t *testing.T, db *sqlx.DB) *ItemBuilder {
+func NewItemBuilder(t testing.TB, db *sqlx.DB) *ItemBuilder {
 	return &ItemBuilder{
 		t:          t,
 		db:         db,
@@ -44,6 +45,22 @@ func (b *ItemBuilder) WithDescription(description string) *ItemBuilder {
 	return b
 }
 
+func (b *ItemBuilder) WithKind(kind model.ItemKind) *ItemBuilder {
+	b.model.Kind = kind
+	return b
+}
+
+// WithCustomFields sets the custom field values of the item, given as a JSON object. They are not validated.
+func (b *ItemBuilder) WithCustomFields(customFields string) *ItemBuilder {
+	b.model.CustomFields = json.RawMessage(customFields)
+	return b
+}
+
+func (b *ItemBuilder) AsDeleted() *ItemBuilder {
+	b.model.Deleted = true
+	return b
+}
+
 // WithCreatedHistoryRecord adds a history record for the item creation in the item_history table.
 // The order of these functions in the builder is important, as the history records are inserted in the order they are added.
 func (b *ItemBuilder) WithCreatedHistoryRecord(userID, locationID uuid.UUID) *ItemBuilder {
@@ -66,7 +83,7 @@ func (b *ItemBuilder) WithCreatedHistoryRecord(userID, locationID uuid.UUID) *It
 
 	// Add the history builder function to be handled in the Build function later.
 	b.historyFns = append(b.historyFns, func() error {
-		return b.buildHistoryForItem(history, userID)
+		return b.buildHistoryForItem(history, userID, nil)
 	})
 
 	return b
@@ -91,7 +108,7 @@ func (b *ItemBuilder) WithTrackedHistoryRecord(userID, locationID uuid.UUID) *It
 
 	// Add the history builder function to be handled in the Build function later.
 	b.historyFns = append(b.historyFns, func() error {
-		return b.buildHistoryForItem(history, userID)
+		return b.buildHistoryForItem(history, userID, nil)
 	})
 
 	return b
@@ -116,17 +133,182 @@ func (b *ItemBuilder) WithTrackedUserHistoryRecord(userID, trackedToUser uuid.UU
 
 	// Add the history builder function to be handled in the Build function later.
 	b.historyFns = append(b.historyFns, func() error {
-		return b.buildHistoryForItem(history, userID)
+		return b.buildHistoryForItem(history, userID, nil)
+	})
+
+	return b
+}
+
+// WithCheckedOutHistoryRecord adds a history record for checking an item out to a user until dueAt in the item_history table.
+// The order of these functions in the builder is important, as the history records are inserted in the order they are added.
+func (b *ItemBuilder) WithCheckedOutHistoryRecord(userID, trackedToUser uuid.UUID, dueAt time.Time) *ItemBuilder {
+	data := &model.ItemTrackedUserHistoryData{
+		UserID: trackedToUser,
+		DueAt:  &dueAt,
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		b.t.Fatalf("failed to marshal history data: %v", err)
+	}
+
+	history := model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeTrackedUser,
+		Data: jsonData,
+	}
+
+	// Add the history builder function to be handled in the Build function later.
+	b.historyFns = append(b.historyFns, func() error {
+		return b.buildHistoryForItem(history, userID, nil)
+	})
+
+	return b
+}
+
+// WithContainedHistoryRecord puts the item inside the container and adds a history record for it in the item_history table.
+// The item is not moved, so it should already be at the location of the container.
+// The order of these functions in the builder is important, as the history records are inserted in the order they are added.
+func (b *ItemBuilder) WithContainedHistoryRecord(userID, containerID uuid.UUID) *ItemBuilder {
+	data := &model.ItemContainmentHistoryData{
+		ContainerID: containerID,
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		b.t.Fatalf("failed to marshal history data: %v", err)
+	}
+
+	history := model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeContained,
+		Data: jsonData,
+	}
+
+	// Add the history builder function to be handled in the Build function later.
+	b.historyFns = append(b.historyFns, func() error {
+		stmt := "insert into item_containment (item_id, container_id) values ($1, $2);"
+		if _, err := b.db.Exec(stmt, b.model.ID, containerID); err != nil {
+			return err
+		}
+		return b.buildHistoryForItem(history, userID, nil)
+	})
+
+	return b
+}
+
+// WithStockReceivedHistoryRecord receives quantity of a stock item into the location and adds a history record for it
+// in the item_history table, keeping the stock_levels table in step.
+// The order of these functions in the builder is important, as the history records are inserted in the order they are added.
+func (b *ItemBuilder) WithStockReceivedHistoryRecord(userID, locationID uuid.UUID, quantity int) *ItemBuilder {
+	b.historyFns = append(b.historyFns, func() error {
+		stmt := `
+			insert into stock_levels (item_id, location_id, quantity)
+			values ($1, $2, $3)
+			on conflict (item_id, location_id) do update
+			set quantity = stock_levels.quantity + excluded.quantity
+			returning quantity;`
+
+		var total int
+		if err := b.db.Get(&total, stmt, b.model.ID, locationID, quantity); err != nil {
+			return err
+		}
+
+		jsonData, err := json.Marshal(&model.ItemStockHistoryData{
+			LocationID: locationID,
+			Delta:      quantity,
+			Quantity:   total,
+		})
+		if err != nil {
+			return err
+		}
+
+		history := model.HistoryDataContainer{
+			Type: model.ItemHistoryTypeStockReceived,
+			Data: jsonData,
+		}
+		return b.buildHistoryForItem(history, userID, nil)
+	})
+
+	return b
+}
+
+// WithStatusChangedHistoryRecord moves the item to the status and adds a history record for it in the item_history table.
+// The transition is not checked against the settings. Items start in service.
+// The order of these functions in the builder is important, as the history records are inserted in the order they are added.
+func (b *ItemBuilder) WithStatusChangedHistoryRecord(userID uuid.UUID, to model.ItemStatus, reason string) *ItemBuilder {
+	from := b.model.Status
+	if from == "" {
+		from = model.ItemStatusInService
+	}
+	b.model.Status = to
+
+	data := &model.ItemStatusChangedHistoryData{
+		From:   from,
+		To:     to,
+		Reason: reason,
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		b.t.Fatalf("failed to marshal history data: %v", err)
+	}
+
+	history := model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeStatusChanged,
+		Data: jsonData,
+	}
+
+	// Add the history builder function to be handled in the Build function later.
+	b.historyFns = append(b.historyFns, func() error {
+		if _, err := b.db.Exec("update items set status = $1 where id = $2;", to, b.model.ID); err != nil {
+			return err
+		}
+		return b.buildHistoryForItem(history, userID, nil)
+	})
+
+	return b
+}
+
+// WithDeletedHistoryRecord adds a history record for the deletion of an item in the item_history table.
+// The order of these functions in the builder is important, as the history records are inserted in the order they are added.
+func (b *ItemBuilder) WithDeletedHistoryRecord(userID uuid.UUID) *ItemBuilder {
+	history := model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeDeleted,
+		Data: json.RawMessage("{}"),
+	}
+
+	// Add the history builder function to be handled in the Build function later.
+	b.historyFns = append(b.historyFns, func() error {
+		return b.buildHistoryForItem(history, userID, nil)
+	})
+
+	return b
+}
+
+// WithDeletedHistoryRecordAt adds a history record for the deletion of an item at the given time in the item_history table.
+// The order of these functions in the builder is important, as the history records are inserted in the order they are added.
+func (b *ItemBuilder) WithDeletedHistoryRecordAt(userID uuid.UUID, deletedAt time.Time) *ItemBuilder {
+	history := model.HistoryDataContainer{
+		Type: model.ItemHistoryTypeDeleted,
+		Data: json.RawMessage("{}"),
+	}
+
+	// Add the history builder function to be handled in the Build function later.
+	b.historyFns = append(b.historyFns, func() error {
+		return b.buildHistoryForItem(history, userID, &deletedAt)
 	})
 
 	return b
 }
 
 func (b *ItemBuilder) Build() *model.ItemModel {
+	if b.model.Kind == "" {
+		b.model.Kind = model.ItemKindUnit
+	}
+
 	insert := `
-		INSERT INTO items (identifier, reference, group_key, description) 
-		VALUES ($1, $2, $3, $4) 
-		returning id, created_at, updated_at;`
+		INSERT INTO items (identifier, reference, group_key, description, deleted, kind, custom_fields) 
+		VALUES ($1, $2, $3, $4, $5, $6, coalesce($7::jsonb, '{}'::jsonb)) 
+		returning id, created_at, updated_at, custom_fields;`
 
 	err := b.db.Get(
 		b.model,
@@ -135,6 +317,9 @@ func (b *ItemBuilder) Build() *model.ItemModel {
 		b.model.Reference,
 		b.model.GroupKey,
 		b.model.Description,
+		b.model.Deleted,
+		b.model.Kind,
+		b.model.CustomFields,
 	)
 
 	if err != nil {
@@ -150,17 +335,37 @@ func (b *ItemBuilder) Build() *model.ItemModel {
 	return b.model
 }
 
-func (b *ItemBuilder) buildHistoryForItem(history model.HistoryDataContainer, userID uuid.UUID) error {
+// buildHistoryForItem inserts the history record, at createdAt when it is given and at the current time otherwise.
+func (b *ItemBuilder) buildHistoryForItem(history model.HistoryDataContainer, userID uuid.UUID, createdAt *time.Time) error {
 	historyJSON, err := json.Marshal(history)
 	if err != nil {
 		return err
 	}
 
+	// Keep the item_current_location table in step with the history, as the item repository does.
 	stmt := `
-		insert into item_history (user_id, item_id, data)
-		values ($1, $2, $3)`
+		with history as (
+			insert into item_history (user_id, item_id, data, created_at)
+			values ($1, $2, $3, coalesce($4::timestamptz, now()))
+			returning item_id, data, created_at
+		)
+		insert into item_current_location (item_id, type, location_id, tracked_at)
+		select
+			item_id,
+			(data->>'type')::text,
+			case (data->>'type')::text
+				when 'tracked-user' then (data->'data'->>'userId')::uuid
+				else (data->'data'->>'locationId')::uuid
+			end,
+			created_at
+		from history
+		where (data->>'type') in ('created', 'tracked', 'tracked-user')
+		on conflict (item_id) do update
+		set type = excluded.type,
+			location_id = excluded.location_id,
+			tracked_at = excluded.tracked_at;`
 
-	if _, err = b.db.Exec(stmt, userID, b.model.ID, historyJSON); err != nil {
+	if _, err = b.db.Exec(stmt, userID, b.model.ID, historyJSON, createdAt); err != nil {
 		return err
 	}
 	return nil
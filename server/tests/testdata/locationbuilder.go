@@ -1,18 +1,19 @@
 package testdata
 
 import (
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"quantum/internal/model"
 	"testing"
 )
 
 type LocationBuilder struct {
-	t     *testing.T
+	t     testing.TB
 	db    *sqlx.DB
 	model *model.LocationModel
 }
 
-func NewLocationBuilder(t *testing.T, db *sqlx.DB) *LocationBuilder {
+func NewLocationBuilder(t testing.TB, db *sqlx.DB) *LocationBuilder {
 	return &LocationBuilder{
 		t:     t,
 		db:    db,
@@ -30,6 +31,11 @@ func (b *LocationBuilder) WithDescription(description string) *LocationBuilder {
 	return b
 }
 
+func (b *LocationBuilder) WithParent(parentID uuid.UUID) *LocationBuilder {
+	b.model.ParentID = &parentID
+	return b
+}
+
 func (b *LocationBuilder) AsDeleted() *LocationBuilder {
 	b.model.IsDeleted = true
 	return b
@@ -37,11 +43,11 @@ func (b *LocationBuilder) AsDeleted() *LocationBuilder {
 
 func (b *LocationBuilder) Build() *model.LocationModel {
 	insert := `
-		insert into locations (name, description, is_deleted)
-	    values ($1, $2, $3)
+		insert into locations (name, description, parent_id, is_deleted)
+	    values ($1, $2, $3, $4)
 	    returning id, created_at, updated_at;`
 
-	if err := b.db.Get(b.model, insert, b.model.Name, b.model.Description, b.model.IsDeleted); err != nil {
+	if err := b.db.Get(b.model, insert, b.model.Name, b.model.Description, b.model.ParentID, b.model.IsDeleted); err != nil {
 		b.t.Fatalf("failed to insert location: %v", err)
 	}
 	return b.model
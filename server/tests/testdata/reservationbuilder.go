@@ -0,0 +1,66 @@
+package testdata
+
+import (
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"quantum/internal/model"
+	"testing"
+	"time"
+)
+
+type ReservationBuilder struct {
+	t     testing.TB
+	db    *sqlx.DB
+	model *model.ReservationModel
+}
+
+// NewReservationBuilder builds a reservation of the item for the user, made by the user themselves unless WithCreatedBy is used.
+func NewReservationBuilder(t testing.TB, db *sqlx.DB, itemID, userID uuid.UUID) *ReservationBuilder {
+	return &ReservationBuilder{
+		t:  t,
+		db: db,
+		model: &model.ReservationModel{
+			ItemID:          itemID,
+			UserID:          userID,
+			CreatedByUserID: userID,
+		},
+	}
+}
+
+func (b *ReservationBuilder) WithPurpose(purpose string) *ReservationBuilder {
+	b.model.Purpose = purpose
+	return b
+}
+
+func (b *ReservationBuilder) WithCreatedBy(userID uuid.UUID) *ReservationBuilder {
+	b.model.CreatedByUserID = userID
+	return b
+}
+
+func (b *ReservationBuilder) Between(startsAt, endsAt time.Time) *ReservationBuilder {
+	b.model.StartsAt = startsAt
+	b.model.EndsAt = endsAt
+	return b
+}
+
+func (b *ReservationBuilder) Build() *model.ReservationModel {
+	insert := `
+		insert into reservations (item_id, user_id, purpose, starts_at, ends_at, created_by_user_id)
+		values ($1, $2, $3, $4, $5, $6)
+		returning id, created_at;`
+
+	err := b.db.Get(
+		b.model,
+		insert,
+		b.model.ItemID,
+		b.model.UserID,
+		b.model.Purpose,
+		b.model.StartsAt,
+		b.model.EndsAt,
+		b.model.CreatedByUserID,
+	)
+	if err != nil {
+		b.t.Fatalf("failed to insert reservation: %v", err)
+	}
+	return b.model
+}
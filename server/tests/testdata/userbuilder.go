@@ -8,12 +8,12 @@ import (
 )
 
 type UserBuilder struct {
-	t     *testing.T
+	t     testing.TB
 	db    *sqlx.DB
 	model *model.User
 }
 
-func NewUserBuilder(t *testing.T, db *sqlx.DB) *UserBuilder {
+func NewUserBuilder(t testing.TB, db *sqlx.DB) *UserBuilder {
 	return &UserBuilder{
 		t:  t,
 		db: db,
@@ -23,7 +23,7 @@ func NewUserBuilder(t *testing.T, db *sqlx.DB) *UserBuilder {
 	}
 }
 
-func InsertAdminUser(t *testing.T, db *sqlx.DB) *model.User {
+func InsertAdminUser(t testing.TB, db *sqlx.DB) *model.User {
 	return NewUserBuilder(t, db).
 		WithName("Adam Admin").
 		WithUsername("adam.admin").
@@ -31,7 +31,7 @@ func InsertAdminUser(t *testing.T, db *sqlx.DB) *model.User {
 		Build()
 }
 
-func InsertWriterUser(t *testing.T, db *sqlx.DB) *model.User {
+func InsertWriterUser(t testing.TB, db *sqlx.DB) *model.User {
 	return NewUserBuilder(t, db).
 		WithName("Wayne Writer").
 		WithUsername("wayne.writer").
@@ -39,7 +39,7 @@ func InsertWriterUser(t *testing.T, db *sqlx.DB) *model.User {
 		Build()
 }
 
-func InsertReaderUser(t *testing.T, db *sqlx.DB) *model.User {
+func InsertReaderUser(t testing.TB, db *sqlx.DB) *model.User {
 	return NewUserBuilder(t, db).
 		WithName("Randy Reader").
 		WithUsername("randy.reader").
@@ -47,7 +47,7 @@ func InsertReaderUser(t *testing.T, db *sqlx.DB) *model.User {
 		Build()
 }
 
-func InsertTrackerUser(t *testing.T, db *sqlx.DB) *model.User {
+func InsertTrackerUser(t testing.TB, db *sqlx.DB) *model.User {
 	return NewUserBuilder(t, db).
 		WithName("Terry Tracker").
 		WithUsername("terry.tracker").
@@ -5,10 +5,19 @@ import (
 	"testing"
 )
 
-func CleanDatabase(t *testing.T, db *sqlx.DB) {
+func CleanDatabase(t testing.TB, db *sqlx.DB) {
 	t.Helper()
 	_, err := db.Exec(`
+		DELETE FROM webhooks;
+		DELETE FROM reservations;
+		DELETE FROM reservation_feed_tokens;
+		DELETE FROM audit_sessions;
+		DELETE FROM stock_thresholds;
+		DELETE FROM stock_levels;
+		DELETE FROM group_custom_fields;
+		DELETE FROM item_current_location;
 		DELETE FROM item_history;
+		DELETE FROM location_history;
 		DELETE FROM locations;
 		DELETE FROM items;
 		DELETE FROM settings;
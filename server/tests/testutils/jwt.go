@@ -8,7 +8,7 @@ import (
 	"time"
 )
 
-func RequestWithJWT(t *testing.T, req *http.Request, user *model.User, secret string) {
+func RequestWithJWT(t testing.TB, req *http.Request, user *model.User, secret string) {
 	claims := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub":   user.ID.String(),
 		"exp":   time.Now().Add(time.Hour).Unix(),
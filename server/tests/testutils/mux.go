@@ -4,6 +4,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"quantum/internal/handler"
+	"quantum/internal/model"
+	"strings"
+	"testing"
 )
 
 func ServeRequest(h handler.HandlerBuilder, req *http.Request, secret string) *httptest.ResponseRecorder {
@@ -15,3 +18,11 @@ func ServeRequest(h handler.HandlerBuilder, req *http.Request, secret string) *h
 	mux.ServeHTTP(rr, req)
 	return rr
 }
+
+func ServeRequestAs(t testing.TB, h handler.HandlerBuilder, user *model.User, secret, method, url, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, strings.NewReader(body))
+	if user != nil {
+		RequestWithJWT(t, req, user, secret)
+	}
+	return ServeRequest(h, req, secret)
+}